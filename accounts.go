@@ -0,0 +1,199 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"your_project_name/storage"
+)
+
+// Связь учётных записей с анкетами и компаниями. Пользователь с ролью candidate видит вакансии глазами
+// своей анкеты (лента рекомендаций) и сам ведёт её, кроме статуса и тегов. Готовую анкету связывает
+// администратор пользователей: адрес почты не подтверждается, поэтому сопоставлять анкету по email
+// автоматически нельзя; анкета, которую кандидат заполнил сам, связывается сразу. Рекрутер и
+// администратор компании привязываются к компании и меняют только её вакансии (см. authorizeCompany).
+
+// Статус и теги анкеты ведёт рекрутер, остальные поля кандидат меняет сам.
+var ownCandidateFields = func() []EditableField {
+	var fields []EditableField
+	for _, f := range candidateEditableFields {
+		if f.Name != "status" && f.Name != "tags" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}()
+
+// Анкета, связанная с пользователем.
+func userCandidate(app *App, userID int) (storage.Candidate, error) {
+	var candidateID int
+	err := app.DB.QueryRow("SELECT id FROM candidates WHERE user_id = $1", userID).Scan(&candidateID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Candidate{}, notFoundError("учётная запись не связана с анкетой кандидата")
+	}
+	if err != nil {
+		return storage.Candidate{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return getCandidateByID(app, candidateID)
+}
+
+// Связывает пользователя с анкетой; candidateID = 0 снимает связь.
+func linkCandidateAccount(app *App, username string, candidateID int) error {
+	user, err := app.Users.GetByUsername(normalizeText(username, false))
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+	if candidateID == 0 {
+		if _, err := app.DB.Exec("UPDATE candidates SET user_id = NULL WHERE user_id = $1", user.ID); err != nil {
+			return fmt.Errorf("ошибка изменения анкеты: %w", err)
+		}
+		audit("user.candidate_unlinked", "target_user_id", user.ID, "username", user.Username)
+		return nil
+	}
+	if user.Role != storage.RoleCandidate {
+		return validationErrorf("с анкетой связывается только пользователь с ролью %s, у %s — %s", storage.RoleCandidate, user.Username, user.Role)
+	}
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return err
+	}
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var owner sql.NullInt64
+		if err := tx.QueryRow("SELECT user_id FROM candidates WHERE id = $1 FOR UPDATE", candidateID).Scan(&owner); err != nil {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		if owner.Valid && int(owner.Int64) != user.ID {
+			return validationErrorf("анкета уже связана с другой учётной записью")
+		}
+		if _, err := tx.Exec("UPDATE candidates SET user_id = NULL WHERE user_id = $1 AND id <> $2", user.ID, candidateID); err != nil {
+			return fmt.Errorf("ошибка изменения анкеты: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE candidates SET user_id = $1 WHERE id = $2", user.ID, candidateID); err != nil {
+			return fmt.Errorf("ошибка изменения анкеты: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	audit("user.candidate_linked", "target_user_id", user.ID, "username", user.Username, "candidate_id", candidateID)
+	return nil
+}
+
+// Привязывает пользователя к компании; companyID = 0 снимает привязку.
+func setUserCompany(app *App, username string, companyID int) error {
+	user, err := app.Users.GetByUsername(normalizeText(username, false))
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+	if companyID != 0 {
+		if !roleAllows(user.Role, permJobsWrite) {
+			return validationErrorf("к компании привязываются те, кто ведёт вакансии, у %s роль %s", user.Username, user.Role)
+		}
+		if _, err := getCompany(app, companyID); err != nil {
+			return err
+		}
+	}
+	if err := app.Users.SetCompany(user.ID, companyID); err != nil {
+		return err
+	}
+	audit("user.company", "target_user_id", user.ID, "username", user.Username, "from", user.CompanyID, "to", companyID)
+	return nil
+}
+
+// Анкета, которую кандидат заполняет сам, создаётся сразу связанной с его учётной записью.
+func createOwnCandidateProfile(app *App, candidate storage.Candidate) (int, error) {
+	userID := actingUserID()
+	if userID == 0 {
+		return 0, permissionError("требуется авторизация")
+	}
+	user, err := app.Users.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user.Role != storage.RoleCandidate {
+		return 0, validationErrorf("свою анкету заполняет только пользователь с ролью %s", storage.RoleCandidate)
+	}
+	if err := prepareCandidate(&candidate); err != nil {
+		return 0, err
+	}
+	var id int
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var linked bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM candidates WHERE user_id = $1)", userID).Scan(&linked); err != nil {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		if linked {
+			return validationErrorf("анкета уже заполнена, её можно изменить")
+		}
+		var err error
+		if id, err = storage.NewPostgres(tx).Candidates.Create(candidate); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE candidates SET user_id = $1 WHERE id = $2", userID, id); err != nil {
+			return fmt.Errorf("ошибка изменения анкеты: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	publish(app, Event{Kind: eventCandidateSaved, ID: id})
+	audit("user.candidate_linked", "target_user_id", userID, "username", user.Username, "candidate_id", id)
+	return id, nil
+}
+
+func updateOwnCandidateProfile(app *App, values map[string]interface{}) error {
+	candidate, err := userCandidate(app, actingUserID())
+	if err != nil {
+		return err
+	}
+	if _, err := fieldColumns(ownCandidateFields, values); err != nil {
+		return err
+	}
+	return updateCandidateFields(app, candidate.ID, values)
+}
+
+func ownProfileMenu(app *App) {
+	candidate, err := userCandidate(app, actingUserID())
+	if classifyError(err) == kindNotFound {
+		if !confirm("Анкета ещё не заполнена. Заполнить её сейчас?") {
+			return
+		}
+		filled, ok := promptCandidate(nil, nil)
+		if !ok {
+			return
+		}
+		id, err := createOwnCandidateProfile(app, filled)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Анкета сохранена, ID: %d\n", id)
+		}
+		return
+	}
+	handleError(err)
+	if err != nil {
+		return
+	}
+	writeCandidate(os.Stdout, candidate)
+	if !confirm("Изменить анкету?") {
+		return
+	}
+	fmt.Println("Enter — оставить текущее значение, «-» — очистить необязательное поле.")
+	changes := runEditForm(ownCandidateFields, candidateFormValues(candidate))
+	if len(changes) == 0 {
+		return
+	}
+	err = updateOwnCandidateProfile(app, changes)
+	handleError(err)
+	if err == nil {
+		fmt.Println("Анкета обновлена.")
+	}
+}
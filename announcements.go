@@ -0,0 +1,389 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+// Объявления администраторов: технические работы, изменения правил и т. п. Объявление адресуется ролям
+// (пустой список — всем) и, при необходимости, сотрудникам одной компании. В меню оно показывается над
+// списком действий, пока пользователь не отметит его прочитанным (пункт «Объявления»); веб-интерфейс
+// получает то же через методы announcement.pending и announcement.acknowledge. Объявление можно ограничить
+// датой или снять раньше — тогда оно пропадает у всех.
+
+type Announcement struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body,omitempty"`
+	Roles       []string   `json:"roles,omitempty"`
+	CompanyID   int        `json:"company_id,omitempty"`
+	CompanyName string     `json:"company_name,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	WithdrawnAt *time.Time `json:"withdrawn_at,omitempty"`
+	// Сколько пользователей отметили объявление прочитанным; только в списке для администратора.
+	Acknowledged int `json:"acknowledged"`
+}
+
+// Проверяет и сохраняет объявление; until — последний день показа (пустая — без срока).
+func publishAnnouncement(app *App, a Announcement, until string) (int, error) {
+	a.Title, a.Body = strings.TrimSpace(a.Title), strings.TrimSpace(a.Body)
+	if a.Title == "" {
+		return 0, validationErrorf("укажите заголовок объявления")
+	}
+	for _, role := range a.Roles {
+		if err := validateRole(role); err != nil {
+			return 0, err
+		}
+	}
+	if a.CompanyID != 0 {
+		if _, err := getCompany(app, a.CompanyID); err != nil {
+			return 0, err
+		}
+	}
+	day, err := parseOptionalDate(until)
+	if err != nil {
+		return 0, err
+	}
+	var expiresAt sql.NullTime
+	if day != nil {
+		expiresAt = sql.NullTime{Time: day.AddDate(0, 0, 1), Valid: true}
+		if expiresAt.Time.Before(time.Now()) {
+			return 0, validationErrorf("срок показа объявления уже прошёл")
+		}
+	}
+	if a.Roles == nil {
+		a.Roles = []string{}
+	}
+	var id int
+	err = app.DB.QueryRow(`INSERT INTO announcements (title, body, roles, company_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		a.Title, a.Body, pq.Array(a.Roles), sql.NullInt64{Int64: int64(a.CompanyID), Valid: a.CompanyID != 0},
+		nullUserID(actingUserID()), expiresAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения объявления: %w", err)
+	}
+	audit("announcement.published", "id", id, "roles", strings.Join(a.Roles, ","), "company_id", a.CompanyID)
+	return id, nil
+}
+
+func withdrawAnnouncement(app *App, id int) error {
+	result, err := app.DB.Exec("UPDATE announcements SET withdrawn_at = now() WHERE id = $1 AND withdrawn_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("ошибка снятия объявления: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("действующее объявление с таким ID не найдено")
+	}
+	audit("announcement.withdrawn", "id", id)
+	return nil
+}
+
+const announcementColumns = `a.id, a.title, a.body, a.roles, COALESCE(a.company_id, 0), COALESCE(c.name, ''),
+	COALESCE(u.username, ''), a.created_at, a.expires_at, a.withdrawn_at`
+
+const announcementJoins = `FROM announcements a
+	LEFT JOIN companies c ON c.id = a.company_id
+	LEFT JOIN users u ON u.id = a.created_by`
+
+// Все объявления для администратора, начиная с новых; all — вместе со снятыми и истёкшими.
+func listAnnouncements(app *App, all bool) ([]Announcement, error) {
+	return queryAnnouncements(app.DB, `SELECT `+announcementColumns+`,
+		(SELECT count(*) FROM announcement_acks k WHERE k.announcement_id = a.id)
+		`+announcementJoins+`
+		WHERE $1 OR (a.withdrawn_at IS NULL AND (a.expires_at IS NULL OR a.expires_at > now()))
+		ORDER BY a.created_at DESC, a.id DESC`, all)
+}
+
+// Объявление a видно пользователю r: действующее, для его роли и компании.
+const announcementVisible = `a.withdrawn_at IS NULL AND (a.expires_at IS NULL OR a.expires_at > now())
+	  AND (cardinality(a.roles) = 0 OR r.role = ANY(a.roles))
+	  AND (a.company_id IS NULL OR a.company_id = r.company_id)`
+
+// Объявления, которые пользователь ещё не отметил прочитанными.
+func pendingAnnouncements(app *App, userID int) ([]Announcement, error) {
+	if userID == 0 {
+		return nil, permissionError("требуется авторизация")
+	}
+	return queryAnnouncements(app.DB, `SELECT `+announcementColumns+`, 0
+		`+announcementJoins+`
+		JOIN users r ON r.id = $1
+		WHERE `+announcementVisible+`
+		  AND NOT EXISTS (SELECT 1 FROM announcement_acks k WHERE k.announcement_id = a.id AND k.user_id = r.id)
+		ORDER BY a.created_at, a.id`, userID)
+}
+
+func queryAnnouncements(db *sql.DB, query string, args ...interface{}) ([]Announcement, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var announcements []Announcement
+	for rows.Next() {
+		var a Announcement
+		var expiresAt, withdrawnAt sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Title, &a.Body, pq.Array(&a.Roles), &a.CompanyID, &a.CompanyName, &a.CreatedBy,
+			&a.CreatedAt, &expiresAt, &withdrawnAt, &a.Acknowledged); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if expiresAt.Valid {
+			a.ExpiresAt = &expiresAt.Time
+		}
+		if withdrawnAt.Valid {
+			a.WithdrawnAt = &withdrawnAt.Time
+		}
+		announcements = append(announcements, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return announcements, nil
+}
+
+// Отмечает объявления прочитанными; без ID — все, что сейчас видны пользователю. Чужие, снятые и
+// несуществующие объявления не различаются: на любое из них — «не найдено», и ничего не отмечается.
+func acknowledgeAnnouncements(app *App, userID int, ids ...int) error {
+	if userID == 0 {
+		return permissionError("требуется авторизация")
+	}
+	var requested pq.Int64Array
+	seen := make(map[int]bool)
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			requested = append(requested, int64(id))
+		}
+	}
+	return storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var visible int
+		err := tx.QueryRow(`WITH visible AS (
+				SELECT a.id FROM announcements a JOIN users r ON r.id = $1
+				WHERE `+announcementVisible+`
+				  AND ($2::bigint[] IS NULL OR a.id = ANY($2))
+			), acked AS (
+				INSERT INTO announcement_acks (announcement_id, user_id)
+				SELECT id, $1 FROM visible ON CONFLICT DO NOTHING
+			)
+			SELECT count(*) FROM visible`, userID, requested).Scan(&visible)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения отметки: %w", err)
+		}
+		if requested != nil && visible < len(requested) {
+			return notFoundError("объявление не найдено")
+		}
+		return nil
+	})
+}
+
+func writeAnnouncement(w io.Writer, a Announcement) {
+	fmt.Fprintf(w, "#%d %s\n", a.ID, a.Title)
+	if a.Body != "" {
+		fmt.Fprintln(w, a.Body)
+	}
+}
+
+func announcementLine(a Announcement) string {
+	audience := "для всех"
+	if len(a.Roles) > 0 {
+		audience = "для ролей " + strings.Join(a.Roles, ", ")
+	}
+	if a.CompanyName != "" {
+		audience += ", компания " + a.CompanyName
+	}
+	state := "без срока"
+	switch {
+	case a.WithdrawnAt != nil:
+		state = "снято " + a.WithdrawnAt.Local().Format("2006-01-02 15:04")
+	case a.ExpiresAt != nil && a.ExpiresAt.Before(time.Now()):
+		state = "истекло " + a.ExpiresAt.Local().Format("2006-01-02")
+	case a.ExpiresAt != nil:
+		state = "до " + a.ExpiresAt.AddDate(0, 0, -1).Local().Format("2006-01-02")
+	}
+	createdBy := a.CreatedBy
+	if createdBy == "" {
+		createdBy = "—"
+	}
+	return fmt.Sprintf("#%d %s; %s; %s; опубликовал %s %s; прочитали: %d", a.ID, a.Title, audience, state, createdBy,
+		a.CreatedAt.Local().Format("2006-01-02 15:04"), a.Acknowledged)
+}
+
+// Баннер над меню: объявления, которые пользователь ещё не отметил прочитанными.
+func showAnnouncementBanner(app *App, userID int) {
+	pending, err := pendingAnnouncements(app, userID)
+	if err != nil {
+		opLogger().Warn("ошибка чтения объявлений", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	fmt.Println("\n==================== Объявления ====================")
+	for _, a := range pending {
+		writeAnnouncement(os.Stdout, a)
+	}
+	fmt.Println("Отметить прочитанными можно в пункте «Объявления».")
+	fmt.Println("====================================================")
+}
+
+func announcementsMenu(app *App) {
+	userID := sessionUserID()
+	pending, err := pendingAnnouncements(app, userID)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if len(pending) == 0 {
+		fmt.Println("Новых объявлений нет.")
+	}
+	for _, a := range pending {
+		writeAnnouncement(os.Stdout, a)
+	}
+	manage := authorize(app, permAnnouncements) == nil
+	prompt := "Номер — отметить прочитанным, a — отметить все, Enter — назад: "
+	if manage {
+		prompt = "Номер — отметить прочитанным, a — отметить все, l — все объявления, n — опубликовать, «w номер» — снять, Enter — назад: "
+	}
+	input := getInput(prompt)
+	switch text, withdraw := strings.CutPrefix(input, "w"); {
+	case input == "":
+		return
+	case input == "a":
+		err = acknowledgeAnnouncements(app, userID)
+	case manage && input == "l":
+		announcements, err := listAnnouncements(app, true)
+		handleError(err)
+		for _, a := range announcements {
+			fmt.Println(announcementLine(a))
+		}
+		return
+	case manage && input == "n":
+		publishAnnouncementMenu(app)
+		return
+	case manage && withdraw:
+		id, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			fmt.Println("Укажите номер объявления, например: w 12")
+			return
+		}
+		err = withdrawAnnouncement(app, id)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Объявление снято.")
+		}
+		return
+	default:
+		id, convErr := strconv.Atoi(input)
+		if convErr != nil {
+			fmt.Println("Неверный выбор действия.")
+			return
+		}
+		err = acknowledgeAnnouncements(app, userID, id)
+	}
+	handleError(err)
+	if err == nil {
+		fmt.Println("Отмечено прочитанным.")
+	}
+}
+
+func publishAnnouncementMenu(app *App) {
+	var a Announcement
+	a.Title = getInput("Заголовок: ")
+	a.Body = getInput("Текст: ")
+	a.Roles = splitList(getInput(fmt.Sprintf("Роли через запятую (%s; Enter — все): ", strings.Join(roleNames, ", "))), ",")
+	if company := strings.TrimSpace(getInput("ID или название компании (Enter — все компании): ")); company != "" {
+		id, err := resolveEntityID(app.DB, entityCompany, company)
+		handleError(err)
+		if err != nil {
+			return
+		}
+		a.CompanyID = id
+	}
+	id, err := publishAnnouncement(app, a, getInput("Последний день показа, ГГГГ-ММ-ДД (Enter — без срока): "))
+	handleError(err)
+	if err == nil {
+		fmt.Printf("Объявление #%d опубликовано.\n", id)
+	}
+}
+
+// announce publish -title <заголовок> [-body текст] [-roles роли] [-company компания] [-until ГГГГ-ММ-ДД] |
+// announce list [-all] [-json] | announce withdraw <ID>
+func runAnnounceCommand(app *App, args []string) int {
+	usage := "Использование: announce publish -title <заголовок> [-body текст] [-roles роли через запятую] " +
+		"[-company ID или название компании] [-until ГГГГ-ММ-ДД] | announce list [-all] [-json] | announce withdraw <ID>"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "publish":
+		flags := flag.NewFlagSet("announce publish", flag.ContinueOnError)
+		title := flags.String("title", "", "заголовок объявления")
+		body := flags.String("body", "", "текст объявления")
+		roles := flags.String("roles", "", "роли через запятую: "+strings.Join(roleNames, ", ")+"; по умолчанию все")
+		company := flags.String("company", "", "только для сотрудников компании")
+		until := flags.String("until", "", "последний день показа, ГГГГ-ММ-ДД")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		a := Announcement{Title: *title, Body: *body, Roles: splitList(*roles, ",")}
+		if *company != "" {
+			id, err := resolveEntityID(app.DB, entityCompany, *company)
+			if err != nil {
+				return reportError(err)
+			}
+			a.CompanyID = id
+		}
+		id, err := publishAnnouncement(app, a, *until)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Объявление #%d опубликовано.", id), map[string]int{"id": id})
+	case "list":
+		flags := flag.NewFlagSet("announce list", flag.ContinueOnError)
+		all := flags.Bool("all", false, "показать и снятые, и истёкшие объявления")
+		asJSON := flags.Bool("json", false, "вывести список в JSON")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		useJSON(*asJSON)
+		announcements, err := listAnnouncements(app, *all)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: announcements, text: func(w io.Writer) {
+			if len(announcements) == 0 {
+				fmt.Fprintln(w, "Объявлений нет.")
+			}
+			for _, a := range announcements {
+				fmt.Fprintln(w, announcementLine(a))
+			}
+		}})
+	case "withdraw":
+		if len(args) < 2 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(validationErrorf("ID объявления должен быть числом"))
+		}
+		if err := withdrawAnnouncement(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Объявление снято.", map[string]int{"id": id})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
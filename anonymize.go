@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+const salaryBandWidth = 50000
+
+var fakeFirstNames = []string{"Иван", "Пётр", "Алексей", "Дмитрий", "Сергей", "Анна", "Мария", "Елена", "Ольга", "Наталья"}
+var fakeLastNames = []string{"Смирнов", "Кузнецов", "Попов", "Васильев", "Новиков", "Морозов", "Волков", "Соколов", "Лебедев", "Козлов"}
+var fakeMiddleNames = []string{"Иванович", "Петрович", "Сергеевич", "Андреевич", "Олегович"}
+
+type AnonymizedSnapshot struct {
+	Companies   []storage.Company    `json:"companies"`
+	Candidates  []storage.Candidate  `json:"candidates"`
+	JobOpenings []storage.JobOpening `json:"job_openings"`
+}
+
+func fakeFullName(rnd *rand.Rand) string {
+	return fmt.Sprintf("%s %s %s",
+		fakeLastNames[rnd.Intn(len(fakeLastNames))],
+		fakeFirstNames[rnd.Intn(len(fakeFirstNames))],
+		fakeMiddleNames[rnd.Intn(len(fakeMiddleNames))])
+}
+
+func fakeEmail(id int) string {
+	return fmt.Sprintf("candidate%d@example.com", id)
+}
+
+// Диапазон +7 900 000-xx-xx выглядит правдоподобно и уникален по ID кандидата.
+func fakePhone(id int) string {
+	return fmt.Sprintf("+7900%07d", id%10000000)
+}
+
+// Вилки зарплат перемешиваются только внутри своей полосы и валюты, чтобы распределение по уровням
+// осталось реалистичным; полоса определяется серединой вилки.
+func shuffleSalariesWithinBands(jobOpenings []storage.JobOpening, rnd *rand.Rand) {
+	type bandKey struct {
+		currency string
+		band     int
+	}
+	bands := map[bandKey][]int{}
+	for i, j := range jobOpenings {
+		key := bandKey{j.SalaryCurrency, int(math.Floor(jobSalary(j) / salaryBandWidth))}
+		bands[key] = append(bands[key], i)
+	}
+	for _, indexes := range bands {
+		ranges := make([][2]float64, len(indexes))
+		for k, idx := range indexes {
+			ranges[k] = [2]float64{jobOpenings[idx].SalaryMin, jobOpenings[idx].SalaryMax}
+		}
+		rnd.Shuffle(len(ranges), func(a, b int) { ranges[a], ranges[b] = ranges[b], ranges[a] })
+		for k, idx := range indexes {
+			jobOpenings[idx].SalaryMin, jobOpenings[idx].SalaryMax = ranges[k][0], ranges[k][1]
+		}
+	}
+}
+
+func anonymizeCandidates(candidates []storage.Candidate, rnd *rand.Rand) {
+	for i := range candidates {
+		candidates[i].FullName = fakeFullName(rnd)
+		candidates[i].Email = fakeEmail(candidates[i].ID)
+		if candidates[i].Phone != "" {
+			candidates[i].Phone = fakePhone(candidates[i].ID)
+		}
+	}
+}
+
+// Колонки с личными данными, которые стираются из old_values и new_values журнала изменений.
+var auditPersonalColumns = []string{"full_name", "email", "phone", "username"}
+
+// Остальные личные данные: адреса пользователей и получателей, тексты писем, отклики с публичной формы
+// и их IP-адреса, комментарии и причины, которые пишут о кандидатах, история поиска и черновики форм.
+var anonymizeStatements = []struct{ title, query string }{
+	{"адресов пользователей", "UPDATE users SET email = 'user' || id || '@example.com' WHERE email <> ''"},
+	{"адресов уведомлений компаний", "UPDATE notification_settings SET email = 'company' || company_id || '@example.com' WHERE email <> ''"},
+	{"уведомлений", `UPDATE notifications SET recipient = 'recipient' || id || '@example.com', subject = 'Уведомление ' || id,
+		body = '', summary = ''`},
+	{"откликов на проверке", `UPDATE apply_review_queue SET request = request || jsonb_build_object('full_name', 'Кандидат ' || id,
+		'email', 'applicant' || id || '@example.com', 'phone', '', 'resume_file_name', '', 'client_ip', ''), resume = NULL, client_ip = ''`},
+	{"публичных откликов", "UPDATE public_applications SET client_ip = '' WHERE client_ip <> ''"},
+	{"попыток отклика", "DELETE FROM apply_attempts"},
+	{"списка блокировок", "DELETE FROM apply_blocklist WHERE kind IN ('ip', 'email')"},
+	{"комментариев к откликам", "UPDATE application_status_history SET comment = '' WHERE comment <> ''"},
+	{"юридических удержаний", "UPDATE legal_holds SET reason = 'удержание ' || id, release_reason = ''"},
+	{"истории поиска", "DELETE FROM search_history"},
+	{"черновиков форм", "DELETE FROM form_drafts"},
+}
+
+// Строки меняются прямыми запросами, а не через репозитории: иначе журнал изменений сохранил бы
+// прежние ФИО, контакты и вилки в old_values. Уже записанные в журнал личные данные стираются
+// в той же транзакции. Файлы резюме удаляются после её завершения.
+func anonymizeInPlace(db *sql.DB) error {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	var resumeKeys []string
+	err := storage.WithTx(db, func(tx storage.DBTX) error {
+		repos := storage.NewPostgres(tx)
+		candidates, err := repos.Candidates.List()
+		if err != nil {
+			return err
+		}
+		anonymizeCandidates(candidates, rnd)
+		for _, c := range candidates {
+			_, err := tx.Exec("UPDATE candidates SET full_name = $2, email = $3, phone = $4 WHERE id = $1",
+				c.ID, c.FullName, c.Email, c.Phone)
+			if err != nil {
+				return fmt.Errorf("ошибка анонимизации кандидата %d: %w", c.ID, err)
+			}
+		}
+
+		jobOpenings, err := repos.JobOpenings.List()
+		if err != nil {
+			return err
+		}
+		shuffleSalariesWithinBands(jobOpenings, rnd)
+		for _, j := range jobOpenings {
+			_, err := tx.Exec("UPDATE job_openings SET salary_min = $2, salary_max = $3 WHERE id = $1", j.ID, j.SalaryMin, j.SalaryMax)
+			if err != nil {
+				return fmt.Errorf("ошибка анонимизации вакансии %d: %w", j.ID, err)
+			}
+		}
+
+		_, err = tx.Exec("UPDATE users SET username = 'user' || id")
+		if err != nil {
+			return fmt.Errorf("ошибка анонимизации пользователей: %w", err)
+		}
+		for _, s := range anonymizeStatements {
+			if _, err := tx.Exec(s.query); err != nil {
+				return fmt.Errorf("ошибка анонимизации %s: %w", s.title, err)
+			}
+		}
+		if resumeKeys, err = deleteResumeRows(tx); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE audit_log SET old_values = old_values - $1::text[], new_values = new_values - $1::text[]
+			WHERE entity IN ('candidates', 'users')`, pq.Array(auditPersonalColumns))
+		if err != nil {
+			return fmt.Errorf("ошибка анонимизации журнала изменений: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range resumeKeys {
+		removeCandidateResume(slog.Default(), key)
+	}
+	return nil
+}
+
+func deleteResumeRows(tx storage.DBTX) ([]string, error) {
+	rows, err := tx.Query("DELETE FROM resume_files RETURNING storage_key")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка анонимизации резюме: %w", err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("ошибка анонимизации резюме: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func exportAnonymized(app *App, path string) error {
+	if path == "" {
+		return errors.New("путь к файлу не может быть пустым")
+	}
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	var snapshot AnonymizedSnapshot
+	var err error
+	if snapshot.Companies, err = app.Companies.List(); err != nil {
+		return err
+	}
+	if snapshot.Candidates, err = app.Candidates.List(); err != nil {
+		return err
+	}
+	if snapshot.JobOpenings, err = app.JobOpenings.List(); err != nil {
+		return err
+	}
+	anonymizeCandidates(snapshot.Candidates, rnd)
+	shuffleSalariesWithinBands(snapshot.JobOpenings, rnd)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return fmt.Errorf("ошибка записи файла: %w", err)
+	}
+	return nil
+}
+
+func anonymizeMenu(app *App) {
+	fmt.Println("1. Анонимизировать текущую базу (необратимо)")
+	fmt.Println("2. Выгрузить анонимизированную копию в файл")
+	choice, err := getIntInput("Введите номер действия: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+
+	switch choice {
+	case 1:
+		confirm := getInput("Все ФИО, email, телефоны, имена пользователей, тексты уведомлений и IP-адреса будут заменены, " +
+			"а файлы резюме и черновики удалены. Введите ANONYMIZE для подтверждения: ")
+		if confirm != "ANONYMIZE" {
+			fmt.Println("Операция отменена.")
+			return
+		}
+		err := anonymizeInPlace(app.DB)
+		handleError(err)
+		if err == nil {
+			fmt.Println("База данных анонимизирована.")
+		}
+	case 2:
+		path := getInput("Введите путь к файлу: ")
+		err := exportAnonymized(app, path)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Анонимизированная копия сохранена в", path)
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
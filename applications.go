@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+var applicationStatusTitles = map[string]string{
+	storage.ApplicationNew:       "новый",
+	storage.ApplicationScreening: "отбор",
+	storage.ApplicationInterview: "собеседование",
+	storage.ApplicationOffer:     "оффер",
+	storage.ApplicationRejected:  "отказ",
+	storage.ApplicationHired:     "принят",
+}
+
+func validateApplicationStatus(status string) error {
+	for _, s := range storage.ApplicationStatuses {
+		if s == status {
+			return nil
+		}
+	}
+	return validationErrorf("неизвестный статус отклика %q, допустимые: %s", status, strings.Join(storage.ApplicationStatuses, ", "))
+}
+
+// Проверяет, что вакансия принимает отклики, и возвращает очищенный источник.
+func prepareApplication(app *App, jobOpeningID int, source string) (string, error) {
+	jobOpening, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return "", err
+	}
+	if jobOpening.Status != storage.JobOpen {
+		return "", validationErrorf("вакансия %s, отклики на неё не принимаются", jobStatusTitles[jobOpening.Status])
+	}
+	return sanitizeText("источник", strings.ToLower(source), maxNameLength)
+}
+
+// Кандидат и вакансия проверяются заранее, чтобы сообщить, чего именно не хватает.
+func createApplication(app *App, candidateID, jobOpeningID int, source string, changedBy int) (int, error) {
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return 0, err
+	}
+	source, err := prepareApplication(app, jobOpeningID, source)
+	if err != nil {
+		return 0, err
+	}
+	return app.Applications.Create(storage.Application{CandidateID: candidateID, JobOpeningID: jobOpeningID, Source: source}, changedBy)
+}
+
+// Новый кандидат и его отклик создаются в одной транзакции: если отклик записать не удалось,
+// кандидат без отклика не остаётся.
+func createApplicationWithCandidate(app *App, candidate storage.Candidate, jobOpeningID int, source string, changedBy int) (int, int, error) {
+	if err := prepareCandidate(&candidate); err != nil {
+		return 0, 0, err
+	}
+	source, err := prepareApplication(app, jobOpeningID, source)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var candidateID, id int
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var err error
+		candidateID, id, err = insertApplicationWithCandidate(tx, candidate, jobOpeningID, source, changedBy)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	publish(app, Event{Kind: eventCandidateSaved, ID: candidateID})
+	return candidateID, id, nil
+}
+
+// Запись уже проверенных кандидата и отклика в транзакции tx; событие о кандидате публикует вызывающий
+// после фиксации.
+func insertApplicationWithCandidate(tx storage.DBTX, candidate storage.Candidate, jobOpeningID int, source string, changedBy int) (int, int, error) {
+	repos := storage.NewPostgres(tx)
+	candidateID, err := repos.Candidates.Create(candidate)
+	if err != nil {
+		return 0, 0, err
+	}
+	id, err := repos.Applications.Create(storage.Application{CandidateID: candidateID, JobOpeningID: jobOpeningID, Source: source}, changedBy)
+	return candidateID, id, err
+}
+
+func getApplication(app *App, id int) (storage.Application, error) {
+	application, err := app.Applications.GetByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.Application{}, notFoundError("отклик не найден")
+	}
+	return application, err
+}
+
+func changeApplicationStatus(app *App, id int, status, comment string, changedBy int) error {
+	if err := validateApplicationStatus(status); err != nil {
+		return err
+	}
+	err := app.Applications.SetStatus(id, status, normalizeText(comment, true), changedBy)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("отклик не найден")
+	}
+	if err != nil {
+		return err
+	}
+	publish(app, Event{Kind: eventApplicationStatusChanged, ID: id})
+	return nil
+}
+
+func applicationsByCandidate(app *App, candidateID int) ([]storage.Application, error) {
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return nil, err
+	}
+	return app.Applications.ListByCandidate(candidateID)
+}
+
+func applicationsByJobOpening(app *App, jobOpeningID int) ([]storage.Application, error) {
+	if _, err := getJobOpeningByID(app, jobOpeningID); err != nil {
+		return nil, err
+	}
+	return app.Applications.ListByJobOpening(jobOpeningID)
+}
+
+func applicationHistory(app *App, id int) ([]storage.ApplicationStatusChange, error) {
+	if _, err := getApplication(app, id); err != nil {
+		return nil, err
+	}
+	return app.Applications.History(id)
+}
+
+func printApplications(applications []storage.Application) {
+	if len(applications) == 0 {
+		fmt.Println("Откликов нет.")
+		return
+	}
+	fmt.Printf("%-6s %-10s %-10s %-14s %s\n", "ID", "Кандидат", "Вакансия", "Статус", "Обновлён")
+	for _, a := range applications {
+		fmt.Printf("%-6d %-10d %-10d %-14s %s\n", a.ID, a.CandidateID, a.JobOpeningID,
+			applicationStatusTitles[a.Status], a.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+func applicationsMenu(app *App) {
+	fmt.Println("1. Добавить отклик")
+	fmt.Println("2. Изменить статус отклика")
+	fmt.Println("3. Отклики кандидата")
+	fmt.Println("4. Отклики на вакансию")
+	fmt.Println("5. История отклика")
+	fmt.Println("6. Поиск по воронке")
+	choice, err := getIntInput("Введите номер действия: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	perm := permApplicationsRead
+	if choice == 1 || choice == 2 {
+		perm = permApplicationsWrite
+	}
+	if err := authorize(app, perm); err != nil {
+		handleError(err)
+		return
+	}
+
+	switch choice {
+	case 1:
+		candidateText := getInput("Введите ID, ФИО или email кандидата: ")
+		candidateID, err := resolveEntityID(app.DB, entityCandidate, candidateText)
+		var newCandidate *storage.Candidate
+		if classifyError(err) == kindNotFound && authorize(app, permCandidatesWrite) == nil &&
+			confirm(fmt.Sprintf("Кандидата «%s» нет. Добавить его вместе с откликом?", strings.TrimSpace(candidateText))) {
+			candidate, ok := promptCandidate(map[string]string{"full_name": strings.TrimSpace(candidateText)}, nil)
+			if !ok {
+				return
+			}
+			newCandidate, err = &candidate, nil
+		}
+		handleError(err)
+		if err != nil {
+			return
+		}
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		source := getInput("Источник (сайт, рекомендация, hh.ru…; Enter — не указывать): ")
+		if newCandidate != nil {
+			candidateID, id, err := createApplicationWithCandidate(app, *newCandidate, jobOpeningID, source, sessionUserID())
+			handleError(err)
+			if err == nil {
+				fmt.Printf("Кандидат добавлен, ID: %d. Отклик добавлен, ID: %d\n", candidateID, id)
+			}
+			return
+		}
+		id, err := createApplication(app, candidateID, jobOpeningID, source, sessionUserID())
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Отклик добавлен, ID: %d\n", id)
+		}
+	case 2:
+		id, err := getIntInput("Введите ID отклика: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		status := getInput(fmt.Sprintf("Новый статус (%s): ", strings.Join(storage.ApplicationStatuses, "/")))
+		comment := getInput("Комментарий (необязательно): ")
+		err = changeApplicationStatus(app, id, strings.TrimSpace(status), comment, sessionUserID())
+		handleError(err)
+		if err == nil {
+			fmt.Println("Статус отклика изменён.")
+		}
+	case 3:
+		candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		applications, err := applicationsByCandidate(app, candidateID)
+		handleError(err)
+		if err == nil {
+			printApplications(applications)
+		}
+	case 4:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		applications, err := applicationsByJobOpening(app, jobOpeningID)
+		handleError(err)
+		if err == nil {
+			printApplications(applications)
+		}
+	case 5:
+		id, err := getIntInput("Введите ID отклика: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		history, err := applicationHistory(app, id)
+		handleError(err)
+		if err == nil {
+			for _, change := range history {
+				from := "—"
+				if change.FromStatus != "" {
+					from = applicationStatusTitles[change.FromStatus]
+				}
+				fmt.Printf("%s  %s → %s", change.ChangedAt.Format("2006-01-02 15:04"), from, applicationStatusTitles[change.ToStatus])
+				if change.Comment != "" {
+					fmt.Printf("  (%s)", change.Comment)
+				}
+				fmt.Println()
+			}
+		}
+	case 6:
+		pipelineSearchMenu(app)
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
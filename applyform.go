@@ -0,0 +1,332 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Публичная форма отклика: рекрутер публикует вакансию ссылкой <APP_BASE_URL>/apply/<токен>, и внешний
+// кандидат без учётной записи откликается через неё (методы apply.form и apply.submit). Без токена
+// откликнуться нельзя, поэтому перебором ID вакансий форму не найти, а ссылку можно отозвать или
+// перевыпустить, если её начали заваливать откликами. Из отклика создаётся отклик с источником site
+// и кандидат, если кандидата с таким email ещё нет, — дальше они идут по обычной воронке; согласие
+// на обработку данных записывается в public_applications вместе с текстом, на который согласился кандидат.
+
+const applySource = "site"
+
+const applyConsentText = "Я согласен на обработку моих персональных данных для рассмотрения на эту вакансию."
+
+type ApplyFormLink struct {
+	ID           int    `json:"id"`
+	JobOpeningID int    `json:"job_opening_id"`
+	Token        string `json:"token"`
+	URL          string `json:"url"`
+}
+
+// То, что видит кандидат на странице формы.
+type PublicVacancy struct {
+	Title          string   `json:"title"`
+	Company        string   `json:"company"`
+	Location       string   `json:"location,omitempty"`
+	Experience     string   `json:"experience,omitempty"`
+	RequiredSkills []string `json:"required_skills,omitempty"`
+	ConsentText    string   `json:"consent_text"`
+	// Поля ApplyRequest, которые должна содержать форма, и те, что можно не заполнять.
+	RequiredFields []string `json:"required_fields"`
+	OptionalFields []string `json:"optional_fields"`
+}
+
+// Возраст обязателен, как и у любого кандидата в базе (см. prepareCandidate).
+var (
+	applyRequiredFields = []string{"full_name", "age", "email", "consent"}
+	applyOptionalFields = []string{"phone", "resume_file_name", "resume"}
+)
+
+type ApplyRequest struct {
+	Token    string `json:"token,omitempty"`
+	FullName string `json:"full_name"`
+	Age      int    `json:"age"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Consent  bool   `json:"consent"`
+	// Необязательно; PDF или DOCX, в JSON — base64.
+	ResumeFileName string `json:"resume_file_name"`
+	Resume         []byte `json:"resume,omitempty"`
+	// Скрытое поле-ловушка: человек его не видит и оставляет пустым.
+	Website string `json:"website,omitempty"`
+	// Адрес кандидата. Из данных формы не читается: его берут из запроса, который передаёт веб-сервер
+	// (поле client_ip конверта JSON-RPC).
+	ClientIP string `json:"-"`
+}
+
+func applyFormURL(token string) string {
+	return fmt.Sprintf("%s/apply/%s", appBaseURL(), token)
+}
+
+// Токен из ссылки вида .../apply/<токен> или сам токен.
+func applyFormToken(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.LastIndex(text, "/apply/"); i >= 0 {
+		text = text[i+len("/apply/"):]
+	}
+	return strings.Trim(text, "/")
+}
+
+// Публикует форму отклика на открытую вакансию; прежняя ссылка, если была, перестаёт действовать.
+func publishApplyForm(app *App, jobOpeningID int) (ApplyFormLink, error) {
+	link := ApplyFormLink{JobOpeningID: jobOpeningID}
+	jobOpening, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return link, err
+	}
+	if err := authorizeCompany(app, jobOpening.CompanyID); err != nil {
+		return link, err
+	}
+	if jobOpening.Status != storage.JobOpen {
+		return link, validationErrorf("вакансия %s, форму отклика для неё опубликовать нельзя", jobStatusTitles[jobOpening.Status])
+	}
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return link, fmt.Errorf("ошибка генерации ссылки: %w", err)
+	}
+	link.Token = hex.EncodeToString(raw)
+	link.URL = applyFormURL(link.Token)
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		if _, err := tx.Exec("UPDATE apply_forms SET revoked_at = now() WHERE job_opening_id = $1 AND revoked_at IS NULL", jobOpeningID); err != nil {
+			return err
+		}
+		return tx.QueryRow("INSERT INTO apply_forms (job_opening_id, token_hash, created_by) VALUES ($1, $2, $3) RETURNING id",
+			jobOpeningID, hashToken(link.Token), nullUserID(actingUserID())).Scan(&link.ID)
+	})
+	if err != nil {
+		return link, fmt.Errorf("ошибка публикации формы отклика: %w", err)
+	}
+	audit("job.apply_form.published", "job_opening_id", jobOpeningID, "form_id", link.ID)
+	return link, nil
+}
+
+func revokeApplyForm(app *App, jobOpeningID int) error {
+	if err := authorizeJobOpening(app, jobOpeningID); err != nil {
+		return err
+	}
+	result, err := app.DB.Exec("UPDATE apply_forms SET revoked_at = now() WHERE job_opening_id = $1 AND revoked_at IS NULL", jobOpeningID)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва формы отклика: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("у вакансии нет опубликованной формы отклика")
+	}
+	audit("job.apply_form.revoked", "job_opening_id", jobOpeningID)
+	return nil
+}
+
+// Форма и вакансия по токену. Как и у ссылок на профиль, неизвестный и отозванный токены не различаются.
+func applyFormByToken(app *App, token string) (int, storage.JobOpening, error) {
+	invalid := permissionError("ссылка на форму отклика недействительна")
+	token = applyFormToken(token)
+	if token == "" {
+		return 0, storage.JobOpening{}, validationErrorf("укажите ссылку на форму отклика")
+	}
+	var formID, jobOpeningID int
+	err := app.DB.QueryRow("SELECT id, job_opening_id FROM apply_forms WHERE token_hash = $1 AND revoked_at IS NULL",
+		hashToken(token)).Scan(&formID, &jobOpeningID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.JobOpening{}, invalid
+	}
+	if err != nil {
+		return 0, storage.JobOpening{}, fmt.Errorf("ошибка проверки ссылки: %w", err)
+	}
+	jobOpening, err := app.JobOpenings.GetByID(jobOpeningID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return 0, storage.JobOpening{}, invalid
+	}
+	if err != nil {
+		return 0, storage.JobOpening{}, err
+	}
+	if jobOpening.Status != storage.JobOpen {
+		return 0, storage.JobOpening{}, validationErrorf("вакансия больше не принимает отклики")
+	}
+	return formID, jobOpening, nil
+}
+
+func publicVacancy(app *App, token string) (PublicVacancy, error) {
+	_, jobOpening, err := applyFormByToken(app, token)
+	if err != nil {
+		return PublicVacancy{}, err
+	}
+	company, err := getCompany(app, jobOpening.CompanyID)
+	if err != nil {
+		return PublicVacancy{}, err
+	}
+	return PublicVacancy{
+		Title:          jobOpening.Title,
+		Company:        company.Name,
+		Location:       jobOpening.Location,
+		Experience:     jobOpening.Experience,
+		RequiredSkills: jobOpening.RequiredSkills,
+		ConsentText:    applyConsentText,
+		RequiredFields: applyRequiredFields,
+		OptionalFields: applyOptionalFields,
+	}, nil
+}
+
+// Отклик внешнего кандидата. Подозрительный отклик (см. screenPublicApplication) не создаёт кандидата,
+// а ждёт проверки; кандидат в обоих случаях получает один и тот же ответ. 0 — отклик отложен.
+func submitPublicApplication(app *App, req ApplyRequest) (int, error) {
+	formID, jobOpening, err := applyFormByToken(app, req.Token)
+	if err != nil {
+		return 0, err
+	}
+	if !req.Consent {
+		return 0, validationErrorf("без согласия на обработку персональных данных отклик не принимается")
+	}
+	if strings.TrimSpace(req.Email) == "" {
+		return 0, validationErrorf("укажите email, чтобы с вами могли связаться")
+	}
+	if req.Age <= 0 {
+		return 0, validationErrorf("укажите возраст")
+	}
+	if len(req.Resume) > 0 {
+		if _, err := detectResumeType(req.ResumeFileName, req.Resume); err != nil {
+			return 0, err
+		}
+	}
+	// Скрытое поле заполняют только боты; им отвечаем как при успехе, чтобы они не подстраивались.
+	if req.Website != "" {
+		audit("application.public_spam", "form_id", formID, "reason", "honeypot", "client_ip", req.ClientIP)
+		return 0, nil
+	}
+	reasons, err := screenPublicApplication(app, req)
+	if err != nil {
+		return 0, err
+	}
+	if len(reasons) > 0 {
+		return 0, holdPublicApplication(app, formID, req, reasons)
+	}
+	return createPublicApplication(app, formID, jobOpening.ID, req)
+}
+
+// Резюме проверяется до записи отклика, а сохраняется после: если хранилище файлов недоступно,
+// отклик всё равно остаётся, а кандидат не отправляет форму повторно.
+func createPublicApplication(app *App, formID, jobOpeningID int, req ApplyRequest) (int, error) {
+	var candidateID, applicationID int
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var err error
+		candidateID, applicationID, err = insertPublicApplication(app, tx, formID, jobOpeningID, req)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	finishPublicApplication(app, formID, jobOpeningID, candidateID, applicationID, req)
+	return applicationID, nil
+}
+
+// Кандидат, отклик и запись о согласии создаются в транзакции tx: отклика без согласия не бывает.
+// Кандидат с тем же email уже есть — отклик привязывается к нему; его анкета по данным формы не меняется,
+// потому что форма открыта всем.
+func insertPublicApplication(app *App, tx storage.DBTX, formID, jobOpeningID int, req ApplyRequest) (int, int, error) {
+	candidate := storage.Candidate{FullName: req.FullName, Age: req.Age, Email: req.Email, Phone: req.Phone}
+	if err := prepareCandidate(&candidate); err != nil {
+		return 0, 0, err
+	}
+	source, err := prepareApplication(app, jobOpeningID, applySource)
+	if err != nil {
+		return 0, 0, err
+	}
+	candidateID, err := candidateByEmail(tx, candidate.Email)
+	if err != nil {
+		return 0, 0, err
+	}
+	var applicationID int
+	if candidateID == 0 {
+		candidateID, applicationID, err = insertApplicationWithCandidate(tx, candidate, jobOpeningID, source, 0)
+	} else {
+		applicationID, err = insertPublicApplicationFor(tx, candidateID, jobOpeningID, source)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = tx.Exec("INSERT INTO public_applications (application_id, form_id, consent_text, client_ip) VALUES ($1, $2, $3, $4)",
+		applicationID, formID, applyConsentText, req.ClientIP)
+	if err != nil {
+		return 0, 0, fmt.Errorf("ошибка записи согласия: %w", err)
+	}
+	return candidateID, applicationID, nil
+}
+
+// Первый кандидат с таким email без учёта регистра; 0 — такого нет. Блокировка по адресу до конца
+// транзакции не даёт двум одновременным откликам создать двух кандидатов.
+func candidateByEmail(tx storage.DBTX, email string) (int, error) {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(lower($1)))", email); err != nil {
+		return 0, fmt.Errorf("ошибка поиска кандидата: %w", err)
+	}
+	var id int
+	err := tx.QueryRow("SELECT id FROM candidates WHERE lower(email) = lower($1) ORDER BY id LIMIT 1", email).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка поиска кандидата: %w", err)
+	}
+	return id, nil
+}
+
+func insertPublicApplicationFor(tx storage.DBTX, candidateID, jobOpeningID int, source string) (int, error) {
+	var applied bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM applications WHERE candidate_id = $1 AND job_opening_id = $2)",
+		candidateID, jobOpeningID).Scan(&applied)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка проверки откликов: %w", err)
+	}
+	if applied {
+		return 0, validationErrorf("вы уже откликались на эту вакансию")
+	}
+	return storage.NewPostgres(tx).Applications.Create(storage.Application{CandidateID: candidateID, JobOpeningID: jobOpeningID,
+		Source: source}, 0)
+}
+
+// То, что делается после фиксации транзакции: событие о кандидате, резюме и журнал.
+func finishPublicApplication(app *App, formID, jobOpeningID, candidateID, applicationID int, req ApplyRequest) {
+	publish(app, Event{Kind: eventCandidateSaved, ID: candidateID})
+	if len(req.Resume) > 0 {
+		if _, err := storeResume(app, candidateID, req.ResumeFileName, req.Resume, false); err != nil {
+			opLogger().Warn("резюме из формы отклика не сохранено", "application_id", applicationID, "error", err)
+		}
+	}
+	audit("application.public_submitted", "application_id", applicationID, "job_opening_id", jobOpeningID, "form_id", formID)
+}
+
+// apply-form publish <вакансия> | apply-form revoke <вакансия>
+func runApplyFormCommand(app *App, args []string) int {
+	usage := "Использование: apply-form publish <ID или название вакансии> | apply-form revoke <ID или название вакансии>"
+	if len(args) != 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityJobOpening, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	switch args[0] {
+	case "publish":
+		link, err := publishApplyForm(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(link.URL, link)
+	case "revoke":
+		if err := revokeApplyForm(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Форма отклика отозвана.", map[string]int{"job_opening_id": id})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+// Защита публичной формы отклика. Отклик отклоняется сразу, если с адреса за последний час пришло больше
+// APPLY_RATE_LIMIT откликов (по умолчанию 5; 0 — без ограничения) или адрес, email либо домен есть в
+// apply_blocklist. Заполненное поле-ловушка (website) — признак бота: такой отклик молча отбрасывается.
+// Отклик с одноразового почтового ящика не отклоняется, а попадает в очередь проверки и становится
+// кандидатом только после одобрения; ошибочно задержанный человек так не теряется.
+
+// Домены одноразовой почты; дополнять лучше через apply-blocklist, если такие отклики нужно отклонять сразу.
+var disposableEmailDomains = []string{
+	"mailinator.com", "guerrillamail.com", "guerrillamail.net", "sharklasers.com", "10minutemail.com",
+	"temp-mail.org", "tempmail.com", "yopmail.com", "trashmail.com", "getnada.com", "dispostable.com",
+	"maildrop.cc", "throwawaymail.com", "fakeinbox.com", "mohmal.com", "emailondeck.com", "mintemail.com",
+	"tempail.com", "dropmail.me", "1secmail.com",
+}
+
+var applyBlockKinds = []string{"ip", "email", "domain"}
+
+type ApplyBlock struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApplyReviewItem struct {
+	ID           int          `json:"id"`
+	JobOpeningID int          `json:"job_opening_id"`
+	Request      ApplyRequest `json:"request"`
+	HasResume    bool         `json:"has_resume"`
+	ClientIP     string       `json:"client_ip,omitempty"`
+	Reasons      []string     `json:"reasons"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func applyRateLimit() int {
+	if n, err := strconv.Atoi(os.Getenv("APPLY_RATE_LIMIT")); err == nil && n >= 0 {
+		return n
+	}
+	return 5
+}
+
+func emailDomain(email string) string {
+	_, domain, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	return domain
+}
+
+// Проверки перед приёмом отклика: ошибка — отклик отклонён, причины — отклик нужно проверить вручную.
+// Без адреса клиента отклик не принимается: иначе ограничение частоты и блокировка по IP не действовали бы.
+func screenPublicApplication(app *App, req ApplyRequest) ([]string, error) {
+	ip := req.ClientIP
+	if ip == "" {
+		return nil, validationErrorf("не передан адрес клиента: веб-сервер должен указать его в поле client_ip запроса")
+	}
+	if net.ParseIP(ip) == nil {
+		return nil, validationErrorf("неверный адрес клиента %q", ip)
+	}
+	if err := checkApplyRate(app, ip); err != nil {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	domain := emailDomain(email)
+	var blocked int
+	err := app.DB.QueryRow(`SELECT COUNT(*) FROM apply_blocklist
+		WHERE (kind = 'ip' AND value = $1) OR (kind = 'email' AND value = $2) OR (kind = 'domain' AND value = $3)`,
+		ip, email, domain).Scan(&blocked)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки списка блокировок: %w", err)
+	}
+	if blocked > 0 {
+		audit("application.public_spam", "reason", "blocklist", "client_ip", ip, "email_domain", domain)
+		return nil, permissionError("отклик не принят")
+	}
+
+	var reasons []string
+	if containsString(disposableEmailDomains, domain) {
+		reasons = append(reasons, "одноразовый email ("+domain+")")
+	}
+	return reasons, nil
+}
+
+// Каждая попытка записывается до проверки, поэтому отклонённые тоже расходуют лимит.
+func checkApplyRate(app *App, ip string) error {
+	limit := applyRateLimit()
+	if limit == 0 {
+		return nil
+	}
+	var attempts int
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		if _, err := tx.Exec("DELETE FROM apply_attempts WHERE attempted_at < now() - interval '1 day'"); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO apply_attempts (client_ip) VALUES ($1)", ip); err != nil {
+			return err
+		}
+		return tx.QueryRow("SELECT COUNT(*) FROM apply_attempts WHERE client_ip = $1 AND attempted_at > now() - interval '1 hour'", ip).
+			Scan(&attempts)
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка учёта откликов: %w", err)
+	}
+	if attempts > limit {
+		audit("application.public_spam", "reason", "rate_limit", "client_ip", ip, "attempts", attempts)
+		return permissionError("слишком много откликов с вашего адреса, попробуйте позже")
+	}
+	return nil
+}
+
+func holdPublicApplication(app *App, formID int, req ApplyRequest, reasons []string) error {
+	resume := req.Resume
+	req.Resume, req.Token = nil, ""
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации отклика: %w", err)
+	}
+	var id int
+	err = app.DB.QueryRow(`INSERT INTO apply_review_queue (form_id, request, resume, client_ip, reasons)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, formID, data, resume, req.ClientIP, pq.Array(reasons)).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения отклика на проверку: %w", err)
+	}
+	audit("application.public_held", "review_id", id, "form_id", formID, "reasons", strings.Join(reasons, "; "))
+	return nil
+}
+
+// Рекрутер и администратор компании видят только отклики на вакансии своей компании.
+func listApplyReviewQueue(app *App) ([]ApplyReviewItem, error) {
+	companyID, err := companyScope(app)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := app.DB.Query(`
+		SELECT q.id, f.job_opening_id, q.request, q.resume IS NOT NULL, q.client_ip, q.reasons, q.created_at
+		FROM apply_review_queue q
+		JOIN apply_forms f ON f.id = q.form_id
+		JOIN job_openings j ON j.id = f.job_opening_id
+		WHERE q.decision IS NULL AND ($1 = 0 OR j.company_id = $1)
+		ORDER BY q.created_at`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var items []ApplyReviewItem
+	for rows.Next() {
+		var item ApplyReviewItem
+		var data []byte
+		if err := rows.Scan(&item.ID, &item.JobOpeningID, &data, &item.HasResume, &item.ClientIP, pq.Array(&item.Reasons), &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if err := json.Unmarshal(data, &item.Request); err != nil {
+			return nil, fmt.Errorf("ошибка чтения отклика #%d: %w", item.ID, err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return items, nil
+}
+
+// Помечает отклик одобренным в транзакции tx и возвращает его данные. Решение ставится условно, поэтому
+// при одновременном или повторном одобрении второй вызов получает «не найден», а не второго кандидата.
+func claimApplyReview(tx storage.DBTX, id int) (int, int, ApplyRequest, error) {
+	var formID, jobOpeningID int
+	var req ApplyRequest
+	var data []byte
+	err := tx.QueryRow(`
+		UPDATE apply_review_queue q SET decision = 'approved', reviewed_by = $2, reviewed_at = now()
+		FROM apply_forms f
+		WHERE q.id = $1 AND q.decision IS NULL AND f.id = q.form_id
+		RETURNING q.form_id, f.job_opening_id, q.request, q.resume, q.client_ip`, id, nullUserID(actingUserID())).
+		Scan(&formID, &jobOpeningID, &data, &req.Resume, &req.ClientIP)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, req, notFoundError("отклик на проверке с таким ID не найден")
+	}
+	if err != nil {
+		return 0, 0, req, fmt.Errorf("ошибка сохранения решения: %w", err)
+	}
+	resume, ip := req.Resume, req.ClientIP
+	if err := json.Unmarshal(data, &req); err != nil {
+		return 0, 0, req, fmt.Errorf("ошибка чтения отклика #%d: %w", id, err)
+	}
+	req.Resume, req.ClientIP = resume, ip
+	return formID, jobOpeningID, req, nil
+}
+
+// Решение по отклику принимает сотрудник компании, на вакансию которой он подан.
+func authorizeApplyReview(app *App, id int) error {
+	var jobOpeningID int
+	err := app.DB.QueryRow(`SELECT f.job_opening_id FROM apply_review_queue q JOIN apply_forms f ON f.id = q.form_id
+		WHERE q.id = $1 AND q.decision IS NULL`, id).Scan(&jobOpeningID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundError("отклик на проверке с таким ID не найден")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return authorizeJobOpening(app, jobOpeningID)
+}
+
+// Одобренный отклик проходит обычный путь: создаются кандидат и отклик, сохраняется резюме. Решение,
+// кандидат, отклик и согласие записываются одной транзакцией: если что-то не удалось, отклик остаётся
+// на проверке, и ничего не создано.
+func approveApplyReview(app *App, id int) (int, error) {
+	if err := authorizeApplyReview(app, id); err != nil {
+		return 0, err
+	}
+	var formID, jobOpeningID, candidateID, applicationID int
+	var req ApplyRequest
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var err error
+		if formID, jobOpeningID, req, err = claimApplyReview(tx, id); err != nil {
+			return err
+		}
+		candidateID, applicationID, err = insertPublicApplication(app, tx, formID, jobOpeningID, req)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE apply_review_queue SET application_id = $2, resume = NULL WHERE id = $1", id, applicationID)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения решения: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	finishPublicApplication(app, formID, jobOpeningID, candidateID, applicationID, req)
+	audit("application.public_approved", "review_id", id, "application_id", applicationID)
+	return applicationID, nil
+}
+
+// Отклонённый отклик остаётся в очереди как запись о решении, но без персональных данных и резюме.
+func rejectApplyReview(app *App, id int) error {
+	if err := authorizeApplyReview(app, id); err != nil {
+		return err
+	}
+	result, err := app.DB.Exec(`UPDATE apply_review_queue SET decision = 'rejected', request = '{}', resume = NULL,
+		reviewed_by = $2, reviewed_at = now() WHERE id = $1 AND decision IS NULL`, id, nullUserID(actingUserID()))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения решения: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("отклик на проверке с таким ID не найден")
+	}
+	audit("application.public_rejected", "review_id", id)
+	return nil
+}
+
+func applyReviewLine(item ApplyReviewItem) string {
+	resume := ""
+	if item.HasResume {
+		resume = ", есть резюме"
+	}
+	return fmt.Sprintf("#%d %s: %s <%s>, вакансия %d%s; причины: %s", item.ID, item.CreatedAt.Local().Format("2006-01-02 15:04"),
+		item.Request.FullName, item.Request.Email, item.JobOpeningID, resume, strings.Join(item.Reasons, "; "))
+}
+
+func normalizeApplyBlock(kind, value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch kind {
+	case "ip":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", validationErrorf("неверный IP-адрес %q", value)
+		}
+		return ip.String(), nil
+	case "email":
+		if err := validateEmailSyntax(value); err != nil {
+			return "", err
+		}
+		return value, nil
+	case "domain":
+		value = strings.TrimPrefix(value, "@")
+		if value == "" || strings.ContainsAny(value, "@ /") || !strings.Contains(value, ".") {
+			return "", validationErrorf("неверный домен %q", value)
+		}
+		return value, nil
+	}
+	return "", validationErrorf("неизвестный вид блокировки %q, допустимые: %s", kind, strings.Join(applyBlockKinds, ", "))
+}
+
+func addApplyBlock(app *App, kind, value, reason string) (int, error) {
+	value, err := normalizeApplyBlock(kind, value)
+	if err != nil {
+		return 0, err
+	}
+	reason, err = sanitizeText("причина", reason, maxNameLength)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	err = app.DB.QueryRow(`INSERT INTO apply_blocklist (kind, value, reason, created_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (kind, value) DO UPDATE SET reason = EXCLUDED.reason RETURNING id`,
+		kind, value, reason, nullUserID(actingUserID())).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения блокировки: %w", err)
+	}
+	audit("apply.blocklist.added", "id", id, "kind", kind, "value", value)
+	return id, nil
+}
+
+func removeApplyBlock(app *App, id int) error {
+	result, err := app.DB.Exec("DELETE FROM apply_blocklist WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления блокировки: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("блокировка с таким ID не найдена")
+	}
+	audit("apply.blocklist.removed", "id", id)
+	return nil
+}
+
+func listApplyBlocklist(app *App) ([]ApplyBlock, error) {
+	rows, err := app.DB.Query(`
+		SELECT b.id, b.kind, b.value, b.reason, COALESCE(u.username, ''), b.created_at
+		FROM apply_blocklist b LEFT JOIN users u ON u.id = b.created_by
+		ORDER BY b.kind, b.value`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var blocks []ApplyBlock
+	for rows.Next() {
+		var b ApplyBlock
+		if err := rows.Scan(&b.ID, &b.Kind, &b.Value, &b.Reason, &b.CreatedBy, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		blocks = append(blocks, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return blocks, nil
+}
+
+func applyReviewMenu(app *App) {
+	items, err := listApplyReviewQueue(app)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if len(items) == 0 {
+		fmt.Println("Откликов на проверке нет.")
+		return
+	}
+	for _, item := range items {
+		fmt.Println(applyReviewLine(item))
+	}
+	input := getInput("«a номер» — принять, «r номер» — отклонить, «b номер» — отклонить и заблокировать email, Enter — назад: ")
+	if input == "" {
+		return
+	}
+	action, idText, _ := strings.Cut(input, " ")
+	id, err := strconv.Atoi(strings.TrimSpace(idText))
+	if err != nil {
+		fmt.Println("Укажите номер отклика, например: a 12")
+		return
+	}
+	switch action {
+	case "a":
+		applicationID, err := approveApplyReview(app, id)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Отклик принят, ID отклика: %d\n", applicationID)
+		}
+	case "r", "b":
+		var email string
+		for _, item := range items {
+			if item.ID == id {
+				email = item.Request.Email
+			}
+		}
+		if err := rejectApplyReview(app, id); err != nil {
+			handleError(err)
+			return
+		}
+		fmt.Println("Отклик отклонён.")
+		if action == "b" && email != "" {
+			_, err := addApplyBlock(app, "email", email, fmt.Sprintf("спам, отклик #%d", id))
+			handleError(err)
+			if err == nil {
+				fmt.Println("Email заблокирован:", email)
+			}
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
+
+// apply-review list | apply-review approve <ID> | apply-review reject <ID>
+func runApplyReviewCommand(app *App, args []string) int {
+	usage := "Использование: apply-review list | apply-review approve <ID> | apply-review reject <ID>"
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		items, err := listApplyReviewQueue(app)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: items, text: func(w io.Writer) {
+			if len(items) == 0 {
+				fmt.Fprintln(w, "Откликов на проверке нет.")
+			}
+			for _, item := range items {
+				fmt.Fprintln(w, applyReviewLine(item))
+			}
+		}})
+	case len(args) == 2 && (args[0] == "approve" || args[0] == "reject"):
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		if args[0] == "reject" {
+			if err := rejectApplyReview(app, id); err != nil {
+				return reportError(err)
+			}
+			return printDone("Отклик отклонён.", map[string]int{"id": id})
+		}
+		applicationID, err := approveApplyReview(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Отклик принят, ID отклика: %d", applicationID), map[string]int{"id": id, "application_id": applicationID})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
+
+// apply-blocklist list | apply-blocklist add <ip|email|domain> <значение> [причина] | apply-blocklist remove <ID>
+func runApplyBlocklistCommand(app *App, args []string) int {
+	usage := "Использование: apply-blocklist list | apply-blocklist add <ip|email|domain> <значение> [причина] | apply-blocklist remove <ID>"
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		blocks, err := listApplyBlocklist(app)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: blocks, text: func(w io.Writer) {
+			if len(blocks) == 0 {
+				fmt.Fprintln(w, "Список блокировок пуст.")
+			}
+			for _, b := range blocks {
+				fmt.Fprintf(w, "#%d %s %s — %s (%s, %s)\n", b.ID, b.Kind, b.Value, b.Reason, b.CreatedBy, b.CreatedAt.Local().Format("2006-01-02"))
+			}
+		}})
+	case len(args) >= 3 && args[0] == "add":
+		id, err := addApplyBlock(app, args[1], args[2], strings.Join(args[3:], " "))
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Блокировка добавлена, ID: %d", id), map[string]int{"id": id})
+	case len(args) == 2 && args[0] == "remove":
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		if err := removeApplyBlock(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Блокировка снята.", map[string]int{"id": id})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// История откликов: триггер на applications пишет каждую версию строки в application_versions с периодом
+// её действия, поэтому воронку можно построить на любой прошедший момент (pipeline -as-of). Закрытые
+// версии старше APPLICATION_HISTORY_DAYS (по умолчанию два года) удаляет очистка по срокам хранения
+// (purge.go); текущие версии не удаляются, а состояние на момент раньше срока хранения уже не восстановить.
+
+const defaultApplicationHistoryDays = 730
+
+// 0 — история хранится без ограничения.
+func applicationHistoryRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("APPLICATION_HISTORY_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultApplicationHistoryDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Момент для воронки «как было»: дата ГГГГ-ММ-ДД — состояние на конец этого дня, иначе момент в формате
+// RFC 3339. Пустая строка — нынешнее состояние (nil).
+func parseAsOf(text string) (*time.Time, error) {
+	if text == "" {
+		return nil, nil
+	}
+	at, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		day, dayErr := time.ParseInLocation("2006-01-02", text, time.Local)
+		if dayErr != nil {
+			return nil, validationErrorf("момент %q: ожидается дата ГГГГ-ММ-ДД или время вида 2024-03-01T12:00:00+03:00", text)
+		}
+		// Сегодняшний день ещё не кончился: его состояние — нынешнее.
+		if at = day.AddDate(0, 0, 1).Add(-time.Nanosecond); at.After(time.Now()) {
+			at = time.Now()
+		}
+	}
+	if at.After(time.Now()) {
+		return nil, validationErrorf("момент %s ещё не наступил", text)
+	}
+	if retention := applicationHistoryRetention(); retention > 0 && at.Before(time.Now().Add(-retention)) {
+		return nil, validationErrorf("история откликов хранится %d дней, состояние на %s уже не восстановить", int(retention.Hours()/24), text)
+	}
+	return &at, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Журнал изменений данных (таблица audit_log) ведут репозитории storage; здесь — его просмотр
+// администратором и привязка изменений к пользователю текущей операции.
+
+func init() {
+	storage.AuditActor = actingUserID
+}
+
+// Пользователь текущей операции: из токена метода JSON-RPC или из сессии меню; 0 — консольная команда.
+func actingUserID() int {
+	if currentOperation.UserID != 0 {
+		return currentOperation.UserID
+	}
+	return sessionUserID()
+}
+
+// Условия выборки журнала в том виде, в каком их задают меню, команда audit-log и метод audit.list.
+// Даты — ГГГГ-ММ-ДД, To включает указанный день.
+type AuditLogQuery struct {
+	Username string `json:"username"`
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entity_id"`
+	Action   string `json:"action"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+func (q AuditLogQuery) filter(app *App) (storage.AuditFilter, error) {
+	filter := storage.AuditFilter{EntityID: q.EntityID}
+	if username := normalizeText(q.Username, false); username != "" {
+		user, err := app.Users.GetByUsername(username)
+		if errors.Is(err, storage.ErrNotFound) {
+			return filter, notFoundError("пользователь не найден")
+		}
+		if err != nil {
+			return filter, err
+		}
+		filter.UserID = user.ID
+	}
+	if filter.Entity = strings.TrimSpace(q.Entity); filter.Entity != "" && !containsString(storage.AuditEntities, filter.Entity) {
+		return filter, validationErrorf("неизвестная таблица %q, допустимые: %s", filter.Entity, strings.Join(storage.AuditEntities, ", "))
+	}
+	if filter.Action = strings.TrimSpace(q.Action); filter.Action != "" && !containsString(storage.AuditActions, filter.Action) {
+		return filter, validationErrorf("неизвестное действие %q, допустимые: %s", filter.Action, strings.Join(storage.AuditActions, ", "))
+	}
+	if filter.EntityID < 0 {
+		return filter, validationErrorf("ID записи не может быть отрицательным")
+	}
+	var err error
+	if filter.From, err = parseOptionalDate(q.From); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseOptionalDate(q.To); err != nil {
+		return filter, err
+	}
+	if filter.To != nil {
+		to := filter.To.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, validationErrorf("дата «с» должна быть не позже даты «по»")
+	}
+	return filter, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func listAuditLog(app *App, q AuditLogQuery, list ListQuery) ([]storage.AuditEntry, error) {
+	filter, err := q.filter(app)
+	if err != nil {
+		return nil, err
+	}
+	if list.Sort == "" {
+		list.Sort = "-id"
+	}
+	opts, err := list.options(storage.AuditSorts)
+	if err != nil {
+		return nil, err
+	}
+	return app.Audit.List(filter, opts)
+}
+
+func auditLogLine(e storage.AuditEntry) string {
+	user := e.Username
+	if user == "" {
+		user = "—"
+	}
+	values := string(e.NewValues)
+	if e.Action == storage.AuditDelete {
+		values = string(e.OldValues)
+	}
+	line := fmt.Sprintf("%-8d %s  %-16s %-7s %s #%d  %s", e.ID, e.ChangedAt.Local().Format("2006-01-02 15:04"), user,
+		e.Action, e.Entity, e.EntityID, values)
+	if e.Action == storage.AuditUpdate {
+		line += "  было: " + string(e.OldValues)
+	}
+	return line
+}
+
+func auditLogMenu(app *App) {
+	var q AuditLogQuery
+	q.Username = getInput("Пользователь (Enter — любой): ")
+	q.Entity = getInput(fmt.Sprintf("Таблица (%s; Enter — любая): ", strings.Join(storage.AuditEntities, "/")))
+	if q.Entity != "" {
+		id, err := getIntInput("ID записи (0 — любая): ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		q.EntityID = id
+	}
+	q.Action = getInput(fmt.Sprintf("Действие (%s; Enter — любое): ", strings.Join(storage.AuditActions, "/")))
+	q.From = getInput("С даты ГГГГ-ММ-ДД (Enter — с начала): ")
+	q.To = getInput("По дату ГГГГ-ММ-ДД (Enter — по сегодня): ")
+	list := ListQuery{PerPage: 20}
+	browsePages(list.PerPage, "Изменений не найдено.", func(page int) ([]string, int, error) {
+		list.Page = page
+		entries, err := listAuditLog(app, q, list)
+		lines := make([]string, len(entries))
+		for i, e := range entries {
+			lines[i] = auditLogLine(e)
+		}
+		return lines, -1, err
+	})
+}
+
+// audit-log [-user имя] [-entity таблица] [-id N] [-action insert|update|delete] [-from ГГГГ-ММ-ДД]
+// [-to ГГГГ-ММ-ДД] [-page N] [-per-page N] [-sort поле]
+func runAuditLogCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("audit-log", flag.ContinueOnError)
+	var q AuditLogQuery
+	flags.StringVar(&q.Username, "user", "", "имя пользователя, внёсшего изменения")
+	flags.StringVar(&q.Entity, "entity", "", "таблица: "+strings.Join(storage.AuditEntities, ", "))
+	flags.IntVar(&q.EntityID, "id", 0, "ID записи в таблице")
+	flags.StringVar(&q.Action, "action", "", "действие: "+strings.Join(storage.AuditActions, ", "))
+	flags.StringVar(&q.From, "from", "", "изменения начиная с даты ГГГГ-ММ-ДД")
+	flags.StringVar(&q.To, "to", "", "изменения по дату ГГГГ-ММ-ДД включительно")
+	list := ListQuery{}
+	flags.IntVar(&list.Page, "page", 1, "номер страницы")
+	flags.IntVar(&list.PerPage, "per-page", 50, "записей на странице")
+	flags.StringVar(&list.Sort, "sort", "", "поле сортировки: "+sortFieldNames(storage.AuditSorts)+"; «-» впереди — по убыванию; по умолчанию -id")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	entries, err := listAuditLog(app, q, list)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: entries, text: func(w io.Writer) {
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "Изменений не найдено.")
+		}
+		for _, e := range entries {
+			fmt.Fprintln(w, auditLogLine(e))
+		}
+	}})
+}
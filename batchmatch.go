@@ -0,0 +1,501 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+const matchBatchJob = "matches.recompute"
+
+type matchBatchPayload struct {
+	TopN int `json:"top_n"`
+}
+
+type MatchBatchStats struct {
+	Vacancies  int           `json:"vacancies"`
+	Candidates int           `json:"candidates"`
+	Stored     int           `json:"stored"`
+	Duration   time.Duration `json:"duration_ns"`
+}
+
+type NewMatchCount struct {
+	JobOpeningID int    `json:"job_opening_id"`
+	Title        string `json:"title"`
+	CompanyID    int    `json:"company_id"`
+	New          int    `json:"new"`
+	Total        int    `json:"total"`
+}
+
+type StoredMatch struct {
+	CandidateID    int       `json:"candidate_id"`
+	FullName       string    `json:"full_name"`
+	Score          float64   `json:"score"`
+	Rank           int       `json:"rank"`
+	FirstMatchedAt time.Time `json:"first_matched_at"`
+	ComputedAt     time.Time `json:"computed_at"`
+}
+
+func matchBatchTopN() int {
+	n, err := strconv.Atoi(os.Getenv("MATCH_TOP_N"))
+	if err != nil || n <= 0 {
+		n = 20
+	}
+	return n
+}
+
+func matchBatchInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("MATCH_BATCH_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = 6 * time.Hour
+	}
+	return interval
+}
+
+// Сохраняет выдачу одной вакансии: пары, оставшиеся в топе, сохраняют first_matched_at,
+// выбывшие удаляются. Вся выдача пишется одним запросом через массивы.
+func storeVacancyMatches(tx *sql.Tx, jobOpeningID int, matches []CandidateMatch, computedAt time.Time) error {
+	candidateIDs := make([]int64, len(matches))
+	scores := make([]float64, len(matches))
+	ranks := make([]int64, len(matches))
+	for i, m := range matches {
+		candidateIDs[i] = int64(m.Candidate.ID)
+		scores[i] = m.Score
+		ranks[i] = int64(i + 1)
+	}
+	_, err := tx.Exec(`INSERT INTO match_results (job_opening_id, candidate_id, score, rank, first_matched_at, computed_at)
+		SELECT $1::int, c, s, r, $5::timestamptz, $5::timestamptz FROM unnest($2::int[], $3::float8[], $4::int[]) AS t(c, s, r)
+		ON CONFLICT (job_opening_id, candidate_id) DO UPDATE SET score = EXCLUDED.score, rank = EXCLUDED.rank, computed_at = EXCLUDED.computed_at`,
+		jobOpeningID, pq.Array(candidateIDs), pq.Array(scores), pq.Array(ranks), computedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения результатов подбора: %w", err)
+	}
+	_, err = tx.Exec("DELETE FROM match_results WHERE job_opening_id = $1 AND NOT (candidate_id = ANY($2::int[]))",
+		jobOpeningID, pq.Array(candidateIDs))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления устаревших результатов подбора: %w", err)
+	}
+	return nil
+}
+
+// Подбирает топ-N кандидатов для всех открытых вакансий за один проход: вакансии, кандидаты и веса
+// компаний читаются по одному разу, оценки считаются в памяти. Результаты закрытых и
+// приостановленных вакансий удаляются.
+func recomputeAllMatches(db *sql.DB, topN int) (MatchBatchStats, error) {
+	started := time.Now()
+	repos := storage.NewPostgres(db)
+	jobOpenings, err := repos.JobOpenings.ListByStatus(storage.JobOpen)
+	if err != nil {
+		return MatchBatchStats{}, err
+	}
+	candidates, err := repos.Candidates.List()
+	if err != nil {
+		return MatchBatchStats{}, err
+	}
+	stats := MatchBatchStats{Vacancies: len(jobOpenings), Candidates: len(candidates)}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return MatchBatchStats{}, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	companyWeights := map[int]MatchWeights{}
+	for _, job := range jobOpenings {
+		weights, ok := companyWeights[job.CompanyID]
+		if !ok {
+			if weights, err = companyMatchWeights(db, job.CompanyID); err != nil {
+				return MatchBatchStats{}, err
+			}
+			companyWeights[job.CompanyID] = weights
+		}
+		matches := rankPool(job, candidates, weights, topN)
+		if err := storeVacancyMatches(tx, job.ID, matches, started); err != nil {
+			return MatchBatchStats{}, err
+		}
+		stats.Stored += len(matches)
+	}
+	_, err = tx.Exec(`DELETE FROM match_results WHERE job_opening_id IN (SELECT id FROM job_openings WHERE status <> $1)`, storage.JobOpen)
+	if err != nil {
+		return MatchBatchStats{}, fmt.Errorf("ошибка удаления результатов закрытых вакансий: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return MatchBatchStats{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	stats.Duration = time.Since(started)
+	return stats, nil
+}
+
+// Задача пересчитывает подбор и ставит себя на следующий запуск через MATCH_BATCH_INTERVAL.
+func handleMatchBatchJob(db *sql.DB, payload json.RawMessage) error {
+	var p matchBatchPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("неверные параметры задачи: %w", err)
+	}
+	if p.TopN <= 0 {
+		p.TopN = matchBatchTopN()
+	}
+	if _, err := recomputeAllMatches(db, p.TopN); err != nil {
+		return err
+	}
+	_, err := enqueueJobAt(db, matchBatchJob, p, time.Now().Add(matchBatchInterval()))
+	return err
+}
+
+func init() {
+	registerJobHandler(matchBatchJob, handleMatchBatchJob)
+}
+
+func scheduleMatchBatch(db *sql.DB) error {
+	scheduled, err := jobScheduled(db, matchBatchJob)
+	if err != nil || scheduled {
+		return err
+	}
+	_, err = enqueueJob(db, matchBatchJob, matchBatchPayload{})
+	return err
+}
+
+// Сколько пар «вакансия — кандидат» впервые попали в топ после since, по открытым вакансиям.
+func newMatchCounts(db *sql.DB, since time.Time, companyID int) ([]NewMatchCount, error) {
+	rows, err := db.Query(`
+		SELECT j.id, j.title, COALESCE(j.company_id, 0),
+			COUNT(*) FILTER (WHERE m.first_matched_at > $1), COUNT(*)
+		FROM match_results m
+		JOIN job_openings j ON j.id = m.job_opening_id
+		WHERE j.status = $2 AND ($3 = 0 OR j.company_id = $3)
+		GROUP BY j.id, j.title, j.company_id
+		ORDER BY 4 DESC, j.id`, since, storage.JobOpen, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []NewMatchCount
+	for rows.Next() {
+		var c NewMatchCount
+		if err := rows.Scan(&c.JobOpeningID, &c.Title, &c.CompanyID, &c.New, &c.Total); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return counts, nil
+}
+
+func storedMatches(db *sql.DB, jobOpeningID int) ([]StoredMatch, error) {
+	rows, err := db.Query(`
+		SELECT m.candidate_id, c.full_name, m.score, m.rank, m.first_matched_at, m.computed_at
+		FROM match_results m
+		JOIN candidates c ON c.id = m.candidate_id
+		WHERE m.job_opening_id = $1
+		ORDER BY m.rank`, jobOpeningID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []StoredMatch
+	for rows.Next() {
+		var m StoredMatch
+		if err := rows.Scan(&m.CandidateID, &m.FullName, &m.Score, &m.Rank, &m.FirstMatchedAt, &m.ComputedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return matches, nil
+}
+
+// matches recompute [-top N] | matches new [-since 24h] [-company ID] | matches show <ID вакансии>
+func runMatchesCommand(app *App, args []string) int {
+	usage := "Использование: matches recompute [-top N] | matches new [-since 24h] [-company ID] | matches show <ID вакансии>"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "recompute":
+		flags := flag.NewFlagSet("matches recompute", flag.ContinueOnError)
+		topN := flags.Int("top", matchBatchTopN(), "сколько лучших кандидатов хранить для вакансии")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if *topN <= 0 {
+			return reportError(validationErrorf("размер выдачи должен быть положительным"))
+		}
+		stats, err := recomputeAllMatches(app.DB, *topN)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Вакансий: %d, кандидатов: %d, сохранено пар: %d, время: %s",
+			stats.Vacancies, stats.Candidates, stats.Stored, stats.Duration.Round(time.Millisecond)), stats)
+	case "new":
+		flags := flag.NewFlagSet("matches new", flag.ContinueOnError)
+		since := flags.Duration("since", 24*time.Hour, "новыми считаются пары, появившиеся за этот период")
+		company := entityFlagVar(flags, "company", entityCompany, "только вакансии компании (ID или название)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		companyID, err := company.resolve(app.DB)
+		if err != nil {
+			return reportError(err)
+		}
+		counts, err := newMatchCounts(app.DB, time.Now().Add(-*since), companyID)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: counts, text: func(w io.Writer) {
+			fmt.Fprintf(w, "%-6s %-40s %6s %6s\n", "ID", "Вакансия", "Новых", "Всего")
+			for _, c := range counts {
+				fmt.Fprintf(w, "%-6d %-40s %6d %6d\n", c.JobOpeningID, c.Title, c.New, c.Total)
+			}
+		}})
+	case "show":
+		if len(args) != 2 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		id, err := resolveEntityID(app.DB, entityJobOpening, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		if _, err := getJobOpeningByID(app, id); err != nil {
+			return reportError(err)
+		}
+		matches, err := storedMatches(app.DB, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: matches, text: func(w io.Writer) {
+			if len(matches) == 0 {
+				fmt.Fprintln(w, "Результатов подбора нет; выполните matches recompute.")
+			}
+			for _, m := range matches {
+				fmt.Fprintf(w, "%d. %.0f%%  ID: %d, %s (в выдаче с %s)\n", m.Rank, m.Score*100, m.CandidateID, m.FullName,
+					m.FirstMatchedAt.Format("2006-01-02 15:04"))
+			}
+		}})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
+
+const (
+	matchCandidateJob  = "matches.candidate"
+	matchJobOpeningJob = "matches.job_opening"
+)
+
+type matchTargetPayload struct {
+	ID int `json:"id"`
+}
+
+// Полный пересчёт выдачи одной вакансии; результаты закрытой или удалённой вакансии удаляются.
+func recomputeVacancyMatches(db *sql.DB, jobOpeningID int, topN int) error {
+	repos := storage.NewPostgres(db)
+	job, err := repos.JobOpenings.GetByID(jobOpeningID)
+	if err == storage.ErrNotFound || err == nil && job.Status != storage.JobOpen {
+		_, err = db.Exec("DELETE FROM match_results WHERE job_opening_id = $1", jobOpeningID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	candidates, err := repos.Candidates.List()
+	if err != nil {
+		return err
+	}
+	weights, err := companyMatchWeights(db, job.CompanyID)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	if err := storeVacancyMatches(tx, job.ID, rankPool(job, candidates, weights, topN), time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Пересчитывает только вакансии, затронутые изменением кандидата: открытые вакансии, где требуется
+// хотя бы один из его навыков, и те, в выдаче которых он уже есть. Для каждой из них ранжируются
+// только текущий топ и сам кандидат; полный пересчёт вакансии нужен лишь тогда, когда кандидат
+// опустился на последнее место заполненного топа — на его место может претендовать кто-то извне.
+// Вакансии, где у кандидата нет общих навыков и он не был в топе, ждут планового пересчёта.
+func recomputeCandidateMatches(db *sql.DB, candidateID int, topN int) error {
+	repos := storage.NewPostgres(db)
+	candidate, err := repos.Candidates.GetByID(candidateID)
+	if err == storage.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(`
+		SELECT id FROM job_openings
+		WHERE status = $1 AND (required_skills ?| $2::text[]
+			OR id IN (SELECT job_opening_id FROM match_results WHERE candidate_id = $3))
+		ORDER BY id`, storage.JobOpen, pq.Array(nonNilSkills(candidate.Skills)), candidateID)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	var jobIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		jobIDs = append(jobIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	for _, jobID := range jobIDs {
+		job, err := repos.JobOpenings.GetByID(jobID)
+		if err == storage.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		weights, err := companyMatchWeights(db, job.CompanyID)
+		if err != nil {
+			return err
+		}
+		stored, err := storedMatches(db, jobID)
+		if err != nil {
+			return err
+		}
+
+		pool := []storage.Candidate{candidate}
+		wasInTop := false
+		for _, m := range stored {
+			if m.CandidateID == candidateID {
+				wasInTop = true
+				continue
+			}
+			c, err := repos.Candidates.GetByID(m.CandidateID)
+			if err == storage.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			pool = append(pool, c)
+		}
+		ranked := rankPool(job, pool, weights, 0)
+		if wasInTop && len(stored) >= topN && ranked[len(ranked)-1].Candidate.ID == candidateID {
+			if err := recomputeVacancyMatches(db, jobID, topN); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(ranked) > topN {
+			ranked = ranked[:topN]
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("ошибка начала транзакции: %w", err)
+		}
+		err = storeVacancyMatches(tx, jobID, ranked, time.Now())
+		if err == nil {
+			err = tx.Commit()
+		}
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func nonNilSkills(skills []string) []string {
+	if skills == nil {
+		return []string{}
+	}
+	return skills
+}
+
+func handleMatchCandidateJob(db *sql.DB, payload json.RawMessage) error {
+	var p matchTargetPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("неверные параметры задачи: %w", err)
+	}
+	return recomputeCandidateMatches(db, p.ID, matchBatchTopN())
+}
+
+func handleMatchJobOpeningJob(db *sql.DB, payload json.RawMessage) error {
+	var p matchTargetPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("неверные параметры задачи: %w", err)
+	}
+	return recomputeVacancyMatches(db, p.ID, matchBatchTopN())
+}
+
+func init() {
+	registerJobHandler(matchCandidateJob, handleMatchCandidateJob)
+	registerJobHandler(matchJobOpeningJob, handleMatchJobOpeningJob)
+
+	subscribe(eventCandidateSaved, func(app *App, event Event) error {
+		return enqueueJobOnce(app.DB, matchCandidateJob, matchTargetPayload{ID: event.ID})
+	})
+	subscribe(eventJobOpeningSaved, func(app *App, event Event) error {
+		return enqueueJobOnce(app.DB, matchJobOpeningJob, matchTargetPayload{ID: event.ID})
+	})
+	// Перестройка пересчитывает выдачу вакансий по текущим данным, а не очищает match_results: иначе все
+	// пары стали бы новыми и кандидатам заново ушли бы письма о подборе. Сброс убирает только выдачу
+	// вакансий, которые уже не открыты.
+	registerProjection(Projection{
+		Name:        "matches",
+		Description: "выдача пакетного подбора (match_results)",
+		Kinds:       []string{eventCandidateSaved, eventJobOpeningSaved},
+		Reset: func(app *App) error {
+			_, err := app.DB.Exec("DELETE FROM match_results WHERE job_opening_id NOT IN (SELECT id FROM job_openings WHERE status = $1)", storage.JobOpen)
+			if err != nil {
+				return fmt.Errorf("ошибка очистки результатов подбора: %w", err)
+			}
+			return nil
+		},
+		Handle: func(app *App, event Event) error {
+			if event.Kind == eventCandidateSaved {
+				return recomputeCandidateMatches(app.DB, event.ID, matchBatchTopN())
+			}
+			return recomputeVacancyMatches(app.DB, event.ID, matchBatchTopN())
+		},
+	})
+	subscribe(eventMatchRulesChanged, func(app *App, event Event) error {
+		jobOpenings, err := app.JobOpenings.ListByStatus(storage.JobOpen)
+		if err != nil {
+			return err
+		}
+		for _, j := range jobOpenings {
+			if j.CompanyID != event.ID {
+				continue
+			}
+			if err := enqueueJobOnce(app.DB, matchJobOpeningJob, matchTargetPayload{ID: j.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
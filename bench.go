@@ -0,0 +1,175 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+type benchResult struct {
+	Stats      LatencyStats  `json:"stats"`
+	Baseline   *LatencyStats `json:"baseline,omitempty"`
+	Regression bool          `json:"regression"`
+}
+
+// Горячие пути: поиск по навыку, список вакансий, выгрузка в CSV и подбор кандидатов. Подбор замеряется
+// на первой вакансии базы; если вакансий нет, его в списке нет.
+func benchmarkOperations(app *App) ([]benchOperation, error) {
+	operations := append(searchOperations(app),
+		benchOperation{"список всех вакансий", func() error { _, err := app.JobOpenings.List(); return err }},
+		benchOperation{"выгрузка кандидатов в CSV", func() error { return exportCSV(app) }},
+	)
+	var jobOpeningID int
+	err := app.DB.QueryRow("SELECT id FROM job_openings ORDER BY id LIMIT 1").Scan(&jobOpeningID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return operations, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	operations = append(operations, benchOperation{"подбор кандидатов на вакансию", func() error {
+		return matchCandidates(app, jobOpeningID)
+	}})
+	return operations, nil
+}
+
+// Подбор, как в команде match: веса вакансии, первые 20 кандидатов.
+func matchCandidates(app *App, jobOpeningID int) error {
+	weights, err := jobMatchWeights(app, jobOpeningID)
+	if err != nil {
+		return err
+	}
+	_, err = rankCandidates(app, jobOpeningID, weights, 20)
+	return err
+}
+
+// Выгрузка всех кандидатов со всеми полями, как export candidates, но без записи в файл.
+func exportCSV(app *App) error {
+	fields, err := parseExportFields("candidates", "")
+	if err != nil {
+		return err
+	}
+	records, err := loadExportRecords(app, "candidates", exportFilter{})
+	if err != nil {
+		return err
+	}
+	return writeExport(io.Discard, formatCSV, fields, records)
+}
+
+func loadBenchBaseline(path string) (map[string]LatencyStats, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения базовой линии: %w", err)
+	}
+	var stats []LatencyStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("ошибка разбора базовой линии: %w", err)
+	}
+	baseline := map[string]LatencyStats{}
+	for _, s := range stats {
+		baseline[s.Operation] = s
+	}
+	return baseline, nil
+}
+
+func saveBenchBaseline(path string, stats []LatencyStats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации базовой линии: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи базовой линии: %w", err)
+	}
+	return nil
+}
+
+// Сравнение идёт по медиане: p95 на малом числе прогонов слишком шумный.
+func compareWithBaseline(stats []LatencyStats, baseline map[string]LatencyStats, threshold float64) []benchResult {
+	var results []benchResult
+	for _, s := range stats {
+		result := benchResult{Stats: s}
+		if b, ok := baseline[s.Operation]; ok {
+			result.Baseline = &b
+			result.Regression = float64(s.P50) > float64(b.P50)*(1+threshold)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func runBenchCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	runs := flags.Int("runs", 10, "количество прогонов каждой операции")
+	baselinePath := flags.String("baseline", "bench_baseline.json", "файл с базовой линией")
+	threshold := flags.Float64("threshold", 0.2, "допустимое замедление медианы (0.2 = 20%)")
+	update := flags.Bool("update", false, "записать текущие результаты как новую базовую линию")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *runs <= 0 {
+		fmt.Println("Количество прогонов должно быть положительным.")
+		return exitUsage
+	}
+
+	operations, err := benchmarkOperations(app)
+	if err != nil {
+		return reportError(err)
+	}
+	stats, err := measureOperations(operations, *runs)
+	if err != nil {
+		return reportError(err)
+	}
+
+	if *update {
+		err := saveBenchBaseline(*baselinePath, stats)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: stats, text: func(w io.Writer) {
+			printLatencyStats(w, stats)
+			fmt.Fprintln(w, "Базовая линия сохранена в", *baselinePath)
+		}})
+	}
+
+	baseline, err := loadBenchBaseline(*baselinePath)
+	if err != nil {
+		return reportError(err)
+	}
+
+	results := compareWithBaseline(stats, baseline, *threshold)
+	failed := false
+	for _, r := range results {
+		failed = failed || r.Regression
+	}
+	printView(sharedView{data: results, text: func(w io.Writer) {
+		for _, r := range results {
+			status := "нет базовой линии"
+			if r.Baseline != nil {
+				// Нулевая медиана в базовой линии (файл правили вручную) — относительное изменение не считается.
+				change := "—"
+				if r.Baseline.P50 > 0 {
+					change = fmt.Sprintf("%+.1f%%", (float64(r.Stats.P50)/float64(r.Baseline.P50)-1)*100)
+				}
+				status = fmt.Sprintf("было %v, изменение %s", r.Baseline.P50, change)
+				if r.Regression {
+					status += " — РЕГРЕССИЯ"
+				}
+			}
+			fmt.Fprintf(w, "%-40s p50: %-12v (%s)\n", r.Stats.Operation, r.Stats.P50, status)
+		}
+		if failed {
+			fmt.Fprintf(w, "Производительность ухудшилась больше чем на %.0f%%.\n", *threshold*100)
+		}
+	}})
+	if failed {
+		return exitFailure
+	}
+	return exitOK
+}
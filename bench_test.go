@@ -0,0 +1,82 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+)
+
+// Бенчмарки горячих путей на заполненной базе (например, после «Сгенерировать нагрузочные данные»):
+//
+//	BENCH_DATABASE_URL=postgres://... go test -run '^$' -bench . -benchtime 20x
+//
+// Без BENCH_DATABASE_URL бенчмарки пропускаются. Схема приводится к последней версии, поэтому боевую
+// базу указывать не стоит. Те же операции с базовой линией и порогом замеряет команда bench.
+
+func benchApp(b *testing.B) *App {
+	b.Helper()
+	dsn := os.Getenv("BENCH_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_URL не задан")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	configureDB(db)
+	if _, err := migrateUp(db, 0); err != nil {
+		b.Fatal(err)
+	}
+	return newApp(db)
+}
+
+func benchJobOpeningID(b *testing.B, app *App) int {
+	b.Helper()
+	var id int
+	err := app.DB.QueryRow("SELECT id FROM job_openings ORDER BY id LIMIT 1").Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		b.Skip("в базе нет вакансий")
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	return id
+}
+
+func runBenchmark(b *testing.B, run func() error) {
+	b.Helper()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCandidateSkillSearch(b *testing.B) {
+	app := benchApp(b)
+	runBenchmark(b, func() error { _, err := findCandidatesBySkill(app, "Go", ListQuery{}); return err })
+}
+
+func BenchmarkJobOpeningSkillSearch(b *testing.B) {
+	app := benchApp(b)
+	runBenchmark(b, func() error { _, err := findJobOpeningsBySkill(app, "PostgreSQL", ListQuery{}); return err })
+}
+
+func BenchmarkJobOpeningList(b *testing.B) {
+	app := benchApp(b)
+	runBenchmark(b, func() error { _, err := app.JobOpenings.List(); return err })
+}
+
+func BenchmarkMatchCandidates(b *testing.B) {
+	app := benchApp(b)
+	id := benchJobOpeningID(b, app)
+	runBenchmark(b, func() error { return matchCandidates(app, id) })
+}
+
+func BenchmarkExportCSV(b *testing.B) {
+	app := benchApp(b)
+	runBenchmark(b, func() error { return exportCSV(app) })
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Рыночная статистика зарплат по навыку; пустой регион — данные по всей стране.
+type SalaryBenchmark struct {
+	Skill      string
+	Region     string
+	P25        float64
+	Median     float64
+	P75        float64
+	SampleSize int
+	Source     string
+	UpdatedAt  time.Time
+}
+
+type VacancyBenchmark struct {
+	JobOpeningID int     `json:"job_opening_id"`
+	Title        string  `json:"title"`
+	Salary       float64 `json:"salary"`
+	MarketP25    float64 `json:"market_p25"`
+	MarketMedian float64 `json:"market_median"`
+	// Навыки вакансии, для которых нашлась статистика; без них сравнение не выполняется.
+	BenchmarkedSkills []string `json:"benchmarked_skills"`
+	BelowMarket       bool     `json:"below_market"`
+}
+
+func benchmarkSkillKey(skill string) string {
+	return strings.ToLower(strings.TrimSpace(skill))
+}
+
+// Ожидается CSV с заголовком; обязательны колонки skill и median, остальные (region, p25, p75, sample_size) — по желанию.
+func readBenchmarksCSV(r io.Reader, source string) ([]SalaryBenchmark, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, validationErrorf("файл пуст")
+	}
+	if err != nil {
+		return nil, validationErrorf("ошибка чтения заголовка CSV: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"skill", "median"} {
+		if _, ok := columns[required]; !ok {
+			return nil, validationErrorf("в заголовке CSV нет колонки %s", required)
+		}
+	}
+
+	var benchmarks []SalaryBenchmark
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, validationErrorf("строка %d: %v", line, err)
+		}
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) (float64, error) {
+			if value(name) == "" {
+				return 0, nil
+			}
+			n, err := strconv.ParseFloat(value(name), 64)
+			if err != nil || n < 0 {
+				return 0, validationErrorf("строка %d: %s должно быть неотрицательным числом", line, name)
+			}
+			return n, nil
+		}
+
+		b := SalaryBenchmark{Skill: benchmarkSkillKey(value("skill")), Region: strings.ToUpper(value("region")), Source: source}
+		if b.Skill == "" {
+			return nil, validationErrorf("строка %d: не указан навык", line)
+		}
+		if b.Median, err = number("median"); err != nil {
+			return nil, err
+		}
+		if b.Median == 0 {
+			return nil, validationErrorf("строка %d: не указана медианная зарплата", line)
+		}
+		if b.P25, err = number("p25"); err != nil {
+			return nil, err
+		}
+		if b.P75, err = number("p75"); err != nil {
+			return nil, err
+		}
+		sampleSize, err := number("sample_size")
+		if err != nil {
+			return nil, err
+		}
+		b.SampleSize = int(sampleSize)
+		benchmarks = append(benchmarks, b)
+	}
+	return benchmarks, nil
+}
+
+// Повторный импорт той же пары навык/регион заменяет статистику.
+func saveBenchmarks(db *sql.DB, benchmarks []SalaryBenchmark) error {
+	return storage.WithTx(db, func(tx storage.DBTX) error {
+		for _, b := range benchmarks {
+			_, err := tx.Exec(`INSERT INTO salary_benchmarks (skill, region, p25, median, p75, sample_size, source, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, now())
+			ON CONFLICT (skill, region) DO UPDATE SET p25 = EXCLUDED.p25, median = EXCLUDED.median, p75 = EXCLUDED.p75,
+				sample_size = EXCLUDED.sample_size, source = EXCLUDED.source, updated_at = now()`,
+				b.Skill, b.Region, b.P25, b.Median, b.P75, b.SampleSize, b.Source)
+			if err != nil {
+				return fmt.Errorf("ошибка сохранения статистики по навыку %s: %w", b.Skill, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Статистика региона имеет приоритет над общей по стране.
+func loadBenchmarks(db storage.DBTX, region string) (map[string]SalaryBenchmark, error) {
+	rows, err := db.Query(`SELECT skill, region, p25, median, p75, sample_size, source, updated_at
+    FROM salary_benchmarks WHERE region = '' OR region = $1 ORDER BY region`, strings.ToUpper(region))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	benchmarks := map[string]SalaryBenchmark{}
+	for rows.Next() {
+		var b SalaryBenchmark
+		if err := rows.Scan(&b.Skill, &b.Region, &b.P25, &b.Median, &b.P75, &b.SampleSize, &b.Source, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		benchmarks[b.Skill] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return benchmarks, nil
+}
+
+// Рыночная оценка вакансии — среднее медиан (и 25-х перцентилей) по её навыкам, для которых есть статистика.
+// Вакансия считается ниже рынка, если зарплата меньше threshold от этой медианы. Статистика — в валюте
+// SALARY_CURRENCY, вилка вакансии сравнивается серединой, переведённой в эту валюту.
+func compareWithMarket(app *App, companyID int, region string, threshold float64) ([]VacancyBenchmark, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, validationErrorf("порог должен быть в диапазоне (0; 1]")
+	}
+	if _, err := getCompany(app, companyID); err != nil {
+		return nil, err
+	}
+	benchmarks, err := loadBenchmarks(app.reader(), region)
+	if err != nil {
+		return nil, err
+	}
+	if len(benchmarks) == 0 {
+		return nil, notFoundError("рыночная статистика не загружена, импортируйте её командой benchmark import")
+	}
+	jobOpenings, err := app.JobOpenings.List()
+	if err != nil {
+		return nil, err
+	}
+	rates, err := loadCurrencyRates(app.reader())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []VacancyBenchmark
+	for _, j := range jobOpenings {
+		if j.CompanyID != companyID {
+			continue
+		}
+		salary, ok := rates.convert(jobSalary(j), j.SalaryCurrency, salaryCurrency())
+		if !ok {
+			continue
+		}
+		v := VacancyBenchmark{JobOpeningID: j.ID, Title: j.Title, Salary: salary}
+		var p25Sum float64
+		var p25Count int
+		for _, skill := range j.RequiredSkills {
+			b, ok := benchmarks[benchmarkSkillKey(skill)]
+			if !ok {
+				continue
+			}
+			v.BenchmarkedSkills = append(v.BenchmarkedSkills, skill)
+			v.MarketMedian += b.Median
+			if b.P25 > 0 {
+				p25Sum += b.P25
+				p25Count++
+			}
+		}
+		if n := len(v.BenchmarkedSkills); n > 0 {
+			v.MarketMedian /= float64(n)
+			if p25Count > 0 {
+				v.MarketP25 = p25Sum / float64(p25Count)
+			}
+			v.BelowMarket = v.Salary < v.MarketMedian*threshold
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func printVacancyBenchmarks(w io.Writer, result []VacancyBenchmark) {
+	if len(result) == 0 {
+		fmt.Fprintln(w, "У компании нет вакансий.")
+		return
+	}
+	fmt.Fprintf(w, "%-6s %-30s %12s %12s %8s\n", "ID", "Вакансия", "Зарплата", "Рынок", "Доля")
+	below := 0
+	for _, v := range result {
+		if len(v.BenchmarkedSkills) == 0 {
+			fmt.Fprintf(w, "%-6d %-30s %12.0f %12s %8s\n", v.JobOpeningID, v.Title, v.Salary, "нет данных", "—")
+			continue
+		}
+		mark := ""
+		if v.BelowMarket {
+			mark = "  ниже рынка"
+			below++
+		}
+		fmt.Fprintf(w, "%-6d %-30s %12.0f %12.0f %7.0f%%%s\n", v.JobOpeningID, v.Title, v.Salary, v.MarketMedian, v.Salary/v.MarketMedian*100, mark)
+	}
+	if below > 0 {
+		fmt.Fprintf(w, "\nВакансий со значительно заниженной зарплатой: %d\n", below)
+	}
+}
+
+// benchmark import <файл.csv> [-source имя] | benchmark compare <ID компании> [-region код] [-threshold доля] [-snapshot]
+func runBenchmarkCommand(app *App, args []string) int {
+	usage := "Использование: benchmark import <файл.csv> [-source имя] | benchmark compare <ID компании> [-region код] [-threshold 0.8] [-snapshot]"
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "import":
+		flags := flag.NewFlagSet("benchmark import", flag.ContinueOnError)
+		source := flags.String("source", "csv", "источник данных, сохраняется вместе со статистикой")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		defer file.Close()
+		benchmarks, err := readBenchmarksCSV(file, *source)
+		if err == nil {
+			err = saveBenchmarks(app.DB, benchmarks)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Загружено записей статистики: %d", len(benchmarks)), map[string]int{"imported": len(benchmarks)})
+	case "compare":
+		companyID, err := resolveEntityID(app.DB, entityCompany, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		flags := flag.NewFlagSet("benchmark compare", flag.ContinueOnError)
+		region := flags.String("region", "", "регион статистики (по умолчанию — по всей стране)")
+		threshold := flags.Float64("threshold", 0.8, "доля рыночной медианы, ниже которой зарплата считается заниженной")
+		snapshot := flags.Bool("snapshot", reportSnapshotEnabled(), "сравнивать по снимку базы на один момент")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		var result []VacancyBenchmark
+		err = withReportSnapshot(app, *snapshot, func(app *App) (err error) {
+			result, err = compareWithMarket(app, companyID, *region, *threshold)
+			return err
+		})
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: result, text: func(w io.Writer) { printVacancyBenchmarks(w, result) }})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
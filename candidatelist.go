@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+var candidateStatusTitles = map[string]string{
+	storage.CandidateActive:   "ищет работу",
+	storage.CandidatePassive:  "рассматривает предложения",
+	storage.CandidatePlaced:   "трудоустроен",
+	storage.CandidateArchived: "в архиве",
+}
+
+func validateCandidateStatus(status string) error {
+	for _, s := range storage.CandidateStatuses {
+		if s == status {
+			return nil
+		}
+	}
+	return validationErrorf("неизвестный статус кандидата %q, допустимые: %s", status, strings.Join(storage.CandidateStatuses, ", "))
+}
+
+func validateCandidateStatusValue(value interface{}) error {
+	s, _ := value.(string)
+	return validateCandidateStatus(s)
+}
+
+// Дата в формате ГГГГ-ММ-ДД; пустая строка — nil.
+func parseOptionalDate(text string) (*time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return nil, validationErrorf("дата %q должна быть в формате ГГГГ-ММ-ДД", text)
+	}
+	return &t, nil
+}
+
+// Фильтр списка кандидатов в том виде, в каком его задают команда candidates и метод candidate.list.
+// Даты — ГГГГ-ММ-ДД; ActiveBefore включает указанный день. Ожидаемая зарплата — в валюте Currency
+// (пустая — SALARY_CURRENCY).
+type CandidateListFilter struct {
+	Statuses      []string `json:"statuses"`
+	Tags          []string `json:"tags"`
+	MinExperience float64  `json:"min_experience"`
+	MaxExperience float64  `json:"max_experience"`
+	Location      string   `json:"location"`
+	AvailableBy   string   `json:"available_by"`
+	ActiveSince   string   `json:"active_since"`
+	ActiveBefore  string   `json:"active_before"`
+	MinSalary     float64  `json:"min_salary"`
+	MaxSalary     float64  `json:"max_salary"`
+	Currency      string   `json:"currency"`
+}
+
+func (f CandidateListFilter) filter() (storage.CandidateFilter, error) {
+	var filter storage.CandidateFilter
+	for _, status := range f.Statuses {
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		if err := validateCandidateStatus(status); err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	var err error
+	if filter.Tags, err = sanitizeSkills(f.Tags); err != nil {
+		return filter, err
+	}
+	if f.MinExperience < 0 || f.MaxExperience < 0 || (f.MaxExperience > 0 && f.MaxExperience < f.MinExperience) {
+		return filter, validationErrorf("стаж: «от» и «до» не могут быть отрицательными, «до» не может быть меньше «от»")
+	}
+	filter.MinExperience, filter.MaxExperience = f.MinExperience, f.MaxExperience
+	if f.MinSalary < 0 || f.MaxSalary < 0 || (f.MaxSalary > 0 && f.MaxSalary < f.MinSalary) {
+		return filter, validationErrorf("зарплата: «от» и «до» не могут быть отрицательными, «до» не может быть меньше «от»")
+	}
+	filter.MinSalary, filter.MaxSalary = f.MinSalary, f.MaxSalary
+	if filter.SalaryCurrency, err = normalizeCurrency(f.Currency); err != nil {
+		return filter, err
+	}
+	if filter.SalaryCurrency == "" {
+		filter.SalaryCurrency = salaryCurrency()
+	}
+	if filter.Location, err = sanitizeText("город", f.Location, maxNameLength); err != nil {
+		return filter, err
+	}
+	if filter.AvailableBy, err = parseOptionalDate(f.AvailableBy); err != nil {
+		return filter, err
+	}
+	if filter.ActiveSince, err = parseOptionalDate(f.ActiveSince); err != nil {
+		return filter, err
+	}
+	if filter.ActiveBefore, err = parseOptionalDate(f.ActiveBefore); err != nil {
+		return filter, err
+	}
+	if filter.ActiveBefore != nil {
+		next := filter.ActiveBefore.AddDate(0, 0, 1)
+		filter.ActiveBefore = &next
+	}
+	return filter, nil
+}
+
+// candidates [-status s,…] [-tag t,…] [-min-exp лет] [-max-exp лет] [-min-salary сумма] [-max-salary сумма] [-currency код]
+// [-location город] [-available-by дата] [-active-since дата] [-active-before дата] [-page N] [-per-page N] [-sort поле]
+func runCandidatesCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("candidates", flag.ContinueOnError)
+	var f CandidateListFilter
+	var statuses, tags string
+	flags.StringVar(&statuses, "status", "", "статусы через запятую: "+strings.Join(storage.CandidateStatuses, ", "))
+	flags.StringVar(&tags, "tag", "", "теги через запятую; нужны все")
+	flags.Float64Var(&f.MinExperience, "min-exp", 0, "стаж не меньше, лет")
+	flags.Float64Var(&f.MaxExperience, "max-exp", 0, "стаж не больше, лет")
+	flags.Float64Var(&f.MinSalary, "min-salary", 0, "ожидаемая зарплата не меньше")
+	flags.Float64Var(&f.MaxSalary, "max-salary", 0, "ожидаемая зарплата не больше")
+	flags.StringVar(&f.Currency, "currency", "", "валюта зарплаты в фильтре, по умолчанию SALARY_CURRENCY")
+	flags.StringVar(&f.Location, "location", "", "город")
+	flags.StringVar(&f.AvailableBy, "available-by", "", "может выйти не позже даты ГГГГ-ММ-ДД")
+	flags.StringVar(&f.ActiveSince, "active-since", "", "последняя активность не раньше даты ГГГГ-ММ-ДД")
+	flags.StringVar(&f.ActiveBefore, "active-before", "", "последняя активность не позже даты ГГГГ-ММ-ДД")
+	q := ListQuery{}
+	flags.IntVar(&q.Page, "page", 1, "номер страницы")
+	flags.IntVar(&q.PerPage, "per-page", 50, "кандидатов на странице")
+	flags.StringVar(&q.Sort, "sort", "", "поле сортировки: "+sortFieldNames(storage.CandidateSorts)+"; «-» впереди — по убыванию")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	f.Statuses = splitList(statuses, ",")
+	f.Tags = splitList(tags, ",")
+	candidates, total, err := listCandidates(app, f, q)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: candidates, text: func(w io.Writer) {
+		if len(candidates) == 0 {
+			fmt.Fprintln(w, "Кандидаты не найдены.")
+			return
+		}
+		for _, c := range candidates {
+			fmt.Fprintf(w, "ID: %d, ФИО: %s, Статус: %s, Опыт: %s, Город: %s, Теги: %s\n", c.ID, c.FullName, c.Status, c.Experience,
+				c.Location, strings.Join(c.Tags, ", "))
+		}
+		fmt.Fprintf(w, "Страница %d, показано %d из %d.\n", q.Page, len(candidates), total)
+	}})
+}
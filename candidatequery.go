@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"your_project_name/storage"
+)
+
+// Язык запросов к кандидатам:
+//
+//	(go OR golang) AND postgres AND NOT junior AND salary<=200000
+//
+// Слово без поля — навык, а если это название уровня (junior, middle, senior, lead) — уровень.
+// Явные поля: skill, seniority, location (city), language, salary, age, experience (стаж в годах); «:» равносилен «=».
+// salary задаётся в валюте SALARY_CURRENCY, ожидания кандидатов в других валютах переводятся по курсам.
+// AND можно опускать: «go postgres» — то же, что «go AND postgres». Значения с пробелами берутся в кавычки.
+// Запрос компилируется в условие WHERE, где значения передаются только параметрами, а колонки и операторы
+// выбираются из фиксированных списков.
+
+const maxQueryTerms = 50
+
+type queryTokenKind int
+
+const (
+	tokWord queryTokenKind = iota
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokEOF
+)
+
+type queryToken struct {
+	Kind queryTokenKind
+	Text string
+	Pos  int
+}
+
+func isQueryWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("+#._-/", r)
+}
+
+func tokenizeQuery(text string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{tokRParen, ")", i})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, validationErrorf("незакрытая кавычка в позиции %d", i+1)
+			}
+			tokens = append(tokens, queryToken{tokString, string(runes[i+1 : end]), i})
+			i = end + 1
+		case strings.ContainsRune("<>=!:", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != '=' && r != ':' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, validationErrorf("неизвестный оператор «!» в позиции %d, используйте != или NOT", i+1)
+			}
+			tokens = append(tokens, queryToken{tokOp, op, i})
+			i += len([]rune(op))
+		case isQueryWordRune(r):
+			start := i
+			for i < len(runes) && isQueryWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{tokWord, string(runes[start:i]), start})
+		default:
+			return nil, validationErrorf("недопустимый символ %q в позиции %d", r, i+1)
+		}
+	}
+	return append(tokens, queryToken{Kind: tokEOF, Pos: len(runes)}), nil
+}
+
+// Узлы разобранного запроса.
+type queryNode interface {
+	compile(b *queryBuilder) string
+}
+
+type queryAnd struct{ Left, Right queryNode }
+type queryOr struct{ Left, Right queryNode }
+type queryNot struct{ Operand queryNode }
+
+type queryCondition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+	terms  int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	t := p.tokens[p.pos]
+	if t.Kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) keyword(t queryToken, word string) bool {
+	return t.Kind == tokWord && strings.EqualFold(t.Text, word)
+}
+
+func parseCandidateQuery(text string) (queryNode, error) {
+	tokens, err := tokenizeQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, validationErrorf("пустой запрос")
+	}
+	p := &queryParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != tokEOF {
+		return nil, validationErrorf("неожиданное %q в позиции %d", t.Text, t.Pos+1)
+	}
+	return node, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword(p.peek(), "OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if p.keyword(t, "AND") {
+			p.next()
+		} else if t.Kind == tokEOF || t.Kind == tokRParen || p.keyword(t, "OR") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = queryAnd{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.keyword(p.peek(), "NOT") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	t := p.next()
+	switch t.Kind {
+	case tokLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.Kind != tokRParen {
+			return nil, validationErrorf("ожидалась «)» в позиции %d", closing.Pos+1)
+		}
+		return node, nil
+	case tokWord, tokString:
+		p.terms++
+		if p.terms > maxQueryTerms {
+			return nil, validationErrorf("в запросе больше %d условий", maxQueryTerms)
+		}
+		if t.Kind == tokWord && p.peek().Kind == tokOp {
+			return p.parseComparison(t)
+		}
+		if t.Kind == tokWord && isSeniorityName(t.Text) {
+			return queryCondition{Field: "seniority", Op: "=", Value: t.Text}, nil
+		}
+		return queryCondition{Field: "skill", Op: "=", Value: t.Text}, nil
+	case tokEOF:
+		return nil, validationErrorf("запрос обрывается: ожидалось условие")
+	}
+	return nil, validationErrorf("неожиданное %q в позиции %d", t.Text, t.Pos+1)
+}
+
+// Поля запроса: числовые сравниваются любым оператором, текстовые — только на равенство и неравенство.
+var queryFields = map[string]struct {
+	Numeric bool
+}{
+	"skill":      {},
+	"seniority":  {},
+	"location":   {},
+	"language":   {},
+	"salary":     {Numeric: true},
+	"age":        {Numeric: true},
+	"experience": {Numeric: true},
+}
+
+var queryFieldAliases = map[string]string{"city": "location", "город": "location", "навык": "skill", "зарплата": "salary", "возраст": "age", "lang": "language", "exp": "experience", "опыт": "experience", "стаж": "experience"}
+
+func (p *queryParser) parseComparison(field queryToken) (queryNode, error) {
+	name := strings.ToLower(field.Text)
+	if alias, ok := queryFieldAliases[name]; ok {
+		name = alias
+	}
+	spec, ok := queryFields[name]
+	if !ok {
+		return nil, validationErrorf("неизвестное поле %q в позиции %d, доступны: skill, seniority, location, language, salary, age, experience", field.Text, field.Pos+1)
+	}
+	op := p.next()
+	if op.Text == ":" {
+		op.Text = "="
+	}
+	if !spec.Numeric && op.Text != "=" && op.Text != "!=" {
+		return nil, validationErrorf("поле %s сравнивается только через = или !=", name)
+	}
+	value := p.next()
+	if value.Kind != tokWord && value.Kind != tokString {
+		return nil, validationErrorf("ожидалось значение поля %s в позиции %d", name, value.Pos+1)
+	}
+	if spec.Numeric {
+		if _, err := strconv.ParseFloat(value.Text, 64); err != nil {
+			return nil, validationErrorf("значение поля %s должно быть числом, а не %q", name, value.Text)
+		}
+	}
+	return queryCondition{Field: name, Op: op.Text, Value: value.Text}, nil
+}
+
+func isSeniorityName(word string) bool {
+	for _, level := range seniorityLevels {
+		if strings.EqualFold(level.Name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Собирает параметры запроса; каждое значение попадает в SQL только как $n.
+type queryBuilder struct {
+	args []interface{}
+}
+
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (n queryAnd) compile(b *queryBuilder) string {
+	return "(" + n.Left.compile(b) + " AND " + n.Right.compile(b) + ")"
+}
+
+func (n queryOr) compile(b *queryBuilder) string {
+	return "(" + n.Left.compile(b) + " OR " + n.Right.compile(b) + ")"
+}
+
+func (n queryNot) compile(b *queryBuilder) string {
+	return "NOT (" + n.Operand.compile(b) + ")"
+}
+
+func (n queryCondition) compile(b *queryBuilder) string {
+	var condition string
+	switch n.Field {
+	case "skill":
+		condition = "EXISTS (SELECT 1 FROM jsonb_array_elements_text(COALESCE(skills, '[]')) AS s(v) WHERE lower(s.v) = lower(" + b.arg(n.Value) + "))"
+	case "language":
+		condition = "EXISTS (SELECT 1 FROM jsonb_array_elements_text(languages) AS l(v) WHERE lower(l.v) = lower(" + b.arg(n.Value) + "))"
+	case "seniority":
+		condition = "COALESCE(experience, '') ILIKE " + b.arg("%"+storage.EscapeLike(n.Value)+"%")
+	case "location":
+		condition = "lower(location) = lower(" + b.arg(n.Value) + ")"
+	case "salary", "age", "experience":
+		column := map[string]string{"salary": storage.BaseSalarySQL("expected_salary"), "age": "age", "experience": storage.ExperienceYearsSQL}[n.Field]
+		value, _ := strconv.ParseFloat(n.Value, 64)
+		op := n.Op
+		if op == "!=" {
+			op = "<>"
+		}
+		param := b.arg(value)
+		if n.Field == "salary" {
+			param += " * (SELECT rate FROM currency_rates WHERE currency = " + b.arg(salaryCurrency()) + ")"
+		}
+		return column + " " + op + " " + param
+	}
+	if n.Op == "!=" {
+		return "NOT (" + condition + ")"
+	}
+	return condition
+}
+
+// Разбирает запрос и возвращает условие WHERE с параметрами $1…$n.
+func compileCandidateQuery(text string) (string, []interface{}, error) {
+	node, err := parseCandidateQuery(text)
+	if err != nil {
+		return "", nil, err
+	}
+	b := &queryBuilder{}
+	return node.compile(b), b.args, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCandidateQuery(t *testing.T) {
+	skill := func(v string) queryCondition { return queryCondition{Field: "skill", Op: "=", Value: v} }
+	tests := []struct {
+		name  string
+		query string
+		want  queryNode
+	}{
+		{"одно слово — навык", "go", skill("go")},
+		{"уровень без поля", "Senior", queryCondition{Field: "seniority", Op: "=", Value: "Senior"}},
+		{"неявный AND", "go postgres", queryAnd{skill("go"), skill("postgres")}},
+		{"AND без учёта регистра", "go and postgres", queryAnd{skill("go"), skill("postgres")}},
+		{"AND связывает сильнее OR", "go OR rust postgres",
+			queryOr{skill("go"), queryAnd{skill("rust"), skill("postgres")}}},
+		{"скобки", "(go OR rust) postgres",
+			queryAnd{queryOr{skill("go"), skill("rust")}, skill("postgres")}},
+		{"NOT", "NOT junior", queryNot{queryCondition{Field: "seniority", Op: "=", Value: "junior"}}},
+		{"двойное NOT", "NOT NOT go", queryNot{queryNot{skill("go")}}},
+		{"числовое сравнение", "salary<=200000", queryCondition{Field: "salary", Op: "<=", Value: "200000"}},
+		{"двоеточие — равенство", "city:Москва", queryCondition{Field: "location", Op: "=", Value: "Москва"}},
+		{"псевдоним поля", "стаж>=3", queryCondition{Field: "experience", Op: ">=", Value: "3"}},
+		{"значение в кавычках", `location="Нижний Новгород"`,
+			queryCondition{Field: "location", Op: "=", Value: "Нижний Новгород"}},
+		{"неравенство текста", "language!=english", queryCondition{Field: "language", Op: "!=", Value: "english"}},
+		{"символы навыков", "c++ c# node.js", queryAnd{queryAnd{skill("c++"), skill("c#")}, skill("node.js")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCandidateQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseCandidateQuery(%q): %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCandidateQuery(%q) = %#v, ожидалось %#v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCandidateQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"пустой запрос", "   ", "пустой запрос"},
+		{"незакрытая кавычка", `location="Москва`, "незакрытая кавычка"},
+		{"незакрытая скобка", "(go OR rust", "ожидалась «)»"},
+		{"лишняя скобка", "go)", "неожиданное"},
+		{"обрыв после AND", "go AND", "запрос обрывается"},
+		{"обрыв после NOT", "NOT", "запрос обрывается"},
+		{"одиночный !", "go ! rust", "неизвестный оператор"},
+		{"недопустимый символ", "go; DROP TABLE candidates", "недопустимый символ"},
+		{"неизвестное поле", "password=1", "неизвестное поле"},
+		{"нечисловое значение", "salary>много", "должно быть числом"},
+		{"сравнение текста", "location>Москва", "только через = или !="},
+		{"нет значения", "age>=", "ожидалось значение"},
+		{"слишком много условий", strings.Repeat("go ", maxQueryTerms+1), "больше"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCandidateQuery(tt.query)
+			if err == nil {
+				t.Fatalf("parseCandidateQuery(%q): ожидалась ошибка", tt.query)
+			}
+			if classifyError(err) != kindValidation {
+				t.Errorf("ошибка %v не считается ошибкой ввода", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %q не содержит %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Значения из запроса попадают в SQL только параметрами.
+func TestCompileCandidateQueryParams(t *testing.T) {
+	where, args, err := compileCandidateQuery(`"'; DROP TABLE candidates; --" OR location="O'Reilly" OR age<30`)
+	if err != nil {
+		t.Fatalf("compileCandidateQuery: %v", err)
+	}
+	if strings.Contains(where, "DROP") || strings.Contains(where, "Reilly") {
+		t.Errorf("значение запроса попало в текст SQL: %s", where)
+	}
+	want := []interface{}{"'; DROP TABLE candidates; --", "O'Reilly", 30.0}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("параметры %#v, ожидалось %#v", args, want)
+	}
+	for i := range want {
+		if !strings.Contains(where, fmt.Sprintf("$%d", i+1)) {
+			t.Errorf("в условии %s нет параметра $%d", where, i+1)
+		}
+	}
+}
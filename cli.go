@@ -0,0 +1,266 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Команды candidate и job — то же, что пункты меню о кандидатах и вакансиях, но без вопросов: всё задаётся
+// аргументами, результат печатается одной строкой или в JSON (-json), а об ошибке сообщает код возврата.
+// Так программу можно вызывать из скриптов и cron; --format json или csv выводит результат для разбора. Поля в update называются так же, как в методах
+// candidate.update и jobOpening.update: «поле=значение», списки — через запятую. Меню запускается командой
+// interactive или без команды.
+
+const (
+	cliCandidateUsage = "Использование: candidate add -name ФИО [-age N] [-email адрес] [-phone номер] [-experience текст] [-skills a,b] " +
+		"[-languages a,b] [-salary «сумма [валюта]»] [-location город] [-json]\n" +
+		"               candidate get <ID, ФИО или email> [-json]\n" +
+		"               candidate update <ID, ФИО или email> поле=значение…\n" +
+		"               candidate delete <ID, ФИО или email>"
+	cliJobUsage = "Использование: job add -company <ID или название> -title название [-experience текст] [-skills a,b] [-languages a,b] " +
+		"[-salary «150000-200000 USD»] [-location город] [-json]\n" +
+		"               job list [-skill навык] [-status статус|all] [-salary-from сумма] [-salary-to сумма] [-currency код] " +
+		"[-page N] [-per-page N] [-sort поле] [-json]\n" +
+		"               job get <ID или название> [-json]\n" +
+		"               job update <ID или название> поле=значение…\n" +
+		"               job status <ID или название> <open|on_hold|closed>\n" +
+		"               job delete <ID или название>"
+)
+
+// Разбирает «поле=значение» по описаниям полей; проверка значений — в fieldColumns, как у меню и RPC.
+func parseFieldAssignments(fields []EditableField, args []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, arg := range args {
+		name, text, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, validationErrorf("%q: ожидается поле=значение", arg)
+		}
+		field, ok := findEditableField(fields, strings.TrimSpace(name))
+		if !ok {
+			names := make([]string, len(fields))
+			for i, f := range fields {
+				names[i] = f.Name
+			}
+			return nil, validationErrorf("поле %q нельзя изменять, допустимые: %s", name, strings.Join(names, ", "))
+		}
+		value, err := parseFieldValue(field, strings.TrimSpace(text))
+		if err != nil {
+			return nil, validationErrorf("поле %s: %v", field.Name, err)
+		}
+		values[field.Name] = value
+	}
+	return values, nil
+}
+
+func runCandidateCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(cliCandidateUsage)
+		return exitUsage
+	}
+	if args[0] == "add" {
+		return runCandidateAddCommand(app, args[1:])
+	}
+	if len(args) < 2 {
+		fmt.Println(cliCandidateUsage)
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityCandidate, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	switch args[0] {
+	case "get":
+		flags := flag.NewFlagSet("candidate get", flag.ContinueOnError)
+		asJSON := flags.Bool("json", false, "вывести кандидата в JSON")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		useJSON(*asJSON)
+		candidate, err := getCandidateByID(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: candidate, text: func(w io.Writer) { writeCandidate(w, candidate) }})
+	case "update":
+		values, err := parseFieldAssignments(candidateEditableFields, args[2:])
+		if err == nil {
+			err = updateCandidateFields(app, id, values)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Кандидат обновлён.", map[string]int{"id": id})
+	case "delete":
+		if err := deleteCandidate(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Кандидат удалён.", map[string]int{"id": id})
+	}
+	fmt.Println(cliCandidateUsage)
+	return exitUsage
+}
+
+func runCandidateAddCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("candidate add", flag.ContinueOnError)
+	var c storage.Candidate
+	flags.StringVar(&c.FullName, "name", "", "ФИО кандидата")
+	flags.IntVar(&c.Age, "age", 0, "возраст")
+	flags.StringVar(&c.Email, "email", "", "адрес почты")
+	flags.StringVar(&c.Phone, "phone", "", "телефон")
+	flags.StringVar(&c.Experience, "experience", "", "опыт работы")
+	flags.StringVar(&c.Location, "location", "", "город")
+	skills := flags.String("skills", "", "навыки через запятую")
+	languages := flags.String("languages", "", "языки через запятую")
+	salary := flags.String("salary", "", "ожидаемая зарплата и, через пробел, валюта")
+	asJSON := flags.Bool("json", false, `вывести {"id": N}`)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Println(cliCandidateUsage)
+		return exitUsage
+	}
+	useJSON(*asJSON)
+	c.Skills = parseFormList(*skills)
+	c.Languages = parseFormList(*languages)
+	if *salary != "" {
+		var err error
+		if c.ExpectedSalary, c.SalaryCurrency, err = parseSalaryAmount(*salary); err != nil {
+			return reportError(err)
+		}
+	}
+	id, err := addCandidate(app, c)
+	if err != nil {
+		return reportError(err)
+	}
+	return printDone(fmt.Sprintf("Кандидат добавлен, ID: %d", id), map[string]int{"id": id})
+}
+
+func runJobCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(cliJobUsage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "add":
+		return runJobAddCommand(app, args[1:])
+	case "list":
+		return runJobListCommand(app, args[1:])
+	}
+	if len(args) < 2 {
+		fmt.Println(cliJobUsage)
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityJobOpening, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	switch {
+	case args[0] == "get":
+		flags := flag.NewFlagSet("job get", flag.ContinueOnError)
+		asJSON := flags.Bool("json", false, "вывести вакансию в JSON")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		useJSON(*asJSON)
+		jobOpening, err := getJobOpeningByID(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: jobOpening, text: func(w io.Writer) { writeJobOpening(w, jobOpening) }})
+	case args[0] == "update":
+		values, err := parseFieldAssignments(jobOpeningEditableFields, args[2:])
+		if err == nil {
+			err = updateJobOpeningFields(app, id, values)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Вакансия обновлена.", map[string]int{"id": id})
+	case args[0] == "status" && len(args) == 3:
+		if err := setJobOpeningStatus(app, id, args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Статус вакансии изменён.", map[string]interface{}{"id": id, "status": args[2]})
+	case args[0] == "delete":
+		if err := deleteJobOpening(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Вакансия удалена.", map[string]int{"id": id})
+	}
+	fmt.Println(cliJobUsage)
+	return exitUsage
+}
+
+func runJobAddCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("job add", flag.ContinueOnError)
+	var j storage.JobOpening
+	company := flags.String("company", "", "ID или название компании")
+	flags.StringVar(&j.Title, "title", "", "название вакансии")
+	flags.StringVar(&j.Experience, "experience", "", "требуемый опыт")
+	flags.StringVar(&j.Location, "location", "", "город")
+	skills := flags.String("skills", "", "требуемые навыки через запятую")
+	languages := flags.String("languages", "", "требуемые языки через запятую")
+	salary := flags.String("salary", "", "зарплата: сумма, вилка «от-до», «от …» или «до …» и валюта")
+	asJSON := flags.Bool("json", false, `вывести {"id": N}`)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() > 0 || *company == "" {
+		fmt.Println(cliJobUsage)
+		return exitUsage
+	}
+	useJSON(*asJSON)
+	var err error
+	if j.CompanyID, err = resolveEntityID(app.DB, entityCompany, *company); err != nil {
+		return reportError(err)
+	}
+	if j.SalaryMin, j.SalaryMax, j.SalaryCurrency, err = parseSalaryRange(*salary); err != nil {
+		return reportError(err)
+	}
+	j.RequiredSkills = parseFormList(*skills)
+	j.Languages = parseFormList(*languages)
+	id, err := addJobOpening(app, j)
+	if err != nil {
+		return reportError(err)
+	}
+	return printDone(fmt.Sprintf("Вакансия добавлена, ID: %d", id), map[string]int{"id": id})
+}
+
+func runJobListCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("job list", flag.ContinueOnError)
+	var f JobOpeningListFilter
+	flags.StringVar(&f.Skill, "skill", "", "требуемый навык")
+	flags.StringVar(&f.Status, "status", "", "статус: "+strings.Join(storage.JobStatuses, ", ")+" или all; по умолчанию открытые")
+	flags.Float64Var(&f.SalaryFrom, "salary-from", 0, "зарплата не меньше")
+	flags.Float64Var(&f.SalaryTo, "salary-to", 0, "зарплата не больше")
+	flags.StringVar(&f.Currency, "currency", "", "валюта зарплаты в фильтре, по умолчанию SALARY_CURRENCY")
+	q := ListQuery{}
+	flags.IntVar(&q.Page, "page", 1, "номер страницы")
+	flags.IntVar(&q.PerPage, "per-page", 50, "вакансий на странице")
+	flags.StringVar(&q.Sort, "sort", "", "поле сортировки: "+sortFieldNames(storage.JobOpeningSorts)+"; «-» впереди — по убыванию")
+	asJSON := flags.Bool("json", false, "вывести вакансии в JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	useJSON(*asJSON)
+	jobOpenings, total, err := listJobOpenings(app, f, q)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: jobOpenings, text: func(w io.Writer) {
+		if len(jobOpenings) == 0 {
+			fmt.Fprintln(w, "Вакансии не найдены.")
+			return
+		}
+		for _, j := range jobOpenings {
+			fmt.Fprintf(w, "ID: %d, Название: %s, Компания ID: %d, Статус: %s, Зарплата: %s, Навыки: %s\n", j.ID, j.Title, j.CompanyID,
+				j.Status, jobSalaryText(j), strings.Join(j.RequiredSkills, ", "))
+		}
+		fmt.Fprintf(w, "Страница %d, показано %d из %d.\n", q.Page, len(jobOpenings), total)
+	}})
+}
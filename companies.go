@@ -0,0 +1,447 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+const companyExpiryJob = "companies.expire"
+
+func trialPeriod() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("COMPANY_TRIAL_DAYS"))
+	if err != nil || days <= 0 {
+		days = 14
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Задача переводит компании с истёкшим сроком в expired и ставит себя на следующий час.
+func handleCompanyExpiryJob(db *sql.DB, payload json.RawMessage) error {
+	if _, err := storage.NewPostgres(db).Companies.ExpireDue(time.Now()); err != nil {
+		return err
+	}
+	_, err := enqueueJobAt(db, companyExpiryJob, struct{}{}, time.Now().Add(time.Hour))
+	return err
+}
+
+func init() {
+	registerJobHandler(companyExpiryJob, handleCompanyExpiryJob)
+}
+
+func scheduleCompanyExpiry(db *sql.DB) error {
+	scheduled, err := jobScheduled(db, companyExpiryJob)
+	if err != nil || scheduled {
+		return err
+	}
+	_, err = enqueueJob(db, companyExpiryJob, struct{}{})
+	return err
+}
+
+func getCompany(app *App, id int) (storage.Company, error) {
+	company, err := app.Companies.GetByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.Company{}, notFoundError("компания не найдена")
+	}
+	return company, err
+}
+
+// Продление отсчитывается от текущего срока, а если он уже прошёл — от сегодняшнего дня.
+// Истёкшая компания после продления снова становится активной.
+func extendCompany(app *App, id, days int) (time.Time, error) {
+	if days <= 0 {
+		return time.Time{}, validationErrorf("срок продления должен быть положительным")
+	}
+	var expiresAt time.Time
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		company, err := lockCompany(tx, id)
+		if err != nil {
+			return err
+		}
+		from := time.Now()
+		if company.ExpiresAt != nil && company.ExpiresAt.After(from) {
+			from = *company.ExpiresAt
+		}
+		expiresAt = from.AddDate(0, 0, days)
+		status := company.Status
+		if status == storage.CompanyExpired {
+			status = storage.CompanyActive
+		}
+		return storage.NewPostgres(tx).Companies.SetStatus(id, status, &expiresAt)
+	})
+	return expiresAt, err
+}
+
+// Компания, заблокированная до конца транзакции tx: срок и статус читаются и пишутся без гонки
+// с одновременным продлением или сменой статуса.
+func lockCompany(tx storage.DBTX, id int) (storage.Company, error) {
+	if err := storage.LockRow(tx, "companies", id); err != nil {
+		return storage.Company{}, err
+	}
+	company, err := storage.NewPostgres(tx).Companies.GetByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.Company{}, notFoundError("компания не найдена")
+	}
+	return company, err
+}
+
+func setCompanyStatus(app *App, id int, status string) error {
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		company, err := lockCompany(tx, id)
+		if err != nil {
+			return err
+		}
+		expiresAt := company.ExpiresAt
+		if status == storage.CompanyActive && expiresAt != nil && !expiresAt.After(time.Now()) {
+			expiresAt = nil
+		}
+		return storage.NewPostgres(tx).Companies.SetStatus(id, status, expiresAt)
+	})
+	if err != nil {
+		return err
+	}
+	audit("company.status", "id", id, "status", status)
+	return nil
+}
+
+func renameCompany(app *App, id int, name string) error {
+	name, err := sanitizeText("название компании", name, maxNameLength)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return validationErrorf("название компании не может быть пустым")
+	}
+	err = app.Companies.Rename(id, name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("компания не найдена")
+	}
+	return err
+}
+
+func deleteCompany(app *App, id int, force bool) error {
+	err := app.Companies.Delete(id, force)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("компания не найдена")
+	}
+	if err == nil {
+		audit("company.delete", "id", id, "force", force)
+	}
+	return err
+}
+
+func setCompanyIndustry(app *App, id int, industry string) error {
+	industry, err := sanitizeText("отрасль", industry, maxNameLength)
+	if err != nil {
+		return err
+	}
+	err = app.Companies.SetIndustry(id, industry)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("компания не найдена")
+	}
+	return err
+}
+
+// Каталог компаний с поиском по названию и отрасли.
+func companyDirectory(app *App, search string, q ListQuery) ([]storage.CompanySummary, error) {
+	search, err := sanitizeText("поиск", search, maxNameLength)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := q.options(storage.CompanySorts)
+	if err != nil {
+		return nil, err
+	}
+	return app.Companies.Directory(search, opts)
+}
+
+type CompanyJobOpening struct {
+	ID             int     `json:"id"`
+	Title          string  `json:"title"`
+	SalaryMin      float64 `json:"salary_min"`
+	SalaryMax      float64 `json:"salary_max"`
+	SalaryCurrency string  `json:"salary_currency"`
+	Recruiter      string  `json:"recruiter,omitempty"`
+	Applications   int     `json:"applications"`
+	Hires          int     `json:"hires"`
+	// Открытые отклики, стоящие на этапе дольше SLA.
+	Stale int `json:"stale"`
+}
+
+type CompanyDetails struct {
+	storage.Company
+	// Число вакансий по статусам.
+	JobOpenings     map[string]int      `json:"job_openings"`
+	OpenJobOpenings []CompanyJobOpening `json:"open_job_openings"`
+	Applications    int                 `json:"applications"`
+	Hires           int                 `json:"hires"`
+	// Нанятые, по которым записан уход (retention left).
+	Left             int      `json:"left"`
+	ActiveRecruiters int      `json:"active_recruiters"`
+	AvgDaysToHire    *float64 `json:"avg_days_to_hire,omitempty"`
+	// Состояние воронки: откликов на каждом этапе по всем вакансиям и сколько открытых из них нарушают SLA.
+	Pipeline          map[string]int `json:"pipeline"`
+	StaleApplications int            `json:"stale_applications"`
+}
+
+func companyDetails(app *App, id int) (CompanyDetails, error) {
+	company, err := getCompany(app, id)
+	if err != nil {
+		return CompanyDetails{}, err
+	}
+	details := CompanyDetails{Company: company, JobOpenings: map[string]int{}, OpenJobOpenings: []CompanyJobOpening{}, Pipeline: map[string]int{}}
+	for _, status := range storage.JobStatuses {
+		details.JobOpenings[status] = 0
+	}
+	for _, status := range storage.ApplicationStatuses {
+		details.Pipeline[status] = 0
+	}
+
+	sla := fmt.Sprintf("%d hours", int(applicationSLA().Hours()))
+	rows, err := app.DB.Query(`
+		SELECT j.id, j.title, j.salary_min, j.salary_max, j.salary_currency, j.status, j.recruiter_id, COALESCE(u.username, ''),
+			COUNT(a.id), COUNT(a.id) FILTER (WHERE a.status = $2),
+			COUNT(a.id) FILTER (WHERE a.status NOT IN ('hired', 'rejected') AND a.updated_at < now() - $3::interval)
+		FROM job_openings j
+		LEFT JOIN users u ON u.id = j.recruiter_id
+		LEFT JOIN applications a ON a.job_opening_id = j.id
+		WHERE j.company_id = $1
+		GROUP BY j.id, u.username
+		ORDER BY j.id`, id, storage.ApplicationHired, sla)
+	if err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	recruiters := map[int64]bool{}
+	for rows.Next() {
+		var j CompanyJobOpening
+		var status string
+		var recruiterID sql.NullInt64
+		if err := rows.Scan(&j.ID, &j.Title, &j.SalaryMin, &j.SalaryMax, &j.SalaryCurrency, &status, &recruiterID, &j.Recruiter, &j.Applications, &j.Hires, &j.Stale); err != nil {
+			return CompanyDetails{}, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		details.JobOpenings[status]++
+		details.Applications += j.Applications
+		details.Hires += j.Hires
+		details.StaleApplications += j.Stale
+		if status != storage.JobClosed && recruiterID.Valid {
+			recruiters[recruiterID.Int64] = true
+		}
+		if status == storage.JobOpen {
+			details.OpenJobOpenings = append(details.OpenJobOpenings, j)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	details.ActiveRecruiters = len(recruiters)
+
+	stages, err := app.DB.Query(`
+		SELECT a.status, COUNT(*) FROM applications a
+		JOIN job_openings j ON j.id = a.job_opening_id
+		WHERE j.company_id = $1
+		GROUP BY a.status`, id)
+	if err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer stages.Close()
+	for stages.Next() {
+		var status string
+		var count int
+		if err := stages.Scan(&status, &count); err != nil {
+			return CompanyDetails{}, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		details.Pipeline[status] = count
+	}
+	if err := stages.Err(); err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	var avgDays sql.NullFloat64
+	err = app.DB.QueryRow(`
+		SELECT EXTRACT(EPOCH FROM AVG(h.changed_at - a.created_at)) / 86400
+		FROM application_status_history h
+		JOIN applications a ON a.id = h.application_id
+		JOIN job_openings j ON j.id = a.job_opening_id
+		WHERE j.company_id = $1 AND h.to_status = $2`, id, storage.ApplicationHired).Scan(&avgDays)
+	if err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	if avgDays.Valid {
+		details.AvgDaysToHire = &avgDays.Float64
+	}
+
+	err = app.DB.QueryRow(`
+		SELECT COUNT(*) FROM hire_outcomes o
+		JOIN applications a ON a.id = o.application_id
+		JOIN job_openings j ON j.id = a.job_opening_id
+		WHERE j.company_id = $1 AND o.left_at IS NOT NULL`, id).Scan(&details.Left)
+	if err != nil {
+		return CompanyDetails{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return details, nil
+}
+
+func writeCompanyDetails(w io.Writer, d CompanyDetails) {
+	expires := "бессрочно"
+	if d.ExpiresAt != nil {
+		expires = d.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	fmt.Fprintf(w, "ID: %d\nКомпания: %s\nСтатус: %s\nДействует до: %s\n", d.ID, d.Name, d.Status, expires)
+	if d.Industry != "" {
+		fmt.Fprintf(w, "Отрасль: %s\n", d.Industry)
+	}
+	fmt.Fprintf(w, "Вакансии: открыто %d, приостановлено %d, закрыто %d\n",
+		d.JobOpenings[storage.JobOpen], d.JobOpenings[storage.JobOnHold], d.JobOpenings[storage.JobClosed])
+	fmt.Fprintf(w, "Отклики: %d, наймы: %d, из них ушли: %d\n", d.Applications, d.Hires, d.Left)
+	fmt.Fprintf(w, "Активных рекрутеров: %d, среднее время найма: %s\n", d.ActiveRecruiters, formatDaysToHire(d.AvgDaysToHire))
+	stages := make([]string, 0, len(storage.ApplicationStatuses))
+	for _, status := range storage.ApplicationStatuses {
+		stages = append(stages, fmt.Sprintf("%s %d", applicationStatusTitles[status], d.Pipeline[status]))
+	}
+	fmt.Fprintf(w, "Воронка: %s; нарушают SLA: %d\n", strings.Join(stages, ", "), d.StaleApplications)
+	fmt.Fprintf(w, "Ссылка: %s\n", entityURL(entityCompany, d.ID))
+	if len(d.OpenJobOpenings) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Открытые вакансии:")
+	fmt.Fprintf(w, "%-6s %-30s %-22s %-16s %8s %6s %5s\n", "ID", "Название", "Зарплата", "Рекрутер", "Отклики", "Наймы", "SLA")
+	for _, j := range d.OpenJobOpenings {
+		recruiter := j.Recruiter
+		if recruiter == "" {
+			recruiter = "—"
+		}
+		salary := formatSalaryRange(j.SalaryMin, j.SalaryMax, j.SalaryCurrency)
+		fmt.Fprintf(w, "%-6d %-30s %-22s %-16s %8d %6d %5d\n", j.ID, j.Title, salary, recruiter, j.Applications, j.Hires, j.Stale)
+	}
+}
+
+func formatDaysToHire(days *float64) string {
+	if days == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%.1f дн.", *days)
+}
+
+func printCompanies(w io.Writer, companies []storage.CompanySummary) {
+	if len(companies) == 0 {
+		fmt.Fprintln(w, "Компании не найдены.")
+		return
+	}
+	fmt.Fprintf(w, "%-6s %-30s %-20s %-10s %8s %8s %10s %12s\n", "ID", "Компания", "Отрасль", "Статус", "Открыто", "Закрыто", "Рекрутеры", "Время найма")
+	for _, c := range companies {
+		fmt.Fprintln(w, companyLine(c))
+	}
+}
+
+func companyLine(c storage.CompanySummary) string {
+	industry := c.Industry
+	if industry == "" {
+		industry = "—"
+	}
+	return fmt.Sprintf("%-6d %-30s %-20s %-10s %8d %8d %10d %12s", c.ID, c.Name, industry, c.Status,
+		c.OpenJobOpenings, c.ClosedJobOpenings, c.ActiveRecruiters, formatDaysToHire(c.AvgDaysToHire))
+}
+
+func companyDirectoryMenu(app *App) {
+	search := getInput("Поиск по названию или отрасли (Enter — все компании): ")
+	q := ListQuery{PerPage: 20, Sort: sortPrompt(storage.CompanySorts)}
+	browsePages(q.PerPage, "Компании не найдены.", func(page int) ([]string, int, error) {
+		q.Page = page
+		companies, err := companyDirectory(app, search, q)
+		lines := make([]string, len(companies))
+		for i, c := range companies {
+			lines[i] = companyLine(c)
+		}
+		return lines, -1, err
+	})
+}
+
+// company list [-search текст] [-page N] [-per-page N] [-sort поле] | show <ID> | rename <ID> <название> |
+// industry <ID> <отрасль> | delete <ID> [--force] | extend <ID> <дней> | suspend <ID> | activate <ID>
+func runCompanyCommand(app *App, args []string) int {
+	usage := "Использование: company list [-search текст] [-page N] [-per-page N] [-sort поле] | company show <ID> [-out файл] [-copy] | " +
+		"company rename <ID> <название> | company industry <ID> <отрасль> | company delete <ID> [--force] | " +
+		"company extend <ID> <дней> | company suspend <ID> | company activate <ID>"
+	if len(args) > 0 && args[0] == "list" {
+		flags := flag.NewFlagSet("company list", flag.ContinueOnError)
+		search := flags.String("search", "", "подстрока названия или отрасли")
+		q := ListQuery{}
+		flags.IntVar(&q.Page, "page", 1, "номер страницы")
+		flags.IntVar(&q.PerPage, "per-page", 50, "компаний на странице")
+		flags.StringVar(&q.Sort, "sort", "", "поле сортировки: "+sortFieldNames(storage.CompanySorts)+"; «-» впереди — по убыванию")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		companies, err := companyDirectory(app, *search, q)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: companies, text: func(w io.Writer) { printCompanies(w, companies) }})
+	}
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityCompany, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+
+	switch {
+	case args[0] == "show":
+		flags := flag.NewFlagSet("company show", flag.ContinueOnError)
+		var o shareOptions
+		o.register(flags)
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		return showEntityCard(app, entityCompany, id, o)
+	case args[0] == "rename" && len(args) == 3:
+		if err := renameCompany(app, id, args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания переименована.", map[string]interface{}{"id": id, "name": args[2]})
+	case args[0] == "industry" && len(args) == 3:
+		if err := setCompanyIndustry(app, id, args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Отрасль компании изменена.", map[string]interface{}{"id": id, "industry": args[2]})
+	case args[0] == "delete" && (len(args) == 2 || len(args) == 3 && args[2] == "--force"):
+		if err := deleteCompany(app, id, len(args) == 3); err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания удалена.", map[string]int{"id": id})
+	case args[0] == "extend" && len(args) == 3:
+		days, err := strconv.Atoi(args[2])
+		if err != nil {
+			return reportError(err)
+		}
+		expiresAt, err := extendCompany(app, id, days)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания действует до "+expiresAt.Format("2006-01-02 15:04"), map[string]interface{}{"id": id, "expires_at": expiresAt})
+	case args[0] == "suspend" && len(args) == 2:
+		if err := setCompanyStatus(app, id, storage.CompanySuspended); err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания приостановлена.", map[string]interface{}{"id": id, "status": storage.CompanySuspended})
+	case args[0] == "activate" && len(args) == 2:
+		if err := setCompanyStatus(app, id, storage.CompanyActive); err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания активна.", map[string]interface{}{"id": id, "status": storage.CompanyActive})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
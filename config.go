@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Настройки приложения. Источники по убыванию приоритета: флаги командной строки, переменные окружения,
+// файл .env, файл настроек (-config, CONFIG_FILE или config.toml в текущем каталоге), значения по умолчанию.
+// Остальной код по-прежнему читает настройки через os.Getenv: loadConfig записывает итоговые значения
+// в окружение процесса.
+//
+// Файл настроек — плоский TOML: «ключ = значение» и секции. Ключ key в секции [section] задаёт переменную
+// SECTION_KEY, ключ вне секций — переменную с тем же именем: [database] url = "..." — это DATABASE_URL,
+// [smtp] port = 587 — SMTP_PORT. Так в файле можно задать любую настройку, не только перечисленные ниже.
+
+type setting struct {
+	Env     string
+	Flag    string
+	Default string
+	Usage   string
+	// Обязательную настройку нужно задать в одном из источников, иначе программа не запускается.
+	Required bool
+	// Значение не показывается командой config.
+	Secret   bool
+	validate func(value string) error
+}
+
+var settings = []setting{
+	{Env: "DATABASE_URL", Flag: "db-url", Required: true, Usage: "строка подключения к PostgreSQL"},
+	{Env: "DB_MAX_OPEN_CONNS", Flag: "db-max-open", Default: "10", Usage: "максимум открытых соединений с базой, 0 — без ограничения",
+		validate: nonNegativeSetting},
+	{Env: "DB_MAX_IDLE_CONNS", Flag: "db-max-idle", Default: "5", Usage: "сколько простаивающих соединений держать открытыми",
+		validate: nonNegativeSetting},
+	{Env: "DB_CONN_MAX_LIFETIME", Flag: "db-conn-lifetime", Default: "30m", Usage: "через сколько соединение переоткрывается, 0 — никогда",
+		validate: durationSetting},
+	{Env: "BCRYPT_COST", Flag: "bcrypt-cost", Default: strconv.Itoa(bcrypt.DefaultCost), Usage: "сложность хеширования паролей",
+		validate: bcryptCostSetting},
+	{Env: "SMTP_HOST", Flag: "smtp-host", Usage: "почтовый сервер; без него уведомления выключены"},
+	{Env: "SMTP_PORT", Flag: "smtp-port", Default: "587", Usage: "порт почтового сервера", validate: nonNegativeSetting},
+	{Env: "SMTP_USERNAME", Flag: "smtp-username", Usage: "пользователь почтового сервера"},
+	{Env: "SMTP_PASSWORD", Secret: true},
+	{Env: "SMTP_FROM", Flag: "smtp-from", Usage: "адрес отправителя писем"},
+	{Env: "JWT_SECRET", Secret: true},
+	{Env: "LOG_LEVEL", Flag: "log-level", Usage: "уровень журнала: debug, info, warn, error", validate: logLevelSetting},
+	{Env: "LOG_FORMAT", Flag: "log-format", Default: "text", Usage: "формат журнала: text или json", validate: logFormatSetting},
+	{Env: "LOG_FILE", Flag: "log-file", Usage: "файл журнала; без него журнал пишется в stderr"},
+	{Env: "APP_BASE_URL", Flag: "base-url", Default: "http://localhost:8080", Usage: "адрес веб-интерфейса и API для ссылок на записи",
+		validate: baseURLSetting},
+	{Env: "APP_LANGUAGE", Flag: "lang", Default: "ru", Usage: "язык интерфейса", validate: languageSetting},
+	{Env: "RESUME_STORAGE", Flag: "resume-storage", Default: "disk", Usage: "где хранить файлы резюме: disk или s3",
+		validate: resumeStorageSetting},
+	{Env: "RESUME_DIR", Flag: "resume-dir", Default: "resumes", Usage: "каталог файлов резюме для RESUME_STORAGE=disk"},
+	{Env: "S3_ENDPOINT", Flag: "s3-endpoint", Usage: "адрес S3-совместимого хранилища резюме", validate: baseURLSetting},
+	{Env: "S3_BUCKET", Flag: "s3-bucket", Usage: "бакет для файлов резюме"},
+	{Env: "S3_REGION", Flag: "s3-region", Default: "us-east-1", Usage: "регион S3"},
+	{Env: "S3_ACCESS_KEY", Flag: "s3-access-key", Usage: "ключ доступа к S3"},
+	{Env: "S3_SECRET_KEY", Secret: true},
+	{Env: "APPLY_RATE_LIMIT", Flag: "apply-rate-limit", Default: "5", Usage: "сколько откликов в час принимать с одного адреса, 0 — без ограничения",
+		validate: nonNegativeSetting},
+	{Env: "SALARY_CURRENCY", Flag: "salary-currency", Default: defaultSalaryCurrency, Usage: "валюта зарплат, если она не указана явно",
+		validate: currencySetting},
+	{Env: "APPLICATION_HISTORY_DAYS", Flag: "application-history-days", Default: strconv.Itoa(defaultApplicationHistoryDays),
+		Usage: "сколько дней хранить историю откликов для воронки на прошлую дату, 0 — без ограничения", validate: nonNegativeSetting},
+	{Env: "RETENTION_REJECTED_CANDIDATES_DAYS", Flag: "retention-rejected-candidates-days", Default: "730",
+		Usage: "через сколько дней без изменений удалять кандидатов, у которых все отклики отклонены, 0 — не удалять", validate: nonNegativeSetting},
+	{Env: "RETENTION_AUDIT_LOG_DAYS", Flag: "retention-audit-log-days", Default: "1825",
+		Usage: "сколько дней хранить журнал изменений, 0 — без ограничения", validate: nonNegativeSetting},
+	{Env: "RETENTION_NOTIFICATIONS_DAYS", Flag: "retention-notifications-days", Default: "90",
+		Usage: "сколько дней хранить обработанные уведомления (кроме ожидающих отправки), 0 — без ограничения", validate: nonNegativeSetting},
+	{Env: "PURGE_BATCH", Flag: "purge-batch", Default: strconv.Itoa(defaultPurgeBatch),
+		Usage: "сколько записей удалять за раз при очистке по срокам хранения", validate: nonNegativeSetting},
+	{Env: "REPORT_SNAPSHOT", Flag: "report-snapshot", Default: "false", Usage: "строить отчёты в одной транзакции REPEATABLE READ, чтобы все цифры относились к одному моменту",
+		validate: boolSetting},
+}
+
+// Языки интерфейса; тексты программы пока есть только на русском.
+var supportedLanguages = []string{"ru"}
+
+func nonNegativeSetting(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < 0 {
+		return fmt.Errorf("ожидается неотрицательное целое число, получено %q", value)
+	}
+	return nil
+}
+
+func boolSetting(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("ожидается true или false, получено %q", value)
+	}
+	return nil
+}
+
+func durationSetting(value string) error {
+	if d, err := time.ParseDuration(value); err != nil || d < 0 {
+		return fmt.Errorf("ожидается длительность вида 30m или 1h, получено %q", value)
+	}
+	return nil
+}
+
+func bcryptCostSetting(value string) error {
+	if n, err := strconv.Atoi(value); err != nil || n < bcrypt.MinCost || n > bcrypt.MaxCost {
+		return fmt.Errorf("ожидается число от %d до %d, получено %q", bcrypt.MinCost, bcrypt.MaxCost, value)
+	}
+	return nil
+}
+
+func logLevelSetting(value string) error {
+	if _, err := parseLogLevel(value); err != nil {
+		return fmt.Errorf("неизвестный уровень %q, допустимые: debug, info, warn, error", value)
+	}
+	return nil
+}
+
+func logFormatSetting(value string) error {
+	if format := strings.ToLower(value); format != "text" && format != "json" {
+		return fmt.Errorf("допустимые значения text и json, получено %q", value)
+	}
+	return nil
+}
+
+func baseURLSetting(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("ожидается адрес вида https://hr.example.com, получено %q", value)
+	}
+	return nil
+}
+
+func resumeStorageSetting(value string) error {
+	if value != "disk" && value != "s3" {
+		return fmt.Errorf("допустимые значения disk и s3, получено %q", value)
+	}
+	return nil
+}
+
+func languageSetting(value string) error {
+	if !containsString(supportedLanguages, value) {
+		return fmt.Errorf("язык %q не поддерживается, доступные: %s", value, strings.Join(supportedLanguages, ", "))
+	}
+	return nil
+}
+
+var configFile string
+
+func registerConfigFlags(flags *flag.FlagSet) {
+	flags.StringVar(&configFile, "config", "", "файл настроек (по умолчанию CONFIG_FILE или config.toml)")
+	for _, s := range settings {
+		if s.Flag != "" {
+			flags.String(s.Flag, "", fmt.Sprintf("%s (%s)", s.Usage, s.Env))
+		}
+	}
+}
+
+// Откуда взято значение каждой настройки — для команды config.
+var configSources = map[string]string{}
+
+// Собирает настройки из всех источников и проверяет их. Ошибка перечисляет все незаданные
+// обязательные и все неверные настройки сразу, чтобы их можно было исправить за один раз.
+func loadConfig(flags *flag.FlagSet) error {
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.Env); ok {
+			configSources[s.Env] = "окружение"
+		}
+	}
+
+	dotenv, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("ошибка чтения .env: %w", err)
+	}
+	setMissingEnv(dotenv, ".env")
+
+	path, explicit := configFile, configFile != ""
+	if !explicit {
+		path, explicit = os.Getenv("CONFIG_FILE"), os.Getenv("CONFIG_FILE") != ""
+	}
+	if !explicit {
+		path = "config.toml"
+	}
+	values, err := readConfigFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	setMissingEnv(values, path)
+
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.Flag == f.Name {
+				os.Setenv(s.Env, f.Value.String())
+				configSources[s.Env] = "флаг -" + f.Name
+			}
+		}
+	})
+	for _, s := range settings {
+		if _, ok := os.LookupEnv(s.Env); !ok && s.Default != "" {
+			os.Setenv(s.Env, s.Default)
+			configSources[s.Env] = "по умолчанию"
+		}
+	}
+	return checkConfig()
+}
+
+func setMissingEnv(values map[string]string, source string) {
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		os.Setenv(key, value)
+		configSources[key] = source
+	}
+}
+
+func checkConfig() error {
+	var problems []string
+	for _, s := range settings {
+		value := os.Getenv(s.Env)
+		switch {
+		case value == "" && s.Required:
+			problems = append(problems, fmt.Sprintf("%s не задан (%s)", s.Env, settingSourcesHint(s)))
+		case value != "" && s.validate != nil:
+			if err := s.validate(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.Env, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("ошибка настроек:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func settingSourcesHint(s setting) string {
+	section, key, ok := strings.Cut(strings.ToLower(s.Env), "_")
+	fileKey := strings.ToLower(s.Env)
+	if ok {
+		fileKey = fmt.Sprintf("[%s] %s", section, key)
+	}
+	hint := fmt.Sprintf("переменная окружения или .env, %s в файле настроек", fileKey)
+	if s.Flag != "" {
+		hint = "флаг -" + s.Flag + ", " + hint
+	}
+	return hint
+}
+
+// Читает плоский TOML: секции, «ключ = значение», комментарии с #. Строки — в двойных или одинарных
+// кавычках, числа и true/false — как есть. Вложенные таблицы и массивы не поддерживаются.
+func readConfigFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("файл настроек %s не найден: %w", path, err)
+		}
+		return nil, fmt.Errorf("ошибка открытия файла настроек: %w", err)
+	}
+	defer file.Close()
+
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: ожидается «ключ = значение» или [секция]", path, n)
+		}
+		value, err := parseConfigValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		if section != "" {
+			key = section + "_" + key
+		}
+		values[strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла настроек: %w", err)
+	}
+	return values, nil
+}
+
+// Отрезает комментарий, не трогая # внутри кавычек.
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseConfigValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", errors.New("не указано значение")
+	case strings.HasPrefix(raw, `"`):
+		value, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("неверная строка %s", raw)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("неверная строка %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.ContainsAny(raw, " \t[{"):
+		return "", fmt.Errorf("значение %s нужно взять в кавычки", raw)
+	}
+	return raw, nil
+}
+
+var dsnPassword = regexp.MustCompile(`password=\S+`)
+
+// Значение для показа: секреты и пароль в строке подключения скрыты.
+func displaySetting(s setting, value string) string {
+	if value == "" {
+		return "—"
+	}
+	if s.Secret {
+		return "***"
+	}
+	if dsnPassword.MatchString(value) {
+		return dsnPassword.ReplaceAllString(value, "password=***")
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return value
+}
+
+// config — итоговые настройки и откуда взято каждое значение. Работает и без базы данных,
+// чтобы можно было разобраться, почему программа не запускается.
+func runConfigCommand(loadErr error) int {
+	sorted := append([]setting(nil), settings...)
+	sort.SliceStable(sorted, func(i, k int) bool { return sorted[i].Env < sorted[k].Env })
+	type configRow struct {
+		Env    string `json:"env"`
+		Value  string `json:"value"`
+		Source string `json:"source"`
+	}
+	rows := make([]configRow, len(sorted))
+	for i, s := range sorted {
+		value := os.Getenv(s.Env)
+		source := configSources[s.Env]
+		if value == "" {
+			source = "не задано"
+		}
+		rows[i] = configRow{Env: s.Env, Value: displaySetting(s, value), Source: source}
+	}
+	printView(sharedView{data: rows, text: func(w io.Writer) {
+		for _, r := range rows {
+			fmt.Fprintf(w, "%-22s %-40s %s\n", r.Env, r.Value, r.Source)
+		}
+	}})
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, loadErr)
+		return exitUsage
+	}
+	return exitOK
+}
+
+func bcryptCost() int {
+	if n, err := strconv.Atoi(os.Getenv("BCRYPT_COST")); err == nil && n >= bcrypt.MinCost && n <= bcrypt.MaxCost {
+		return n
+	}
+	return bcrypt.DefaultCost
+}
+
+// Размер пула соединений; значения уже проверены loadConfig.
+func configureDB(db *sql.DB) {
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_OPEN_CONNS")); err == nil {
+		db.SetMaxOpenConns(n)
+	}
+	if n, err := strconv.Atoi(os.Getenv("DB_MAX_IDLE_CONNS")); err == nil {
+		db.SetMaxIdleConns(n)
+	}
+	if d, err := time.ParseDuration(os.Getenv("DB_CONN_MAX_LIFETIME")); err == nil {
+		db.SetConnMaxLifetime(d)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Поля кандидата, которые можно загрузить из CSV; по умолчанию колонка называется как поле.
+var candidateCSVFields = []string{"full_name", "age", "email", "phone", "experience", "skills", "expected_salary", "salary_currency", "location",
+	"languages"}
+
+type CSVRowError struct {
+	Line int    `json:"line"`
+	Err  string `json:"error"`
+}
+
+type CSVImportResult struct {
+	Imported int           `json:"imported"`
+	Rejected []CSVRowError `json:"rejected"`
+	// ID вставленных кандидатов — для событий после фиксации транзакции.
+	ids []int
+}
+
+// Разбирает соответствие «поле=колонка» через запятую, например "full_name=ФИО,email=Почта".
+func parseCSVMapping(text string) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, field := range candidateCSVFields {
+		mapping[field] = field
+	}
+	if strings.TrimSpace(text) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(text, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		if _, known := mapping[field]; !ok || !known {
+			return nil, validationErrorf("неверное соответствие %q: ожидается поле=колонка, поля: %s", pair, strings.Join(candidateCSVFields, ", "))
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+func splitList(text, sep string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return strings.Split(text, sep)
+}
+
+func candidateFromCSV(value func(field string) string, listSep string) (storage.Candidate, error) {
+	candidate := storage.Candidate{
+		FullName:       value("full_name"),
+		Email:          value("email"),
+		Phone:          value("phone"),
+		Experience:     value("experience"),
+		Skills:         splitList(value("skills"), listSep),
+		Location:       value("location"),
+		Languages:      splitList(value("languages"), listSep),
+		SalaryCurrency: value("salary_currency"),
+	}
+	if text := value("age"); text != "" {
+		age, err := strconv.Atoi(text)
+		if err != nil {
+			return candidate, validationErrorf("возраст %q не является целым числом", text)
+		}
+		candidate.Age = age
+	}
+	if text := value("expected_salary"); text != "" {
+		salary, err := strconv.ParseFloat(strings.Replace(text, ",", ".", 1), 64)
+		if err != nil {
+			return candidate, validationErrorf("ожидаемая зарплата %q не является числом", text)
+		}
+		candidate.ExpectedSalary = salary
+	}
+	return candidate, prepareCandidate(&candidate)
+}
+
+// Все принятые строки вставляются в одной транзакции; каждая строка — под своей точкой сохранения,
+// чтобы ошибка базы (например, нарушение ограничения) отклоняла только эту строку.
+// При dryRun строки только проверяются, транзакция откатывается.
+func importCandidatesCSV(db *sql.DB, r io.Reader, mapping map[string]string, listSep string, dryRun bool) (CSVImportResult, error) {
+	var result CSVImportResult
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return result, validationErrorf("файл пуст")
+	}
+	if err != nil {
+		return result, validationErrorf("ошибка чтения заголовка CSV: %v", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"full_name", "age"} {
+		if _, ok := columns[strings.ToLower(mapping[required])]; !ok {
+			return result, validationErrorf("в заголовке CSV нет колонки %q для поля %s", mapping[required], required)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	candidates := storage.NewPostgres(tx).Candidates
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			result.Rejected = append(result.Rejected, CSVRowError{Line: line, Err: err.Error()})
+			continue
+		}
+		value := func(field string) string {
+			if i, ok := columns[strings.ToLower(mapping[field])]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		candidate, err := candidateFromCSV(value, listSep)
+		if err == nil {
+			err = insertWithSavepoint(tx, func() error {
+				id, err := candidates.Create(candidate)
+				if err == nil {
+					result.ids = append(result.ids, id)
+				}
+				return err
+			})
+		}
+		if err != nil {
+			message, _ := describeError(err)
+			result.Rejected = append(result.Rejected, CSVRowError{Line: line, Err: message})
+			continue
+		}
+		result.Imported++
+	}
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return CSVImportResult{}, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return result, nil
+}
+
+func publishImportedCandidates(app *App, result CSVImportResult) {
+	for _, id := range result.ids {
+		publish(app, Event{Kind: eventCandidateSaved, ID: id})
+	}
+}
+
+func insertWithSavepoint(tx *sql.Tx, insert func() error) error {
+	if _, err := tx.Exec("SAVEPOINT csv_row"); err != nil {
+		return fmt.Errorf("ошибка создания точки сохранения: %w", err)
+	}
+	if err := insert(); err != nil {
+		if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT csv_row"); rbErr != nil {
+			return fmt.Errorf("ошибка отката строки: %w", rbErr)
+		}
+		return err
+	}
+	_, err := tx.Exec("RELEASE SAVEPOINT csv_row")
+	return err
+}
+
+func printCSVImportResult(w io.Writer, result CSVImportResult, dryRun bool) {
+	for _, e := range result.Rejected {
+		fmt.Fprintf(w, "строка %d: %s\n", e.Line, e.Err)
+	}
+	if dryRun {
+		fmt.Fprintf(w, "Проверка завершена: годных строк %d, отклонено %d.\n", result.Imported, len(result.Rejected))
+		return
+	}
+	fmt.Fprintf(w, "Импортировано кандидатов: %d, отклонено строк: %d.\n", result.Imported, len(result.Rejected))
+}
+
+// import-candidates <файл.csv> [-map поле=колонка,...] [-sep ;] [-dry-run]
+func runImportCandidatesCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Использование: import-candidates <файл.csv> [-map full_name=ФИО,age=Возраст,...] [-sep ;] [-dry-run]")
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("import-candidates", flag.ContinueOnError)
+	mappingText := flags.String("map", "", "соответствие полей колонкам CSV: поле=колонка через запятую")
+	listSep := flags.String("sep", ";", "разделитель навыков и языков внутри ячейки")
+	dryRun := flags.Bool("dry-run", false, "только проверить строки, ничего не сохраняя")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if *listSep == "" {
+		return reportError(validationErrorf("разделитель списков не может быть пустым"))
+	}
+	mapping, err := parseCSVMapping(*mappingText)
+	if err != nil {
+		return reportError(err)
+	}
+	file, err := os.Open(args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	defer file.Close()
+
+	result, err := importCandidatesCSV(app.DB, file, mapping, *listSep, *dryRun)
+	if err != nil {
+		return reportError(err)
+	}
+	if !*dryRun {
+		publishImportedCandidates(app, result)
+	}
+	// В CSV — отклонённые строки, как их выводит таблица.
+	printView(sharedView{
+		data: result,
+		text: func(w io.Writer) { printCSVImportResult(w, result, *dryRun) },
+		csv:  func(w io.Writer) error { return writeDataCSV(w, result.Rejected) },
+	})
+	if len(result.Rejected) > 0 {
+		return exitValidation
+	}
+	return exitOK
+}
+
+func importCandidatesMenu(app *App) {
+	path := getInput("Путь к CSV-файлу: ")
+	mapping, err := parseCSVMapping(getInput("Соответствие полей колонкам (поле=колонка через запятую, Enter — по названиям полей): "))
+	handleError(err)
+	if err != nil {
+		return
+	}
+	file, err := os.Open(path)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	result, err := importCandidatesCSV(app.DB, file, mapping, ";", false)
+	handleError(err)
+	if err == nil {
+		publishImportedCandidates(app, result)
+		printCSVImportResult(os.Stdout, result, false)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSVMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		changed map[string]string
+		wantErr bool
+	}{
+		{"по умолчанию", "", nil, false},
+		{"одно поле", "full_name=ФИО", map[string]string{"full_name": "ФИО"}, false},
+		{"пробелы вокруг", " full_name = ФИО , email = Почта ", map[string]string{"full_name": "ФИО", "email": "Почта"}, false},
+		{"без знака равенства", "full_name", nil, true},
+		{"неизвестное поле", "password=Пароль", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCSVMapping(tt.text)
+			if tt.wantErr {
+				if classifyError(err) != kindValidation {
+					t.Fatalf("parseCSVMapping(%q) = %v, ожидалась ошибка ввода", tt.text, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCSVMapping(%q): %v", tt.text, err)
+			}
+			want := map[string]string{}
+			for _, field := range candidateCSVFields {
+				want[field] = field
+			}
+			for field, column := range tt.changed {
+				want[field] = column
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parseCSVMapping(%q) = %v, ожидалось %v", tt.text, got, want)
+			}
+		})
+	}
+}
+
+func TestCandidateFromCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     map[string]string
+		wantErr string
+		check   func(t *testing.T, age int, salary float64, skills []string)
+	}{
+		{"минимальная строка", map[string]string{"full_name": "Иван Петров", "age": "30"}, "",
+			func(t *testing.T, age int, salary float64, skills []string) {
+				if age != 30 || salary != 0 || len(skills) != 0 {
+					t.Errorf("возраст %d, зарплата %v, навыки %q", age, salary, skills)
+				}
+			}},
+		{"зарплата с запятой и навыки", map[string]string{"full_name": "Иван Петров", "age": "30",
+			"expected_salary": "150000,50", "skills": "Go;SQL"}, "",
+			func(t *testing.T, age int, salary float64, skills []string) {
+				if salary != 150000.5 || len(skills) != 2 {
+					t.Errorf("зарплата %v, навыки %q", salary, skills)
+				}
+			}},
+		{"возраст не число", map[string]string{"full_name": "Иван Петров", "age": "тридцать"}, "не является целым числом", nil},
+		{"зарплата не число", map[string]string{"full_name": "Иван Петров", "age": "30", "expected_salary": "много"}, "не является числом", nil},
+		{"нет имени", map[string]string{"age": "30"}, "обязательные поля", nil},
+		{"нет возраста", map[string]string{"full_name": "Иван Петров"}, "обязательные поля", nil},
+		{"отрицательная зарплата", map[string]string{"full_name": "Иван Петров", "age": "30", "expected_salary": "-1"}, "отрицательной", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate, err := candidateFromCSV(func(field string) string { return tt.row[field] }, ";")
+			if tt.wantErr != "" {
+				if classifyError(err) != kindValidation || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("candidateFromCSV = %v, ожидалась ошибка ввода с %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("candidateFromCSV: %v", err)
+			}
+			tt.check(t, candidate.Age, candidate.ExpectedSalary, candidate.Skills)
+		})
+	}
+}
+
+// Заголовок проверяется до обращения к базе, поэтому db здесь не нужна.
+func TestImportCandidatesCSVHeader(t *testing.T) {
+	mapping, _ := parseCSVMapping("")
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"пустой файл", "", "файл пуст"},
+		{"нет колонки возраста", "full_name,email\nИван,ivan@example.com\n", "нет колонки \"age\""},
+		{"незакрытая кавычка в заголовке", "\"full_name,age\n", "ошибка чтения заголовка"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importCandidatesCSV(nil, strings.NewReader(tt.data), mapping, ";", true)
+			if classifyError(err) != kindValidation || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("importCandidatesCSV = %v, ожидалась ошибка ввода с %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Зарплаты вакансий (вилка «от–до») и ожидания кандидатов хранятся в своей валюте, а сравниваются по
+// таблице курсов currency_rates. Курс — стоимость единицы валюты в базовой валюте, у которой курс 1
+// (после миграции это рубль), поэтому сумма переводится из A в B как сумма × курс A / курс B. Валюта,
+// для которой курса нет, в вакансии и у кандидата не указывается — это проверяет база; курсы ведёт
+// администратор командой currency.
+
+const defaultSalaryCurrency = "RUB"
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func init() {
+	storage.DefaultCurrency = salaryCurrency
+}
+
+// Валюта зарплаты по умолчанию: в ней вводятся суммы без явной валюты и ищутся вакансии и кандидаты.
+func salaryCurrency() string {
+	if code, err := normalizeCurrency(os.Getenv("SALARY_CURRENCY")); err == nil && code != "" {
+		return code
+	}
+	return defaultSalaryCurrency
+}
+
+// Код валюты ISO 4217 в верхнем регистре; пустая строка остаётся пустой.
+func normalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code != "" && !currencyCodePattern.MatchString(code) {
+		return "", validationErrorf("код валюты %q должен состоять из трёх латинских букв, например RUB или USD", code)
+	}
+	return code, nil
+}
+
+func currencySetting(value string) error {
+	if !currencyCodePattern.MatchString(strings.ToUpper(value)) {
+		return fmt.Errorf("ожидается код валюты из трёх букв, например RUB, получено %q", value)
+	}
+	return nil
+}
+
+func validateCurrencyValue(value interface{}) error {
+	s, _ := value.(string)
+	code, err := normalizeCurrency(s)
+	if err == nil && code == "" {
+		return validationErrorf("укажите валюту зарплаты")
+	}
+	return err
+}
+
+type CurrencyRate struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type currencyRates map[string]float64
+
+func loadCurrencyRates(db storage.DBTX) (currencyRates, error) {
+	list, err := listCurrencyRates(db)
+	if err != nil {
+		return nil, err
+	}
+	rates := currencyRates{}
+	for _, r := range list {
+		rates[r.Currency] = r.Rate
+	}
+	return rates, nil
+}
+
+// Перевод суммы между валютами; false — для одной из валют нет курса. Пустая валюта — валюта по умолчанию.
+func (r currencyRates) convert(amount float64, from, to string) (float64, bool) {
+	if from == "" {
+		from = salaryCurrency()
+	}
+	if to == "" {
+		to = salaryCurrency()
+	}
+	if from == to {
+		return amount, true
+	}
+	fromRate, fromOK := r[from]
+	toRate, toOK := r[to]
+	if !fromOK || !toOK || toRate == 0 {
+		return 0, false
+	}
+	return amount * fromRate / toRate, true
+}
+
+// Подключает к весам подбора курсы валют, чтобы сравнивать ожидания кандидата с вилкой вакансии.
+func withCurrencyRates(db *sql.DB, weights MatchWeights) (MatchWeights, error) {
+	if weights.rates != nil {
+		return weights, nil
+	}
+	rates, err := loadCurrencyRates(db)
+	if err != nil {
+		return MatchWeights{}, err
+	}
+	weights.rates = rates
+	return weights, nil
+}
+
+func listCurrencyRates(db storage.DBTX) ([]CurrencyRate, error) {
+	rows, err := db.Query("SELECT currency, rate, updated_at FROM currency_rates ORDER BY currency")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения курсов валют: %w", err)
+	}
+	defer rows.Close()
+	var rates []CurrencyRate
+	for rows.Next() {
+		var r CurrencyRate
+		if err := rows.Scan(&r.Currency, &r.Rate, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения курсов валют: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+func setCurrencyRate(app *App, code string, rate float64) error {
+	code, err := normalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	if code == "" {
+		return validationErrorf("укажите код валюты")
+	}
+	if rate <= 0 {
+		return validationErrorf("курс должен быть положительным")
+	}
+	_, err = app.DB.Exec(`INSERT INTO currency_rates (currency, rate) VALUES ($1, $2)
+		ON CONFLICT (currency) DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()`, code, rate)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения курса валюты: %w", err)
+	}
+	audit("currency.rate_set", "currency", code, "rate", rate)
+	return nil
+}
+
+// Курс нельзя удалить, пока в валюте указана хотя бы одна зарплата.
+func deleteCurrencyRate(app *App, code string) error {
+	code, err := normalizeCurrency(code)
+	if err != nil {
+		return err
+	}
+	var used int
+	err = app.DB.QueryRow(`SELECT (SELECT COUNT(*) FROM job_openings WHERE salary_currency = $1)
+		+ (SELECT COUNT(*) FROM candidates WHERE salary_currency = $1)`, code).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	if used > 0 {
+		return validationErrorf("в валюте %s указано зарплат: %d, курс удалить нельзя", code, used)
+	}
+	result, err := app.DB.Exec("DELETE FROM currency_rates WHERE currency = $1", code)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления курса валюты: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError(fmt.Sprintf("курс валюты %s не задан", code))
+	}
+	audit("currency.rate_deleted", "currency", code)
+	return nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// Вилка в том же виде, в каком её вводят: «150000-200000 RUB», «от 150000 RUB», «до 200000 RUB».
+func formatSalaryRange(min, max float64, currency string) string {
+	switch {
+	case min > 0 && max > 0 && min != max:
+		return fmt.Sprintf("%s-%s %s", formatAmount(min), formatAmount(max), currency)
+	case min > 0 && max == 0:
+		return fmt.Sprintf("от %s %s", formatAmount(min), currency)
+	case min == 0 && max > 0:
+		return fmt.Sprintf("до %s %s", formatAmount(max), currency)
+	}
+	return fmt.Sprintf("%s %s", formatAmount(min), currency)
+}
+
+func jobSalaryText(j storage.JobOpening) string {
+	return formatSalaryRange(j.SalaryMin, j.SalaryMax, j.SalaryCurrency)
+}
+
+// Одна сумма вместо вилки — для полос анонимизации, сравнения с рынком и похожих вакансий:
+// середина вилки, а у «от …» и «до …» — заданная граница.
+func jobSalary(j storage.JobOpening) float64 {
+	if j.SalaryMin > 0 && j.SalaryMax > 0 {
+		return (j.SalaryMin + j.SalaryMax) / 2
+	}
+	return j.SalaryMin + j.SalaryMax
+}
+
+func validateSalaryRange(min, max float64) error {
+	switch {
+	case min < 0 || max < 0:
+		return validationErrorf("зарплата не может быть отрицательной")
+	case min == 0 && max == 0:
+		return validationErrorf("укажите зарплату: сумму, вилку «от-до», «от …» или «до …»")
+	case max > 0 && min > max:
+		return validationErrorf("нижняя граница зарплаты больше верхней")
+	}
+	return nil
+}
+
+// Разбирает «150000», «150000-200000 USD», «от 150000», «до 200 000 EUR», «от 150000 до 200000».
+// Валюта — последнее слово из трёх букв; без неё возвращается пустая строка.
+func parseSalaryRange(input string) (float64, float64, string, error) {
+	text, currency, err := cutCurrency(input)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	text = strings.ToLower(text)
+	var min, max float64
+	switch {
+	case strings.HasPrefix(text, "от"):
+		from, to, bounded := strings.Cut(strings.TrimPrefix(text, "от"), "до")
+		if min, err = parseSalaryNumber(from); err == nil && bounded {
+			max, err = parseSalaryNumber(to)
+		}
+	case strings.HasPrefix(text, "до"):
+		max, err = parseSalaryNumber(strings.TrimPrefix(text, "до"))
+	default:
+		from, to, isRange := strings.Cut(strings.NewReplacer("–", "-", "—", "-").Replace(text), "-")
+		if min, err = parseSalaryNumber(from); err == nil {
+			max = min
+			if isRange {
+				max, err = parseSalaryNumber(to)
+			}
+		}
+	}
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if err := validateSalaryRange(min, max); err != nil {
+		return 0, 0, "", err
+	}
+	return min, max, currency, nil
+}
+
+// Одна сумма с необязательной валютой: «250000» или «3000 USD».
+func parseSalaryAmount(input string) (float64, string, error) {
+	text, currency, err := cutCurrency(input)
+	if err != nil {
+		return 0, "", err
+	}
+	amount, err := parseSalaryNumber(text)
+	return amount, currency, err
+}
+
+func cutCurrency(input string) (string, string, error) {
+	words := strings.Fields(input)
+	if len(words) > 1 {
+		last := words[len(words)-1]
+		if strings.IndexFunc(last, func(r rune) bool { return r >= '0' && r <= '9' }) < 0 && len([]rune(last)) == 3 {
+			code, err := normalizeCurrency(last)
+			if err != nil {
+				return "", "", err
+			}
+			return strings.Join(words[:len(words)-1], " "), code, nil
+		}
+	}
+	return strings.Join(words, " "), "", nil
+}
+
+// Разряды можно отделять пробелами: «200 000».
+func parseSalaryNumber(text string) (float64, error) {
+	text = strings.Join(strings.Fields(text), "")
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, validationErrorf("«%s» — не сумма зарплаты", text)
+	}
+	return n, nil
+}
+
+// currency list | currency set <код> <курс> | currency delete <код>
+func runCurrencyCommand(app *App, args []string) int {
+	usage := "Использование: currency list [-json] | currency set <код> <курс к базовой валюте> | currency delete <код>"
+	switch {
+	case len(args) >= 1 && args[0] == "list":
+		rates, err := listCurrencyRates(app.DB)
+		if err != nil {
+			return reportError(err)
+		}
+		useJSON(len(args) == 2 && args[1] == "-json")
+		return printView(sharedView{data: rates, text: func(w io.Writer) {
+			fmt.Fprintf(w, "Валюта по умолчанию: %s\n", salaryCurrency())
+			for _, r := range rates {
+				fmt.Fprintf(w, "%-4s %14s  обновлён %s\n", r.Currency, formatAmount(r.Rate), r.UpdatedAt.Format("2006-01-02 15:04"))
+			}
+		}})
+	case len(args) == 3 && args[0] == "set":
+		rate, err := strconv.ParseFloat(strings.Replace(args[2], ",", ".", 1), 64)
+		if err != nil {
+			return reportError(validationErrorf("курс %q должен быть числом", args[2]))
+		}
+		if err := setCurrencyRate(app, args[1], rate); err != nil {
+			return reportError(err)
+		}
+		return printDone("Курс сохранён.", map[string]interface{}{"currency": args[1], "rate": rate})
+	case len(args) == 2 && args[0] == "delete":
+		if err := deleteCurrencyRate(app, args[1]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Курс удалён.", map[string]string{"currency": args[1]})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
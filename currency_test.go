@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurrencyRatesConvert(t *testing.T) {
+	t.Setenv("SALARY_CURRENCY", "RUB")
+	rates := currencyRates{"RUB": 1, "USD": 90, "EUR": 100, "XXX": 0}
+	tests := []struct {
+		name     string
+		amount   float64
+		from, to string
+		want     float64
+		ok       bool
+	}{
+		{"та же валюта", 1000, "USD", "USD", 1000, true},
+		{"в базовую", 1000, "USD", "RUB", 90000, true},
+		{"из базовой", 90000, "RUB", "USD", 1000, true},
+		{"через базовую", 900, "EUR", "USD", 1000, true},
+		{"пустая — валюта по умолчанию", 1000, "", "USD", 1000.0 / 90, true},
+		{"обе пустые", 500, "", "", 500, true},
+		{"нет курса исходной", 1000, "GBP", "RUB", 0, false},
+		{"нет курса целевой", 1000, "RUB", "GBP", 0, false},
+		{"нулевой курс целевой", 1000, "RUB", "XXX", 0, false},
+		// Без курса перевод не нужен, если валюты совпадают.
+		{"одна и та же валюта без курса", 1000, "GBP", "GBP", 1000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rates.convert(tt.amount, tt.from, tt.to)
+			if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("convert(%v, %q, %q) = %v, %v; ожидалось %v, %v", tt.amount, tt.from, tt.to, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseSalaryRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		min, max float64
+		currency string
+		wantErr  bool
+	}{
+		{"150000", 150000, 150000, "", false},
+		{"150000-200000 USD", 150000, 200000, "USD", false},
+		{"150000 – 200000", 150000, 200000, "", false},
+		{"от 150000", 150000, 0, "", false},
+		{"до 200 000 eur", 0, 200000, "EUR", false},
+		{"От 150000 до 200000 RUB", 150000, 200000, "RUB", false},
+		{"200000-150000", 0, 0, "", true},
+		{"0", 0, 0, "", true},
+		{"-5", 0, 0, "", true},
+		{"много", 0, 0, "", true},
+		{"150000 US1", 0, 0, "", true},
+		{"150000 ДОЛ", 0, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			min, max, currency, err := parseSalaryRange(tt.input)
+			if tt.wantErr {
+				if classifyError(err) != kindValidation {
+					t.Errorf("parseSalaryRange(%q) = %v, %v, %q, %v; ожидалась ошибка ввода", tt.input, min, max, currency, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSalaryRange(%q): %v", tt.input, err)
+			}
+			if min != tt.min || max != tt.max || currency != tt.currency {
+				t.Errorf("parseSalaryRange(%q) = %v, %v, %q; ожидалось %v, %v, %q", tt.input, min, max, currency, tt.min, tt.max, tt.currency)
+			}
+		})
+	}
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"usd", "USD", false},
+		{" Eur ", "EUR", false},
+		{"", "", false},
+		{"RUBL", "", true},
+		{"US", "", true},
+		{"РУБ", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeCurrency(tt.code)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeCurrency(%q) = %q, %v; ожидалось %q, ошибка: %v", tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Группы меньше этого размера не показываются никогда, даже если DIVERSITY_MIN_GROUP задан меньше.
+const diversityHardMinGroup = 5
+
+var diversityGenders = map[string]string{
+	"female": "женщины",
+	"male":   "мужчины",
+	"other":  "другое",
+}
+
+// Функция выключена по умолчанию и включается в конкретной установке через DIVERSITY_REPORTING.
+func diversityEnabled() bool {
+	switch strings.ToLower(os.Getenv("DIVERSITY_REPORTING")) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+func diversityMinGroup() int {
+	n, err := strconv.Atoi(os.Getenv("DIVERSITY_MIN_GROUP"))
+	if err != nil {
+		n = 10
+	}
+	if n < diversityHardMinGroup {
+		n = diversityHardMinGroup
+	}
+	return n
+}
+
+func requireDiversityEnabled() error {
+	if !diversityEnabled() {
+		return permissionError("отчётность по составу воронки отключена в этой установке (DIVERSITY_REPORTING)")
+	}
+	return nil
+}
+
+// Данные указываются только с согласия кандидата; "none" удаляет их.
+func setCandidateGender(app *App, candidateID int, gender string) error {
+	if err := requireDiversityEnabled(); err != nil {
+		return err
+	}
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return err
+	}
+	if gender == "none" {
+		_, err := app.DB.Exec("DELETE FROM candidate_demographics WHERE candidate_id = $1", candidateID)
+		if err != nil {
+			return fmt.Errorf("ошибка удаления данных: %w", err)
+		}
+		return nil
+	}
+	if _, ok := diversityGenders[gender]; !ok {
+		return validationErrorf("допустимые значения: female, male, other или none")
+	}
+	_, err := app.DB.Exec(`INSERT INTO candidate_demographics (candidate_id, gender) VALUES ($1, $2)
+		ON CONFLICT (candidate_id) DO UPDATE SET gender = EXCLUDED.gender, consented_at = now()`, candidateID, gender)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения данных: %w", err)
+	}
+	return nil
+}
+
+type DiversityRow struct {
+	Stage string `json:"stage"`
+	// Число кандидатов по группам; -1 — группа скрыта.
+	Groups map[string]int `json:"groups"`
+	Total  int            `json:"total"`
+	Hidden bool           `json:"hidden"`
+}
+
+// Скрывает малые группы. Если скрыта ровно одна, скрывается и следующая по размеру,
+// иначе её можно было бы вычислить из итога строки.
+func suppressSmallGroups(row *DiversityRow, minGroup int) {
+	if row.Total < minGroup {
+		row.Hidden = true
+		return
+	}
+	var names []string
+	suppressed := 0
+	for name, count := range row.Groups {
+		if count > 0 && count < minGroup {
+			row.Groups[name] = -1
+			suppressed++
+		} else {
+			names = append(names, name)
+		}
+	}
+	if suppressed == 1 {
+		sort.Slice(names, func(i, k int) bool { return row.Groups[names[i]] < row.Groups[names[k]] })
+		for _, name := range names {
+			if row.Groups[name] > 0 {
+				row.Groups[name] = -1
+				break
+			}
+		}
+	}
+}
+
+// Только агрегаты по этапам воронки и только по кандидатам, добровольно указавшим данные.
+func diversityReport(app *App, jobOpeningID, companyID int) ([]DiversityRow, error) {
+	if err := requireDiversityEnabled(); err != nil {
+		return nil, err
+	}
+	rows, err := app.DB.Query(`
+    SELECT a.status, d.gender, COUNT(*)
+    FROM applications a
+    JOIN candidate_demographics d ON d.candidate_id = a.candidate_id
+    JOIN job_openings j ON j.id = a.job_opening_id
+    WHERE ($1 = 0 OR j.id = $1) AND ($2 = 0 OR j.company_id = $2)
+    GROUP BY a.status, d.gender`, jobOpeningID, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	byStage := map[string]*DiversityRow{}
+	for rows.Next() {
+		var stage, gender string
+		var count int
+		if err := rows.Scan(&stage, &gender, &count); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		row, ok := byStage[stage]
+		if !ok {
+			row = &DiversityRow{Stage: stage, Groups: map[string]int{}}
+			byStage[stage] = row
+		}
+		row.Groups[gender] += count
+		row.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	minGroup := diversityMinGroup()
+	var report []DiversityRow
+	for _, stage := range storage.ApplicationStatuses {
+		row, ok := byStage[stage]
+		if !ok {
+			continue
+		}
+		suppressSmallGroups(row, minGroup)
+		report = append(report, *row)
+	}
+	return report, nil
+}
+
+func writeDiversityReport(w io.Writer, report []DiversityRow) {
+	fmt.Fprintf(w, "Группы меньше %d человек скрыты.\n\n", diversityMinGroup())
+	fmt.Fprintf(w, "%-14s %-10s %-10s %-10s %-10s\n", "Этап", "Всего", diversityGenders["female"], diversityGenders["male"], diversityGenders["other"])
+	for _, row := range report {
+		if row.Hidden {
+			fmt.Fprintf(w, "%-14s %-10s\n", applicationStatusTitles[row.Stage], "скрыто")
+			continue
+		}
+		cells := []string{}
+		for _, gender := range []string{"female", "male", "other"} {
+			switch count := row.Groups[gender]; {
+			case count < 0:
+				cells = append(cells, "скрыто")
+			default:
+				cells = append(cells, fmt.Sprintf("%.0f%%", float64(count)/float64(row.Total)*100))
+			}
+		}
+		fmt.Fprintf(w, "%-14s %-10d %-10s %-10s %-10s\n", applicationStatusTitles[row.Stage], row.Total, cells[0], cells[1], cells[2])
+	}
+}
+
+// diversity set <ID кандидата> <female|male|other|none> | diversity report [-job ID] [-company ID] [-out файл] [-copy]
+func runDiversityCommand(app *App, args []string) int {
+	usage := "Использование: diversity set <ID кандидата> <female|male|other|none> | diversity report [-job ID] [-company ID] [-out файл] [-copy]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch {
+	case args[0] == "set" && len(args) == 3:
+		id, err := resolveEntityID(app.DB, entityCandidate, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		if err := setCandidateGender(app, id, args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Данные сохранены.", map[string]interface{}{"candidate_id": id, "gender": args[2]})
+	case args[0] == "report":
+		flags := flag.NewFlagSet("diversity report", flag.ContinueOnError)
+		jobOpening := entityFlagVar(flags, "job", entityJobOpening, "только по вакансии (ID или название)")
+		company := entityFlagVar(flags, "company", entityCompany, "только по компании (ID или название)")
+		var o shareOptions
+		o.register(flags)
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		jobOpeningID, err := jobOpening.resolve(app.DB)
+		if err != nil {
+			return reportError(err)
+		}
+		companyID, err := company.resolve(app.DB)
+		if err != nil {
+			return reportError(err)
+		}
+		report, err := diversityReport(app, jobOpeningID, companyID)
+		if err != nil {
+			return reportError(err)
+		}
+		view := sharedView{data: report, text: func(w io.Writer) { writeDiversityReport(w, report) }}
+		if code := printView(view); code != exitOK {
+			return code
+		}
+		if err := o.share(view); err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
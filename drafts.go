@@ -0,0 +1,262 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Черновики форм добавления. Каждое введённое поле сразу сохраняется в form_drafts, поэтому, если
+// программа завершится посреди ввода, форму можно продолжить из меню «Черновики». Черновик удаляется,
+// когда запись сохранена или пользователь отменил ввод; неизменявшиеся дольше DRAFT_TTL (7 дней)
+// удаляет фоновая задача. Черновики ведутся только для авторизованного пользователя.
+
+const draftCleanupJob = "drafts.cleanup"
+
+func draftTTL() time.Duration {
+	return tokenTTL("DRAFT_TTL", 7*24*time.Hour)
+}
+
+type FormDraft struct {
+	ID        int               `json:"id"`
+	Form      string            `json:"form"`
+	Title     string            `json:"title"`
+	Fields    map[string]string `json:"fields"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+func saveFormDraft(db *sql.DB, userID int, draft *FormDraft) error {
+	fields, err := json.Marshal(draft.Fields)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации черновика: %w", err)
+	}
+	if draft.ID != 0 {
+		result, err := db.Exec("UPDATE form_drafts SET title = $1, fields = $2, updated_at = now() WHERE id = $3 AND user_id = $4",
+			draft.Title, fields, draft.ID, userID)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения черновика: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil || n > 0 {
+			return err
+		}
+		// Черновик успели удалить (очистка или другой сеанс) — сохраняем заново.
+	}
+	err = db.QueryRow("INSERT INTO form_drafts (user_id, form, title, fields) VALUES ($1, $2, $3, $4) RETURNING id",
+		userID, draft.Form, draft.Title, fields).Scan(&draft.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения черновика: %w", err)
+	}
+	return nil
+}
+
+func deleteFormDraft(db *sql.DB, userID, id int) error {
+	if _, err := db.Exec("DELETE FROM form_drafts WHERE id = $1 AND user_id = $2", id, userID); err != nil {
+		return fmt.Errorf("ошибка удаления черновика: %w", err)
+	}
+	return nil
+}
+
+// Черновики пользователя, от недавно изменённых к давним.
+func formDrafts(db *sql.DB, userID int) ([]FormDraft, error) {
+	rows, err := db.Query("SELECT id, form, title, fields, updated_at FROM form_drafts WHERE user_id = $1 ORDER BY updated_at DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var drafts []FormDraft
+	for rows.Next() {
+		var d FormDraft
+		var fields []byte
+		if err := rows.Scan(&d.ID, &d.Form, &d.Title, &fields, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if err := json.Unmarshal(fields, &d.Fields); err != nil {
+			return nil, fmt.Errorf("ошибка разбора черновика: %w", err)
+		}
+		drafts = append(drafts, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return drafts, nil
+}
+
+// Автосохранение одной формы. nil — форма без черновика; методы nil ничего не делают.
+type draftSaver struct {
+	db     *sql.DB
+	userID int
+	draft  FormDraft
+}
+
+// context — значения, которые нужны для продолжения, но не являются полями формы (например, компания вакансии).
+func newDraftSaver(app *App, form string, context map[string]string) *draftSaver {
+	userID := sessionUserID()
+	if userID == 0 {
+		return nil
+	}
+	if context == nil {
+		context = map[string]string{}
+	}
+	return &draftSaver{db: app.DB, userID: userID, draft: FormDraft{Form: form, Fields: context}}
+}
+
+func resumeDraftSaver(app *App, draft FormDraft) *draftSaver {
+	if draft.Fields == nil {
+		draft.Fields = map[string]string{}
+	}
+	return &draftSaver{db: app.DB, userID: sessionUserID(), draft: draft}
+}
+
+func (s *draftSaver) value(key string) string {
+	if s == nil {
+		return ""
+	}
+	return s.draft.Fields[key]
+}
+
+// Заполняет поля формы значениями из черновика; такие поля не спрашиваются повторно.
+func (s *draftSaver) restore(fields []*formField) {
+	if s == nil {
+		return
+	}
+	for _, f := range fields {
+		if input, ok := s.draft.Fields[f.Key]; ok {
+			f.set(input)
+		}
+	}
+}
+
+// Черновики вспомогательные: если сохранить не удалось, ввод продолжается.
+func (s *draftSaver) save(title string, fields []*formField) {
+	if s == nil {
+		return
+	}
+	for _, f := range fields {
+		if f.filled {
+			s.draft.Fields[f.Key] = f.input
+		}
+	}
+	s.draft.Title = title
+	if len(fields) > 0 && fields[0].input != "" {
+		s.draft.Title += " — " + formPreview(fields[0].input)
+	}
+	if err := saveFormDraft(s.db, s.userID, &s.draft); err != nil {
+		opLogger().Warn("ошибка сохранения черновика", "error", err)
+	}
+}
+
+func (s *draftSaver) discard() {
+	if s == nil || s.draft.ID == 0 {
+		return
+	}
+	if err := deleteFormDraft(s.db, s.userID, s.draft.ID); err != nil {
+		opLogger().Warn("ошибка удаления черновика", "error", err)
+		return
+	}
+	s.draft.ID = 0
+}
+
+// Задача удаляет черновики старше draftTTL и ставит себя на следующий час.
+func handleDraftCleanupJob(db *sql.DB, payload json.RawMessage) error {
+	result, err := db.Exec("DELETE FROM form_drafts WHERE updated_at < $1", time.Now().Add(-draftTTL()))
+	if err != nil {
+		return fmt.Errorf("ошибка удаления старых черновиков: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		slog.Info("удалены старые черновики", "count", n)
+	}
+	_, err = enqueueJobAt(db, draftCleanupJob, struct{}{}, time.Now().Add(time.Hour))
+	return err
+}
+
+func init() {
+	registerJobHandler(draftCleanupJob, handleDraftCleanupJob)
+}
+
+func scheduleDraftCleanup(db *sql.DB) error {
+	scheduled, err := jobScheduled(db, draftCleanupJob)
+	if err != nil || scheduled {
+		return err
+	}
+	_, err = enqueueJob(db, draftCleanupJob, struct{}{})
+	return err
+}
+
+// Продолжает форму черновика; права проверяются по виду формы, как в пунктах меню добавления.
+func resumeDraft(app *App, draft FormDraft) error {
+	switch draft.Form {
+	case entityCandidate:
+		if err := authorize(app, permCandidatesWrite); err != nil {
+			return err
+		}
+		addCandidateMenu(app, resumeDraftSaver(app, draft), nil)
+	case entityJobOpening:
+		if err := authorize(app, permJobsWrite); err != nil {
+			return err
+		}
+		saver := resumeDraftSaver(app, draft)
+		// Проверяется и при сохранении (addJobOpeningWithCompany); здесь — чтобы не заполнять форму зря.
+		if saver.value("new_company") != "" {
+			if err := authorize(app, permCompaniesManage); err != nil {
+				return err
+			}
+		}
+		companyID, _ := strconv.Atoi(saver.value("company_id"))
+		addJobOpeningForm(app, companyID, saver.value("new_company"), nil, saver)
+	default:
+		return validationErrorf("неизвестная форма черновика %q", draft.Form)
+	}
+	return nil
+}
+
+func draftsMenu(app *App) {
+	userID := sessionUserID()
+	drafts, err := formDrafts(app.DB, userID)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if len(drafts) == 0 {
+		fmt.Println("Черновиков нет.")
+		return
+	}
+	for i, d := range drafts {
+		fmt.Printf("%d. %s (изменён %s)\n", i+1, d.Title, d.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("Черновики хранятся %s с последнего изменения.\n", draftTTL())
+	input := getInput("Номер — продолжить, «d номер» — удалить, Enter — назад: ")
+	if input == "" {
+		return
+	}
+	text, remove := strings.CutPrefix(input, "d")
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || n < 1 || n > len(drafts) {
+		fmt.Println("Нет такого черновика.")
+		return
+	}
+	if remove {
+		err := deleteFormDraft(app.DB, userID, drafts[n-1].ID)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Черновик удалён.")
+		}
+		return
+	}
+	handleError(resumeDraft(app, drafts[n-1]))
+}
+
+// Напоминание после входа о незаконченных формах.
+func remindDrafts(app *App, userID int) {
+	drafts, err := formDrafts(app.DB, userID)
+	if err != nil {
+		opLogger().Warn("ошибка чтения черновиков", "error", err)
+		return
+	}
+	if len(drafts) > 0 {
+		fmt.Printf("У вас есть незаконченные черновики: %d. Продолжить их можно в пункте «Черновики».\n", len(drafts))
+	}
+}
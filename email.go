@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type mxCacheEntry struct {
+	err     error
+	warning string
+	expires time.Time
+}
+
+var (
+	mxCacheMu sync.Mutex
+	mxCache   = map[string]mxCacheEntry{}
+)
+
+func validateEmailSyntax(email string) error {
+	if email == "" {
+		return nil
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return validationErrorf("«%s» не похож на адрес электронной почты", email)
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return validationErrorf("в адресе «%s» неверный домен", email)
+	}
+	return nil
+}
+
+func mxCheckEnabled() bool {
+	switch strings.ToLower(os.Getenv("EMAIL_MX_CHECK")) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// Проверка MX включается через EMAIL_MX_CHECK. Несуществующий домен (gmail.con) — ошибка;
+// если DNS недоступен, возвращается только предупреждение, чтобы не блокировать ввод.
+func checkEmailDomain(email string) (string, error) {
+	if email == "" || !mxCheckEnabled() {
+		return "", nil
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+
+	mxCacheMu.Lock()
+	entry, ok := mxCache[domain]
+	mxCacheMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.warning, entry.err
+	}
+
+	warning, err := lookupMailDomain(domain)
+	if warning == "" {
+		mxCacheMu.Lock()
+		mxCache[domain] = mxCacheEntry{err: err, expires: time.Now().Add(time.Hour)}
+		mxCacheMu.Unlock()
+	}
+	return warning, err
+}
+
+func lookupMailDomain(domain string) (string, error) {
+	timeout := 3 * time.Second
+	if d, err := time.ParseDuration(os.Getenv("EMAIL_MX_TIMEOUT")); err == nil && d > 0 {
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		return "", nil
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsNotFound {
+		return fmt.Sprintf("не удалось проверить домен %s: DNS недоступен", domain), nil
+	}
+
+	// Без MX-записи почту принимает сам хост домена (RFC 5321), поэтому проверяем и A/AAAA.
+	if _, err := net.DefaultResolver.LookupHost(ctx, domain); err == nil {
+		return "", nil
+	} else if errors.As(err, &dnsErr) && !dnsErr.IsNotFound {
+		return fmt.Sprintf("не удалось проверить домен %s: DNS недоступен", domain), nil
+	}
+	return "", validationErrorf("домен %s не существует или не принимает почту — проверьте, нет ли опечатки", domain)
+}
+
+func checkEmailInteractive(email string) error {
+	warning, err := checkEmailDomain(email)
+	if warning != "" {
+		fmt.Println("Предупреждение:", warning)
+	}
+	return err
+}
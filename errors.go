@@ -0,0 +1,249 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+var verbose bool
+
+// Коды завершения неинтерактивных команд; скрипты могут ветвиться по ним.
+const (
+	exitOK         = 0
+	exitFailure    = 1
+	exitUsage      = 2
+	exitValidation = 3
+	exitNotFound   = 4
+	exitPermission = 5
+	exitInfra      = 6
+	exitQuota      = 7
+)
+
+type errorKind string
+
+const (
+	kindValidation errorKind = "validation"
+	kindNotFound   errorKind = "not_found"
+	kindPermission errorKind = "permission"
+	kindInfra      errorKind = "infrastructure"
+	kindQuota      errorKind = "quota_exceeded"
+	kindOther      errorKind = "error"
+)
+
+var errorFormat = "text"
+
+// Помечает ошибку категорией, не меняя её текст.
+type kindError struct {
+	kind errorKind
+	err  error
+}
+
+func (e kindError) Error() string { return e.err.Error() }
+func (e kindError) Unwrap() error { return e.err }
+
+func validationErrorf(format string, args ...interface{}) error {
+	return kindError{kindValidation, fmt.Errorf(format, args...)}
+}
+
+func notFoundError(message string) error {
+	return kindError{kindNotFound, errors.New(message)}
+}
+
+func permissionError(message string) error {
+	return kindError{kindPermission, errors.New(message)}
+}
+
+func classifyError(err error) errorKind {
+	var kErr kindError
+	if errors.As(err, &kErr) {
+		return kErr.kind
+	}
+	var quotaErr *storage.QuotaError
+	if errors.As(err, &quotaErr) {
+		return kindQuota
+	}
+	var inactiveErr *storage.CompanyInactiveError
+	if errors.As(err, &inactiveErr) {
+		return kindPermission
+	}
+	var openErr *storage.OpenJobOpeningsError
+	if errors.As(err, &openErr) {
+		return kindValidation
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return kindNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "22", pqErr.Code.Class() == "23":
+			return kindValidation
+		case pqErr.Code.Class() == "28", pqErr.Code == "42501":
+			return kindPermission
+		}
+		return kindInfra
+	}
+
+	var netErr *net.OpError
+	var numErr *strconv.NumError
+	switch {
+	case errors.As(err, &netErr), errors.Is(err, driver.ErrBadConn):
+		return kindInfra
+	case errors.As(err, &numErr):
+		return kindValidation
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, fs.ErrNotExist):
+		return kindNotFound
+	case errors.Is(err, fs.ErrPermission):
+		return kindPermission
+	}
+	return kindOther
+}
+
+func exitCodeFor(kind errorKind) int {
+	switch kind {
+	case kindValidation:
+		return exitValidation
+	case kindNotFound:
+		return exitNotFound
+	case kindPermission:
+		return exitPermission
+	case kindInfra:
+		return exitInfra
+	case kindQuota:
+		return exitQuota
+	}
+	return exitFailure
+}
+
+// Выводит ошибку команды в выбранном формате (--error-format) и возвращает код завершения.
+// В формате json ошибка пишется одной строкой в stderr.
+func reportError(err error) int {
+	kind := classifyError(err)
+	code := exitCodeFor(kind)
+	if errorFormat != "json" {
+		handleError(err)
+		return code
+	}
+
+	logError(err)
+	message, hint := describeError(err)
+	json.NewEncoder(os.Stderr).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"kind":      kind,
+			"exit_code": code,
+			"message":   message,
+			"hint":      hint,
+			"detail":    err.Error(),
+		},
+	})
+	return code
+}
+
+var pqDetailKeyValue = regexp.MustCompile(`\((.+?)\)=\((.*)\)`)
+
+// Возвращает понятное пользователю сообщение и подсказку; исходная ошибка показывается только в режиме -v.
+func describeError(err error) (string, string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return describePQError(pqErr)
+	}
+
+	var netErr *net.OpError
+	if errors.As(err, &netErr) {
+		return "нет соединения с базой данных",
+			"проверьте DATABASE_URL (команда config покажет, откуда он взят) и что PostgreSQL запущен"
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return fmt.Sprintf("%q не является числом", numErr.Num),
+			"введите число цифрами, дробную часть отделяйте точкой"
+	}
+
+	var inactiveErr *storage.CompanyInactiveError
+	if errors.As(err, &inactiveErr) {
+		return err.Error(), "данные компании доступны только для чтения; продлить или возобновить её может администратор (company extend, company activate)"
+	}
+
+	var openErr *storage.OpenJobOpeningsError
+	if errors.As(err, &openErr) {
+		return err.Error(), "закройте вакансии компании или удалите её вместе с ними (company delete <ID> --force)"
+	}
+
+	if classifyError(err) == kindQuota {
+		return err.Error(), "закройте или удалите ненужные вакансии либо попросите администратора увеличить квоту (quota set)"
+	}
+
+	return err.Error(), ""
+}
+
+func describePQError(pqErr *pq.Error) (string, string) {
+	value := ""
+	if m := pqDetailKeyValue.FindStringSubmatch(pqErr.Detail); m != nil {
+		value = m[2]
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		switch pqErr.Table {
+		case "companies":
+			return fmt.Sprintf("компания «%s» уже существует", value),
+				"используйте существующую компанию или выберите другое название"
+		case "users":
+			return fmt.Sprintf("пользователь «%s» уже существует", value),
+				"выберите другое имя пользователя или авторизуйтесь"
+		case "applications":
+			return "кандидат уже откликнулся на эту вакансию",
+				"измените статус существующего отклика"
+		}
+		return "такая запись уже существует", "проверьте уникальные поля"
+	case "23503":
+		if strings.HasSuffix(pqErr.Constraint, "salary_currency_fkey") {
+			return fmt.Sprintf("курс валюты %s не задан", value),
+				"добавьте курс командой currency set <код> <курс> или укажите другую валюту"
+		}
+		if pqErr.Table == "job_openings" {
+			return fmt.Sprintf("компании с ID %s не существует", value),
+				"сначала добавьте компанию (пункт «Добавить компанию») и используйте её ID"
+		}
+		return "запись ссылается на несуществующий объект", "проверьте указанные ID"
+	case "23514":
+		if pqErr.Constraint == "job_openings_salary_range_check" {
+			return "неверная вилка зарплаты", "укажите хотя бы одну границу, нижняя не может быть больше верхней"
+		}
+		return "значение не прошло проверку", "проверьте введённые данные"
+	case "23502":
+		return fmt.Sprintf("поле %s обязательно для заполнения", pqErr.Column), ""
+	case "22003":
+		return "число выходит за допустимый диапазон",
+			"зарплата не может превышать 99 999 999.99"
+	case "22001":
+		return "значение слишком длинное", "сократите введённый текст"
+	case "28P01", "28000":
+		return "не удалось авторизоваться в базе данных",
+			"проверьте имя пользователя и пароль в DATABASE_URL"
+	case "3D000":
+		return "база данных не найдена", "проверьте имя базы в DATABASE_URL"
+	case "23000":
+		// Так отказывает триггер legal_hold_guard; текст уже называет кандидата или отклик.
+		if strings.Contains(pqErr.Message, "юридическим удержанием") {
+			return pqErr.Message, "снимите удержание командой legal-hold release, затем повторите удаление"
+		}
+		return "нарушена целостность данных", "проверьте связанные записи"
+	}
+	return "ошибка базы данных", "повторите с флагом -v, чтобы увидеть подробности"
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Алгоритм подбора для офлайн-сравнения: чем больше оценка, тем выше кандидат в выдаче.
+type matcherFunc func(job storage.JobOpening, candidate storage.Candidate, weights MatchWeights) float64
+
+type namedMatcher struct {
+	Name  string
+	Score matcherFunc
+}
+
+// keyword — то, что делает поиск по навыку: число совпавших требуемых навыков;
+// weighted — взвешенная оценка matchCandidate с весами компании вакансии.
+var matchers = []namedMatcher{
+	{"keyword", func(job storage.JobOpening, candidate storage.Candidate, _ MatchWeights) float64 {
+		_, matched, _ := scoreSkills(job, candidate)
+		return float64(len(matched))
+	}},
+	{"weighted", func(job storage.JobOpening, candidate storage.Candidate, weights MatchWeights) float64 {
+		return matchCandidate(job, candidate, weights).Score
+	}},
+}
+
+type MatcherEvaluation struct {
+	Matcher   string          `json:"matcher"`
+	Vacancies int             `json:"vacancies"`
+	Precision map[int]float64 `json:"precision_at"`
+	MRR       float64         `json:"mrr"`
+}
+
+// Кандидаты, дошедшие хотя бы до этапа stage, по вакансиям (ID вакансии → ID кандидата).
+// Учитывается вся история статусов: кандидат, прошедший собеседование и потом получивший отказ, тоже считается.
+func loadRelevantCandidates(app *App, stage string, companyID int) (map[int]map[int]bool, map[int][]int, error) {
+	target := funnelStageIndex(stage)
+	if target <= 0 {
+		return nil, nil, validationErrorf("этап релевантности должен быть одним из: screening, interview, offer, hired")
+	}
+	rows, err := app.DB.Query(`
+		SELECT a.job_opening_id, a.candidate_id, a.status, COALESCE(h.to_status, '')
+		FROM applications a
+		JOIN job_openings j ON j.id = a.job_opening_id
+		LEFT JOIN application_status_history h ON h.application_id = a.id
+		WHERE $1 = 0 OR j.company_id = $1
+		ORDER BY a.job_opening_id, a.candidate_id`, companyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	relevant := map[int]map[int]bool{}
+	applicants := map[int][]int{}
+	seen := map[[2]int]bool{}
+	for rows.Next() {
+		var jobID, candidateID int
+		var status, historyStatus string
+		if err := rows.Scan(&jobID, &candidateID, &status, &historyStatus); err != nil {
+			return nil, nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		key := [2]int{jobID, candidateID}
+		if !seen[key] {
+			seen[key] = true
+			applicants[jobID] = append(applicants[jobID], candidateID)
+		}
+		if funnelStageIndex(status) >= target || funnelStageIndex(historyStatus) >= target {
+			if relevant[jobID] == nil {
+				relevant[jobID] = map[int]bool{}
+			}
+			relevant[jobID][candidateID] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return relevant, applicants, nil
+}
+
+// Precision@k делится на min(k, размер пула), иначе на вакансиях с тремя откликами
+// P@10 не могла бы превысить 0.3 и сравнение стало бы неинформативным.
+func precisionAt(ranked []int, relevant map[int]bool, k int) float64 {
+	if k > len(ranked) {
+		k = len(ranked)
+	}
+	if k == 0 {
+		return 0
+	}
+	hits := 0
+	for _, id := range ranked[:k] {
+		if relevant[id] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(k)
+}
+
+func reciprocalRank(ranked []int, relevant map[int]bool) float64 {
+	for i, id := range ranked {
+		if relevant[id] {
+			return 1 / float64(i+1)
+		}
+	}
+	return 0
+}
+
+// Переигрывает исторические отклики: для каждой вакансии, где есть релевантные кандидаты,
+// каждый алгоритм ранжирует пул (откликнувшихся или всех кандидатов), и по позициям релевантных
+// считаются precision@k и MRR. При равной оценке порядок — по ID кандидата, одинаково для всех алгоритмов.
+func evaluateMatchers(app *App, stage string, companyID int, allCandidates bool, ks []int) ([]MatcherEvaluation, error) {
+	relevant, applicants, err := loadRelevantCandidates(app, stage, companyID)
+	if err != nil {
+		return nil, err
+	}
+	jobOpenings, err := app.JobOpenings.List()
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := app.Candidates.List()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]storage.Candidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.ID] = c
+	}
+
+	results := make([]MatcherEvaluation, len(matchers))
+	for i, m := range matchers {
+		results[i] = MatcherEvaluation{Matcher: m.Name, Precision: map[int]float64{}}
+	}
+	companyWeights := map[int]MatchWeights{}
+	for _, job := range jobOpenings {
+		if len(relevant[job.ID]) == 0 {
+			continue
+		}
+		weights, ok := companyWeights[job.CompanyID]
+		if !ok {
+			if weights, err = companyMatchWeights(app.DB, job.CompanyID); err != nil {
+				return nil, err
+			}
+			companyWeights[job.CompanyID] = weights
+		}
+		pool := candidates
+		if !allCandidates {
+			pool = pool[:0:0]
+			for _, id := range applicants[job.ID] {
+				pool = append(pool, byID[id])
+			}
+		}
+
+		for i, m := range matchers {
+			scores := make(map[int]float64, len(pool))
+			ranked := make([]int, 0, len(pool))
+			for _, c := range pool {
+				scores[c.ID] = m.Score(job, c, weights)
+				ranked = append(ranked, c.ID)
+			}
+			sort.Slice(ranked, func(a, b int) bool {
+				if scores[ranked[a]] != scores[ranked[b]] {
+					return scores[ranked[a]] > scores[ranked[b]]
+				}
+				return ranked[a] < ranked[b]
+			})
+			results[i].Vacancies++
+			for _, k := range ks {
+				results[i].Precision[k] += precisionAt(ranked, relevant[job.ID], k)
+			}
+			results[i].MRR += reciprocalRank(ranked, relevant[job.ID])
+		}
+	}
+
+	for i := range results {
+		if results[i].Vacancies == 0 {
+			continue
+		}
+		n := float64(results[i].Vacancies)
+		for k := range results[i].Precision {
+			results[i].Precision[k] /= n
+		}
+		results[i].MRR /= n
+	}
+	return results, nil
+}
+
+func parseCutoffs(text string) ([]int, error) {
+	var ks []int
+	for _, part := range strings.Split(text, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || k <= 0 {
+			return nil, validationErrorf("глубина выдачи %q должна быть положительным числом", part)
+		}
+		ks = append(ks, k)
+	}
+	sort.Ints(ks)
+	return ks, nil
+}
+
+func printMatcherEvaluations(w io.Writer, results []MatcherEvaluation, ks []int) {
+	if len(results) == 0 || results[0].Vacancies == 0 {
+		fmt.Fprintln(w, "Нет вакансий с кандидатами, дошедшими до выбранного этапа.")
+		return
+	}
+	fmt.Fprintf(w, "Вакансий в оценке: %d\n", results[0].Vacancies)
+	fmt.Fprintf(w, "%-10s", "Алгоритм")
+	for _, k := range ks {
+		fmt.Fprintf(w, " %7s", fmt.Sprintf("P@%d", k))
+	}
+	fmt.Fprintf(w, " %7s\n", "MRR")
+	for _, r := range results {
+		fmt.Fprintf(w, "%-10s", r.Matcher)
+		for _, k := range ks {
+			fmt.Fprintf(w, " %7.3f", r.Precision[k])
+		}
+		fmt.Fprintf(w, " %7.3f\n", r.MRR)
+	}
+}
+
+// Строка на алгоритм, precision@k — по столбцу на глубину.
+func writeMatcherEvaluationsCSV(w io.Writer, results []MatcherEvaluation, ks []int) error {
+	writer := csv.NewWriter(w)
+	header := []string{"matcher", "vacancies"}
+	for _, k := range ks {
+		header = append(header, fmt.Sprintf("p@%d", k))
+	}
+	writer.Write(append(header, "mrr"))
+	for _, r := range results {
+		record := []string{r.Matcher, strconv.Itoa(r.Vacancies)}
+		for _, k := range ks {
+			record = append(record, strconv.FormatFloat(r.Precision[k], 'f', 3, 64))
+		}
+		writer.Write(append(record, strconv.FormatFloat(r.MRR, 'f', 3, 64)))
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// evaluate-matching [-relevant hired] [-k 1,5,10] [-company ID] [-all]
+func runEvaluateMatchingCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("evaluate-matching", flag.ContinueOnError)
+	stage := flags.String("relevant", storage.ApplicationHired, "этап, дойдя до которого кандидат считается релевантным: screening, interview, offer, hired")
+	cutoffs := flags.String("k", "1,5,10", "глубины выдачи для precision@k через запятую")
+	company := entityFlagVar(flags, "company", entityCompany, "только вакансии компании (ID или название)")
+	all := flags.Bool("all", false, "ранжировать всех кандидатов, а не только откликнувшихся на вакансию")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	ks, err := parseCutoffs(*cutoffs)
+	if err != nil {
+		return reportError(err)
+	}
+	companyID, err := company.resolve(app.DB)
+	if err != nil {
+		return reportError(err)
+	}
+
+	results, err := evaluateMatchers(app, *stage, companyID, *all, ks)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{
+		data: results,
+		text: func(w io.Writer) { printMatcherEvaluations(w, results, ks) },
+		csv:  func(w io.Writer) error { return writeMatcherEvaluationsCSV(w, results, ks) },
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+// События изменения данных. Подписчики вызываются синхронно в том же процессе, поэтому
+// долгую работу они не выполняют, а ставят в очередь задач.
+const (
+	eventCandidateSaved           = "candidate.saved"
+	eventJobOpeningSaved          = "job_opening.saved"
+	eventMatchRulesChanged        = "match_rules.changed"
+	eventApplicationStatusChanged = "application.status_changed"
+)
+
+type Event struct {
+	Kind string
+	// ID изменённой записи: кандидата, вакансии, компании или отклика — в зависимости от Kind.
+	ID int
+}
+
+type EventHandler func(app *App, event Event) error
+
+var eventHandlers = map[string][]EventHandler{}
+
+func subscribe(kind string, handler EventHandler) {
+	eventHandlers[kind] = append(eventHandlers[kind], handler)
+}
+
+// Ошибка подписчика не отменяет уже сохранённое изменение: она только пишется в журнал,
+// а данные подправит следующий плановый пересчёт.
+func publish(app *App, event Event) {
+	for _, handler := range eventHandlers[event.Kind] {
+		if err := handler(app, event); err != nil {
+			app.logger().Error("ошибка обработки события", "event", event.Kind, "id", event.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Поля выгрузки по умолчанию — в порядке колонок CSV; имена совпадают с JSON-полями сущностей.
+var exportFields = map[string][]string{
+	"candidates":   {"id", "full_name", "age", "email", "phone", "experience", "skills", "expected_salary", "salary_currency", "location", "languages"},
+	"job_openings": {"id", "company_id", "title", "status", "experience", "salary_min", "salary_max", "salary_currency", "required_skills", "location", "languages"},
+	"companies":    {"id", "name", "status", "expires_at"},
+	"applications": {"id", "candidate_id", "job_opening_id", "status", "source", "created_at", "updated_at"},
+}
+
+type exportFilter struct {
+	Skill     string
+	CompanyID int
+	Status    string
+}
+
+func containsSkill(skills []string, skill string) bool {
+	for _, s := range skills {
+		if strings.EqualFold(strings.TrimSpace(s), skill) {
+			return true
+		}
+	}
+	return false
+}
+
+// Загружает записи сущности с учётом фильтров; фильтр, не применимый к сущности, — ошибка,
+// чтобы опечатка в команде не выгрузила молча всё подряд.
+func loadExportRecords(app *App, entity string, filter exportFilter) ([]interface{}, error) {
+	skill := strings.TrimSpace(filter.Skill)
+	var records []interface{}
+	switch entity {
+	case "candidates":
+		if filter.CompanyID != 0 || filter.Status != "" {
+			return nil, validationErrorf("для кандидатов доступен только фильтр -skill")
+		}
+		candidates, err := app.Candidates.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range candidates {
+			if skill == "" || containsSkill(c.Skills, skill) {
+				records = append(records, c)
+			}
+		}
+	case "job_openings":
+		if filter.Status != "" && filter.Status != "all" {
+			if err := validateJobStatus(filter.Status); err != nil {
+				return nil, err
+			}
+		}
+		jobOpenings, err := app.JobOpenings.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range jobOpenings {
+			if (skill == "" || containsSkill(j.RequiredSkills, skill)) &&
+				(filter.CompanyID == 0 || j.CompanyID == filter.CompanyID) &&
+				(filter.Status == "" || filter.Status == "all" || j.Status == filter.Status) {
+				records = append(records, j)
+			}
+		}
+	case "companies":
+		if skill != "" {
+			return nil, validationErrorf("для компаний фильтр -skill недоступен")
+		}
+		companies, err := app.Companies.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range companies {
+			if (filter.CompanyID == 0 || c.ID == filter.CompanyID) && (filter.Status == "" || c.Status == filter.Status) {
+				records = append(records, c)
+			}
+		}
+	case "applications":
+		if skill != "" {
+			return nil, validationErrorf("для откликов фильтр -skill недоступен")
+		}
+		applications, err := app.Applications.List()
+		if err != nil {
+			return nil, err
+		}
+		companyOf := map[int]int{}
+		if filter.CompanyID != 0 {
+			jobOpenings, err := app.JobOpenings.List()
+			if err != nil {
+				return nil, err
+			}
+			for _, j := range jobOpenings {
+				companyOf[j.ID] = j.CompanyID
+			}
+		}
+		for _, a := range applications {
+			if (filter.CompanyID == 0 || companyOf[a.JobOpeningID] == filter.CompanyID) && (filter.Status == "" || a.Status == filter.Status) {
+				records = append(records, a)
+			}
+		}
+	default:
+		return nil, validationErrorf("неизвестная сущность %q, доступны: candidates, job_openings, companies, applications", entity)
+	}
+	return records, nil
+}
+
+func parseExportFields(entity, text string) ([]string, error) {
+	all, ok := exportFields[entity]
+	if !ok {
+		return nil, validationErrorf("неизвестная сущность %q, доступны: candidates, job_openings, companies, applications", entity)
+	}
+	if strings.TrimSpace(text) == "" {
+		return all, nil
+	}
+	var fields []string
+	for _, name := range strings.Split(text, ",") {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, f := range all {
+			known = known || f == name
+		}
+		if !known {
+			return nil, validationErrorf("неизвестное поле %q, доступны: %s", name, strings.Join(all, ", "))
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Запись переводится в map через её JSON-представление, поэтому имена полей выгрузки
+// и RPC совпадают; поля, опущенные из-за omitempty, выгружаются пустыми.
+func selectFields(record interface{}, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации записи: %w", err)
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("ошибка сериализации записи: %w", err)
+	}
+	selected := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		selected[f] = all[f]
+	}
+	return selected, nil
+}
+
+// Списки пишутся в одну ячейку через «;», как их читает import-candidates.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = csvValue(item)
+		}
+		return strings.Join(parts, ";")
+	}
+	return fmt.Sprint(value)
+}
+
+func writeExport(w io.Writer, format string, fields []string, records []interface{}) error {
+	rows := make([]map[string]interface{}, 0, len(records))
+	for _, r := range records {
+		row, err := selectFields(r, fields)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write(fields)
+		for _, row := range rows {
+			values := make([]string, len(fields))
+			for i, f := range fields {
+				values[i] = csvValue(row[f])
+			}
+			writer.Write(values)
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return validationErrorf("неизвестный формат %q, доступны: csv, json", format)
+}
+
+// export <сущность> [-format csv|json] [-fields a,b] [-skill навык] [-company ID] [-status статус] [-o файл]
+func runExportCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Использование: export candidates|job_openings|companies|applications [-format csv|json] [-fields a,b] [-skill навык] [-company ID] [-status статус] [-o файл]")
+		return exitUsage
+	}
+	entity := args[0]
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	// Без -format выгрузка следует --format json, иначе остаётся CSV.
+	defaultFormat := formatCSV
+	if outputFormat == formatJSON {
+		defaultFormat = formatJSON
+	}
+	format := flags.String("format", defaultFormat, "формат: csv или json")
+	fieldsText := flags.String("fields", "", "поля через запятую (по умолчанию все)")
+	var filter exportFilter
+	flags.StringVar(&filter.Skill, "skill", "", "только кандидаты или вакансии с этим навыком")
+	company := entityFlagVar(flags, "company", entityCompany, "только записи компании (ID или название)")
+	flags.StringVar(&filter.Status, "status", "", "только записи с этим статусом")
+	output := flags.String("o", "", "файл (по умолчанию stdout)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if *format != "csv" && *format != "json" {
+		return reportError(validationErrorf("неизвестный формат %q, доступны: csv, json", *format))
+	}
+	fields, err := parseExportFields(entity, *fieldsText)
+	if err != nil {
+		return reportError(err)
+	}
+	if filter.CompanyID, err = company.resolve(app.DB); err != nil {
+		return reportError(err)
+	}
+	records, err := loadExportRecords(app, entity, filter)
+	if err != nil {
+		return reportError(err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return reportError(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := writeExport(w, *format, fields, records); err != nil {
+		return reportError(err)
+	}
+	if *output != "" {
+		return printDone(fmt.Sprintf("Выгружено записей: %d", len(records)), map[string]interface{}{"exported": len(records), "file": *output})
+	}
+	return exitOK
+}
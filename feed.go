@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// Лента рекомендаций для кандидата: открытые вакансии, на которые он ещё не откликался, по убыванию
+// оценки подбора его анкеты с весами компании вакансии — той же, по которой рекрутер видит кандидата
+// в выдаче. «Ещё похожие» оставляет в ленте вакансии, похожие на выбранную (см. similarJobOpenings),
+// и ставит выше самые похожие из подходящих. Вакансии с нулевой оценкой в ленту не попадают.
+
+// Доля похожести в оценке «ещё похожих»; остальное — оценка подбора.
+const feedSimilarityShare = 0.6
+
+const loginFeedLimit = 5
+
+type VacancyRecommendation struct {
+	storage.JobOpening
+	Score         float64  `json:"score"`
+	Match         float64  `json:"match"`
+	Similarity    float64  `json:"similarity,omitempty"`
+	MatchedSkills []string `json:"matched_skills"`
+	MissingSkills []string `json:"missing_skills"`
+}
+
+// likeID — вакансия, на которую должны быть похожи рекомендации; 0 — обычная лента.
+func vacancyFeed(app *App, userID, likeID, limit int) ([]VacancyRecommendation, error) {
+	candidate, err := userCandidate(app, userID)
+	if err != nil {
+		return nil, err
+	}
+	jobOpenings, err := app.JobOpenings.ListByStatus(storage.JobOpen)
+	if err != nil {
+		return nil, err
+	}
+	applications, err := app.Applications.ListByCandidate(candidate.ID)
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int]bool{likeID: true}
+	for _, a := range applications {
+		applied[a.JobOpeningID] = true
+	}
+	var similarity map[int]float64
+	if likeID != 0 {
+		similar, err := similarJobOpenings(app, likeID, len(jobOpenings))
+		if err != nil {
+			return nil, err
+		}
+		similarity = make(map[int]float64, len(similar))
+		for _, s := range similar {
+			similarity[s.ID] = s.Score
+		}
+	}
+
+	weights := map[int]MatchWeights{}
+	var feed []VacancyRecommendation
+	for _, j := range jobOpenings {
+		if applied[j.ID] || similarity != nil && similarity[j.ID] == 0 {
+			continue
+		}
+		w, ok := weights[j.CompanyID]
+		if !ok {
+			if w, err = companyMatchWeights(app.DB, j.CompanyID); err != nil {
+				return nil, err
+			}
+			if w, err = withSkillGraph(app.DB, w); err != nil {
+				return nil, err
+			}
+			weights[j.CompanyID] = w
+		}
+		match := matchCandidate(j, candidate, w)
+		if match.Score == 0 {
+			continue
+		}
+		r := VacancyRecommendation{JobOpening: j, Score: match.Score, Match: match.Score,
+			MatchedSkills: match.MatchedSkills, MissingSkills: match.MissingSkills}
+		if similarity != nil {
+			r.Similarity = similarity[j.ID]
+			r.Score = feedSimilarityShare*r.Similarity + (1-feedSimilarityShare)*r.Match
+		}
+		feed = append(feed, r)
+	}
+	sort.SliceStable(feed, func(i, k int) bool { return feed[i].Score > feed[k].Score })
+	if limit = normalizeSimilarLimit(limit); len(feed) > limit {
+		feed = feed[:limit]
+	}
+	return feed, nil
+}
+
+func printVacancyFeed(w io.Writer, app *App, feed []VacancyRecommendation) {
+	if len(feed) == 0 {
+		fmt.Fprintln(w, "Подходящих открытых вакансий нет.")
+		return
+	}
+	companies := map[int]string{}
+	for _, r := range feed {
+		company, ok := companies[r.CompanyID]
+		if !ok {
+			if c, err := app.Companies.GetByID(r.CompanyID); err == nil {
+				company = c.Name
+			}
+			companies[r.CompanyID] = company
+		}
+		fmt.Fprintf(w, "%-5d %3.0f%%  %s — %s, %s\n", r.ID, r.Score*100, r.Title, company, jobSalaryText(r.JobOpening))
+		if len(r.MatchedSkills) > 0 {
+			fmt.Fprintf(w, "       совпадает: %s\n", strings.Join(r.MatchedSkills, ", "))
+		}
+	}
+}
+
+// Лента при входе кандидата; без связанной анкеты ничего не показывается.
+func showLoginFeed(app *App, userID int) {
+	if _, err := userCandidate(app, userID); err != nil {
+		return
+	}
+	feed, err := vacancyFeed(app, userID, 0, loginFeedLimit)
+	handleError(err)
+	if err == nil && len(feed) > 0 {
+		fmt.Println("Вакансии, подходящие вам:")
+		printVacancyFeed(os.Stdout, app, feed)
+	}
+}
+
+func vacancyFeedMenu(app *App) {
+	likeID := 0
+	for {
+		feed, err := vacancyFeed(app, actingUserID(), likeID, 0)
+		handleError(err)
+		if err != nil {
+			return
+		}
+		printVacancyFeed(os.Stdout, app, feed)
+		input := getInput("«ещё <ID вакансии>» — ещё похожие, «все» — вся лента, Enter — назад: ")
+		switch text, more := strings.CutPrefix(input, "ещё"); {
+		case input == "":
+			return
+		case input == "все":
+			likeID = 0
+		case more:
+			id, err := strconv.Atoi(strings.TrimSpace(text))
+			if err != nil {
+				fmt.Println("Укажите ID вакансии, например: ещё 12")
+				continue
+			}
+			likeID = id
+		default:
+			fmt.Println("Неверный выбор действия.")
+		}
+	}
+}
+
+// feed <пользователь> [-like ID] [-limit N] [-json]
+func runFeedCommand(app *App, args []string) int {
+	usage := "Использование: feed <пользователь> [-like ID вакансии] [-limit N] [-json]"
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("feed", flag.ContinueOnError)
+	likeID := flags.Int("like", 0, "показать вакансии, похожие на эту")
+	limit := flags.Int("limit", defaultSimilarLimit, "сколько вакансий показать")
+	asJSON := flags.Bool("json", false, "вывести ленту в JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	useJSON(*asJSON)
+	user, err := app.Users.GetByUsername(normalizeText(args[0], false))
+	if errors.Is(err, storage.ErrNotFound) {
+		err = notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return reportError(err)
+	}
+	feed, err := vacancyFeed(app, user.ID, *likeID, *limit)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: feed, text: func(w io.Writer) { printVacancyFeed(w, app, feed) }})
+}
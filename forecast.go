@@ -0,0 +1,260 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Этапы воронки по порядку; rejected в воронку не входит — это выход из неё на любом этапе.
+var funnelStages = []string{
+	storage.ApplicationNew, storage.ApplicationScreening, storage.ApplicationInterview, storage.ApplicationOffer, storage.ApplicationHired,
+}
+
+// Если у компании меньше завершённых откликов, конверсии считаются по всем компаниям.
+const minForecastHistory = 20
+
+type StageForecast struct {
+	Stage string `json:"stage"`
+	// Сколько кандидатов вакансии сейчас на этапе.
+	Current int `json:"current"`
+	// Исторические доли: переход на следующий этап и найм в итоге.
+	Conversion   float64 `json:"conversion"`
+	HireChance   float64 `json:"hire_chance"`
+	SampleSize   int     `json:"sample_size"`
+	MedianToHire float64 `json:"median_days_to_hire"`
+}
+
+type HiringForecast struct {
+	JobOpeningID   int             `json:"job_opening_id"`
+	Openings       int             `json:"openings"`
+	Hired          int             `json:"hired"`
+	ExpectedHires  float64         `json:"expected_hires"`
+	HiresLow       float64         `json:"hires_low"`
+	HiresHigh      float64         `json:"hires_high"`
+	DaysToFill     float64         `json:"days_to_fill"`
+	DaysToFillLow  float64         `json:"days_to_fill_low"`
+	DaysToFillHigh float64         `json:"days_to_fill_high"`
+	Stages         []StageForecast `json:"stages"`
+	HistoryScope   string          `json:"history_scope"`
+	HistorySize    int             `json:"history_size"`
+	// Сколько ещё новых откликов нужно, чтобы ожидаемое число наймов покрыло вакансию; 0 — достаточно.
+	SourceMore int `json:"source_more"`
+}
+
+type funnelHistory struct {
+	maxStage  int
+	hiredAt   time.Time
+	reachedAt map[int]time.Time
+}
+
+func funnelStageIndex(status string) int {
+	for i, s := range funnelStages {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// Загружает историю завершённых (hired или rejected) откликов; companyID = 0 — по всем компаниям.
+func loadFunnelHistory(app *App, companyID int) ([]funnelHistory, error) {
+	query := `SELECT a.id, a.created_at, h.to_status, h.changed_at
+    FROM applications a
+    JOIN application_status_history h ON h.application_id = a.id
+    JOIN job_openings j ON j.id = a.job_opening_id
+    WHERE a.status IN ('hired', 'rejected') AND ($1 = 0 OR j.company_id = $1)
+    ORDER BY a.id, h.changed_at, h.id`
+	rows, err := app.reader().Query(query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var history []funnelHistory
+	lastID := 0
+	for rows.Next() {
+		var id int
+		var createdAt, changedAt time.Time
+		var status string
+		if err := rows.Scan(&id, &createdAt, &status, &changedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if id != lastID {
+			history = append(history, funnelHistory{reachedAt: map[int]time.Time{0: createdAt}})
+			lastID = id
+		}
+		h := &history[len(history)-1]
+		stage := funnelStageIndex(status)
+		if stage < 0 {
+			continue
+		}
+		if _, ok := h.reachedAt[stage]; !ok {
+			h.reachedAt[stage] = changedAt
+		}
+		if stage > h.maxStage {
+			h.maxStage = stage
+		}
+		if status == storage.ApplicationHired {
+			h.hiredAt = changedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return history, nil
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(math.Round(p*float64(len(sorted)-1)))]
+}
+
+// Кандидат, пропустивший этап (например, сразу из new в interview), считается прошедшим и его.
+// Ожидаемые наймы — сумма вероятностей найма по текущим кандидатам; диапазон — 80% по нормальному
+// приближению суммы бернуллиевских величин. Срок закрытия — дни до найма от самого продвинутого занятого этапа.
+func forecastHiring(app *App, jobOpeningID, openings int) (HiringForecast, error) {
+	if openings <= 0 {
+		return HiringForecast{}, validationErrorf("число позиций должно быть положительным")
+	}
+	job, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return HiringForecast{}, err
+	}
+	forecast := HiringForecast{JobOpeningID: job.ID, Openings: openings, HistoryScope: "company"}
+	history, err := loadFunnelHistory(app, job.CompanyID)
+	if err == nil && len(history) < minForecastHistory {
+		forecast.HistoryScope = "all"
+		history, err = loadFunnelHistory(app, 0)
+	}
+	if err != nil {
+		return HiringForecast{}, err
+	}
+	forecast.HistorySize = len(history)
+
+	applications, err := app.Applications.ListByJobOpening(job.ID)
+	if err != nil {
+		return HiringForecast{}, err
+	}
+	current := make([]int, len(funnelStages))
+	for _, a := range applications {
+		if stage := funnelStageIndex(a.Status); stage >= 0 {
+			current[stage]++
+		}
+	}
+	hiredStage := len(funnelStages) - 1
+	forecast.Hired = current[hiredStage]
+
+	reached := make([]int, len(funnelStages))
+	daysToHire := make([][]float64, len(funnelStages))
+	for _, h := range history {
+		for stage := 0; stage <= h.maxStage; stage++ {
+			reached[stage]++
+		}
+		if h.hiredAt.IsZero() {
+			continue
+		}
+		for stage, at := range h.reachedAt {
+			daysToHire[stage] = append(daysToHire[stage], h.hiredAt.Sub(at).Hours()/24)
+		}
+	}
+
+	var variance float64
+	deepest := -1
+	for stage := range funnelStages {
+		s := StageForecast{Stage: funnelStages[stage], Current: current[stage], SampleSize: reached[stage]}
+		if stage < hiredStage && reached[stage] > 0 {
+			s.Conversion = float64(reached[stage+1]) / float64(reached[stage])
+			s.HireChance = float64(reached[hiredStage]) / float64(reached[stage])
+		}
+		sort.Float64s(daysToHire[stage])
+		s.MedianToHire = percentile(daysToHire[stage], 0.5)
+		forecast.Stages = append(forecast.Stages, s)
+
+		if stage < hiredStage && current[stage] > 0 {
+			forecast.ExpectedHires += float64(current[stage]) * s.HireChance
+			variance += float64(current[stage]) * s.HireChance * (1 - s.HireChance)
+			deepest = stage
+		}
+	}
+	spread := 1.2816 * math.Sqrt(variance)
+	forecast.HiresLow = math.Max(0, forecast.ExpectedHires-spread)
+	forecast.HiresHigh = forecast.ExpectedHires + spread
+	if deepest >= 0 {
+		days := daysToHire[deepest]
+		forecast.DaysToFill = percentile(days, 0.5)
+		forecast.DaysToFillLow = percentile(days, 0.1)
+		forecast.DaysToFillHigh = percentile(days, 0.9)
+	}
+
+	missing := float64(openings-forecast.Hired) - forecast.ExpectedHires
+	if newChance := forecast.Stages[0].HireChance; missing > 0 && newChance > 0 {
+		forecast.SourceMore = int(math.Ceil(missing / newChance))
+	}
+	return forecast, nil
+}
+
+func printHiringForecast(w io.Writer, f HiringForecast) {
+	scope := "по компании"
+	if f.HistoryScope == "all" {
+		scope = "по всем компаниям (истории компании недостаточно)"
+	}
+	fmt.Fprintf(w, "Прогноз по вакансии %d, история: %d завершённых откликов %s\n\n", f.JobOpeningID, f.HistorySize, scope)
+	fmt.Fprintf(w, "%-14s %8s %10s %10s %12s\n", "Этап", "Сейчас", "Переход", "Найм", "Дней до найма")
+	for _, s := range f.Stages {
+		fmt.Fprintf(w, "%-14s %8d %9.0f%% %9.0f%% %12.0f\n", applicationStatusTitles[s.Stage], s.Current, s.Conversion*100, s.HireChance*100, s.MedianToHire)
+	}
+	fmt.Fprintf(w, "\nНанято: %d из %d\n", f.Hired, f.Openings)
+	fmt.Fprintf(w, "Ожидаемые наймы из текущей воронки: %.1f (80%%: %.1f–%.1f)\n", f.ExpectedHires, f.HiresLow, f.HiresHigh)
+	if f.DaysToFill > 0 {
+		fmt.Fprintf(w, "Срок закрытия: ~%.0f дн. (80%%: %.0f–%.0f)\n", f.DaysToFill, f.DaysToFillLow, f.DaysToFillHigh)
+	}
+	switch {
+	case f.Hired >= f.Openings:
+		fmt.Fprintln(w, "Вакансия закрыта.")
+	case f.SourceMore > 0:
+		fmt.Fprintf(w, "Текущей воронки, скорее всего, не хватит: нужно ещё около %d откликов.\n", f.SourceMore)
+	case f.HistorySize == 0:
+		fmt.Fprintln(w, "Истории откликов пока нет, прогноз невозможен.")
+	default:
+		fmt.Fprintln(w, "Текущей воронки должно хватить.")
+	}
+}
+
+// forecast <ID вакансии> [-openings N] [-snapshot]
+func runForecastCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Использование: forecast <ID вакансии> [-openings N] [-snapshot]")
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityJobOpening, args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	flags := flag.NewFlagSet("forecast", flag.ContinueOnError)
+	openings := flags.Int("openings", 1, "сколько человек нужно нанять на вакансию")
+	snapshot := flags.Bool("snapshot", reportSnapshotEnabled(), "строить прогноз по снимку базы на один момент")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	var forecast HiringForecast
+	err = withReportSnapshot(app, *snapshot, func(app *App) (err error) {
+		forecast, err = forecastHiring(app, id, *openings)
+		return err
+	})
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{
+		data: forecast,
+		text: func(w io.Writer) { printHiringForecast(w, forecast) },
+		csv:  func(w io.Writer) error { return writeDataCSV(w, forecast.Stages) },
+	})
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Пустой ввод оставляет текущее значение, «-» очищает необязательное поле.
+// Каждое поле проверяется сразу, при ошибке вопрос повторяется.
+func runEditForm(fields []EditableField, current map[string]string) map[string]interface{} {
+	changes := map[string]interface{}{}
+	fmt.Println("Для длинных полей: завершите ввод строкой «.», «@файл» — из файла, «!edit» — в редакторе.")
+	for _, field := range fields {
+		for {
+			input := getInput(fmt.Sprintf("%s [%s]: ", field.Label, formPreview(current[field.Name])))
+			if input == "" {
+				break
+			}
+			if input == "-" {
+				if !field.Optional {
+					fmt.Println("Это поле обязательно, его нельзя очистить.")
+					continue
+				}
+				input = ""
+			}
+			var value interface{}
+			var err error
+			if field.Kind == "text" && input != "" {
+				value, err = readLongText(input, current[field.Name])
+			} else {
+				value, err = parseFieldValue(field, input)
+			}
+			if err == nil && field.Validate != nil {
+				err = field.Validate(value)
+			}
+			if err == nil && field.Kind == "email" {
+				err = checkEmailInteractive(value.(string))
+			}
+			if err != nil {
+				handleError(err)
+				continue
+			}
+			changes[field.Name] = value
+			break
+		}
+	}
+	return changes
+}
+
+func formPreview(value string) string {
+	firstLine, _, multiline := strings.Cut(value, "\n")
+	runes := []rune(firstLine)
+	if len(runes) > 60 {
+		return string(runes[:60]) + "…"
+	}
+	if multiline {
+		return firstLine + " …"
+	}
+	return firstLine
+}
+
+func candidateFormValues(c storage.Candidate) map[string]string {
+	return map[string]string{
+		"full_name":       c.FullName,
+		"age":             strconv.Itoa(c.Age),
+		"email":           c.Email,
+		"phone":           c.Phone,
+		"experience":      c.Experience,
+		"skills":          strings.Join(c.Skills, ", "),
+		"expected_salary": fmt.Sprintf("%.2f", c.ExpectedSalary),
+		"salary_currency": c.SalaryCurrency,
+		"location":        c.Location,
+		"languages":       strings.Join(c.Languages, ", "),
+		"status":          c.Status,
+		"tags":            strings.Join(c.Tags, ", "),
+		"available_from":  formDate(c.AvailableFrom),
+	}
+}
+
+func formDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func jobOpeningFormValues(j storage.JobOpening) map[string]string {
+	return map[string]string{
+		"company_id":      strconv.Itoa(j.CompanyID),
+		"title":           j.Title,
+		"experience":      j.Experience,
+		"salary_min":      fmt.Sprintf("%.2f", j.SalaryMin),
+		"salary_max":      fmt.Sprintf("%.2f", j.SalaryMax),
+		"salary_currency": j.SalaryCurrency,
+		"required_skills": strings.Join(j.RequiredSkills, ", "),
+		"location":        j.Location,
+		"languages":       strings.Join(j.Languages, ", "),
+	}
+}
+
+func editCandidateForm(app *App, id int) (bool, error) {
+	candidate, err := getCandidateByID(app, id)
+	if err != nil {
+		return false, err
+	}
+	fmt.Println("Enter — оставить текущее значение, «-» — очистить необязательное поле.")
+	changes := runEditForm(candidateEditableFields, candidateFormValues(candidate))
+	if len(changes) == 0 {
+		return false, nil
+	}
+	return true, updateCandidateFields(app, id, changes)
+}
+
+func editJobOpeningForm(app *App, id int) (bool, error) {
+	jobOpening, err := getJobOpeningByID(app, id)
+	if err != nil {
+		return false, err
+	}
+	if err := authorizeCompany(app, jobOpening.CompanyID); err != nil {
+		return false, err
+	}
+	fmt.Println("Enter — оставить текущее значение, «-» — очистить необязательное поле.")
+	changes := runEditForm(jobOpeningEditableFields, jobOpeningFormValues(jobOpening))
+	if len(changes) == 0 {
+		return false, nil
+	}
+	return true, updateJobOpeningFields(app, id, changes)
+}
+
+// Формы добавления записей. Ошибка в одном поле не прерывает ввод: после всех вопросов
+// показывается сводка с результатами проверки, и пользователь либо сохраняет запись, либо
+// вводит заново нужное поле, либо отменяет добавление.
+
+type formField struct {
+	// Имя поля в черновике.
+	Key    string
+	Label  string
+	Prompt string
+	Long   bool
+	// Разбирает и проверяет ввод, записывая значение в запись формы.
+	Set func(input string) error
+
+	input  string
+	err    error
+	filled bool
+}
+
+func (f *formField) set(input string) {
+	f.input, f.err, f.filled = input, f.Set(input), true
+}
+
+func (f *formField) ask() {
+	if !f.Long {
+		f.set(getInput(f.Prompt))
+		return
+	}
+	input, err := getLongTextInput(f.Prompt)
+	if err != nil {
+		f.input, f.err, f.filled = "", err, true
+		return
+	}
+	f.set(input)
+}
+
+// Запрашивает незаполненные поля и показывает сводку, пока пользователь не сохранит запись или не откажется.
+// check — проверка записи целиком, как при сохранении. false — добавление отменено или отложено.
+// Каждое введённое поле сохраняется в черновик saver.
+func runForm(title string, fields []*formField, saver *draftSaver, check func() error) bool {
+	saver.restore(fields)
+	for _, f := range fields {
+		if !f.filled {
+			f.ask()
+			saver.save(title, fields)
+		}
+	}
+	for {
+		fmt.Printf("\n%s:\n", title)
+		valid := true
+		for i, f := range fields {
+			status := "ок"
+			if f.err != nil {
+				status, valid = "ошибка: "+f.err.Error(), false
+			}
+			value := formPreview(f.input)
+			if value == "" {
+				value = "—"
+			}
+			fmt.Printf("%d. %s: %s  [%s]\n", i+1, f.Label, value, status)
+		}
+		if valid {
+			if err := check(); err != nil {
+				fmt.Println("Запись не пройдёт проверку:", err)
+				valid = false
+			}
+		}
+		prompt := "Номер поля — исправить, q — отменить"
+		if valid {
+			prompt = "Enter — сохранить, номер поля — исправить, q — отменить"
+		}
+		if saver != nil {
+			prompt += ", s — отложить"
+		}
+		input := getInput(prompt + ": ")
+		switch {
+		case input == "q":
+			saver.discard()
+			fmt.Println("Добавление отменено.")
+			return false
+		case input == "s" && saver != nil:
+			fmt.Println("Черновик сохранён, продолжить ввод можно в пункте «Черновики».")
+			return false
+		case input == "":
+			if valid {
+				return true
+			}
+			fmt.Println("Сначала исправьте поля с ошибками.")
+		default:
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 || n > len(fields) {
+				fmt.Println("Нет такого поля.")
+				continue
+			}
+			fields[n-1].ask()
+			saver.save(title, fields)
+		}
+	}
+}
+
+func parseFormInt(input string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		return 0, validationErrorf("введите целое число")
+	}
+	return n, nil
+}
+
+func parseFormList(input string) []string {
+	items := []string{}
+	for _, item := range splitList(input, ",") {
+		items = append(items, strings.TrimSpace(item))
+	}
+	return items
+}
+
+// Запрашивает поля нового кандидата; часть полей может быть уже известна (ФИО из добавления отклика,
+// данные из резюме) — они не спрашиваются, но их можно исправить в сводке.
+// false — пользователь отменил или отложил добавление.
+func promptCandidate(prefill map[string]string, saver *draftSaver) (storage.Candidate, bool) {
+	var candidate storage.Candidate
+	fields := []*formField{
+		{Key: "full_name", Label: "ФИО", Prompt: "Введите ФИО кандидата: ", Set: func(input string) (err error) {
+			if candidate.FullName, err = sanitizeText("ФИО", input, maxNameLength); err != nil {
+				return err
+			}
+			if candidate.FullName == "" {
+				return validationErrorf("ФИО кандидата не может быть пустым")
+			}
+			return nil
+		}},
+		{Key: "age", Label: "Возраст", Prompt: "Введите возраст кандидата: ", Set: func(input string) (err error) {
+			if candidate.Age, err = parseFormInt(input); err != nil {
+				return err
+			}
+			if candidate.Age <= 0 {
+				return validationErrorf("возраст кандидата должен быть положительным")
+			}
+			return nil
+		}},
+		{Key: "email", Label: "Email", Prompt: "Введите email кандидата: ", Set: func(input string) error {
+			candidate.Email = strings.TrimSpace(input)
+			if err := validateEmailSyntax(candidate.Email); err != nil {
+				return err
+			}
+			return checkEmailInteractive(candidate.Email)
+		}},
+		{Key: "phone", Label: "Телефон", Prompt: "Введите телефон кандидата (необязательно): ", Set: func(input string) (err error) {
+			candidate.Phone, err = normalizePhone(input)
+			return err
+		}},
+		{Key: "experience", Label: "Опыт работы", Prompt: "Введите опыт работы кандидата: ", Long: true, Set: func(input string) (err error) {
+			candidate.Experience, err = sanitizeLongText("опыт работы", input)
+			return err
+		}},
+		{Key: "skills", Label: "Навыки", Prompt: "Введите навыки кандидата (через запятую): ", Set: func(input string) (err error) {
+			candidate.Skills, err = sanitizeSkills(parseFormList(input))
+			return err
+		}},
+		{Key: "expected_salary", Label: "Ожидаемая зарплата", Prompt: "Введите ожидаемую зарплату, например 250000 или 3000 USD (Enter — не указывать): ", Set: func(input string) (err error) {
+			candidate.ExpectedSalary, candidate.SalaryCurrency = 0, ""
+			if strings.TrimSpace(input) == "" {
+				return nil
+			}
+			if candidate.ExpectedSalary, candidate.SalaryCurrency, err = parseSalaryAmount(input); err != nil {
+				return err
+			}
+			if candidate.ExpectedSalary < 0 {
+				return validationErrorf("ожидаемая зарплата не может быть отрицательной")
+			}
+			return nil
+		}},
+		{Key: "status", Label: "Статус", Prompt: fmt.Sprintf("Введите статус кандидата (%s; Enter — %s): ",
+			strings.Join(storage.CandidateStatuses, "/"), storage.CandidateActive), Set: func(input string) error {
+			candidate.Status = strings.ToLower(strings.TrimSpace(input))
+			if candidate.Status == "" {
+				candidate.Status = storage.CandidateActive
+			}
+			return validateCandidateStatus(candidate.Status)
+		}},
+		{Key: "tags", Label: "Теги", Prompt: "Введите теги кандидата через запятую (необязательно): ", Set: func(input string) (err error) {
+			candidate.Tags, err = sanitizeSkills(parseFormList(input))
+			return err
+		}},
+		{Key: "available_from", Label: "Может выйти с", Prompt: "Когда кандидат может выйти, ГГГГ-ММ-ДД (Enter — сразу): ", Set: func(input string) (err error) {
+			candidate.AvailableFrom, err = parseOptionalDate(input)
+			return err
+		}},
+	}
+	for _, f := range fields {
+		if input := prefill[f.Key]; input != "" {
+			f.set(input)
+		}
+	}
+	ok := runForm("Новый кандидат", fields, saver, func() error {
+		c := candidate
+		return prepareCandidate(&c)
+	})
+	return candidate, ok
+}
+
+// Запрашивает поля новой вакансии; поля, заданные шаблоном, не спрашиваются, но их можно исправить в сводке.
+// Компания выбирается до формы и в ней не меняется.
+func promptJobOpening(companyTitle string, jobOpening storage.JobOpening, template *JobTemplate, saver *draftSaver) (storage.JobOpening, bool) {
+	fields := []*formField{
+		{Key: "title", Label: "Название", Prompt: "Введите название вакансии: ", Set: func(input string) (err error) {
+			if jobOpening.Title, err = sanitizeText("название вакансии", input, maxNameLength); err != nil {
+				return err
+			}
+			if jobOpening.Title == "" {
+				return validationErrorf("название вакансии не может быть пустым")
+			}
+			return nil
+		}},
+		{Key: "experience", Label: "Требуемый опыт", Prompt: "Введите требуемый опыт работы: ", Long: true, Set: func(input string) (err error) {
+			jobOpening.Experience, err = sanitizeLongText("требуемый опыт", input)
+			return err
+		}},
+		{Key: "salary", Label: "Зарплата", Prompt: "Введите зарплату: сумму, вилку 150000-200000, «от …» или «до …», валюта — в конце, например USD: ", Set: func(input string) (err error) {
+			jobOpening.SalaryMin, jobOpening.SalaryMax, jobOpening.SalaryCurrency, err = parseSalaryRange(input)
+			return err
+		}},
+		{Key: "required_skills", Label: "Требуемые навыки", Prompt: "Введите требуемые навыки (через запятую): ", Set: func(input string) (err error) {
+			jobOpening.RequiredSkills, err = sanitizeSkills(parseFormList(input))
+			return err
+		}},
+	}
+	if template != nil {
+		fields[0].set(template.Title)
+		fields[1].set(template.Experience)
+		fields[3].set(strings.Join(template.RequiredSkills, ", "))
+	}
+	ok := runForm("Новая вакансия компании "+companyTitle, fields, saver, func() error {
+		j := jobOpening
+		return prepareJobOpening(&j)
+	})
+	return jobOpening, ok
+}
+
+func addCandidateMenu(app *App, saver *draftSaver, prefill map[string]string) {
+	candidate, ok := promptCandidate(prefill, saver)
+	if !ok {
+		return
+	}
+	candidateID, err := addCandidate(app, candidate)
+	handleError(err)
+	if err != nil {
+		keptInDraft(saver)
+		return
+	}
+	saver.discard()
+	fmt.Printf("Кандидат успешно добавлен! ID: %d\n", candidateID)
+}
+
+func keptInDraft(saver *draftSaver) {
+	if saver != nil {
+		fmt.Println("Введённые данные остались в черновике, продолжить можно в пункте «Черновики».")
+	}
+}
+
+// Несуществующую компанию можно создать вместе с вакансией; шаблон вакансии предлагается только для существующей.
+func addJobOpeningMenu(app *App) {
+	companyText := getInput("Введите ID или название компании: ")
+	newCompany := ""
+	companyID, err := resolveEntityID(app.DB, entityCompany, companyText)
+	if classifyError(err) == kindNotFound && authorize(app, permCompaniesManage) == nil &&
+		confirm(fmt.Sprintf("Компании «%s» нет. Создать её вместе с вакансией?", strings.TrimSpace(companyText))) {
+		newCompany, err = strings.TrimSpace(companyText), nil
+	}
+	handleError(err)
+	if err != nil {
+		return
+	}
+	var template *JobTemplate
+	if newCompany == "" {
+		template, err = chooseJobTemplate(app.DB, companyID)
+		handleError(err)
+		if err != nil {
+			return
+		}
+	}
+	saver := newDraftSaver(app, entityJobOpening, map[string]string{"company_id": strconv.Itoa(companyID), "new_company": newCompany})
+	addJobOpeningForm(app, companyID, newCompany, template, saver)
+}
+
+// Форма вакансии для выбранной компании; newCompany — название компании, которую нужно создать.
+func addJobOpeningForm(app *App, companyID int, newCompany string, template *JobTemplate, saver *draftSaver) {
+	companyTitle := fmt.Sprintf("«%s» (новая)", newCompany)
+	if newCompany == "" {
+		company, err := getCompany(app, companyID)
+		if err == nil {
+			err = authorizeCompany(app, companyID)
+		}
+		handleError(err)
+		if err != nil {
+			return
+		}
+		companyTitle = fmt.Sprintf("«%s»", company.Name)
+	}
+	jobOpening, ok := promptJobOpening(companyTitle, storage.JobOpening{CompanyID: companyID}, template, saver)
+	if !ok {
+		return
+	}
+	if newCompany != "" {
+		companyID, jobOpeningID, err := addJobOpeningWithCompany(app, newCompany, jobOpening)
+		handleError(err)
+		if err != nil {
+			keptInDraft(saver)
+			return
+		}
+		saver.discard()
+		fmt.Printf("Компания добавлена, ID: %d. Вакансия успешно добавлена! ID: %d\n", companyID, jobOpeningID)
+		return
+	}
+	jobOpeningID, err := addJobOpening(app, jobOpening)
+	handleError(err)
+	if err != nil {
+		keptInDraft(saver)
+		return
+	}
+	saver.discard()
+	fmt.Printf("Вакансия успешно добавлена! ID: %d\n", jobOpeningID)
+}
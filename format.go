@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Формат вывода команд (--format). table — текст для человека, как в меню; json — данные команды в JSON,
+// чтобы передать их в jq; csv — те же данные таблицей с заголовком: поля верхнего уровня — столбцы,
+// списки — через запятую, вложенные объекты — JSON в ячейке. Отчёты, у которых есть своя таблица для
+// -out .csv, выводят её. Флаг -json у отдельных команд остался и равносилен --format json. Ошибки
+// по-прежнему идут в stderr в формате --error-format, поэтому stdout остаётся пригодным для разбора.
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+var outputFormat = formatTable
+
+func validOutputFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatCSV
+}
+
+// -json команды переключает вывод в JSON.
+func useJSON(asJSON bool) {
+	if asJSON {
+		outputFormat = formatJSON
+	}
+}
+
+func printJSON(v interface{}) int {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return reportError(fmt.Errorf("ошибка сериализации: %w", err))
+	}
+	fmt.Println(string(data))
+	return exitOK
+}
+
+// Печатает результат команды в выбранном формате.
+func printView(v sharedView) int {
+	data := v.data
+	// Пустой список — [] и в JSON, а не null.
+	if value := reflect.ValueOf(data); value.Kind() == reflect.Slice && value.IsNil() {
+		data = []struct{}{}
+	}
+	switch outputFormat {
+	case formatJSON:
+		return printJSON(data)
+	case formatCSV:
+		var err error
+		if v.csv != nil {
+			err = v.csv(os.Stdout)
+		} else {
+			err = writeDataCSV(os.Stdout, data)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	v.text(os.Stdout)
+	return exitOK
+}
+
+// Сообщения о ходе работы: в table — вместе с результатом, иначе — в stderr, чтобы не мешать разбору stdout.
+func printNotice(a ...interface{}) {
+	if outputFormat == formatTable {
+		fmt.Println(a...)
+		return
+	}
+	fmt.Fprintln(os.Stderr, a...)
+}
+
+// Результат операции: сообщение для человека или данные (например, ID созданной записи) для скрипта.
+// data == nil — в JSON и CSV уходит само сообщение.
+func printDone(message string, data interface{}) int {
+	if data == nil {
+		data = map[string]string{"message": message}
+	}
+	return printView(sharedView{data: data, text: func(w io.Writer) { fmt.Fprintln(w, message) }})
+}
+
+// Таблица из JSON-представления данных: объект — одна строка, список — по строке на элемент. Столбцы
+// идут в порядке полей JSON; если у элементов разные поля, недостающие ячейки остаются пустыми.
+func writeDataCSV(w io.Writer, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации: %w", err)
+	}
+	items := []json.RawMessage{raw}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return fmt.Errorf("ошибка сериализации: %w", err)
+		}
+	}
+	var header []string
+	columns := map[string]bool{}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		keys, row, err := csvRow(item)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !columns[key] {
+				columns[key] = true
+				header = append(header, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+	writer := csv.NewWriter(w)
+	if len(header) > 0 {
+		writer.Write(header)
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, key := range header {
+			record[i] = row[key]
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Поля объекта в порядке JSON и их значения для ячеек; не объект — один столбец value.
+func csvRow(item json.RawMessage) ([]string, map[string]string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(item), []byte("{")) {
+		return []string{"value"}, map[string]string{"value": csvCell(item)}, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(item))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка сериализации: %w", err)
+	}
+	var keys []string
+	row := map[string]string{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("ошибка сериализации: %w", err)
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, fmt.Errorf("ошибка сериализации: %w", err)
+		}
+		keys = append(keys, key)
+		row[key] = csvCell(value)
+	}
+	return keys, row, nil
+}
+
+// Строка — как есть, список строк и чисел — через запятую, null — пустая ячейка, остальное — JSON.
+func csvCell(value json.RawMessage) string {
+	text := string(bytes.TrimSpace(value))
+	switch {
+	case text == "null":
+		return ""
+	case strings.HasPrefix(text, `"`):
+		var s string
+		json.Unmarshal(value, &s)
+		return s
+	case strings.HasPrefix(text, "["):
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			return text
+		}
+		parts := make([]string, len(list))
+		for i, item := range list {
+			if item := bytes.TrimSpace(item); bytes.HasPrefix(item, []byte("{")) || bytes.HasPrefix(item, []byte("[")) {
+				return text
+			}
+			parts[i] = csvCell(item)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return text
+}
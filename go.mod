@@ -6,7 +6,10 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/lib/pq v1.10.9 // indirect
+	github.com/nyaruka/phonenumbers v1.3.6
 	github.com/spf13/cobra v1.8.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/crypto v0.29.0 // indirect
+	golang.org/x/text v0.20.0
+	google.golang.org/protobuf v1.33.0 // indirect
 )
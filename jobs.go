@@ -0,0 +1,348 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+type Job struct {
+	ID          int             `db:"id"`
+	Kind        string          `db:"kind"`
+	Payload     json.RawMessage `db:"payload"`
+	Status      string          `db:"status"`
+	Attempts    int             `db:"attempts"`
+	MaxAttempts int             `db:"max_attempts"`
+	LastError   string          `db:"last_error"`
+	RunAt       time.Time       `db:"run_at"`
+	CreatedAt   time.Time       `db:"created_at"`
+}
+
+type JobHandler func(db *sql.DB, payload json.RawMessage) error
+
+var jobHandlers = map[string]JobHandler{}
+
+func registerJobHandler(kind string, handler JobHandler) {
+	jobHandlers[kind] = handler
+}
+
+func enqueueJob(db *sql.DB, kind string, payload interface{}) (int, error) {
+	return enqueueJobAt(db, kind, payload, time.Now())
+}
+
+func enqueueJobAt(db *sql.DB, kind string, payload interface{}, runAt time.Time) (int, error) {
+	if kind == "" {
+		return 0, errors.New("тип задачи не может быть пустым")
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сериализации параметров задачи: %w", err)
+	}
+
+	var id int
+	err = db.QueryRow("INSERT INTO jobs (kind, payload, run_at) VALUES ($1, $2, $3) RETURNING id", kind, payloadJSON, runAt).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка постановки задачи в очередь: %w", err)
+	}
+	return id, nil
+}
+
+// Не ставит задачу, если такая же (тип и параметры) уже ждёт в очереди: серия правок
+// одной записи даёт один пересчёт.
+func enqueueJobOnce(db *sql.DB, kind string, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации параметров задачи: %w", err)
+	}
+	var pending bool
+	err = db.QueryRow("SELECT EXISTS (SELECT 1 FROM jobs WHERE kind = $1 AND payload = $2::jsonb AND status = 'pending')", kind, payloadJSON).Scan(&pending)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки очереди задач: %w", err)
+	}
+	if pending {
+		return nil
+	}
+	_, err = enqueueJob(db, kind, payload)
+	return err
+}
+
+// Есть ли задача этого типа, ожидающая или выполняющаяся; нужно периодическим задачам, которые ставят себя сами.
+func jobScheduled(db *sql.DB, kind string) (bool, error) {
+	var scheduled bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM jobs WHERE kind = $1 AND status IN ('pending', 'running'))", kind).Scan(&scheduled)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки очереди задач: %w", err)
+	}
+	return scheduled, nil
+}
+
+// SKIP LOCKED позволяет нескольким воркерам (и нескольким процессам) разбирать очередь без двойного захвата.
+func claimJob(db *sql.DB) (*Job, error) {
+	var job Job
+	err := db.QueryRow(`
+    UPDATE jobs SET status = 'running', attempts = attempts + 1, updated_at = now()
+    WHERE id = (
+        SELECT id FROM jobs
+        WHERE status = 'pending' AND run_at <= now()
+        ORDER BY run_at, id
+        FOR UPDATE SKIP LOCKED
+        LIMIT 1
+    )
+    RETURNING id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at`).
+		Scan(&job.ID, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError, &job.RunAt, &job.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения задачи: %w", err)
+	}
+	return &job, nil
+}
+
+func jobBackoff(attempts int) time.Duration {
+	delay := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+func finishJob(db *sql.DB, job *Job, runErr error) error {
+	var err error
+	switch {
+	case runErr == nil:
+		_, err = db.Exec("UPDATE jobs SET status = 'completed', last_error = '', updated_at = now() WHERE id = $1", job.ID)
+	case job.Attempts >= job.MaxAttempts:
+		_, err = db.Exec("UPDATE jobs SET status = 'dead', last_error = $2, updated_at = now() WHERE id = $1", job.ID, runErr.Error())
+	default:
+		runAt := time.Now().Add(jobBackoff(job.Attempts))
+		_, err = db.Exec("UPDATE jobs SET status = 'pending', last_error = $2, run_at = $3, updated_at = now() WHERE id = $1",
+			job.ID, runErr.Error(), runAt)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления статуса задачи: %w", err)
+	}
+	return nil
+}
+
+// Неудачная попытка — warn, последняя неудачная попытка — error: задача больше не повторится.
+func logJobResult(job *Job, runErr error) {
+	logger := slog.With("action", "job."+job.Kind, "job_id", job.ID, "attempt", job.Attempts)
+	switch {
+	case runErr == nil:
+		logger.Debug("задача выполнена")
+	case job.Attempts >= job.MaxAttempts:
+		logger.Error("задача не выполнена, попытки исчерпаны", "error", runErr)
+	default:
+		logger.Warn("задача не выполнена, будет повтор", "error", runErr)
+	}
+}
+
+func runJob(db *sql.DB, job *Job) (err error) {
+	handler, ok := jobHandlers[job.Kind]
+	if !ok {
+		job.Attempts = job.MaxAttempts
+		return fmt.Errorf("нет обработчика для задачи типа %q", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("паника в обработчике задачи: %v", r)
+		}
+	}()
+	return handler(db, job.Payload)
+}
+
+// Задачи, оставшиеся в статусе running после падения процесса, возвращаются в очередь.
+func requeueStaleJobs(db *sql.DB, olderThan time.Duration) error {
+	_, err := db.Exec("UPDATE jobs SET status = 'pending', updated_at = now() WHERE status = 'running' AND updated_at < $1",
+		time.Now().Add(-olderThan))
+	if err != nil {
+		return fmt.Errorf("ошибка возврата зависших задач: %w", err)
+	}
+	return nil
+}
+
+// Сколько раз воркер пробует записать итог задачи и предельная пауза между захватами при недоступной БД.
+const (
+	finishJobAttempts = 3
+	maxClaimBackoff   = time.Minute
+)
+
+// Пауза перед следующим захватом после failures ошибок подряд: удваивается от интервала опроса до maxClaimBackoff.
+func claimBackoff(pollInterval time.Duration, failures int) time.Duration {
+	delay := pollInterval
+	for i := 0; i < failures && delay < maxClaimBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxClaimBackoff {
+		delay = maxClaimBackoff
+	}
+	return delay
+}
+
+// Итог задачи записывается с повторами: иначе выполненная задача остаётся running до requeueStaleJobs
+// и выполняется ещё раз.
+func finishJobWithRetry(db *sql.DB, job *Job, runErr error, pollInterval time.Duration, stop <-chan struct{}) {
+	logger := slog.With("action", "job."+job.Kind, "job_id", job.ID)
+	for attempt := 1; ; attempt++ {
+		err := finishJob(db, job, runErr)
+		if err == nil {
+			return
+		}
+		if attempt == finishJobAttempts {
+			logger.Error("итог задачи не записан, она вернётся в очередь после requeue", "error", err)
+			return
+		}
+		logger.Warn("итог задачи не записан, повтор", "error", err, "attempt", attempt)
+		select {
+		case <-stop:
+			logger.Error("итог задачи не записан до остановки воркера", "error", err)
+			return
+		case <-time.After(claimBackoff(pollInterval, attempt)):
+		}
+	}
+}
+
+func startJobWorkers(db *sql.DB, workers int, pollInterval time.Duration, stop <-chan struct{}) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failures := 0
+			for {
+				wait := pollInterval
+				job, err := claimJob(db)
+				switch {
+				case err != nil:
+					failures++
+					wait = claimBackoff(pollInterval, failures)
+					slog.Warn(err.Error(), "action", "jobs.claim", "failures", failures, "retry_in", wait)
+				case job != nil:
+					failures = 0
+					runErr := runJob(db, job)
+					logJobResult(job, runErr)
+					finishJobWithRetry(db, job, runErr, pollInterval, stop)
+					continue
+				default:
+					failures = 0
+				}
+				select {
+				case <-stop:
+					return
+				case <-time.After(wait):
+				}
+			}
+		}()
+	}
+	return &wg
+}
+
+func listJobs(db *sql.DB, status string) ([]Job, error) {
+	query := "SELECT id, kind, payload, status, attempts, max_attempts, last_error, run_at, created_at FROM jobs"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT 100"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		err := rows.Scan(&job.ID, &job.Kind, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.LastError, &job.RunAt, &job.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func retryJob(db *sql.DB, id int) error {
+	result, err := db.Exec(`UPDATE jobs SET status = 'pending', attempts = 0, run_at = now(), updated_at = now()
+    WHERE id = $1 AND status IN ('dead', 'cancelled')`, id)
+	if err != nil {
+		return fmt.Errorf("ошибка перезапуска задачи: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return notFoundError("задача не найдена или не находится в статусе dead/cancelled")
+	}
+	return nil
+}
+
+func cancelJob(db *sql.DB, id int) error {
+	result, err := db.Exec("UPDATE jobs SET status = 'cancelled', updated_at = now() WHERE id = $1 AND status = 'pending'", id)
+	if err != nil {
+		return fmt.Errorf("ошибка отмены задачи: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return notFoundError("задача не найдена или уже выполняется/завершена")
+	}
+	return nil
+}
+
+func jobsMenu(db *sql.DB) {
+	fmt.Println("1. Список задач")
+	fmt.Println("2. Повторить задачу")
+	fmt.Println("3. Отменить задачу")
+	choice, err := getIntInput("Введите номер действия: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+
+	switch choice {
+	case 1:
+		status := getInput("Фильтр по статусу (pending/running/completed/dead/cancelled, Enter — все): ")
+		jobs, err := listJobs(db, status)
+		handleError(err)
+		if err == nil {
+			for _, j := range jobs {
+				fmt.Printf("ID: %d, Тип: %s, Статус: %s, Попытки: %d/%d, Запуск: %s\n",
+					j.ID, j.Kind, j.Status, j.Attempts, j.MaxAttempts, j.RunAt.Format("2006-01-02 15:04:05"))
+				if j.LastError != "" {
+					fmt.Printf("  Последняя ошибка: %s\n", j.LastError)
+				}
+			}
+		}
+	case 2:
+		id, err := getIntInput("Введите ID задачи: ")
+		handleError(err)
+		if err == nil {
+			err = retryJob(db, id)
+			handleError(err)
+			if err == nil {
+				fmt.Println("Задача поставлена в очередь повторно.")
+			}
+		}
+	case 3:
+		id, err := getIntInput("Введите ID задачи: ")
+		handleError(err)
+		if err == nil {
+			err = cancelJob(db, id)
+			handleError(err)
+			if err == nil {
+				fmt.Println("Задача отменена.")
+			}
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
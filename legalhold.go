@@ -0,0 +1,276 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Юридические удержания: при споре кандидата или отдельный отклик нужно сохранить, что бы ни говорили
+// сроки хранения. Удержание ставит и снимает администратор с указанием причины; пока оно действует,
+// запись не удаляется ни из меню и API, ни очисткой по сроку хранения (purge.go), а её история и журнал
+// изменений не чистятся. Удержание на отклик удерживает и кандидата — иначе отклик ушёл бы вместе с ним.
+// Удаление запрещено и в самой базе (migrations/0030_legal_holds), поэтому удержание действует и при
+// удалении вакансии или компании с откликами.
+
+type LegalHold struct {
+	ID            int        `json:"id"`
+	CandidateID   int        `json:"candidate_id"`
+	CandidateName string     `json:"candidate_name"`
+	ApplicationID int        `json:"application_id,omitempty"`
+	JobTitle      string     `json:"job_title,omitempty"`
+	Reason        string     `json:"reason"`
+	PlacedBy      string     `json:"placed_by,omitempty"`
+	PlacedAt      time.Time  `json:"placed_at"`
+	ReleasedBy    string     `json:"released_by,omitempty"`
+	ReleasedAt    *time.Time `json:"released_at,omitempty"`
+	ReleaseReason string     `json:"release_reason,omitempty"`
+}
+
+// Ставит удержание на кандидата или на отклик — ровно на что-то одно.
+func placeLegalHold(app *App, candidateID, applicationID int, reason string) (int, error) {
+	reason = strings.TrimSpace(reason)
+	switch {
+	case (candidateID == 0) == (applicationID == 0):
+		return 0, validationErrorf("укажите либо кандидата, либо отклик")
+	case reason == "":
+		return 0, validationErrorf("укажите причину удержания")
+	}
+	if candidateID != 0 {
+		if _, err := getCandidateByID(app, candidateID); err != nil {
+			return 0, err
+		}
+	} else {
+		_, err := app.Applications.GetByID(applicationID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, notFoundError("отклик не найден")
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	userID := actingUserID()
+	var id int
+	err := app.DB.QueryRow(`INSERT INTO legal_holds (candidate_id, application_id, reason, placed_by)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		sql.NullInt64{Int64: int64(candidateID), Valid: candidateID != 0},
+		sql.NullInt64{Int64: int64(applicationID), Valid: applicationID != 0}, reason, nullUserID(userID)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения удержания: %w", err)
+	}
+	audit("legal_hold.placed", "hold_id", id, "candidate_id", candidateID, "application_id", applicationID, "reason", reason)
+	return id, nil
+}
+
+func releaseLegalHold(app *App, id int, reason string) error {
+	result, err := app.DB.Exec(`UPDATE legal_holds SET released_at = now(), released_by = $2, release_reason = $3
+		WHERE id = $1 AND released_at IS NULL`, id, nullUserID(actingUserID()), strings.TrimSpace(reason))
+	if err != nil {
+		return fmt.Errorf("ошибка снятия удержания: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("действующее удержание с таким ID не найдено")
+	}
+	audit("legal_hold.released", "hold_id", id, "reason", reason)
+	return nil
+}
+
+// Удержания, начиная с последних; all — вместе со снятыми.
+func listLegalHolds(app *App, all bool) ([]LegalHold, error) {
+	rows, err := app.DB.Query(`
+		SELECT h.id, c.id, c.full_name, COALESCE(h.application_id, 0), COALESCE(j.title, ''), h.reason,
+			COALESCE(pu.username, ''), h.placed_at, COALESCE(ru.username, ''), h.released_at, h.release_reason
+		FROM legal_holds h
+		LEFT JOIN applications a ON a.id = h.application_id
+		LEFT JOIN job_openings j ON j.id = a.job_opening_id
+		JOIN candidates c ON c.id = COALESCE(h.candidate_id, a.candidate_id)
+		LEFT JOIN users pu ON pu.id = h.placed_by
+		LEFT JOIN users ru ON ru.id = h.released_by
+		WHERE $1 OR h.released_at IS NULL
+		ORDER BY h.placed_at DESC, h.id DESC`, all)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var holds []LegalHold
+	for rows.Next() {
+		var h LegalHold
+		var releasedAt sql.NullTime
+		if err := rows.Scan(&h.ID, &h.CandidateID, &h.CandidateName, &h.ApplicationID, &h.JobTitle, &h.Reason,
+			&h.PlacedBy, &h.PlacedAt, &h.ReleasedBy, &releasedAt, &h.ReleaseReason); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if releasedAt.Valid {
+			h.ReleasedAt = &releasedAt.Time
+		}
+		holds = append(holds, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return holds, nil
+}
+
+// Проверка перед удалением кандидата: причина первого действующего удержания на него или его отклики.
+func candidateLegalHold(q storage.DBTX, candidateID int) (string, bool, error) {
+	var reason string
+	err := q.QueryRow(`SELECT h.reason FROM legal_hold_records r JOIN legal_holds h ON h.id = r.hold_id
+		WHERE r.candidate_id = $1 ORDER BY h.placed_at LIMIT 1`, candidateID).Scan(&reason)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("ошибка проверки удержаний: %w", err)
+	}
+	return reason, true, nil
+}
+
+func legalHoldLine(h LegalHold) string {
+	subject := fmt.Sprintf("кандидат %s (ID %d)", h.CandidateName, h.CandidateID)
+	if h.ApplicationID != 0 {
+		subject = fmt.Sprintf("отклик %d: %s на «%s»", h.ApplicationID, h.CandidateName, h.JobTitle)
+	}
+	placedBy := h.PlacedBy
+	if placedBy == "" {
+		placedBy = "—"
+	}
+	line := fmt.Sprintf("#%d %s; причина: %s; поставил %s %s", h.ID, subject, h.Reason, placedBy,
+		h.PlacedAt.Local().Format("2006-01-02 15:04"))
+	if h.ReleasedAt != nil {
+		line += "; снято " + h.ReleasedAt.Local().Format("2006-01-02 15:04")
+		if h.ReleasedBy != "" {
+			line += " (" + h.ReleasedBy + ")"
+		}
+		if h.ReleaseReason != "" {
+			line += ": " + h.ReleaseReason
+		}
+	}
+	return line
+}
+
+func printLegalHolds(w io.Writer, holds []LegalHold) {
+	if len(holds) == 0 {
+		fmt.Fprintln(w, "Удержаний нет.")
+	}
+	for _, h := range holds {
+		fmt.Fprintln(w, legalHoldLine(h))
+	}
+}
+
+func legalHoldMenu(app *App) {
+	holds, err := listLegalHolds(app, false)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	printLegalHolds(os.Stdout, holds)
+	input := getInput("c — удержать кандидата, a — удержать отклик, «r номер» — снять, Enter — назад: ")
+	var candidateID, applicationID int
+	switch text, release := strings.CutPrefix(input, "r"); {
+	case input == "":
+		return
+	case release:
+		id, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			fmt.Println("Укажите номер удержания, например: r 12")
+			return
+		}
+		err = releaseLegalHold(app, id, getInput("Почему удержание снято: "))
+		handleError(err)
+		if err == nil {
+			fmt.Println("Удержание снято.")
+		}
+		return
+	case input == "c":
+		candidateID, err = getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+	case input == "a":
+		applicationID, err = getIntInput("Введите ID отклика: ")
+	default:
+		fmt.Println("Неверный выбор действия.")
+		return
+	}
+	handleError(err)
+	if err != nil {
+		return
+	}
+	id, err := placeLegalHold(app, candidateID, applicationID, getInput("Причина удержания: "))
+	handleError(err)
+	if err == nil {
+		fmt.Printf("Удержание #%d поставлено.\n", id)
+	}
+}
+
+// legal-hold place (-candidate <кандидат> | -application <ID>) -reason <причина> |
+// legal-hold release <ID> [-reason причина] | legal-hold list [-all] [-json]
+func runLegalHoldCommand(app *App, args []string) int {
+	usage := "Использование: legal-hold place (-candidate <ID или ФИО кандидата> | -application <ID отклика>) -reason <причина> | " +
+		"legal-hold release <ID удержания> [-reason причина] | legal-hold list [-all] [-json]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "place":
+		flags := flag.NewFlagSet("legal-hold place", flag.ContinueOnError)
+		candidate := flags.String("candidate", "", "ID, ФИО или email кандидата")
+		applicationID := flags.Int("application", 0, "ID отклика")
+		reason := flags.String("reason", "", "причина удержания")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		candidateID := 0
+		if *candidate != "" {
+			id, err := resolveEntityID(app.DB, entityCandidate, *candidate)
+			if err != nil {
+				return reportError(err)
+			}
+			candidateID = id
+		}
+		id, err := placeLegalHold(app, candidateID, *applicationID, *reason)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Удержание #%d поставлено.", id), map[string]int{"id": id})
+	case "release":
+		if len(args) < 2 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(validationErrorf("ID удержания должен быть числом"))
+		}
+		flags := flag.NewFlagSet("legal-hold release", flag.ContinueOnError)
+		reason := flags.String("reason", "", "почему удержание снято")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		if err := releaseLegalHold(app, id, *reason); err != nil {
+			return reportError(err)
+		}
+		return printDone("Удержание снято.", map[string]int{"id": id})
+	case "list":
+		flags := flag.NewFlagSet("legal-hold list", flag.ContinueOnError)
+		all := flags.Bool("all", false, "показать и снятые удержания")
+		asJSON := flags.Bool("json", false, "вывести список в JSON")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		useJSON(*asJSON)
+		holds, err := listLegalHolds(app, *all)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: holds, text: func(w io.Writer) { printLegalHolds(w, holds) }})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
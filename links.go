@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Постоянные ссылки на записи: <APP_BASE_URL>/candidates/42, /jobs/42, /companies/42. Ссылка печатается
+// в карточках и письмах; команда open и быстрый переход в меню открывают запись по ней. При разборе
+// важен только путь, поэтому открываются и ссылки, скопированные с другого стенда.
+
+var entityURLSegments = map[string]string{
+	entityCandidate:  "candidates",
+	entityJobOpening: "jobs",
+	entityCompany:    "companies",
+}
+
+func appBaseURL() string {
+	return strings.TrimRight(os.Getenv("APP_BASE_URL"), "/")
+}
+
+func entityURL(kind string, id int) string {
+	return fmt.Sprintf("%s/%s/%d", appBaseURL(), entityURLSegments[kind], id)
+}
+
+// Вид и ID записи по ссылке; путь может начинаться с префикса, под которым опубликован сервер.
+func parseEntityURL(link string) (string, int, error) {
+	invalid := validationErrorf("«%s» не ссылка на запись: ожидается адрес вида .../candidates/ID, .../jobs/ID или .../companies/ID", link)
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", 0, invalid
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", 0, invalid
+	}
+	segment, idText := parts[len(parts)-2], parts[len(parts)-1]
+	id, err := strconv.Atoi(idText)
+	if err != nil || id < 1 {
+		return "", 0, invalid
+	}
+	for kind, s := range entityURLSegments {
+		if s == segment {
+			return kind, id, nil
+		}
+	}
+	return "", 0, invalid
+}
+
+// Ссылка в запросе быстрого перехода: «open https://…» или просто адрес.
+func linkInQuery(query string) (string, bool) {
+	words := strings.Fields(query)
+	if len(words) == 2 && (strings.EqualFold(words[0], "open") || strings.EqualFold(words[0], "открыть")) {
+		words = words[1:]
+	}
+	if len(words) == 1 && strings.Contains(words[0], "://") {
+		return words[0], true
+	}
+	return "", false
+}
+
+// open <ссылка> [-out файл] [-copy]
+func runOpenCommand(app *App, args []string) int {
+	usage := "Использование: open <ссылка на запись> [-out файл] [-copy]"
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("open", flag.ContinueOnError)
+	var o shareOptions
+	o.register(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if strings.Contains(args[0], "/p/") {
+		profile, err := viewSharedProfile(app, args[0], "cli")
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: profile, text: func(w io.Writer) { writePublicProfile(w, profile) }})
+	}
+	kind, id, err := parseEntityURL(args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	return showEntityCard(app, kind, id, o)
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+type skillCluster struct {
+	Name   string
+	Weight int
+	Skills []string
+}
+
+// Кластеры задают совместную встречаемость навыков: профиль берёт большую часть навыков из одного кластера.
+var skillClusters = []skillCluster{
+	{Name: "backend-go", Weight: 20, Skills: []string{"Go", "PostgreSQL", "Docker", "Kubernetes", "gRPC", "Redis", "Kafka"}},
+	{Name: "backend-java", Weight: 20, Skills: []string{"Java", "Spring", "PostgreSQL", "Kafka", "Docker", "Hibernate", "Maven"}},
+	{Name: "frontend", Weight: 25, Skills: []string{"JavaScript", "TypeScript", "React", "CSS", "HTML", "Redux", "Webpack"}},
+	{Name: "data", Weight: 15, Skills: []string{"Python", "SQL", "Pandas", "Spark", "Airflow", "ClickHouse", "Machine Learning"}},
+	{Name: "devops", Weight: 10, Skills: []string{"Linux", "Docker", "Kubernetes", "Terraform", "Ansible", "Prometheus", "Bash"}},
+	{Name: "qa", Weight: 10, Skills: []string{"Selenium", "Python", "SQL", "Postman", "Jira", "Java", "Pytest"}},
+}
+
+type seniorityLevel struct {
+	Name         string
+	Weight       int
+	MinYears     int
+	MaxYears     int
+	MedianSalary float64
+}
+
+var seniorityLevels = []seniorityLevel{
+	{Name: "Junior", Weight: 30, MinYears: 0, MaxYears: 2, MedianSalary: 80000},
+	{Name: "Middle", Weight: 40, MinYears: 2, MaxYears: 5, MedianSalary: 160000},
+	{Name: "Senior", Weight: 22, MinYears: 5, MaxYears: 10, MedianSalary: 260000},
+	{Name: "Lead", Weight: 8, MinYears: 8, MaxYears: 15, MedianSalary: 350000},
+}
+
+type LatencyStats struct {
+	Operation string        `json:"operation"`
+	Runs      int           `json:"runs"`
+	P50       time.Duration `json:"p50"`
+	P95       time.Duration `json:"p95"`
+	Max       time.Duration `json:"max"`
+}
+
+func pickCluster(rnd *rand.Rand) skillCluster {
+	total := 0
+	for _, c := range skillClusters {
+		total += c.Weight
+	}
+	n := rnd.Intn(total)
+	for _, c := range skillClusters {
+		if n < c.Weight {
+			return c
+		}
+		n -= c.Weight
+	}
+	return skillClusters[len(skillClusters)-1]
+}
+
+func pickSeniority(rnd *rand.Rand) seniorityLevel {
+	total := 0
+	for _, s := range seniorityLevels {
+		total += s.Weight
+	}
+	n := rnd.Intn(total)
+	for _, s := range seniorityLevels {
+		if n < s.Weight {
+			return s
+		}
+		n -= s.Weight
+	}
+	return seniorityLevels[len(seniorityLevels)-1]
+}
+
+func generateSkills(rnd *rand.Rand, cluster skillCluster, count int) []string {
+	seen := map[string]bool{}
+	var skills []string
+	for _, i := range rnd.Perm(len(cluster.Skills)) {
+		if len(skills) >= count {
+			break
+		}
+		skills = append(skills, cluster.Skills[i])
+		seen[cluster.Skills[i]] = true
+	}
+	for extra := rnd.Intn(3); extra > 0; extra-- {
+		other := skillClusters[rnd.Intn(len(skillClusters))]
+		skill := other.Skills[rnd.Intn(len(other.Skills))]
+		if !seen[skill] {
+			skills = append(skills, skill)
+			seen[skill] = true
+		}
+	}
+	return skills
+}
+
+// Логнормальный разброс вокруг медианы уровня, округлённый до 5000.
+func generateSalary(rnd *rand.Rand, level seniorityLevel) float64 {
+	salary := level.MedianSalary * math.Exp(rnd.NormFloat64()*0.25)
+	return math.Round(salary/5000) * 5000
+}
+
+func generateCandidate(rnd *rand.Rand, n int) storage.Candidate {
+	cluster := pickCluster(rnd)
+	level := pickSeniority(rnd)
+	years := level.MinYears + rnd.Intn(level.MaxYears-level.MinYears+1)
+	return storage.Candidate{
+		FullName:       fakeFullName(rnd),
+		Age:            21 + years + rnd.Intn(6),
+		Email:          fmt.Sprintf("loadtest%d@example.com", n),
+		Experience:     fmt.Sprintf("%s, %d лет опыта", level.Name, years),
+		Skills:         generateSkills(rnd, cluster, 3+rnd.Intn(4)),
+		ExpectedSalary: generateSalary(rnd, level),
+	}
+}
+
+// Вилка — от сгенерированной суммы до суммы на 10–30% выше; у части вакансий только «от».
+func generateJobOpening(rnd *rand.Rand, companyIDs []int) storage.JobOpening {
+	cluster := pickCluster(rnd)
+	level := pickSeniority(rnd)
+	salaryMin := generateSalary(rnd, level)
+	var salaryMax float64
+	if rnd.Intn(4) > 0 {
+		salaryMax = math.Round(salaryMin*(1.1+rnd.Float64()*0.2)/5000) * 5000
+	}
+	return storage.JobOpening{
+		CompanyID:      companyIDs[rnd.Intn(len(companyIDs))],
+		Title:          fmt.Sprintf("%s %s developer", level.Name, cluster.Name),
+		Experience:     fmt.Sprintf("от %d лет", level.MinYears),
+		SalaryMin:      salaryMin,
+		SalaryMax:      salaryMax,
+		RequiredSkills: generateSkills(rnd, cluster, 2+rnd.Intn(3)),
+	}
+}
+
+func generateCompanies(tx *sql.Tx, count int, runID int64) ([]int, error) {
+	var ids []int
+	for i := 0; i < count; i++ {
+		var id int
+		name := fmt.Sprintf("Нагрузочная компания %d-%d", runID, i+1)
+		if err := tx.QueryRow("INSERT INTO companies (name) VALUES ($1) RETURNING id", name).Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка добавления компании: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func generateLoadData(db *sql.DB, rnd *rand.Rand, candidates, jobOpenings int) error {
+	if candidates < 0 || jobOpenings < 0 {
+		return errors.New("количество записей не может быть отрицательным")
+	}
+	runID := time.Now().UnixNano()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyIn("candidates", "full_name", "age", "email", "experience", "skills", "expected_salary"))
+	if err != nil {
+		return fmt.Errorf("ошибка подготовки загрузки кандидатов: %w", err)
+	}
+	for i := 0; i < candidates; i++ {
+		c := generateCandidate(rnd, i)
+		skillsJSON, _ := json.Marshal(c.Skills)
+		if _, err := stmt.Exec(c.FullName, c.Age, c.Email, c.Experience, string(skillsJSON), c.ExpectedSalary); err != nil {
+			return fmt.Errorf("ошибка загрузки кандидатов: %w", err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("ошибка загрузки кандидатов: %w", err)
+	}
+	stmt.Close()
+
+	if jobOpenings > 0 {
+		companyIDs, err := generateCompanies(tx, jobOpenings/50+1, runID)
+		if err != nil {
+			return err
+		}
+		stmt, err := tx.Prepare(pq.CopyIn("job_openings", "company_id", "title", "experience", "salary_min", "salary_max", "required_skills"))
+		if err != nil {
+			return fmt.Errorf("ошибка подготовки загрузки вакансий: %w", err)
+		}
+		for i := 0; i < jobOpenings; i++ {
+			j := generateJobOpening(rnd, companyIDs)
+			skillsJSON, _ := json.Marshal(j.RequiredSkills)
+			if _, err := stmt.Exec(j.CompanyID, j.Title, j.Experience, j.SalaryMin, j.SalaryMax, string(skillsJSON)); err != nil {
+				return fmt.Errorf("ошибка загрузки вакансий: %w", err)
+			}
+		}
+		if _, err := stmt.Exec(); err != nil {
+			return fmt.Errorf("ошибка загрузки вакансий: %w", err)
+		}
+		stmt.Close()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+func measureLatency(operation string, runs int, fn func() error) (LatencyStats, error) {
+	durations := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		start := time.Now()
+		if err := fn(); err != nil {
+			return LatencyStats{}, fmt.Errorf("%s: %w", operation, err)
+		}
+		durations = append(durations, time.Since(start))
+	}
+	sort.Slice(durations, func(a, b int) bool { return durations[a] < durations[b] })
+	return LatencyStats{
+		Operation: operation,
+		Runs:      runs,
+		P50:       durations[len(durations)/2],
+		P95:       durations[(len(durations)*95-1)/100],
+		Max:       durations[len(durations)-1],
+	}, nil
+}
+
+type benchOperation struct {
+	Name string
+	Run  func() error
+}
+
+func searchOperations(app *App) []benchOperation {
+	return []benchOperation{
+		{"поиск кандидатов по навыку Go", func() error { _, err := findCandidatesBySkill(app, "Go", ListQuery{}); return err }},
+		{"поиск кандидатов по навыку React", func() error { _, err := findCandidatesBySkill(app, "React", ListQuery{}); return err }},
+		{"поиск вакансий по навыку PostgreSQL", func() error { _, err := findJobOpeningsBySkill(app, "PostgreSQL", ListQuery{}); return err }},
+	}
+}
+
+func measureOperations(operations []benchOperation, runs int) ([]LatencyStats, error) {
+	var stats []LatencyStats
+	for _, op := range operations {
+		s, err := measureLatency(op.Name, runs, op.Run)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func countRows(db *sql.DB, table string) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM " + table).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта строк: %w", err)
+	}
+	return count, nil
+}
+
+func printLatencyStats(w io.Writer, stats []LatencyStats) {
+	for _, s := range stats {
+		fmt.Fprintf(w, "  %-40s p50: %-12v p95: %-12v max: %v\n", s.Operation, s.P50, s.P95, s.Max)
+	}
+}
+
+// Данные наращиваются ступенями (x10), после каждой ступени замеряется задержка поиска.
+func loadTestMenu(app *App) {
+	target, err := getIntInput("Сколько кандидатов сгенерировать (вакансий будет в 10 раз меньше): ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if target <= 0 {
+		fmt.Println("Количество должно быть положительным.")
+		return
+	}
+	runs, err := getIntInput("Сколько повторов каждого замера: ")
+	handleError(err)
+	if err != nil || runs <= 0 {
+		runs = 5
+	}
+
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	generated := 0
+	for step := 1000; generated < target; step *= 10 {
+		if step > target {
+			step = target
+		}
+		start := time.Now()
+		err := generateLoadData(app.DB, rnd, step-generated, (step-generated)/10)
+		handleError(err)
+		if err != nil {
+			return
+		}
+		generated = step
+
+		total, err := countRows(app.DB, "candidates")
+		handleError(err)
+		if err != nil {
+			return
+		}
+		fmt.Printf("Сгенерировано %d кандидатов за %v, всего в базе: %d\n", generated, time.Since(start).Round(time.Millisecond), total)
+
+		stats, err := measureOperations(searchOperations(app), runs)
+		handleError(err)
+		if err != nil {
+			return
+		}
+		printLatencyStats(os.Stdout, stats)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Журнал работы: ошибки, сбои фоновых задач и действия, важные для аудита (вход, смена роли,
+// удаление записей). stdout занят меню, результатами команд и JSON-RPC, поэтому журнал пишется в stderr
+// или в файл.
+//
+// Настройки (см. config.go): LOG_FILE — файл, в который дописывается журнал; LOG_FORMAT — text (по умолчанию) или json;
+// LOG_LEVEL — debug, info, warn, error. По умолчанию в файл пишется с info, а в stderr — с warn,
+// чтобы записи аудита не перемешивались с меню.
+
+// Текущая операция: пункт меню, команда или метод JSON-RPC. Добавляется к каждой записи журнала.
+var currentOperation struct {
+	Action string
+	UserID int
+	// Адрес клиента из запроса JSON-RPC; у меню и команд пуст.
+	ClientIP string
+}
+
+// Начинает операцию; userID 0 — пользователь берётся из сессии меню.
+func beginOperation(action string, userID int) {
+	currentOperation.Action = action
+	currentOperation.UserID = userID
+	currentOperation.ClientIP = ""
+}
+
+func parseLogLevel(text string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(text)); err != nil {
+		return level, fmt.Errorf("LOG_LEVEL: неизвестный уровень %q, допустимые: debug, info, warn, error", text)
+	}
+	return level, nil
+}
+
+// Настраивает журнал по переменным окружения; возвращает функцию, закрывающую файл журнала.
+func setupLogging() (func(), error) {
+	var out io.Writer = os.Stderr
+	closeLog := func() {}
+	level := slog.LevelWarn
+	if path := os.Getenv("LOG_FILE"); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return closeLog, fmt.Errorf("ошибка открытия файла журнала: %w", err)
+		}
+		out, closeLog, level = file, func() { file.Close() }, slog.LevelInfo
+	}
+	if text := os.Getenv("LOG_LEVEL"); text != "" {
+		var err error
+		if level, err = parseLogLevel(text); err != nil {
+			closeLog()
+			return func() {}, err
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format := strings.ToLower(os.Getenv("LOG_FORMAT")); format {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		closeLog()
+		return func() {}, fmt.Errorf("LOG_FORMAT: допустимые значения text и json, получено %q", format)
+	}
+	slog.SetDefault(slog.New(handler))
+	return closeLog, nil
+}
+
+// Журнал с контекстом текущей операции.
+func opLogger() *slog.Logger {
+	userID := currentOperation.UserID
+	if userID == 0 {
+		userID = sessionUserID()
+	}
+	logger := slog.Default()
+	if currentOperation.Action != "" {
+		logger = logger.With("action", currentOperation.Action)
+	}
+	if userID != 0 {
+		logger = logger.With("user_id", userID)
+	}
+	return logger
+}
+
+// Журнал для действий app; у фоновой задачи — без операции и пользователя меню.
+func (app *App) logger() *slog.Logger {
+	if app.system {
+		return slog.Default()
+	}
+	return opLogger()
+}
+
+// Ошибки пользователя (неверный ввод, нет записи) — debug, отказ в доступе — warn, остальное — error.
+func logError(err error) {
+	kind := classifyError(err)
+	level := slog.LevelError
+	switch kind {
+	case kindValidation, kindNotFound, kindQuota:
+		level = slog.LevelDebug
+	case kindPermission:
+		level = slog.LevelWarn
+	}
+	opLogger().Log(context.Background(), level, err.Error(), "kind", string(kind))
+}
+
+// Запись аудита: кто и что сделал. event — короткое имя действия, например user.login.
+func audit(event string, attrs ...any) {
+	writeAudit(opLogger(), event, attrs)
+}
+
+// То же от имени app: записи фоновых задач не приписываются пользователю меню.
+func (app *App) audit(event string, attrs ...any) {
+	writeAudit(app.logger(), event, attrs)
+}
+
+func writeAudit(logger *slog.Logger, event string, attrs []any) {
+	logger.Info(event, append([]any{"audit", true}, attrs...)...)
+}
@@ -3,51 +3,63 @@ package main
 import (
 	"bufio"
 	"database/sql"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
+
+	"your_project_name/storage"
 )
 
-type User struct {
-	ID           int    `db:"id"`
-	Username     string `db:"username"`
-	PasswordHash string `db:"password_hash"`
-	Role         string `db:"role"`
+// Основные данные (пользователи, компании, кандидаты, вакансии) доступны только через репозитории.
+// DB остаётся для подсистем со своими таблицами: очереди задач, квот, учёта, импорта и нагрузочных тестов.
+type App struct {
+	DB *sql.DB
+	storage.Repositories
+	// Транзакция снимка, в которой строится отчёт (см. withReportSnapshot).
+	snapshot *sql.Tx
+	// App фоновой задачи (newJobApp): действует от имени системы и не читает операцию и сессию меню,
+	// которые в это время меняет другая горутина.
+	system bool
+}
+
+// Для запросов в обход репозиториев: внутри снимка отчёта они идут в его транзакцию.
+func (app *App) reader() storage.DBTX {
+	if app.snapshot != nil {
+		return app.snapshot
+	}
+	return app.DB
 }
 
-type Candidate struct {
-	ID         int      `db:"id"`
-	FullName   string   `db:"full_name"`
-	Age        int      `db:"age"`
-	Email      string   `db:"email"`
-	Experience string   `db:"experience"`
-	Skills     []string `db:"skills"`
+func newApp(db *sql.DB) *App {
+	return &App{DB: db, Repositories: storage.NewPostgres(db)}
 }
 
-type JobOpening struct {
-	ID             int      `db:"id"`
-	CompanyID      int      `db:"company_id"`
-	Title          string   `db:"title"`
-	Experience     string   `db:"experience"`
-	Salary         float64  `db:"salary"`
-	RequiredSkills []string `db:"required_skills"`
+// Для обработчиков фоновых задач: изменения записываются в журнал без пользователя.
+func newJobApp(db *sql.DB) *App {
+	return &App{DB: db, Repositories: storage.NewPostgresBy(db, 0), system: true}
 }
 
-type Company struct {
-	ID   int    `db:"id"`
-	Name string `db:"name"`
+// Репозитории поверх транзакции tx, пишущие журнал от того же имени, что и app.
+func (app *App) reposIn(tx storage.DBTX) storage.Repositories {
+	if app.system {
+		return storage.NewPostgresBy(tx, 0)
+	}
+	return storage.NewPostgres(tx)
 }
 
 func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
 	if err != nil {
 		return "", err
 	}
@@ -59,68 +71,132 @@ func checkPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
-func registerUser(db *sql.DB, username, password string) error {
-	if username == "" || password == "" {
-		return errors.New("имя пользователя и пароль не могут быть пустыми")
-	}
-
-	row := db.QueryRow("SELECT 1 FROM users WHERE username = $1", username)
-	var exists int
-	err := row.Scan(&exists)
-	if err == nil && exists == 1 {
-		return errors.New("пользователь с таким именем уже существует")
-	} else if err != nil && err != sql.ErrNoRows {
-		return fmt.Errorf("ошибка проверки существования пользователя: %w", err)
+// Зарегистрироваться может кто угодно, поэтому новая учётная запись получает роль кандидата: ей видны
+// только вакансии и своя анкета. Доступ к кандидатам и откликам выдаёт администратор (user role).
+func registerUser(app *App, username, password string) error {
+	id, err := createUser(app.Users, storage.User{Username: username, Role: storage.RoleCandidate}, password)
+	if err == nil {
+		audit("user.register", "target_user_id", id, "username", normalizeText(username, false))
 	}
+	return err
+}
 
-	hashedPassword, err := hashPassword(password)
+func createUser(users storage.UserRepository, user storage.User, password string) (int, error) {
+	username, err := sanitizeText("имя пользователя", user.Username, maxUsernameLength)
 	if err != nil {
-		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+		return 0, err
+	}
+	if username == "" || password == "" {
+		return 0, validationErrorf("имя пользователя и пароль не могут быть пустыми")
 	}
 
-	stmt, err := db.Prepare("INSERT INTO users (username, password_hash) VALUES ($1, $2)")
-	if err != nil {
-		return fmt.Errorf("ошибка подготовки запроса: %w", err)
+	_, err = users.GetByUsername(username)
+	if err == nil {
+		return 0, validationErrorf("пользователь с таким именем уже существует")
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return 0, fmt.Errorf("ошибка проверки существования пользователя: %w", err)
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(username, hashedPassword)
+	hashedPassword, err := hashPassword(password)
 	if err != nil {
-		return fmt.Errorf("ошибка регистрации пользователя: %w", err)
+		return 0, fmt.Errorf("ошибка хеширования пароля: %w", err)
 	}
-	return nil
+
+	user.Username = username
+	user.PasswordHash = hashedPassword
+	return users.Create(user)
 }
 
-func loginUser(db *sql.DB, username, password string) (int, string, error) {
-	stmt, err := db.Prepare("SELECT id, password_hash, role FROM users WHERE username = $1")
-	if err != nil {
-		return 0, "", fmt.Errorf("Ошибка подготовки запроса: %w", err)
+func loginUser(app *App, username, password string) (int, string, error) {
+	user, err := app.Users.GetByUsername(username)
+	if errors.Is(err, storage.ErrNotFound) {
+		return 0, "", permissionError("пользователь не найден")
 	}
-	defer stmt.Close()
-
-	var user User
-	err = stmt.QueryRow(username).Scan(&user.ID, &user.PasswordHash, &user.Role)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, "", errors.New("пользователь не найден")
-		}
 		return 0, "", fmt.Errorf("ошибка авторизации: %w", err)
 	}
 
 	if !checkPasswordHash(password, user.PasswordHash) {
-		return 0, "", errors.New("неверный пароль")
+		opLogger().Warn("user.login.failed", "audit", true, "username", user.Username)
+		return 0, "", permissionError("неверный пароль")
 	}
 
+	audit("user.login", "target_user_id", user.ID, "username", user.Username)
 	return user.ID, user.Role, nil
 }
 
+var stdinReader = bufio.NewReader(os.Stdin)
+
 func getInput(prompt string) string {
-	reader := bufio.NewReader(os.Stdin)
 	fmt.Print(prompt)
-	input, _ := reader.ReadString('\n')
+	input, _ := stdinReader.ReadString('\n')
 	return strings.TrimSpace(input)
 }
 
+// Для длинных полей: «@путь» читает значение из файла, «!edit» открывает $EDITOR,
+// иначе строки копятся до одиночной «.» или конца ввода.
+func readLongText(firstLine, current string) (string, error) {
+	switch {
+	case strings.HasPrefix(firstLine, "@"):
+		data, err := os.ReadFile(strings.TrimSpace(firstLine[1:]))
+		if err != nil {
+			return "", fmt.Errorf("ошибка чтения файла: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case firstLine == "!edit":
+		return editInEditor(current)
+	}
+
+	lines := []string{}
+	line := firstLine
+	for line != "." {
+		lines = append(lines, line)
+		next, err := stdinReader.ReadString('\n')
+		if next == "" && err != nil {
+			break
+		}
+		line = strings.TrimRight(next, "\r\n")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+func editInEditor(initial string) (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	file, err := os.CreateTemp("", "kursovaya-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(initial)
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("ошибка записи временного файла: %w", err)
+	}
+
+	cmd := exec.Command(editor, file.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ошибка запуска редактора %s: %w", editor, err)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения временного файла: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func getLongTextInput(prompt string) (string, error) {
+	fmt.Println("(завершите ввод строкой «.»; «@файл» — взять текст из файла; «!edit» — открыть редактор)")
+	firstLine := getInput(prompt)
+	if firstLine == "" {
+		return "", nil
+	}
+	return readLongText(firstLine, "")
+}
+
 func getIntInput(prompt string) (int, error) {
 	input := getInput(prompt)
 	num, err := strconv.Atoi(input)
@@ -152,324 +228,1267 @@ func getStringArrayInput(prompt string) ([]string, error) {
 
 }
 
-func addCompany(db *sql.DB, companyName string) error {
-	if companyName == "" {
-		return errors.New("имя компании не может быть пустым")
-	}
-	stmt, err := db.Prepare("INSERT INTO companies (name) VALUES ($1)")
+func prepareCompanyName(companyName string) (string, error) {
+	companyName, err := sanitizeText("название компании", companyName, maxNameLength)
 	if err != nil {
-		return fmt.Errorf("ошибка подготовки запроса: %w", err)
+		return "", err
+	}
+	if companyName == "" {
+		return "", validationErrorf("имя компании не может быть пустым")
 	}
-	defer stmt.Close()
+	return companyName, nil
+}
 
-	_, err = stmt.Exec(companyName)
+func addCompany(app *App, companyName string) (int, error) {
+	companyName, err := prepareCompanyName(companyName)
 	if err != nil {
-		return fmt.Errorf("ошибка добавления компании: %w", err)
+		return 0, err
 	}
-	return nil
+	return app.Companies.Create(storage.Company{Name: companyName})
 }
 
-func addCandidate(db *sql.DB, candidate Candidate) error {
+// Очищает поля кандидата и проверяет обязательные; общая часть ручного добавления и импорта.
+func prepareCandidate(candidate *storage.Candidate) error {
+	if err := sanitizeCandidate(candidate); err != nil {
+		return err
+	}
 	if candidate.FullName == "" || candidate.Age <= 0 {
-		return errors.New("не все обязательные поля заполнены для кандидата")
+		return validationErrorf("не все обязательные поля заполнены для кандидата")
+	}
+	if candidate.ExpectedSalary < 0 {
+		return validationErrorf("ожидаемая зарплата не может быть отрицательной")
+	}
+	var err error
+	if candidate.SalaryCurrency, err = normalizeCurrency(candidate.SalaryCurrency); err != nil {
+		return err
 	}
+	return validateEmailSyntax(candidate.Email)
+}
 
-	skillsJSON, err := json.Marshal(candidate.Skills)
-	if err != nil {
-		return fmt.Errorf("ошибка сериализации навыков: %w", err)
+func addCandidate(app *App, candidate storage.Candidate) (int, error) {
+	if err := prepareCandidate(&candidate); err != nil {
+		return 0, err
+	}
+	id, err := app.Candidates.Create(candidate)
+	if err == nil {
+		publish(app, Event{Kind: eventCandidateSaved, ID: id})
 	}
+	return id, err
+}
 
-	stmt, err := db.Prepare("INSERT INTO candidates (full_name, age, email, experience, skills) VALUES ($1, $2, $3, $4, $5)")
+// Компания проверяется отдельно: при создании вместе с новой компанией её ID ещё нет.
+func prepareJobOpening(jobOpening *storage.JobOpening) error {
+	if err := sanitizeJobOpening(jobOpening); err != nil {
+		return err
+	}
+	if jobOpening.Title == "" {
+		return validationErrorf("не все обязательные поля заполнены для вакансии")
+	}
+	if err := validateSalaryRange(jobOpening.SalaryMin, jobOpening.SalaryMax); err != nil {
+		return err
+	}
+	var err error
+	jobOpening.SalaryCurrency, err = normalizeCurrency(jobOpening.SalaryCurrency)
+	return err
+}
+
+func addJobOpening(app *App, jobOpening storage.JobOpening) (int, error) {
+	if err := prepareJobOpening(&jobOpening); err != nil {
+		return 0, err
+	}
+	if jobOpening.CompanyID <= 0 {
+		return 0, validationErrorf("не все обязательные поля заполнены для вакансии")
+	}
+	if err := authorizeCompany(app, jobOpening.CompanyID); err != nil {
+		return 0, err
+	}
+	id, err := app.JobOpenings.Create(jobOpening)
+	if err == nil {
+		publish(app, Event{Kind: eventJobOpeningSaved, ID: id})
+	}
+	return id, err
+}
+
+// Новая компания и её первая вакансия создаются в одной транзакции: если вакансию сохранить
+// не удалось, пустая компания не остаётся. Право создавать компании проверяется здесь, а не в меню,
+// чтобы его нельзя было обойти, продолжив черновик; консольные команды без сессии его не требуют.
+func addJobOpeningWithCompany(app *App, companyName string, jobOpening storage.JobOpening) (int, int, error) {
+	if userID := actingUserID(); userID != 0 {
+		if err := authorizeUser(app, &TokenClaims{Subject: strconv.Itoa(userID)}, permCompaniesManage); err != nil {
+			return 0, 0, err
+		}
+	}
+	companyName, err := prepareCompanyName(companyName)
 	if err != nil {
-		return fmt.Errorf("ошибка подготовки запроса: %w", err)
+		return 0, 0, err
+	}
+	if err := prepareJobOpening(&jobOpening); err != nil {
+		return 0, 0, err
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(candidate.FullName, candidate.Age, candidate.Email, candidate.Experience, skillsJSON)
+	var id int
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		repos := storage.NewPostgres(tx)
+		var err error
+		if jobOpening.CompanyID, err = repos.Companies.Create(storage.Company{Name: companyName}); err != nil {
+			return err
+		}
+		id, err = repos.JobOpenings.Create(jobOpening)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("ошибка добавления кандидата: %w", err)
+		return 0, 0, err
+	}
+	publish(app, Event{Kind: eventJobOpeningSaved, ID: id})
+	return jobOpening.CompanyID, id, nil
+}
+
+type EditableField struct {
+	Name     string
+	Column   string
+	Label    string
+	Kind     string
+	Optional bool
+	Validate func(value interface{}) error
+}
+
+func requireNonEmpty(message string) func(value interface{}) error {
+	return func(value interface{}) error {
+		if s, ok := value.(string); ok && strings.TrimSpace(s) == "" {
+			return validationErrorf("%s", message)
+		}
+		return nil
+	}
+}
+
+func requirePositive(message string) func(value interface{}) error {
+	return func(value interface{}) error {
+		switch v := value.(type) {
+		case int:
+			if v <= 0 {
+				return validationErrorf("%s", message)
+			}
+		case float64:
+			if v <= 0 {
+				return validationErrorf("%s", message)
+			}
+		}
+		return nil
+	}
+}
+
+func requireNonNegative(message string) func(value interface{}) error {
+	return func(value interface{}) error {
+		if v, ok := value.(float64); ok && v < 0 {
+			return validationErrorf("%s", message)
+		}
+		return nil
+	}
+}
+
+func validateEmailValue(value interface{}) error {
+	if s, ok := value.(string); ok {
+		return validateEmailSyntax(s)
 	}
 	return nil
 }
 
-func addJobOpening(db *sql.DB, jobOpening JobOpening) error {
-	if jobOpening.Title == "" || jobOpening.CompanyID <= 0 || jobOpening.Salary <= 0 {
-		return errors.New("не все обязательные поля заполнены для вакансии")
+var candidateEditableFields = []EditableField{
+	{Name: "full_name", Column: "full_name", Label: "ФИО", Kind: "string", Validate: requireNonEmpty("ФИО кандидата не может быть пустым")},
+	{Name: "age", Column: "age", Label: "Возраст", Kind: "int", Validate: requirePositive("возраст кандидата должен быть положительным")},
+	{Name: "email", Column: "email", Label: "Email", Kind: "email", Optional: true, Validate: validateEmailValue},
+	{Name: "phone", Column: "phone", Label: "Телефон", Kind: "phone", Optional: true},
+	{Name: "experience", Column: "experience", Label: "Опыт работы", Kind: "text", Optional: true},
+	{Name: "skills", Column: "skills", Label: "Навыки", Kind: "skills", Optional: true},
+	{Name: "expected_salary", Column: "expected_salary", Label: "Ожидаемая зарплата (0 — не указана)", Kind: "float", Validate: requireNonNegative("ожидаемая зарплата не может быть отрицательной")},
+	{Name: "salary_currency", Column: "salary_currency", Label: "Валюта ожидаемой зарплаты", Kind: "currency", Validate: validateCurrencyValue},
+	{Name: "location", Column: "location", Label: "Город", Kind: "string", Optional: true},
+	{Name: "languages", Column: "languages", Label: "Языки", Kind: "skills", Optional: true},
+	{Name: "status", Column: "status", Label: "Статус (active/passive/placed/archived)", Kind: "string", Validate: validateCandidateStatusValue},
+	{Name: "tags", Column: "tags", Label: "Теги", Kind: "skills", Optional: true},
+	{Name: "available_from", Column: "available_from", Label: "Может выйти с (ГГГГ-ММ-ДД, пусто — сразу)", Kind: "date", Optional: true},
+}
+
+var jobOpeningEditableFields = []EditableField{
+	{Name: "company_id", Column: "company_id", Label: "ID компании", Kind: "int", Validate: requirePositive("ID компании должен быть положительным")},
+	{Name: "title", Column: "title", Label: "Название", Kind: "string", Validate: requireNonEmpty("название вакансии не может быть пустым")},
+	{Name: "experience", Column: "experience", Label: "Требуемый опыт", Kind: "text", Optional: true},
+	{Name: "salary_min", Column: "salary_min", Label: "Зарплата от (0 — не ограничена)", Kind: "float", Validate: requireNonNegative("зарплата не может быть отрицательной")},
+	{Name: "salary_max", Column: "salary_max", Label: "Зарплата до (0 — не ограничена)", Kind: "float", Validate: requireNonNegative("зарплата не может быть отрицательной")},
+	{Name: "salary_currency", Column: "salary_currency", Label: "Валюта зарплаты", Kind: "currency", Validate: validateCurrencyValue},
+	{Name: "required_skills", Column: "required_skills", Label: "Требуемые навыки", Kind: "skills", Optional: true},
+	{Name: "location", Column: "location", Label: "Город", Kind: "string", Optional: true},
+	{Name: "languages", Column: "languages", Label: "Требуемые языки", Kind: "skills", Optional: true},
+}
+
+func findEditableField(fields []EditableField, name string) (EditableField, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f, true
+		}
 	}
+	return EditableField{}, false
+}
 
-	requiredSkillsJSON, err := json.Marshal(jobOpening.RequiredSkills)
-	if err != nil {
-		return fmt.Errorf("ошибка сериализации навыков: %w", err)
+func parseFieldValue(field EditableField, input string) (interface{}, error) {
+	switch field.Kind {
+	case "int":
+		num, err := strconv.Atoi(input)
+		if err != nil {
+			return nil, fmt.Errorf("неверный ввод целого числа: %w", err)
+		}
+		return num, nil
+	case "float":
+		num, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return nil, fmt.Errorf("неверный ввод вещественного числа: %w", err)
+		}
+		return num, nil
+	case "phone":
+		return normalizePhone(input)
+	case "skills":
+		skills := []string{}
+		if input != "" {
+			for _, skill := range strings.Split(input, ",") {
+				skills = append(skills, strings.TrimSpace(skill))
+			}
+		}
+		return skills, nil
+	default:
+		return input, nil
 	}
+}
 
-	stmt, err := db.Prepare("INSERT INTO job_openings (company_id, title, experience, salary, required_skills) VALUES ($1, $2, $3, $4, $5)")
+// Проверяет и нормализует переданные поля и переводит их имена в колонки; имена берутся из белого списка, а не из ввода.
+func fieldColumns(allowed []EditableField, values map[string]interface{}) (map[string]interface{}, error) {
+	if len(values) == 0 {
+		return nil, validationErrorf("не указано ни одного поля для изменения")
+	}
+
+	columns := map[string]interface{}{}
+	for name, value := range values {
+		field, ok := findEditableField(allowed, name)
+		if !ok {
+			return nil, validationErrorf("поле %q нельзя изменять", name)
+		}
+		value, err := sanitizeFieldValue(field, value)
+		if err != nil {
+			return nil, err
+		}
+		if field.Validate != nil {
+			if err := field.Validate(value); err != nil {
+				return nil, err
+			}
+		}
+		columns[field.Column] = value
+	}
+	return columns, nil
+}
+
+func updateCandidateFields(app *App, id int, values map[string]interface{}) error {
+	columns, err := fieldColumns(candidateEditableFields, values)
 	if err != nil {
-		return fmt.Errorf("ошибка подготовки запроса: %w", err)
+		return err
+	}
+	err = app.Candidates.Update(id, columns)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("кандидат не найден")
 	}
-	defer stmt.Close()
+	if err == nil {
+		publish(app, Event{Kind: eventCandidateSaved, ID: id})
+	}
+	return err
+}
 
-	_, err = stmt.Exec(jobOpening.CompanyID, jobOpening.Title, jobOpening.Experience, jobOpening.Salary, requiredSkillsJSON)
+// Вакансию нельзя и перенести в компанию, к которой пользователь не привязан.
+func updateJobOpeningFields(app *App, id int, values map[string]interface{}) error {
+	columns, err := fieldColumns(jobOpeningEditableFields, values)
 	if err != nil {
-		return fmt.Errorf("ошибка добавления вакансии: %w", err)
+		return err
 	}
-	return nil
+	if err := authorizeJobOpening(app, id); err != nil {
+		return err
+	}
+	if companyID, ok := columns["company_id"].(int); ok {
+		if err := authorizeCompany(app, companyID); err != nil {
+			return err
+		}
+	}
+	err = app.JobOpenings.Update(id, columns)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("вакансия не найдена")
+	}
+	if err == nil {
+		publish(app, Event{Kind: eventJobOpeningSaved, ID: id})
+	}
+	return err
 }
 
-func findCandidatesBySkill(db *sql.DB, skill string) ([]Candidate, error) {
-	var candidates []Candidate
-	rows, err := db.Query("SELECT id, full_name, age, email, experience, skills FROM candidates WHERE skills @> $1::jsonb", `["`+skill+`"]`)
+func editFieldPrompt(fields []EditableField) (string, interface{}, error) {
+	for i, f := range fields {
+		fmt.Printf("%d. %s\n", i+1, f.Label)
+	}
+	num, err := getIntInput("Выберите поле: ")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		return "", nil, err
+	}
+	if num < 1 || num > len(fields) {
+		return "", nil, errors.New("неверный номер поля")
+	}
+	field := fields[num-1]
+	if field.Kind == "text" {
+		text, err := getLongTextInput("Введите новое значение: ")
+		return field.Name, text, err
+	}
+	prompt := "Введите новое значение: "
+	if field.Kind == "skills" {
+		prompt = "Введите новые навыки (через запятую): "
 	}
-	defer rows.Close()
+	value, err := parseFieldValue(field, getInput(prompt))
+	if err != nil {
+		return "", nil, err
+	}
+	if field.Kind == "email" {
+		if err := validateEmailSyntax(value.(string)); err != nil {
+			return "", nil, err
+		}
+		if err := checkEmailInteractive(value.(string)); err != nil {
+			return "", nil, err
+		}
+	}
+	return field.Name, value, nil
+}
 
-	for rows.Next() {
-		var candidate Candidate
-		var skillsJSON []byte
-		err := rows.Scan(&candidate.ID, &candidate.FullName, &candidate.Age, &candidate.Email, &candidate.Experience, &skillsJSON)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+func getCandidateByID(app *App, id int) (storage.Candidate, error) {
+	candidate, err := app.Candidates.GetByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.Candidate{}, notFoundError("кандидат не найден")
+	}
+	return candidate, err
+}
+
+func getJobOpeningByID(app *App, id int) (storage.JobOpening, error) {
+	jobOpening, err := app.JobOpenings.GetByID(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.JobOpening{}, notFoundError("вакансия не найдена")
+	}
+	return jobOpening, err
+}
+
+var jobStatusTitles = map[string]string{
+	storage.JobOpen:   "открыта",
+	storage.JobOnHold: "приостановлена",
+	storage.JobClosed: "закрыта",
+}
+
+func validateJobStatus(status string) error {
+	for _, s := range storage.JobStatuses {
+		if status == s {
+			return nil
 		}
-		json.Unmarshal(skillsJSON, &candidate.Skills)
-		candidates = append(candidates, candidate)
 	}
+	return validationErrorf("неизвестный статус вакансии %q, допустимые: %s", status, strings.Join(storage.JobStatuses, ", "))
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+func setJobOpeningStatus(app *App, id int, status string) error {
+	if err := validateJobStatus(status); err != nil {
+		return err
+	}
+	if err := authorizeJobOpening(app, id); err != nil {
+		return err
 	}
+	err := app.JobOpenings.SetStatus(id, status)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("вакансия не найдена")
+	}
+	if err == nil {
+		publish(app, Event{Kind: eventJobOpeningSaved, ID: id})
+	}
+	return err
+}
 
-	return candidates, nil
+func closeJobOpening(app *App, id int) error {
+	return setJobOpeningStatus(app, id, storage.JobClosed)
 }
-func listAllJobOpenings(db *sql.DB) error {
-	rows, err := db.Query("SELECT id, company_id, title, experience, salary, required_skills FROM job_openings")
+
+func reopenJobOpening(app *App, id int) error {
+	return setJobOpeningStatus(app, id, storage.JobOpen)
+}
+
+func deleteJobOpening(app *App, id int) error {
+	if err := authorizeJobOpening(app, id); err != nil {
+		return err
+	}
+	err := app.JobOpenings.Delete(id)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("вакансия не найдена")
+	}
+	if err == nil {
+		audit("job_opening.delete", "id", id)
+	}
+	return err
+}
+
+// Фильтр списка вакансий в том виде, в каком его задают меню, метод jobOpening.list и команда job list.
+// Пустой статус — только открытые вакансии, "all" — все. Skill — навык, который вакансия требует.
+// Зарплата «от–до» в валюте Currency (пустая — SALARY_CURRENCY) отбирает вакансии, вилка которых
+// пересекается с ней.
+type JobOpeningListFilter struct {
+	Status     string  `json:"status"`
+	Skill      string  `json:"skill"`
+	SalaryFrom float64 `json:"salary_from"`
+	SalaryTo   float64 `json:"salary_to"`
+	Currency   string  `json:"currency"`
+}
+
+func (f JobOpeningListFilter) filter() (storage.JobOpeningFilter, error) {
+	filter := storage.JobOpeningFilter{Status: f.Status, Skill: normalizeText(f.Skill, false), SalaryFrom: f.SalaryFrom, SalaryTo: f.SalaryTo}
+	switch filter.Status {
+	case "all":
+		filter.Status = ""
+	case "":
+		filter.Status = storage.JobOpen
+	}
+	if filter.Status != "" {
+		if err := validateJobStatus(filter.Status); err != nil {
+			return filter, err
+		}
+	}
+	if f.SalaryFrom < 0 || f.SalaryTo < 0 || (f.SalaryTo > 0 && f.SalaryTo < f.SalaryFrom) {
+		return filter, validationErrorf("зарплата: «от» и «до» не могут быть отрицательными, «до» не может быть меньше «от»")
+	}
+	currency, err := normalizeCurrency(f.Currency)
 	if err != nil {
-		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		return filter, err
 	}
-	defer rows.Close()
+	if currency == "" {
+		currency = salaryCurrency()
+	}
+	filter.SalaryCurrency = currency
+	return filter, nil
+}
 
-	fmt.Println("Все вакансии:")
-	for rows.Next() {
-		var jobOpening JobOpening
-		var requiredSkillsJSON []byte
-		err := rows.Scan(&jobOpening.ID, &jobOpening.CompanyID, &jobOpening.Title, &jobOpening.Experience, &jobOpening.Salary, &requiredSkillsJSON)
+// Возвращает страницу вакансий по фильтру и общее число подходящих.
+func listJobOpenings(app *App, f JobOpeningListFilter, q ListQuery) ([]storage.JobOpening, int, error) {
+	filter, err := f.filter()
+	if err != nil {
+		return nil, 0, err
+	}
+	opts, err := q.options(storage.JobOpeningSorts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return app.JobOpenings.ListPage(filter, opts)
+}
+
+// Удержание проверяется в той же транзакции, что и удаление, под блокировкой строки кандидата:
+// удержание, поставленное параллельно, либо дождётся удаления, либо будет видно проверке.
+// Удержание на отклик, появившееся позже блокировки, всё равно остановит триггер legal_hold_guard.
+func deleteCandidate(app *App, id int) error {
+	var resumeKey string
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var locked int
+		err := tx.QueryRow(`SELECT id FROM candidates WHERE id = $1 FOR UPDATE`, id).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка блокировки кандидата: %w", err)
+		}
+		reason, held, err := candidateLegalHold(tx, id)
 		if err != nil {
-			return fmt.Errorf("ошибка сканирования строки: %w", err)
+			return err
 		}
-		json.Unmarshal(requiredSkillsJSON, &jobOpening.RequiredSkills)
-		fmt.Printf("ID: %d\nКомпания ID: %d\nНазвание: %s\nОпыт: %s\nЗарплата: %.2f\nТребуемые навыки: %v\n\n",
-			jobOpening.ID, jobOpening.CompanyID, jobOpening.Title, jobOpening.Experience, jobOpening.Salary, jobOpening.RequiredSkills)
+		if held {
+			return validationErrorf("кандидат под юридическим удержанием (%s), удалить его можно только после снятия удержания", reason)
+		}
+		resumeKey = resumeKeyBeforeDelete(tx, id)
+		return app.reposIn(tx).Candidates.Delete(id)
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("кандидат не найден")
+	}
+	if err == nil {
+		removeCandidateResume(app.logger(), resumeKey)
+		app.audit("candidate.delete", "id", id)
 	}
+	return err
+}
 
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("ошибка чтения строк: %w", err)
+// Возвращает кандидатов страницы и общее число подходящих под фильтр; весь список без страниц не отдаётся.
+func listCandidates(app *App, f CandidateListFilter, q ListQuery) ([]storage.Candidate, int, error) {
+	if q.PerPage < 1 {
+		return nil, 0, validationErrorf("на странице может быть от 1 до %d записей", maxListPerPage)
 	}
+	filter, err := f.filter()
+	if err != nil {
+		return nil, 0, err
+	}
+	opts, err := q.options(storage.CandidateSorts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return app.Candidates.ListPage(filter, opts)
+}
 
-	return nil
+func writeCandidate(w io.Writer, c storage.Candidate) {
+	fmt.Fprintf(w, "ID: %d\nФИО: %s\nВозраст: %d\nEmail: %s\nТелефон: %s\nОпыт: %s\nНавыки: %s\n",
+		c.ID, c.FullName, c.Age, c.Email, c.Phone, c.Experience, strings.Join(c.Skills, ", "))
+	if c.ExpectedSalary > 0 {
+		fmt.Fprintf(w, "Ожидаемая зарплата: %s %s\n", formatAmount(c.ExpectedSalary), c.SalaryCurrency)
+	}
+	fmt.Fprintf(w, "Статус: %s\n", candidateStatusTitles[c.Status])
+	if len(c.Tags) > 0 {
+		fmt.Fprintf(w, "Теги: %s\n", strings.Join(c.Tags, ", "))
+	}
+	if c.AvailableFrom != nil {
+		fmt.Fprintf(w, "Может выйти с: %s\n", c.AvailableFrom.Format("2006-01-02"))
+	}
+	fmt.Fprintf(w, "Ссылка: %s\n", entityURL(entityCandidate, c.ID))
+}
+
+func browseCandidates(app *App) {
+	const perPage = 20
+	q := ListQuery{PerPage: perPage, Sort: sortPrompt(storage.CandidateSorts)}
+	browsePages(perPage, "Кандидатов нет.", func(page int) ([]string, int, error) {
+		q.Page = page
+		candidates, total, err := listCandidates(app, CandidateListFilter{}, q)
+		lines := make([]string, len(candidates))
+		for i, c := range candidates {
+			lines[i] = fmt.Sprintf("ID: %d, ФИО: %s, Email: %s, Навыки: %s", c.ID, c.FullName, c.Email, strings.Join(c.Skills, ", "))
+		}
+		return lines, total, err
+	})
 }
 
-func findJobOpeningsBySkill(db *sql.DB, skill string) ([]JobOpening, error) {
-	var jobOpenings []JobOpening
-	rows, err := db.Query("SELECT id, company_id, title, experience, salary, required_skills FROM job_openings WHERE required_skills @> $1::jsonb", `["`+skill+`"]`)
+func findCandidatesBySkill(app *App, skill string, q ListQuery) ([]storage.Candidate, error) {
+	opts, err := q.options(storage.CandidateSorts)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	return app.Candidates.FindBySkill(normalizeText(skill, false), opts)
+}
 
-	for rows.Next() {
-		var jobOpening JobOpening
-		var requiredSkillsJSON []byte
-		err := rows.Scan(&jobOpening.ID, &jobOpening.CompanyID, &jobOpening.Title, &jobOpening.Experience, &jobOpening.Salary, &requiredSkillsJSON)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
-		}
-		json.Unmarshal(requiredSkillsJSON, &jobOpening.RequiredSkills)
-		jobOpenings = append(jobOpenings, jobOpening)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
-	}
-
-	return jobOpenings, nil
-}
-
-func createTables(db *sql.DB) error {
-	_, err := db.Exec(`
-    CREATE TABLE IF NOT EXISTS users (
-        id SERIAL PRIMARY KEY,
-        username TEXT UNIQUE NOT NULL,
-        password_hash TEXT NOT NULL,
-        role TEXT NOT NULL DEFAULT 'user'
-    );
-
-    CREATE TABLE IF NOT EXISTS companies (
-        id SERIAL PRIMARY KEY,
-        name TEXT UNIQUE NOT NULL
-    );
-
-    CREATE TABLE IF NOT EXISTS candidates (
-        id SERIAL PRIMARY KEY,
-        full_name TEXT NOT NULL,
-        age INTEGER NOT NULL,
-        email TEXT NOT NULL,
-        experience TEXT,
-        skills JSONB
-    );
-
-    CREATE TABLE IF NOT EXISTS job_openings (
-        id SERIAL PRIMARY KEY,
-        company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE,
-        title TEXT NOT NULL,
-        experience TEXT,
-        salary NUMERIC(10,2) NOT NULL,
-        required_skills JSONB
-    );
-`)
+const (
+	skillMatchAll = "all"
+	skillMatchAny = "any"
+)
+
+// Поиск по нескольким навыкам: mode "all" — нужны все, "any" — хотя бы один;
+// minMatch > 0 задаёт порог явно (например, 3 из 5) и имеет приоритет над mode.
+func findCandidatesBySkills(app *App, skills []string, mode string, minMatch int, q ListQuery) ([]storage.Candidate, error) {
+	skills, err := sanitizeSkills(skills)
 	if err != nil {
-		return fmt.Errorf("ошибка создания таблиц: %w", err)
+		return nil, err
 	}
-	return nil
+	if len(skills) == 0 {
+		return nil, validationErrorf("не указано ни одного навыка для поиска")
+	}
+	if minMatch == 0 {
+		switch mode {
+		case skillMatchAll, "":
+			minMatch = len(skills)
+		case skillMatchAny:
+			minMatch = 1
+		default:
+			return nil, validationErrorf("неизвестный режим поиска %q, допустимые: all, any", mode)
+		}
+	}
+	if minMatch < 0 || minMatch > len(skills) {
+		return nil, validationErrorf("минимальное число совпадений должно быть от 1 до %d", len(skills))
+	}
+	opts, err := q.options(storage.CandidateSorts)
+	if err != nil {
+		return nil, err
+	}
+	return app.Candidates.FindBySkills(skills, minMatch, opts)
+}
+
+func browseJobOpenings(app *App, f JobOpeningListFilter) {
+	const perPage = 10
+	q := ListQuery{PerPage: perPage, Sort: sortPrompt(storage.JobOpeningSorts)}
+	browsePages(perPage, "Вакансий нет.", func(page int) ([]string, int, error) {
+		q.Page = page
+		jobOpenings, total, err := listJobOpenings(app, f, q)
+		lines := make([]string, len(jobOpenings))
+		for i, jobOpening := range jobOpenings {
+			lines[i] = fmt.Sprintf("ID: %d\nКомпания ID: %d\nНазвание: %s\nСтатус: %s\nОпыт: %s\nЗарплата: %s\nТребуемые навыки: %v\n",
+				jobOpening.ID, jobOpening.CompanyID, jobOpening.Title, jobStatusTitles[jobOpening.Status], jobOpening.Experience, jobSalaryText(jobOpening), jobOpening.RequiredSkills)
+		}
+		return lines, total, err
+	})
+}
+
+func writeJobOpening(w io.Writer, j storage.JobOpening) {
+	fmt.Fprintf(w, "ID: %d\nКомпания ID: %d\nНазвание: %s\nСтатус: %s\nОпыт: %s\nЗарплата: %s\nТребуемые навыки: %s\n",
+		j.ID, j.CompanyID, j.Title, jobStatusTitles[j.Status], j.Experience, jobSalaryText(j), strings.Join(j.RequiredSkills, ", "))
+	fmt.Fprintf(w, "Ссылка: %s\n", entityURL(entityJobOpening, j.ID))
+}
+
+func findJobOpeningsBySkill(app *App, skill string, q ListQuery) ([]storage.JobOpening, error) {
+	opts, err := q.options(storage.JobOpeningSorts)
+	if err != nil {
+		return nil, err
+	}
+	return app.JobOpenings.FindBySkill(normalizeText(skill, false), opts)
 }
 
+// Сообщение об ошибке для пользователя; сама ошибка с контекстом операции уходит в журнал.
 func handleError(err error) {
 	if err != nil {
-		fmt.Println("Произошла ошибка:", err)
+		logError(err)
+		message, hint := describeError(err)
+		fmt.Println("Произошла ошибка:", message)
+		if hint != "" {
+			fmt.Println("Подсказка:", hint)
+		}
+		if verbose && message != err.Error() {
+			fmt.Println("Подробности:", err)
+		}
 	}
 }
 func main() {
-	err := godotenv.Load(".env")
+	flag.BoolVar(&verbose, "v", false, "показывать технические подробности ошибок")
+	rpcMode := flag.Bool("rpc", false, "работать как сервер JSON-RPC 2.0 через stdin/stdout")
+	flag.StringVar(&errorFormat, "error-format", "text", "формат вывода ошибок команд: text или json")
+	flag.StringVar(&outputFormat, "format", formatTable, "формат вывода команд: table, json или csv")
+	flag.BoolVar(&exactIDs, "id", false, "принимать в командах только числовые ID, без поиска по названию")
+	registerConfigFlags(flag.CommandLine)
+	flag.Parse()
+	if errorFormat != "text" && errorFormat != "json" {
+		fmt.Fprintln(os.Stderr, "--error-format: допустимые значения text и json")
+		os.Exit(exitUsage)
+	}
+	if !validOutputFormat(outputFormat) {
+		fmt.Fprintln(os.Stderr, "--format: допустимые значения table, json и csv")
+		os.Exit(exitUsage)
+	}
+
+	err := loadConfig(flag.CommandLine)
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfigCommand(err))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	closeLog, err := setupLogging()
 	if err != nil {
-		log.Fatal("env не найдено")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
+	defer closeLog()
 	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
 	if err != nil {
 		log.Fatalf("Failed to connect to the database: %v", err)
 	}
 	defer db.Close()
+	configureDB(db)
 
-	err = createTables(db)
+	if flag.Arg(0) == "migrate" {
+		code := runMigrateCommand(db, flag.Args()[1:])
+		db.Close()
+		os.Exit(code)
+	}
+	if _, err := migrateUp(db, 0); err != nil {
+		code := reportError(err)
+		db.Close()
+		os.Exit(code)
+	}
+
+	app := newApp(db)
+	if *rpcMode {
+		err := serveRPC(app, os.Stdin, os.Stdout)
+		db.Close()
+		if err != nil {
+			slog.Error(err.Error(), "action", "rpc")
+			closeLog()
+			os.Exit(exitFailure)
+		}
+		os.Exit(exitOK)
+	}
+
+	// Без команды, как и командой interactive, запускается меню.
+	if flag.NArg() > 0 && flag.Arg(0) != "interactive" {
+		beginOperation("command."+flag.Arg(0), 0)
+		code := runCommand(app, flag.Arg(0), flag.Args()[1:])
+		db.Close()
+		closeLog()
+		os.Exit(code)
+	}
+
+	fresh, err := isFreshDatabase(db)
 	handleError(err)
-	if err != nil {
-		return
+	if fresh {
+		runOnboarding(app)
 	}
 
-	for {
-		fmt.Println("\nВыберите действие:")
-		fmt.Println("1. Зарегистрироваться")
-		fmt.Println("2. Авторизоваться")
-		fmt.Println("3. Добавить компанию")
-		fmt.Println("4. Добавить кандидата")
-		fmt.Println("5. Добавить вакансию")
-		fmt.Println("6. Найти кандидатов по навыку")
-		fmt.Println("7. Найти вакансии по навыку")
-		fmt.Println("8. Показать все вакансии")
-		fmt.Println("9. Выйти")
+	err = requeueStaleJobs(db, 10*time.Minute)
+	handleError(err)
+	err = scheduleUsageAggregation(db)
+	handleError(err)
+	err = scheduleCompanyExpiry(db)
+	handleError(err)
+	err = scheduleMatchBatch(db)
+	handleError(err)
+	err = scheduleNotifications(db)
+	handleError(err)
+	err = scheduleDraftCleanup(db)
+	handleError(err)
+	err = scheduleRetentionPurge(db)
+	handleError(err)
+	workers, err := strconv.Atoi(os.Getenv("JOB_WORKERS"))
+	if err != nil || workers < 0 {
+		workers = 2
+	}
+	stopWorkers := make(chan struct{})
+	workersDone := startJobWorkers(db, workers, time.Second, stopWorkers)
+	defer workersDone.Wait()
+	defer close(stopWorkers)
 
+	for {
+		session, err := activeSession(app)
+		if err != nil {
+			fmt.Println("Сессия завершена, авторизуйтесь снова.")
+		}
+		if session != nil {
+			showAnnouncementBanner(app, session.UserID())
+		}
+		printMenu(session)
 		choice, err := getIntInput("Введите номер действия: ")
 		handleError(err)
 		if err != nil {
 			continue
 		}
+		beginOperation(fmt.Sprintf("menu.%d", choice), 0)
+		if !runMenuAction(app, choice) {
+			return
+		}
+	}
+}
 
-		switch choice {
-		case 1:
-			username := getInput("Введите имя пользователя: ")
-			password := getInput("Введите пароль: ")
-			err := registerUser(db, username, password)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Регистрация успешна!")
-			}
-		case 2:
-			username := getInput("Введите имя пользователя: ")
-			password := getInput("Введите пароль: ")
-			userID, role, err := loginUser(db, username, password)
-			handleError(err)
-			if err == nil {
-				fmt.Printf("Авторизация успешна! ID пользователя: %d, Роль: %s\n", userID, role)
-			}
-		case 3:
-			companyName := getInput("Введите название компании: ")
-			err := addCompany(db, companyName)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Компания успешно добавлена!")
-			}
-		case 4:
-			candidate := Candidate{}
-			candidate.FullName = getInput("Введите ФИО кандидата: ")
-			candidate.Age, err = getIntInput("Введите возраст кандидата: ")
-			handleError(err)
-			if err != nil {
-				continue
-			}
-			candidate.Email = getInput("Введите email кандидата: ")
-			candidate.Experience = getInput("Введите опыт работы кандидата: ")
-			candidate.Skills, err = getStringArrayInput("Введите навыки кандидата (через запятую): ")
-			handleError(err)
-			if err != nil {
-				continue
-			}
-			err = addCandidate(db, candidate)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Кандидат успешно добавлен!")
-			}
-		case 5:
-			jobOpening := JobOpening{}
-			jobOpening.Title = getInput("Введите название вакансии: ")
-			jobOpening.CompanyID, err = getIntInput("Введите ID компании: ")
+func runCommand(app *App, name string, args []string) int {
+	switch name {
+	case "bench":
+		return runBenchCommand(app, args)
+	case "profile":
+		return runProfileCommand(app, args)
+	case "ndjson-export":
+		return runNDJSONExportCommand(app.DB, args)
+	case "ndjson-import":
+		return runNDJSONImportCommand(app.DB, args)
+	case "onboard-company":
+		return runOnboardCompanyCommand(app.DB, args)
+	case "quota":
+		return runQuotaCommand(app.DB, args)
+	case "usage":
+		return runUsageCommand(app.DB, args)
+	case "company":
+		return runCompanyCommand(app, args)
+	case "similar":
+		return runSimilarCommand(app, args)
+	case "match":
+		return runMatchCommand(app, args)
+	case "match-rules":
+		return runMatchRulesCommand(app, args)
+	case "evaluate-matching":
+		return runEvaluateMatchingCommand(app, args)
+	case "import-candidates":
+		return runImportCandidatesCommand(app, args)
+	case "matches":
+		return runMatchesCommand(app, args)
+	case "export":
+		return runExportCommand(app, args)
+	case "skill-graph":
+		return runSkillGraphCommand(app, args)
+	case "search":
+		return runSearchCommand(app, args)
+	case "candidates":
+		return runCandidatesCommand(app, args)
+	case "saved-searches":
+		return runSavedSearchesCommand(app, args)
+	case "search-history":
+		return runSearchHistoryCommand(app, args)
+	case "notifications":
+		return runNotificationsCommand(app, args)
+	case "benchmark":
+		return runBenchmarkCommand(app, args)
+	case "forecast":
+		return runForecastCommand(app, args)
+	case "user":
+		return runUserCommand(app, args)
+	case "recruiter":
+		return runRecruiterCommand(app, args)
+	case "pipeline":
+		return runPipelineCommand(app, args)
+	case "diversity":
+		return runDiversityCommand(app, args)
+	case "retention":
+		return runRetentionCommand(app, args)
+	case "audit-log":
+		return runAuditLogCommand(app, args)
+	case "show":
+		return runShowCommand(app, args)
+	case "open":
+		return runOpenCommand(app, args)
+	case "profile-share":
+		return runProfileShareCommand(app, args)
+	case "resume":
+		return runResumeCommand(app, args)
+	case "apply-form":
+		return runApplyFormCommand(app, args)
+	case "parse-resume":
+		return runParseResumeCommand(app, args)
+	case "apply-review":
+		return runApplyReviewCommand(app, args)
+	case "apply-blocklist":
+		return runApplyBlocklistCommand(app, args)
+	case "currency":
+		return runCurrencyCommand(app, args)
+	case "projections":
+		return runProjectionsCommand(app, args)
+	case "feed":
+		return runFeedCommand(app, args)
+	case "candidate":
+		return runCandidateCommand(app, args)
+	case "job":
+		return runJobCommand(app, args)
+	case "purge":
+		return runPurgeCommand(app, args)
+	case "legal-hold":
+		return runLegalHoldCommand(app, args)
+	case "announce":
+		return runAnnounceCommand(app, args)
+	default:
+		fmt.Println("Неизвестная команда:", name)
+		return exitUsage
+	}
+}
+
+func printMenu(session *TokenClaims) {
+	if session != nil {
+		fmt.Printf("\nВы вошли как %s (%s)", session.Username, session.Role)
+	}
+	fmt.Println("\nВыберите действие:")
+	fmt.Println("1. Зарегистрироваться")
+	fmt.Println("2. Авторизоваться")
+	fmt.Println("3. Добавить компанию")
+	fmt.Println("4. Добавить кандидата")
+	fmt.Println("5. Добавить вакансию")
+	fmt.Println("6. Найти кандидатов по навыку")
+	fmt.Println("7. Найти вакансии по навыку")
+	fmt.Println("8. Показать все вакансии")
+	fmt.Println("9. Изменить поле кандидата")
+	fmt.Println("10. Изменить поле вакансии")
+	fmt.Println("11. Фоновые задачи")
+	fmt.Println("12. Анонимизировать данные")
+	fmt.Println("13. Сгенерировать нагрузочные данные")
+	fmt.Println("14. Профилировать действие")
+	fmt.Println("15. Редактировать кандидата")
+	fmt.Println("16. Редактировать вакансию")
+	fmt.Println("17. Миграция данных (NDJSON)")
+	fmt.Println("18. Выйти из учётной записи")
+	fmt.Println("19. Отклики")
+	fmt.Println("20. Подобрать кандидатов на вакансию")
+	fmt.Println("21. Управление ролями")
+	fmt.Println("22. Отчёт по рекрутерам")
+	fmt.Println("23. Показать кандидата")
+	fmt.Println("24. Список кандидатов")
+	fmt.Println("25. Удалить кандидата")
+	fmt.Println("26. Изменить статус вакансии")
+	fmt.Println("27. Удалить вакансию")
+	fmt.Println("28. Информация о компании")
+	fmt.Println("29. Переименовать компанию")
+	fmt.Println("30. Удалить компанию")
+	fmt.Println("31. Импорт кандидатов из CSV")
+	fmt.Println("32. Расширенный поиск кандидатов")
+	fmt.Println("33. Каталог компаний")
+	fmt.Println("34. Мои сохранённые поиски")
+	fmt.Println("35. История поиска")
+	fmt.Println("36. Восстановить пароль")
+	fmt.Println("37. Недавно открытые")
+	fmt.Println("38. Быстро открыть по названию")
+	fmt.Println("39. Журнал изменений")
+	fmt.Println("40. Черновики")
+	fmt.Println("41. Ссылки на профиль кандидата")
+	fmt.Println("42. Резюме кандидата")
+	fmt.Println("43. Добавить кандидата из текста резюме")
+	fmt.Println("44. Отклики с сайта на проверке")
+	fmt.Println("45. Настройки уведомлений")
+	fmt.Println("46. Рекомендованные вакансии")
+	fmt.Println("47. Моя анкета")
+	fmt.Println("48. Юридические удержания")
+	fmt.Println("49. Объявления")
+	fmt.Println("0. Выйти")
+}
+
+func runMenuAction(app *App, choice int) bool {
+	if perm, ok := menuPermissions[choice]; ok {
+		if err := authorize(app, perm); err != nil {
 			handleError(err)
-			if err != nil {
-				continue
-			}
-			jobOpening.Experience = getInput("Введите требуемый опыт работы: ")
-			jobOpening.Salary, err = getFloatInput("Введите зарплату: ")
+			return true
+		}
+	}
+	switch choice {
+	case 1:
+		username := getInput("Введите имя пользователя: ")
+		password := getInput("Введите пароль: ")
+		err := registerUser(app, username, password)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Регистрация успешна!")
+		}
+	case 2:
+		username := getInput("Введите имя пользователя: ")
+		password := getInput("Введите пароль: ")
+		pair, err := loginWithTokens(app, username, password)
+		handleError(err)
+		if err == nil {
+			currentSession = &pair
+			claims, _ := parseToken(pair.AccessToken, tokenAccess)
+			userID := claims.UserID()
+			fmt.Printf("Авторизация успешна! ID пользователя: %d, Роль: %s\n", userID, claims.Role)
+			required, err := passwordChangeRequired(app, userID)
 			handleError(err)
-			if err != nil {
-				continue
+			if required {
+				fmt.Println("Вы вошли с временным паролем, его нужно сменить.")
+				err := changePassword(app, userID, getInput("Новый пароль: "))
+				handleError(err)
+				if err == nil {
+					fmt.Println("Пароль изменён.")
+				}
 			}
-			jobOpening.RequiredSkills, err = getStringArrayInput("Введите требуемые навыки (через запятую): ")
-			handleError(err)
-			if err != nil {
-				continue
+			remindDrafts(app, userID)
+			if claims.Role == storage.RoleCandidate {
+				showLoginFeed(app, userID)
 			}
-			err = addJobOpening(db, jobOpening)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Вакансия успешно добавлена!")
+		}
+	case 3:
+		companyName := getInput("Введите название компании: ")
+		companyID, err := addCompany(app, companyName)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Компания успешно добавлена! ID: %d\n", companyID)
+		}
+	case 4:
+		addCandidateMenu(app, newDraftSaver(app, entityCandidate, nil), nil)
+	case 5:
+		addJobOpeningMenu(app)
+	case 6:
+		skills := strings.Split(getInput("Введите навыки для поиска кандидатов через запятую: "), ",")
+		mode, minMatch := skillMatchAll, 0
+		if len(skills) > 1 {
+			if getInput("Нужны все навыки (Enter) или любой (any)? ") == skillMatchAny {
+				mode = skillMatchAny
 			}
-		case 6:
-			skill := getInput("Введите навык для поиска кандидатов: ")
-			candidates, err := findCandidatesBySkill(db, skill)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Найденные кандидаты:")
-				for _, c := range candidates {
-					fmt.Printf("ID: %d, ФИО: %s, Навыки: %v\n", c.ID, c.FullName, c.Skills)
+			if text := getInput("Минимум совпавших навыков (Enter — по режиму): "); text != "" {
+				n, err := strconv.Atoi(text)
+				if err != nil {
+					handleError(validationErrorf("минимум совпадений %q не является целым числом", text))
+					return true
 				}
+				minMatch = n
 			}
-		case 7:
-			skill := getInput("Введите навык для поиска вакансий: ")
-			jobOpenings, err := findJobOpeningsBySkill(db, skill)
-			handleError(err)
-			if err == nil {
-				fmt.Println("Найденные вакансии:")
-				for _, j := range jobOpenings {
-					fmt.Printf("ID: %d, Название: %s, Требуемые навыки: %v\n", j.ID, j.Title, j.RequiredSkills)
+		}
+		if getInput("Учитывать родственные навыки? (y/N): ") == "y" {
+			q := ListQuery{PerPage: 20}
+			browsePages(q.PerPage, "Кандидаты не найдены.", func(page int) ([]string, int, error) {
+				q.Page = page
+				hits, err := findCandidatesBySkillsExpanded(app, skills, mode, minMatch, q)
+				lines := make([]string, len(hits))
+				for i, h := range hits {
+					lines[i] = fmt.Sprintf("%.0f%%  ID: %d, ФИО: %s, Навыки: %v", h.Score*100, h.Candidate.ID, h.Candidate.FullName, h.Candidate.Skills)
+					if len(h.Related) > 0 {
+						lines[i] += "\n      по родственным: " + strings.Join(h.Related, ", ")
+					}
 				}
+				return lines, -1, err
+			})
+			return true
+		}
+		q := ListQuery{PerPage: 20, Sort: sortPrompt(storage.CandidateSorts)}
+		browsePages(q.PerPage, "Кандидаты не найдены.", func(page int) ([]string, int, error) {
+			q.Page = page
+			candidates, err := findCandidatesBySkills(app, skills, mode, minMatch, q)
+			lines := make([]string, len(candidates))
+			for i, c := range candidates {
+				lines[i] = fmt.Sprintf("ID: %d, ФИО: %s, Навыки: %v", c.ID, c.FullName, c.Skills)
+			}
+			return lines, -1, err
+		})
+	case 7:
+		skill := getInput("Введите навык для поиска вакансий: ")
+		q := ListQuery{PerPage: 20, Sort: sortPrompt(storage.JobOpeningSorts)}
+		browsePages(q.PerPage, "Вакансии не найдены.", func(page int) ([]string, int, error) {
+			q.Page = page
+			jobOpenings, err := findJobOpeningsBySkill(app, skill, q)
+			lines := make([]string, len(jobOpenings))
+			for i, j := range jobOpenings {
+				lines[i] = fmt.Sprintf("ID: %d, Название: %s, Требуемые навыки: %v", j.ID, j.Title, j.RequiredSkills)
 			}
-		case 8:
-			err := listAllJobOpenings(db)
+			return lines, -1, err
+		})
+	case 8:
+		f := JobOpeningListFilter{Status: getInput("Статус (open/on_hold/closed/all, Enter — только открытые): ")}
+		if salary := getInput("Зарплата, например 150000-200000 или от 3000 USD (Enter — любая): "); salary != "" {
+			var err error
+			f.SalaryFrom, f.SalaryTo, f.Currency, err = parseSalaryRange(salary)
 			handleError(err)
 			if err != nil {
-				fmt.Println("Ошибка при выводе вакансий:", err)
+				return true
 			}
-		case 9:
-			fmt.Println("Выход из программы.")
-			return
-		default:
-			fmt.Println("Неверный выбор действия. Попробуйте снова.")
 		}
+		browseJobOpenings(app, f)
+	case 9:
+		candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		name, value, err := editFieldPrompt(candidateEditableFields)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		err = updateCandidateFields(app, candidateID, map[string]interface{}{name: value})
+		handleError(err)
+		if err == nil {
+			fmt.Println("Кандидат успешно обновлён!")
+		}
+	case 10:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		name, value, err := editFieldPrompt(jobOpeningEditableFields)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		err = updateJobOpeningFields(app, jobOpeningID, map[string]interface{}{name: value})
+		handleError(err)
+		if err == nil {
+			fmt.Println("Вакансия успешно обновлена!")
+		}
+	case 11:
+		jobsMenu(app.DB)
+	case 12:
+		anonymizeMenu(app)
+	case 13:
+		loadTestMenu(app)
+	case 14:
+		profileMenu(app)
+	case 15:
+		candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		changed, err := editCandidateForm(app, candidateID)
+		handleError(err)
+		if err == nil && changed {
+			fmt.Println("Кандидат успешно обновлён!")
+		} else if err == nil {
+			fmt.Println("Изменений нет.")
+		}
+	case 16:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		changed, err := editJobOpeningForm(app, jobOpeningID)
+		handleError(err)
+		if err == nil && changed {
+			fmt.Println("Вакансия успешно обновлена!")
+		} else if err == nil {
+			fmt.Println("Изменений нет.")
+		}
+	case 17:
+		ndjsonMenu(app.DB)
+	case 18:
+		if currentSession == nil {
+			fmt.Println("Вы не авторизованы.")
+			break
+		}
+		err := logout(app)
+		handleError(err)
+		fmt.Println("Вы вышли из учётной записи.")
+	case 19:
+		applicationsMenu(app)
+	case 20:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		weights, err := jobMatchWeights(app, jobOpeningID)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		matches, err := rankCandidates(app, jobOpeningID, weights, 10)
+		handleError(err)
+		if err == nil {
+			printCandidateMatches(os.Stdout, matches)
+		}
+	case 21:
+		roleMenu(app)
+	case 22:
+		recruiterReportMenu(app)
+	case 23:
+		candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		handleError(openEntity(app, entityCandidate, candidateID))
+	case 24:
+		browseCandidates(app)
+	case 25:
+		candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		candidate, err := getCandidateByID(app, candidateID)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		if !confirm(fmt.Sprintf("Удалить кандидата %s вместе с его откликами?", candidate.FullName)) {
+			fmt.Println("Удаление отменено.")
+			return true
+		}
+		err = deleteCandidate(app, candidateID)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Кандидат удалён.")
+		}
+	case 26:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		status := getInput("Новый статус (open/on_hold/closed): ")
+		err = setJobOpeningStatus(app, jobOpeningID, status)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Вакансия %s.\n", jobStatusTitles[status])
+		}
+	case 27:
+		jobOpeningID, err := getEntityInput(app, entityJobOpening, "Введите ID или название вакансии: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		jobOpening, err := getJobOpeningByID(app, jobOpeningID)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		if !confirm(fmt.Sprintf("Удалить вакансию «%s» вместе с откликами? Чтобы сохранить историю, вакансию можно закрыть.", jobOpening.Title)) {
+			fmt.Println("Удаление отменено.")
+			return true
+		}
+		err = deleteJobOpening(app, jobOpeningID)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Вакансия удалена.")
+		}
+	case 28:
+		companyID, err := getEntityInput(app, entityCompany, "Введите ID или название компании: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		handleError(openEntity(app, entityCompany, companyID))
+	case 29:
+		companyID, err := getEntityInput(app, entityCompany, "Введите ID или название компании: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		name := getInput("Новое название компании: ")
+		err = renameCompany(app, companyID, name)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Компания переименована.")
+		}
+	case 30:
+		companyID, err := getEntityInput(app, entityCompany, "Введите ID или название компании: ")
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		details, err := companyDetails(app, companyID)
+		handleError(err)
+		if err != nil {
+			return true
+		}
+		open := details.JobOpenings[storage.JobOpen] + details.JobOpenings[storage.JobOnHold]
+		force := false
+		if open > 0 {
+			fmt.Printf("У компании %d незакрытых вакансий.\n", open)
+			force = confirm("Удалить компанию вместе с ними?")
+			if !force {
+				fmt.Println("Удаление отменено.")
+				return true
+			}
+		} else if !confirm(fmt.Sprintf("Удалить компанию «%s» вместе со всеми её данными?", details.Name)) {
+			fmt.Println("Удаление отменено.")
+			return true
+		}
+		err = deleteCompany(app, companyID, force)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Компания удалена.")
+		}
+	case 31:
+		importCandidatesMenu(app)
+	case 32:
+		searchCandidatesMenu(app)
+	case 33:
+		companyDirectoryMenu(app)
+	case 34:
+		savedSearchesMenu(app)
+	case 35:
+		searchHistoryMenu(app)
+	case 36:
+		passwordResetMenu(app)
+	case 37:
+		recentViewsMenu(app)
+	case 38:
+		quickOpenMenu(app)
+	case 39:
+		auditLogMenu(app)
+	case 40:
+		draftsMenu(app)
+	case 41:
+		profileShareMenu(app)
+	case 42:
+		resumeMenu(app)
+	case 43:
+		addCandidateFromResumeMenu(app)
+	case 44:
+		applyReviewMenu(app)
+	case 45:
+		notificationSettingsMenu(app)
+	case 46:
+		vacancyFeedMenu(app)
+	case 47:
+		ownProfileMenu(app)
+	case 48:
+		legalHoldMenu(app)
+	case 49:
+		announcementsMenu(app)
+	case 0:
+		fmt.Println("Выход из программы.")
+		return false
+	default:
+		fmt.Println("Неверный выбор действия. Попробуйте снова.")
 	}
+	return true
 }
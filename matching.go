@@ -0,0 +1,377 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+// MustHave — не вес, а штраф: итоговая оценка умножается на (1 - MustHave) за каждый недостающий требуемый навык.
+// RelatedSkills — доля (0–1), с которой недостающий навык засчитывается по родственному из графа навыков;
+// 0 — родственные навыки не учитываются.
+type MatchWeights struct {
+	Skills        float64 `json:"skills"`
+	MustHave      float64 `json:"must_have"`
+	Experience    float64 `json:"experience"`
+	Salary        float64 `json:"salary"`
+	Location      float64 `json:"location"`
+	Language      float64 `json:"language"`
+	RelatedSkills float64 `json:"related_skills,omitempty"`
+
+	// Граф навыков, подключённый withSkillGraph.
+	related *SkillGraph
+	// Курсы валют, подключённые withCurrencyRates; без них сравниваются только зарплаты в одной валюте.
+	rates currencyRates
+}
+
+var defaultMatchWeights = MatchWeights{Skills: 0.6, Experience: 0.25, Salary: 0.15, Location: 0.1, Language: 0.1}
+
+type MatchCriterion struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail"`
+}
+
+type CandidateMatch struct {
+	Candidate     storage.Candidate `json:"candidate"`
+	Score         float64           `json:"score"`
+	Criteria      []MatchCriterion  `json:"criteria"`
+	MatchedSkills []string          `json:"matched_skills"`
+	MissingSkills []string          `json:"missing_skills"`
+}
+
+var experienceYearsPattern = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:\+\s*)?(?:год|года|лет|г\.|years?|yrs?)`)
+
+// Опыт хранится свободным текстом: берётся первое число лет («от 3 лет», «Middle, 4 лет опыта»),
+// а если его нет — минимальный стаж по названию уровня (Junior, Middle, Senior, Lead).
+func parseExperienceYears(text string) (float64, bool) {
+	if m := experienceYearsPattern.FindStringSubmatch(text); m != nil {
+		years, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+		return years, err == nil
+	}
+	lower := strings.ToLower(text)
+	for i := len(seniorityLevels) - 1; i >= 0; i-- {
+		if strings.Contains(lower, strings.ToLower(seniorityLevels[i].Name)) {
+			return float64(seniorityLevels[i].MinYears), true
+		}
+	}
+	return 0, false
+}
+
+// Доля требуемых навыков вакансии, которые есть у кандидата; лишние навыки не штрафуются.
+func scoreSkills(job storage.JobOpening, candidate storage.Candidate) (MatchCriterion, []string, []string) {
+	criterion := MatchCriterion{Name: "skills"}
+	required := skillSet(job.RequiredSkills)
+	has := skillSet(candidate.Skills)
+	var matched, missing []string
+	for _, skill := range job.RequiredSkills {
+		if has[strings.ToLower(strings.TrimSpace(skill))] {
+			matched = append(matched, skill)
+		} else {
+			missing = append(missing, skill)
+		}
+	}
+	if len(required) == 0 {
+		criterion.Score = 1
+		criterion.Detail = "вакансия не требует навыков"
+		return criterion, matched, missing
+	}
+	criterion.Score = float64(len(matched)) / float64(len(matched)+len(missing))
+	criterion.Detail = fmt.Sprintf("%d из %d навыков", len(matched), len(matched)+len(missing))
+	return criterion, matched, missing
+}
+
+// Досчитывает недостающие навыки, у которых у кандидата есть родственный, с весом share × Confidence.
+func scoreRelatedSkills(criterion *MatchCriterion, graph SkillGraph, share float64, candidate storage.Candidate, matched int, missing []string) {
+	has := skillSet(candidate.Skills)
+	credit := 0.0
+	var substitutes []string
+	for _, skill := range missing {
+		if r, ok := graph.bestSubstitute(skill, has); ok {
+			credit += share * r.Confidence
+			substitutes = append(substitutes, skill+" ← "+r.Related)
+		}
+	}
+	if len(substitutes) == 0 {
+		return
+	}
+	criterion.Score = (float64(matched) + credit) / float64(matched+len(missing))
+	criterion.Detail += ", родственные: " + strings.Join(substitutes, ", ")
+}
+
+// Недостаток опыта снижает оценку пропорционально; если опыт не удалось определить, оценка нейтральная.
+func scoreExperience(job storage.JobOpening, candidate storage.Candidate) MatchCriterion {
+	criterion := MatchCriterion{Name: "experience", Score: 0.5}
+	required, okJob := parseExperienceYears(job.Experience)
+	actual, okCandidate := parseExperienceYears(candidate.Experience)
+	switch {
+	case !okJob || required == 0:
+		criterion.Score = 1
+		criterion.Detail = "требования к опыту не указаны"
+	case !okCandidate:
+		criterion.Detail = "опыт кандидата не указан"
+	case actual >= required:
+		criterion.Score = 1
+		criterion.Detail = fmt.Sprintf("%g лет при требуемых %g", actual, required)
+	default:
+		criterion.Score = actual / required
+		criterion.Detail = fmt.Sprintf("%g лет при требуемых %g", actual, required)
+	}
+	return criterion
+}
+
+// Критерий учитывается, только если кандидат указал ожидаемую зарплату и её можно перевести в валюту
+// вакансии. Ожидание сравнивается с верхней границей вилки, а у вилки «от …» — с нижней.
+func scoreSalary(job storage.JobOpening, candidate storage.Candidate, rates currencyRates) (MatchCriterion, bool) {
+	criterion := MatchCriterion{Name: "salary"}
+	offered := job.SalaryMax
+	if offered == 0 {
+		offered = job.SalaryMin
+	}
+	if candidate.ExpectedSalary <= 0 || offered <= 0 {
+		return criterion, false
+	}
+	expected, ok := rates.convert(candidate.ExpectedSalary, candidate.SalaryCurrency, job.SalaryCurrency)
+	if !ok || expected <= 0 {
+		return criterion, false
+	}
+	criterion.Score = math.Min(1, offered/expected)
+	criterion.Detail = fmt.Sprintf("ожидает %.0f %s, предлагается %s", expected, job.SalaryCurrency, jobSalaryText(job))
+	return criterion, true
+}
+
+var remoteLocations = map[string]bool{"удалённо": true, "удаленно": true, "remote": true}
+
+// Учитывается, только если город указан и в вакансии, и у кандидата; удалённая вакансия подходит всем.
+func scoreLocation(job storage.JobOpening, candidate storage.Candidate) (MatchCriterion, bool) {
+	criterion := MatchCriterion{Name: "location"}
+	jobLocation := strings.ToLower(strings.TrimSpace(job.Location))
+	candidateLocation := strings.ToLower(strings.TrimSpace(candidate.Location))
+	switch {
+	case jobLocation == "":
+		return criterion, false
+	case remoteLocations[jobLocation]:
+		criterion.Score = 1
+		criterion.Detail = "удалённая работа"
+	case candidateLocation == "":
+		return criterion, false
+	case jobLocation == candidateLocation:
+		criterion.Score = 1
+		criterion.Detail = candidate.Location
+	default:
+		criterion.Detail = fmt.Sprintf("кандидат в городе %s, вакансия — %s", candidate.Location, job.Location)
+	}
+	return criterion, true
+}
+
+// Доля требуемых языков, которыми владеет кандидат; не учитывается, если языки не указаны.
+func scoreLanguages(job storage.JobOpening, candidate storage.Candidate) (MatchCriterion, bool) {
+	criterion := MatchCriterion{Name: "language"}
+	if len(job.Languages) == 0 || len(candidate.Languages) == 0 {
+		return criterion, false
+	}
+	has := skillSet(candidate.Languages)
+	var missing []string
+	for _, language := range job.Languages {
+		if !has[strings.ToLower(strings.TrimSpace(language))] {
+			missing = append(missing, language)
+		}
+	}
+	criterion.Score = float64(len(job.Languages)-len(missing)) / float64(len(job.Languages))
+	criterion.Detail = fmt.Sprintf("%d из %d языков", len(job.Languages)-len(missing), len(job.Languages))
+	if len(missing) > 0 {
+		criterion.Detail += ", нет: " + strings.Join(missing, ", ")
+	}
+	return criterion, true
+}
+
+// Итог — средневзвешенное по учтённым критериям, поэтому отсутствие ожидаемой зарплаты, города
+// или языков не занижает оценку. Штраф за недостающие навыки применяется к итогу.
+func matchCandidate(job storage.JobOpening, candidate storage.Candidate, weights MatchWeights) CandidateMatch {
+	skills, matched, missing := scoreSkills(job, candidate)
+	if weights.RelatedSkills > 0 && weights.related != nil && len(missing) > 0 {
+		scoreRelatedSkills(&skills, *weights.related, weights.RelatedSkills, candidate, len(matched), missing)
+	}
+	skills.Weight = weights.Skills
+	experience := scoreExperience(job, candidate)
+	experience.Weight = weights.Experience
+	criteria := []MatchCriterion{skills, experience}
+	if salary, ok := scoreSalary(job, candidate, weights.rates); ok {
+		salary.Weight = weights.Salary
+		criteria = append(criteria, salary)
+	}
+	if location, ok := scoreLocation(job, candidate); ok {
+		location.Weight = weights.Location
+		criteria = append(criteria, location)
+	}
+	if language, ok := scoreLanguages(job, candidate); ok {
+		language.Weight = weights.Language
+		criteria = append(criteria, language)
+	}
+
+	var total, weightSum float64
+	for _, c := range criteria {
+		total += c.Score * c.Weight
+		weightSum += c.Weight
+	}
+	match := CandidateMatch{Candidate: candidate, Criteria: criteria, MatchedSkills: matched, MissingSkills: missing}
+	if weightSum > 0 {
+		match.Score = total / weightSum
+	}
+	if weights.MustHave > 0 && len(missing) > 0 {
+		match.Score *= math.Pow(1-weights.MustHave, float64(len(missing)))
+	}
+	return match
+}
+
+func validateMatchWeights(weights MatchWeights) error {
+	for _, w := range []float64{weights.Skills, weights.MustHave, weights.Experience, weights.Salary, weights.Location, weights.Language} {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return validationErrorf("веса критериев должны быть неотрицательными числами")
+		}
+	}
+	if weights.MustHave > 1 {
+		return validationErrorf("штраф за недостающий навык должен быть от 0 до 1")
+	}
+	if weights.RelatedSkills < 0 || weights.RelatedSkills > 1 || math.IsNaN(weights.RelatedSkills) {
+		return validationErrorf("доля зачёта родственного навыка должна быть от 0 до 1")
+	}
+	if weights.Skills+weights.Experience+weights.Salary+weights.Location+weights.Language == 0 {
+		return validationErrorf("хотя бы один вес критерия должен быть больше нуля")
+	}
+	return nil
+}
+
+// Ранжирует всех кандидатов для вакансии; при равной оценке выше тот, у кого больше совпавших навыков.
+func rankCandidates(app *App, jobOpeningID int, weights MatchWeights, limit int) ([]CandidateMatch, error) {
+	if err := validateMatchWeights(weights); err != nil {
+		return nil, err
+	}
+	weights, err := withSkillGraph(app.DB, weights)
+	if err != nil {
+		return nil, err
+	}
+	if weights, err = withCurrencyRates(app.DB, weights); err != nil {
+		return nil, err
+	}
+	job, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := app.Candidates.List()
+	if err != nil {
+		return nil, err
+	}
+	return rankPool(job, candidates, weights, limit), nil
+}
+
+// Ранжирует уже загруженных кандидатов: пакетный подбор вызывает её для каждой вакансии без повторных запросов.
+func rankPool(job storage.JobOpening, candidates []storage.Candidate, weights MatchWeights, limit int) []CandidateMatch {
+	matches := make([]CandidateMatch, 0, len(candidates))
+	for _, c := range candidates {
+		matches = append(matches, matchCandidate(job, c, weights))
+	}
+	sort.SliceStable(matches, func(i, k int) bool {
+		if matches[i].Score != matches[k].Score {
+			return matches[i].Score > matches[k].Score
+		}
+		return len(matches[i].MatchedSkills) > len(matches[k].MatchedSkills)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+var matchCriterionTitles = map[string]string{
+	"skills":     "навыки",
+	"experience": "опыт",
+	"salary":     "зарплата",
+	"location":   "город",
+	"language":   "языки",
+}
+
+// Для CSV: кандидат — своими ID и ФИО, критерии — только итоговой оценкой.
+func candidateMatchRows(matches []CandidateMatch) interface{} {
+	type row struct {
+		Rank          int      `json:"rank"`
+		CandidateID   int      `json:"candidate_id"`
+		FullName      string   `json:"full_name"`
+		Score         float64  `json:"score"`
+		MatchedSkills []string `json:"matched_skills"`
+		MissingSkills []string `json:"missing_skills"`
+	}
+	rows := make([]row, len(matches))
+	for i, m := range matches {
+		rows[i] = row{Rank: i + 1, CandidateID: m.Candidate.ID, FullName: m.Candidate.FullName, Score: m.Score,
+			MatchedSkills: m.MatchedSkills, MissingSkills: m.MissingSkills}
+	}
+	return rows
+}
+
+func printCandidateMatches(w io.Writer, matches []CandidateMatch) {
+	if len(matches) == 0 {
+		fmt.Fprintln(w, "Кандидатов нет.")
+		return
+	}
+	for i, m := range matches {
+		fmt.Fprintf(w, "%d. %.0f%%  ID: %d, %s\n", i+1, m.Score*100, m.Candidate.ID, m.Candidate.FullName)
+		for _, c := range m.Criteria {
+			fmt.Fprintf(w, "     %-9s %3.0f%% (вес %.2f) — %s\n", matchCriterionTitles[c.Name], c.Score*100, c.Weight, c.Detail)
+		}
+		if len(m.MissingSkills) > 0 {
+			fmt.Fprintf(w, "     не хватает: %s\n", strings.Join(m.MissingSkills, ", "))
+		}
+	}
+}
+
+// Флаги значениями по умолчанию берут текущие веса, поэтому указывать нужно только изменяемые.
+func matchWeightFlags(flags *flag.FlagSet, weights *MatchWeights) {
+	flags.Float64Var(&weights.Skills, "w-skills", weights.Skills, "вес совпадения навыков")
+	flags.Float64Var(&weights.MustHave, "w-must-have", weights.MustHave, "штраф за каждый недостающий навык (0–1)")
+	flags.Float64Var(&weights.Experience, "w-experience", weights.Experience, "вес опыта")
+	flags.Float64Var(&weights.Salary, "w-salary", weights.Salary, "вес ожидаемой зарплаты")
+	flags.Float64Var(&weights.Location, "w-location", weights.Location, "вес совпадения города")
+	flags.Float64Var(&weights.Language, "w-language", weights.Language, "вес знания языков")
+	flags.Float64Var(&weights.RelatedSkills, "w-related", weights.RelatedSkills, "доля зачёта недостающего навыка по родственному (0–1)")
+}
+
+// match <ID вакансии> [-limit N] [-w-skills X] [-w-must-have X] [-w-experience X] [-w-salary X] [-w-location X] [-w-language X] [-w-related X]
+func runMatchCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println("Использование: match <ID вакансии> [-limit N] [-w-skills X] [-w-must-have X] [-w-experience X] [-w-salary X] [-w-location X] [-w-language X] [-w-related X]")
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityJobOpening, args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	weights, err := jobMatchWeights(app, id)
+	if err != nil {
+		return reportError(err)
+	}
+	flags := flag.NewFlagSet("match", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "сколько кандидатов показать (0 — всех)")
+	matchWeightFlags(flags, &weights)
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	matches, err := rankCandidates(app, id, weights, *limit)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{
+		data: matches,
+		text: func(w io.Writer) { printCandidateMatches(w, matches) },
+		csv:  func(w io.Writer) error { return writeDataCSV(w, candidateMatchRows(matches)) },
+	})
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"your_project_name/storage"
+)
+
+type MatchRuleChange struct {
+	ID         int           `json:"id"`
+	CompanyID  int           `json:"company_id"`
+	OldWeights *MatchWeights `json:"old_weights"`
+	NewWeights *MatchWeights `json:"new_weights"`
+	ChangedBy  string        `json:"changed_by,omitempty"`
+	ChangedAt  time.Time     `json:"changed_at"`
+}
+
+// Позиция кандидата до и после изменения весов; Rank 0 — кандидат не попал в выдачу.
+type MatchPreviewRow struct {
+	CandidateID int     `json:"candidate_id"`
+	FullName    string  `json:"full_name"`
+	OldRank     int     `json:"old_rank"`
+	NewRank     int     `json:"new_rank"`
+	OldScore    float64 `json:"old_score"`
+	NewScore    float64 `json:"new_score"`
+}
+
+// Ключи, которых нет в сохранённом JSON, получают значения по умолчанию:
+// так правила, сохранённые до появления нового критерия, продолжают работать.
+func decodeMatchWeights(data []byte) (MatchWeights, error) {
+	weights := defaultMatchWeights
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return MatchWeights{}, fmt.Errorf("ошибка чтения весов подбора: %w", err)
+	}
+	return weights, nil
+}
+
+// Веса компании; если компания их не настраивала — веса по умолчанию.
+func companyMatchWeights(db *sql.DB, companyID int) (MatchWeights, error) {
+	var data []byte
+	err := db.QueryRow("SELECT weights FROM match_rules WHERE company_id = $1", companyID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return withCurrencyRates(db, defaultMatchWeights)
+	}
+	if err != nil {
+		return MatchWeights{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	weights, err := decodeMatchWeights(data)
+	if err != nil {
+		return MatchWeights{}, err
+	}
+	if weights, err = withSkillGraph(db, weights); err != nil {
+		return MatchWeights{}, err
+	}
+	return withCurrencyRates(db, weights)
+}
+
+func jobMatchWeights(app *App, jobOpeningID int) (MatchWeights, error) {
+	job, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return MatchWeights{}, err
+	}
+	return companyMatchWeights(app.DB, job.CompanyID)
+}
+
+// Новые веса и запись аудита сохраняются в одной транзакции; строка правил блокируется,
+// чтобы в аудите старые веса совпадали с реально заменёнными.
+func setMatchWeights(app *App, companyID int, weights MatchWeights, changedBy int) error {
+	if err := validateMatchWeights(weights); err != nil {
+		return err
+	}
+	if _, err := getCompany(app, companyID); err != nil {
+		return err
+	}
+	newJSON, err := json.Marshal(weights)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации весов: %w", err)
+	}
+
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var oldJSON []byte
+		err := tx.QueryRow("SELECT weights FROM match_rules WHERE company_id = $1 FOR UPDATE", companyID).Scan(&oldJSON)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO match_rules (company_id, weights) VALUES ($1, $2)
+			ON CONFLICT (company_id) DO UPDATE SET weights = EXCLUDED.weights, updated_at = now()`, companyID, newJSON)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения весов подбора: %w", err)
+		}
+		_, err = tx.Exec("INSERT INTO match_rule_changes (company_id, old_weights, new_weights, changed_by) VALUES ($1, $2, $3, $4)",
+			companyID, oldJSON, newJSON, nullUserID(changedBy))
+		if err != nil {
+			return fmt.Errorf("ошибка записи аудита: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	publish(app, Event{Kind: eventMatchRulesChanged, ID: companyID})
+	return nil
+}
+
+// Удаляет настройку компании, после чего действуют веса по умолчанию; сброс тоже попадает в аудит.
+func resetMatchWeights(app *App, companyID int, changedBy int) error {
+	reset := false
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		var oldJSON []byte
+		err := tx.QueryRow("DELETE FROM match_rules WHERE company_id = $1 RETURNING weights", companyID).Scan(&oldJSON)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка сброса весов подбора: %w", err)
+		}
+		_, err = tx.Exec("INSERT INTO match_rule_changes (company_id, old_weights, new_weights, changed_by) VALUES ($1, $2, NULL, $3)",
+			companyID, oldJSON, nullUserID(changedBy))
+		if err != nil {
+			return fmt.Errorf("ошибка записи аудита: %w", err)
+		}
+		reset = true
+		return nil
+	})
+	if err != nil || !reset {
+		return err
+	}
+	publish(app, Event{Kind: eventMatchRulesChanged, ID: companyID})
+	return nil
+}
+
+func nullUserID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id > 0}
+}
+
+func matchRuleHistory(db *sql.DB, companyID int) ([]MatchRuleChange, error) {
+	rows, err := db.Query(`
+		SELECT c.id, c.company_id, c.old_weights, c.new_weights, COALESCE(u.username, ''), c.changed_at
+		FROM match_rule_changes c
+		LEFT JOIN users u ON u.id = c.changed_by
+		WHERE c.company_id = $1
+		ORDER BY c.changed_at, c.id`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []MatchRuleChange
+	for rows.Next() {
+		var change MatchRuleChange
+		var oldJSON, newJSON []byte
+		if err := rows.Scan(&change.ID, &change.CompanyID, &oldJSON, &newJSON, &change.ChangedBy, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		for _, w := range []struct {
+			data   []byte
+			target **MatchWeights
+		}{{oldJSON, &change.OldWeights}, {newJSON, &change.NewWeights}} {
+			if w.data == nil {
+				continue
+			}
+			weights, err := decodeMatchWeights(w.data)
+			if err != nil {
+				return nil, err
+			}
+			*w.target = &weights
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return changes, nil
+}
+
+// Ранжирует кандидатов вакансии по действующим весам компании и по предложенным,
+// и показывает первые limit позиций новой выдачи вместе с выбывшими из неё.
+func previewMatchWeights(app *App, jobOpeningID int, weights MatchWeights, limit int) ([]MatchPreviewRow, error) {
+	current, err := jobMatchWeights(app, jobOpeningID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := rankCandidates(app, jobOpeningID, current, 0)
+	if err != nil {
+		return nil, err
+	}
+	after, err := rankCandidates(app, jobOpeningID, weights, 0)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > len(after) {
+		limit = len(after)
+	}
+
+	oldRanks := map[int]int{}
+	oldScores := map[int]float64{}
+	for i, m := range before {
+		oldRanks[m.Candidate.ID] = i + 1
+		oldScores[m.Candidate.ID] = m.Score
+	}
+	newScores := map[int]float64{}
+	for _, m := range after {
+		newScores[m.Candidate.ID] = m.Score
+	}
+
+	shown := map[int]bool{}
+	var rows []MatchPreviewRow
+	for i, m := range after[:limit] {
+		row := MatchPreviewRow{CandidateID: m.Candidate.ID, FullName: m.Candidate.FullName,
+			NewRank: i + 1, NewScore: m.Score, OldScore: oldScores[m.Candidate.ID]}
+		if rank := oldRanks[m.Candidate.ID]; rank <= limit {
+			row.OldRank = rank
+		}
+		shown[m.Candidate.ID] = true
+		rows = append(rows, row)
+	}
+	for i, m := range before {
+		if i >= limit {
+			break
+		}
+		if !shown[m.Candidate.ID] {
+			rows = append(rows, MatchPreviewRow{CandidateID: m.Candidate.ID, FullName: m.Candidate.FullName,
+				OldRank: i + 1, OldScore: m.Score, NewScore: newScores[m.Candidate.ID]})
+		}
+	}
+	return rows, nil
+}
+
+func matchWeightsText(w MatchWeights) string {
+	return fmt.Sprintf("навыки %.2f, штраф за навык %.2f, опыт %.2f, зарплата %.2f, город %.2f, языки %.2f, родственные навыки %.2f",
+		w.Skills, w.MustHave, w.Experience, w.Salary, w.Location, w.Language, w.RelatedSkills)
+}
+
+func printMatchPreview(w io.Writer, rows []MatchPreviewRow) {
+	if len(rows) == 0 {
+		fmt.Fprintln(w, "Кандидатов нет.")
+		return
+	}
+	rank := func(r int) string {
+		if r == 0 {
+			return "—"
+		}
+		return strconv.Itoa(r)
+	}
+	fmt.Fprintf(w, "%-6s %-6s %-8s %-30s %s\n", "Было", "Стало", "ID", "Кандидат", "Оценка")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-6s %-6s %-8d %-30s %.0f%% → %.0f%%\n", rank(r.OldRank), rank(r.NewRank), r.CandidateID, r.FullName, r.OldScore*100, r.NewScore*100)
+	}
+}
+
+// match-rules show <ID компании> | set <ID компании> [-w-...] | reset <ID компании> | preview <ID вакансии> [-limit N] [-w-...] | history <ID компании>
+func runMatchRulesCommand(app *App, args []string) int {
+	usage := "Использование: match-rules show <ID компании> | match-rules set <ID компании> [-w-skills X ...] | match-rules reset <ID компании> | " +
+		"match-rules preview <ID вакансии> [-limit N] [-w-skills X ...] | match-rules history <ID компании>"
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	kind := entityCompany
+	if args[0] == "preview" {
+		kind = entityJobOpening
+	}
+	id, err := resolveEntityID(app.DB, kind, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+
+	switch args[0] {
+	case "show":
+		if _, err := getCompany(app, id); err != nil {
+			return reportError(err)
+		}
+		weights, err := companyMatchWeights(app.DB, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: weights, text: func(w io.Writer) { fmt.Fprintln(w, matchWeightsText(weights)) }})
+	case "set":
+		weights, err := companyMatchWeights(app.DB, id)
+		if err != nil {
+			return reportError(err)
+		}
+		flags := flag.NewFlagSet("match-rules set", flag.ContinueOnError)
+		matchWeightFlags(flags, &weights)
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		if err := setMatchWeights(app, id, weights, sessionUserID()); err != nil {
+			return reportError(err)
+		}
+		return printDone("Веса подбора сохранены: "+matchWeightsText(weights), weights)
+	case "reset":
+		if err := resetMatchWeights(app, id, sessionUserID()); err != nil {
+			return reportError(err)
+		}
+		return printDone("Действуют веса по умолчанию.", defaultMatchWeights)
+	case "preview":
+		weights, err := jobMatchWeights(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		flags := flag.NewFlagSet("match-rules preview", flag.ContinueOnError)
+		limit := flags.Int("limit", 10, "сколько позиций сравнить")
+		matchWeightFlags(flags, &weights)
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		if err := validateMatchWeights(weights); err != nil {
+			return reportError(err)
+		}
+		rows, err := previewMatchWeights(app, id, weights, *limit)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: rows, text: func(w io.Writer) { printMatchPreview(w, rows) }})
+	case "history":
+		changes, err := matchRuleHistory(app.DB, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: changes, text: func(w io.Writer) {
+			for _, c := range changes {
+				who := c.ChangedBy
+				if who == "" {
+					who = "—"
+				}
+				fmt.Fprintf(w, "%s  %s\n", c.ChangedAt.Format("2006-01-02 15:04"), who)
+				if c.OldWeights != nil {
+					fmt.Fprintln(w, "  было: ", matchWeightsText(*c.OldWeights))
+				}
+				if c.NewWeights != nil {
+					fmt.Fprintln(w, "  стало:", matchWeightsText(*c.NewWeights))
+				} else {
+					fmt.Fprintln(w, "  стало: веса по умолчанию")
+				}
+			}
+		}})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Тарифицируемые события пишутся через storage.RecordUsage (vacancy_published — при создании вакансии).
+// Количество хранимых кандидатов не пишется событиями, а считается снимком при месячной агрегации.
+const (
+	usageCandidatesStored = "candidates_stored"
+	usageSMSSent          = "sms_sent"
+
+	usageAggregateJob = "usage.aggregate"
+)
+
+type UsageRecord struct {
+	Month       string `json:"month"`
+	CompanyID   *int   `json:"company_id"`
+	CompanyName string `json:"company,omitempty"`
+	Event       string `json:"event"`
+	Quantity    int64  `json:"quantity"`
+}
+
+func parseUsageMonth(s string) (time.Time, error) {
+	month, err := time.ParseInLocation("2006-01", s, time.UTC)
+	if err != nil {
+		return time.Time{}, validationErrorf("месяц %q должен быть в формате ГГГГ-ММ", s)
+	}
+	return month, nil
+}
+
+// Пересчитывает итоги месяца целиком, поэтому повторный запуск безопасен.
+func aggregateUsage(db *sql.DB, month time.Time) error {
+	start := month
+	end := month.AddDate(0, 1, 0)
+	monthKey := start.Format("2006-01-02")
+
+	return storage.WithTx(db, func(tx storage.DBTX) error {
+		if _, err := tx.Exec("DELETE FROM usage_monthly WHERE month = $1", monthKey); err != nil {
+			return fmt.Errorf("ошибка очистки итогов месяца: %w", err)
+		}
+		_, err := tx.Exec(`INSERT INTO usage_monthly (month, company_id, event, quantity)
+			SELECT $1, company_id, event, SUM(quantity) FROM usage_events
+			WHERE occurred_at >= $2 AND occurred_at < $3
+			GROUP BY company_id, event`, monthKey, start, end)
+		if err != nil {
+			return fmt.Errorf("ошибка агрегации событий: %w", err)
+		}
+		_, err = tx.Exec(`INSERT INTO usage_monthly (month, company_id, event, quantity)
+			SELECT $1, NULL, $2, COUNT(*) FROM candidates WHERE created_at < $3`, monthKey, usageCandidatesStored, end)
+		if err != nil {
+			return fmt.Errorf("ошибка подсчёта кандидатов: %w", err)
+		}
+		return nil
+	})
+}
+
+type usageAggregatePayload struct {
+	Month string `json:"month"`
+}
+
+// Задача агрегирует месяц и ставит в очередь следующий на первое число месяца после него.
+func handleUsageAggregateJob(db *sql.DB, payload json.RawMessage) error {
+	var p usageAggregatePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("неверные параметры задачи: %w", err)
+	}
+	month, err := parseUsageMonth(p.Month)
+	if err != nil {
+		return err
+	}
+	if err := aggregateUsage(db, month); err != nil {
+		return err
+	}
+	next := month.AddDate(0, 1, 0)
+	_, err = enqueueJobAt(db, usageAggregateJob, usageAggregatePayload{Month: next.Format("2006-01")}, next.AddDate(0, 1, 0))
+	return err
+}
+
+func init() {
+	registerJobHandler(usageAggregateJob, handleUsageAggregateJob)
+}
+
+// Запускает цепочку месячных агрегаций, если её ещё нет: первым считается прошлый месяц.
+func scheduleUsageAggregation(db *sql.DB) error {
+	scheduled, err := jobScheduled(db, usageAggregateJob)
+	if err != nil || scheduled {
+		return err
+	}
+	now := time.Now().UTC()
+	previous := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	_, err = enqueueJob(db, usageAggregateJob, usageAggregatePayload{Month: previous.Format("2006-01")})
+	return err
+}
+
+// Пустой month — все агрегированные месяцы.
+func getMonthlyUsage(db *sql.DB, month string) ([]UsageRecord, error) {
+	query := `SELECT to_char(u.month, 'YYYY-MM'), u.company_id, COALESCE(c.name, ''), u.event, u.quantity
+		FROM usage_monthly u LEFT JOIN companies c ON c.id = u.company_id`
+	var args []interface{}
+	if month != "" {
+		start, err := parseUsageMonth(month)
+		if err != nil {
+			return nil, err
+		}
+		query += " WHERE u.month = $1"
+		args = append(args, start.Format("2006-01-02"))
+	}
+	query += " ORDER BY u.month, u.company_id NULLS FIRST, u.event"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var records []UsageRecord
+	for rows.Next() {
+		var r UsageRecord
+		var companyID sql.NullInt64
+		if err := rows.Scan(&r.Month, &companyID, &r.CompanyName, &r.Event, &r.Quantity); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if companyID.Valid {
+			id := int(companyID.Int64)
+			r.CompanyID = &id
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return records, nil
+}
+
+func writeUsageCSV(w io.Writer, records []UsageRecord) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"month", "company_id", "company", "event", "quantity"})
+	for _, r := range records {
+		companyID := ""
+		if r.CompanyID != nil {
+			companyID = strconv.Itoa(*r.CompanyID)
+		}
+		writer.Write([]string{r.Month, companyID, r.CompanyName, r.Event, strconv.FormatInt(r.Quantity, 10)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("ошибка записи CSV: %w", err)
+	}
+	return nil
+}
+
+// usage aggregate <ГГГГ-ММ> | usage export [-month ГГГГ-ММ] [-o файл]
+func runUsageCommand(db *sql.DB, args []string) int {
+	usage := "Использование: usage aggregate <ГГГГ-ММ> | usage export [-month ГГГГ-ММ] [-o файл]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "aggregate":
+		if len(args) != 2 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		month, err := parseUsageMonth(args[1])
+		if err == nil {
+			err = aggregateUsage(db, month)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Итоги месяца пересчитаны.", map[string]string{"month": args[1]})
+	case "export":
+		flags := flag.NewFlagSet("usage export", flag.ContinueOnError)
+		month := flags.String("month", "", "месяц в формате ГГГГ-ММ (по умолчанию все)")
+		output := flags.String("o", "", "файл для CSV (по умолчанию stdout)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		records, err := getMonthlyUsage(db, *month)
+		if err != nil {
+			return reportError(err)
+		}
+		var w io.Writer = os.Stdout
+		if *output != "" {
+			file, err := os.Create(*output)
+			if err != nil {
+				return reportError(fmt.Errorf("ошибка создания файла: %w", err))
+			}
+			defer file.Close()
+			w = file
+		}
+		// Выгрузка всегда табличная, кроме --format json.
+		if outputFormat == formatJSON {
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return reportError(fmt.Errorf("ошибка сериализации: %w", err))
+			}
+			fmt.Fprintln(w, string(data))
+			return exitOK
+		}
+		if err := writeUsageCSV(w, records); err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
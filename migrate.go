@@ -0,0 +1,309 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Новые изменения схемы добавляются файлами migrations/NNNN_имя.up.sql и NNNN_имя.down.sql;
+// уже применённые файлы не редактируются.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Ключ advisory-блокировки: два процесса не применяют одну миграцию одновременно.
+const migrationLockKey = 7_205_314
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций: %w", err)
+	}
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		m := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("неверное имя файла миграции: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %w", entry.Name(), err)
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("у миграции %d два разных имени: %s и %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	var migrations []migration
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("у миграции %d нет файла .up.sql", mig.Version)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, k int) bool { return migrations[i].Version < migrations[k].Version })
+	return migrations, nil
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    )`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы миграций: %w", err)
+	}
+	return nil
+}
+
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return applied, nil
+}
+
+// Каждая миграция выполняется в своей транзакции под advisory-блокировкой; после захвата блокировки
+// состояние проверяется заново, поэтому процесс, ждавший другого, не применит миграцию повторно.
+func runMigration(db *sql.DB, mig migration, up bool) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationLockKey); err != nil {
+		return false, fmt.Errorf("ошибка блокировки миграций: %w", err)
+	}
+	var applied bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", mig.Version).Scan(&applied); err != nil {
+		return false, fmt.Errorf("ошибка проверки миграции: %w", err)
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	if up {
+		if _, err := tx.Exec(mig.Up); err != nil {
+			return false, fmt.Errorf("ошибка применения миграции %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err = tx.Exec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", mig.Version, mig.Name)
+	} else {
+		if mig.Down == "" {
+			return false, fmt.Errorf("миграцию %04d_%s нельзя откатить: нет файла .down.sql", mig.Version, mig.Name)
+		}
+		if _, err := tx.Exec(mig.Down); err != nil {
+			return false, fmt.Errorf("ошибка отката миграции %04d_%s: %w", mig.Version, mig.Name, err)
+		}
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = $1", mig.Version)
+	}
+	if err != nil {
+		return false, fmt.Errorf("ошибка записи состояния миграций: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return true, nil
+}
+
+// Применяет не более steps ожидающих миграций (0 — все) и возвращает применённые.
+func migrateUp(db *sql.DB, steps int) ([]migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	var done []migration
+	for _, mig := range migrations {
+		if steps > 0 && len(done) >= steps {
+			break
+		}
+		ran, err := runMigration(db, mig, true)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+// Откатывает steps последних применённых миграций.
+func migrateDown(db *sql.DB, steps int) ([]migration, error) {
+	if steps <= 0 {
+		return nil, validationErrorf("число откатываемых миграций должно быть положительным")
+	}
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var done []migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+		ran, err := runMigration(db, migrations[i], false)
+		if err != nil {
+			return done, err
+		}
+		if ran {
+			done = append(done, migrations[i])
+		}
+	}
+	return done, nil
+}
+
+func migrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var status []MigrationStatus
+	for _, mig := range migrations {
+		s := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// migrate up [N] | migrate down [N] | migrate status
+// Применённые или откаченные миграции; в JSON и CSV — их номера и имена.
+func printMigrations(done []migration, action, none string) {
+	rows := make([]MigrationStatus, len(done))
+	for i, mig := range done {
+		rows[i] = MigrationStatus{Version: mig.Version, Name: mig.Name}
+	}
+	printView(sharedView{data: rows, text: func(w io.Writer) {
+		for _, mig := range done {
+			fmt.Fprintf(w, "%s %04d_%s\n", action, mig.Version, mig.Name)
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(w, none)
+		}
+	}})
+}
+
+func runMigrateCommand(db *sql.DB, args []string) int {
+	usage := "Использование: migrate up [N] | migrate down [N] | migrate status"
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		steps = n
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := migrateUp(db, steps)
+		printMigrations(done, "Применена миграция", "Схема актуальна.")
+		if err != nil {
+			return reportError(err)
+		}
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		done, err := migrateDown(db, steps)
+		printMigrations(done, "Откачена миграция", "Нет применённых миграций.")
+		if err != nil {
+			return reportError(err)
+		}
+	case "status":
+		if len(args) != 1 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		status, err := migrationStatus(db)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: status, text: func(w io.Writer) {
+			for _, s := range status {
+				applied := "ожидает"
+				if s.AppliedAt != nil {
+					applied = "применена " + s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%04d_%-30s %s\n", s.Version, s.Name, applied)
+			}
+		}})
+	default:
+		fmt.Println(usage)
+		return exitUsage
+	}
+	return exitOK
+}
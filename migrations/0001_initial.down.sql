@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS usage_monthly;
+DROP TABLE IF EXISTS usage_events;
+DROP TABLE IF EXISTS salary_benchmarks;
+DROP TABLE IF EXISTS company_quotas;
+DROP TABLE IF EXISTS notification_settings;
+DROP TABLE IF EXISTS job_templates;
+DROP TABLE IF EXISTS application_status_history;
+DROP TABLE IF EXISTS applications;
+DROP TABLE IF EXISTS job_openings;
+DROP TABLE IF EXISTS candidates;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS companies;
@@ -0,0 +1,144 @@
+-- Базовая схема на момент перехода на миграции. Все операторы идемпотентны,
+-- чтобы миграция применялась и к базам, созданным прежним createTables.
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'user'
+);
+
+CREATE TABLE IF NOT EXISTS companies (
+    id SERIAL PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL
+);
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+UPDATE users SET role = 'viewer' WHERE role = 'user';
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS candidates (
+    id SERIAL PRIMARY KEY,
+    full_name TEXT NOT NULL,
+    age INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    phone TEXT NOT NULL DEFAULT '',
+    experience TEXT,
+    skills JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS expected_salary NUMERIC(10,2) NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS candidates_phone_idx ON candidates (phone) WHERE phone <> '';
+
+CREATE TABLE IF NOT EXISTS job_openings (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    experience TEXT,
+    salary NUMERIC(10,2) NOT NULL,
+    required_skills JSONB
+);
+
+CREATE TABLE IF NOT EXISTS applications (
+    id SERIAL PRIMARY KEY,
+    candidate_id INTEGER NOT NULL REFERENCES candidates(id) ON DELETE CASCADE,
+    job_opening_id INTEGER NOT NULL REFERENCES job_openings(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'new',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (candidate_id, job_opening_id)
+);
+
+CREATE INDEX IF NOT EXISTS applications_job_opening_idx ON applications (job_opening_id);
+
+CREATE TABLE IF NOT EXISTS application_status_history (
+    id SERIAL PRIMARY KEY,
+    application_id INTEGER NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL DEFAULT '',
+    to_status TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS application_status_history_application_idx ON application_status_history (application_id);
+
+CREATE TABLE IF NOT EXISTS job_templates (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    experience TEXT NOT NULL DEFAULT '',
+    required_skills JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE TABLE IF NOT EXISTS notification_settings (
+    company_id INTEGER PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    email TEXT NOT NULL DEFAULT '',
+    notify_new_candidates BOOLEAN NOT NULL DEFAULT true,
+    daily_digest BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS company_quotas (
+    company_id INTEGER PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    max_job_openings INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS salary_benchmarks (
+    skill TEXT NOT NULL,
+    region TEXT NOT NULL DEFAULT '',
+    p25 NUMERIC(10,2) NOT NULL DEFAULT 0,
+    median NUMERIC(10,2) NOT NULL,
+    p75 NUMERIC(10,2) NOT NULL DEFAULT 0,
+    sample_size INTEGER NOT NULL DEFAULT 0,
+    source TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (skill, region)
+);
+
+CREATE TABLE IF NOT EXISTS usage_events (
+    id BIGSERIAL PRIMARY KEY,
+    company_id INTEGER REFERENCES companies(id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS usage_events_occurred_idx ON usage_events (occurred_at);
+
+CREATE TABLE IF NOT EXISTS usage_monthly (
+    month DATE NOT NULL,
+    company_id INTEGER REFERENCES companies(id) ON DELETE SET NULL,
+    event TEXT NOT NULL,
+    quantity BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS usage_monthly_month_idx ON usage_monthly (month);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 5,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS jobs_pending_idx ON jobs (run_at) WHERE status = 'pending';
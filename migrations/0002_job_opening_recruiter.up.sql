@@ -0,0 +1,2 @@
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS recruiter_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS job_openings_recruiter_idx ON job_openings (recruiter_id);
@@ -0,0 +1,6 @@
+-- Добровольно указанные данные хранятся отдельно от профиля кандидата и не попадают в экспорт.
+CREATE TABLE IF NOT EXISTS candidate_demographics (
+    candidate_id INTEGER PRIMARY KEY REFERENCES candidates(id) ON DELETE CASCADE,
+    gender TEXT NOT NULL,
+    consented_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS hire_outcomes;
+ALTER TABLE applications DROP COLUMN IF EXISTS source;
@@ -0,0 +1,9 @@
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+
+-- Последнее подтверждение, что нанятый кандидат работает, и дата ухода, если он ушёл.
+CREATE TABLE IF NOT EXISTS hire_outcomes (
+    application_id INTEGER PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    confirmed_at DATE,
+    left_at DATE,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE job_openings DROP COLUMN IF EXISTS status;
@@ -0,0 +1,2 @@
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'open';
+CREATE INDEX IF NOT EXISTS job_openings_status_idx ON job_openings (status);
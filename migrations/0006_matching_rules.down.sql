@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS match_rule_changes;
+DROP TABLE IF EXISTS match_rules;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS languages;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS location;
+ALTER TABLE candidates DROP COLUMN IF EXISTS languages;
+ALTER TABLE candidates DROP COLUMN IF EXISTS location;
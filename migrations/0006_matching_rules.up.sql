@@ -0,0 +1,22 @@
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT '';
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS languages JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS location TEXT NOT NULL DEFAULT '';
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS languages JSONB NOT NULL DEFAULT '[]';
+
+-- Веса подбора кандидатов компании; без записи действуют веса по умолчанию.
+CREATE TABLE IF NOT EXISTS match_rules (
+    company_id INTEGER PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    weights JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS match_rule_changes (
+    id SERIAL PRIMARY KEY,
+    company_id INTEGER NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    old_weights JSONB,
+    new_weights JSONB,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS match_rule_changes_company_idx ON match_rule_changes (company_id, changed_at);
@@ -0,0 +1,12 @@
+-- Лучшие кандидаты по открытым вакансиям из пакетного подбора; first_matched_at — когда пара впервые попала в выдачу.
+CREATE TABLE IF NOT EXISTS match_results (
+    job_opening_id INTEGER NOT NULL REFERENCES job_openings(id) ON DELETE CASCADE,
+    candidate_id INTEGER NOT NULL REFERENCES candidates(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    rank INTEGER NOT NULL,
+    first_matched_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (job_opening_id, candidate_id)
+);
+
+CREATE INDEX IF NOT EXISTS match_results_first_matched_idx ON match_results (first_matched_at);
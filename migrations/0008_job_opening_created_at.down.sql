@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS candidates_expected_salary_idx;
+DROP INDEX IF EXISTS job_openings_salary_idx;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,5 @@
+-- Дата создания вакансии для сортировки списков; существующим вакансиям проставляется время миграции.
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS job_openings_salary_idx ON job_openings (salary);
+CREATE INDEX IF NOT EXISTS candidates_expected_salary_idx ON candidates (expected_salary);
@@ -0,0 +1,2 @@
+ALTER TABLE match_results DROP COLUMN IF EXISTS notified_at;
+DROP TABLE IF EXISTS notifications;
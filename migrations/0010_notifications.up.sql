@@ -0,0 +1,21 @@
+-- Очередь исходящих писем: неотправленное письмо остаётся в pending и повторяется с нарастающей паузой,
+-- после NOTIFY_MAX_ATTEMPTS попыток переходит в failed.
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS notifications_due_idx ON notifications (next_attempt_at) WHERE status = 'pending';
+
+-- Когда кандидату сообщили о попадании в выдачу вакансии; уже найденные пары считаются сообщёнными.
+ALTER TABLE match_results ADD COLUMN IF NOT EXISTS notified_at TIMESTAMPTZ;
+UPDATE match_results SET notified_at = now() WHERE notified_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS job_openings_company_status_idx;
+ALTER TABLE companies DROP COLUMN IF EXISTS industry;
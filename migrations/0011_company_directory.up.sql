@@ -0,0 +1,3 @@
+-- Отрасль компании для поиска по каталогу; пустая строка — не указана.
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS industry TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS job_openings_company_status_idx ON job_openings (company_id, status);
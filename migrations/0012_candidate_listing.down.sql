@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS applications_candidate_updated_idx;
+DROP INDEX IF EXISTS candidates_tags_idx;
+DROP INDEX IF EXISTS candidates_status_idx;
+ALTER TABLE candidates DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE candidates DROP COLUMN IF EXISTS available_from;
+ALTER TABLE candidates DROP COLUMN IF EXISTS tags;
+ALTER TABLE candidates DROP COLUMN IF EXISTS status;
@@ -0,0 +1,10 @@
+-- Статус поиска работы, теги рекрутеров, дата выхода и время последнего изменения профиля для фильтров списка кандидатов.
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS available_from DATE;
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+UPDATE candidates SET updated_at = created_at;
+
+CREATE INDEX IF NOT EXISTS candidates_status_idx ON candidates (status);
+CREATE INDEX IF NOT EXISTS candidates_tags_idx ON candidates USING GIN (tags);
+CREATE INDEX IF NOT EXISTS applications_candidate_updated_idx ON applications (candidate_id, updated_at);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS search_history;
+DROP INDEX IF EXISTS saved_searches_owner_name_idx;
+-- Из одноимённых поисков разных пользователей остаётся самый ранний.
+DELETE FROM saved_searches s USING saved_searches t WHERE s.name = t.name AND s.id > t.id;
+ALTER TABLE saved_searches ADD CONSTRAINT saved_searches_name_key UNIQUE (name);
+ALTER TABLE saved_searches DROP CONSTRAINT IF EXISTS saved_searches_created_by_fkey;
+ALTER TABLE saved_searches ADD CONSTRAINT saved_searches_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,15 @@
+-- Сохранённые поиски принадлежат пользователю (created_by), поиски без владельца — общие.
+-- Имя уникально в пределах владельца; при удалении пользователя его поиски удаляются вместе с ним.
+ALTER TABLE saved_searches DROP CONSTRAINT IF EXISTS saved_searches_name_key;
+ALTER TABLE saved_searches DROP CONSTRAINT IF EXISTS saved_searches_created_by_fkey;
+ALTER TABLE saved_searches ADD CONSTRAINT saved_searches_created_by_fkey FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX IF NOT EXISTS saved_searches_owner_name_idx ON saved_searches ((COALESCE(created_by, 0)), name);
+
+CREATE TABLE IF NOT EXISTS search_history (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    query TEXT NOT NULL,
+    results INTEGER NOT NULL DEFAULT 0,
+    searched_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS search_history_user_idx ON search_history (user_id, id DESC);
@@ -0,0 +1,9 @@
+-- Недавно открытые пользователем кандидаты, вакансии и компании; повторное открытие только обновляет время.
+CREATE TABLE IF NOT EXISTS recent_views (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, entity, entity_id)
+);
+CREATE INDEX IF NOT EXISTS recent_views_user_idx ON recent_views (user_id, viewed_at DESC);
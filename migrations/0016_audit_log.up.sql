@@ -0,0 +1,14 @@
+-- Журнал изменений данных: кто, когда и что изменил. Для изменения хранятся только поменявшиеся поля.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('insert', 'update', 'delete')),
+    old_values JSONB,
+    new_values JSONB,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id);
+CREATE INDEX IF NOT EXISTS audit_log_user_idx ON audit_log (user_id);
+CREATE INDEX IF NOT EXISTS audit_log_changed_at_idx ON audit_log (changed_at);
@@ -0,0 +1,11 @@
+-- Черновики незаконченных форм добавления: значения уже введённых полей по ключам.
+CREATE TABLE IF NOT EXISTS form_drafts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    form TEXT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    fields JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS form_drafts_user_idx ON form_drafts (user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS form_drafts_updated_at_idx ON form_drafts (updated_at);
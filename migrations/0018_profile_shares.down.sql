@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS profile_share_views;
+DROP TABLE IF EXISTS profile_shares;
@@ -0,0 +1,21 @@
+-- Ссылки на профиль кандидата для тех, у кого нет учётной записи. Хранится только SHA-256 токена.
+CREATE TABLE IF NOT EXISTS profile_shares (
+    id SERIAL PRIMARY KEY,
+    candidate_id INTEGER NOT NULL REFERENCES candidates(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    fields TEXT[] NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS profile_shares_candidate_idx ON profile_shares (candidate_id);
+
+-- Каждый просмотр профиля по ссылке.
+CREATE TABLE IF NOT EXISTS profile_share_views (
+    id BIGSERIAL PRIMARY KEY,
+    share_id INTEGER NOT NULL REFERENCES profile_shares(id) ON DELETE CASCADE,
+    via TEXT NOT NULL,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS profile_share_views_share_idx ON profile_share_views (share_id, viewed_at);
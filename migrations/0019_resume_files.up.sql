@@ -0,0 +1,13 @@
+-- Файл резюме кандидата: сам файл лежит в хранилище (каталог или S3) под storage_key, здесь — его описание.
+-- У кандидата одно текущее резюме; замена перезаписывает строку.
+CREATE TABLE IF NOT EXISTS resume_files (
+    id SERIAL PRIMARY KEY,
+    candidate_id INTEGER NOT NULL UNIQUE REFERENCES candidates(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    uploaded_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    uploaded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public_applications;
+DROP TABLE IF EXISTS apply_forms;
@@ -0,0 +1,18 @@
+-- Публичная форма отклика на вакансию: ссылка с токеном, у вакансии одна действующая форма.
+CREATE TABLE IF NOT EXISTS apply_forms (
+    id SERIAL PRIMARY KEY,
+    job_opening_id INTEGER NOT NULL REFERENCES job_openings(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS apply_forms_active_idx ON apply_forms (job_opening_id) WHERE revoked_at IS NULL;
+
+-- Отклики, пришедшие через форму, и согласие кандидата на обработку данных.
+CREATE TABLE IF NOT EXISTS public_applications (
+    application_id INTEGER PRIMARY KEY REFERENCES applications(id) ON DELETE CASCADE,
+    form_id INTEGER NOT NULL REFERENCES apply_forms(id) ON DELETE CASCADE,
+    consent_text TEXT NOT NULL,
+    consented_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
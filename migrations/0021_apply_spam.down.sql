@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS apply_review_queue;
+DROP TABLE IF EXISTS apply_blocklist;
+DROP TABLE IF EXISTS apply_attempts;
+ALTER TABLE public_applications DROP COLUMN IF EXISTS client_ip;
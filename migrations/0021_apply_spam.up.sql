@@ -0,0 +1,37 @@
+-- Защита публичной формы отклика от спама.
+ALTER TABLE public_applications ADD COLUMN IF NOT EXISTS client_ip TEXT NOT NULL DEFAULT '';
+
+-- Каждая попытка отклика с адреса: по ним считается ограничение частоты.
+CREATE TABLE IF NOT EXISTS apply_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    client_ip TEXT NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS apply_attempts_ip_idx ON apply_attempts (client_ip, attempted_at);
+
+-- Запрещённые адреса, email и домены: отклики с них не принимаются.
+CREATE TABLE IF NOT EXISTS apply_blocklist (
+    id SERIAL PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('ip', 'email', 'domain')),
+    value TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (kind, value)
+);
+
+-- Подозрительные отклики ждут решения здесь и попадают в воронку только после одобрения.
+CREATE TABLE IF NOT EXISTS apply_review_queue (
+    id SERIAL PRIMARY KEY,
+    form_id INTEGER NOT NULL REFERENCES apply_forms(id) ON DELETE CASCADE,
+    request JSONB NOT NULL,
+    resume BYTEA,
+    client_ip TEXT NOT NULL DEFAULT '',
+    reasons TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decision TEXT CHECK (decision IN ('approved', 'rejected')),
+    application_id INTEGER REFERENCES applications(id) ON DELETE SET NULL,
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS apply_review_queue_pending_idx ON apply_review_queue (created_at) WHERE decision IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS notifications_batch_idx;
+DROP INDEX IF EXISTS notifications_dedup_idx;
+ALTER TABLE notifications DROP COLUMN IF EXISTS summary;
+ALTER TABLE notifications DROP COLUMN IF EXISTS batch_key;
+ALTER TABLE notifications DROP COLUMN IF EXISTS dedup_key;
@@ -0,0 +1,9 @@
+-- Дедупликация и объединение писем. dedup_key — одно и то же событие для получателя: повторное письмо
+-- с тем же ключом в пределах NOTIFY_DEDUP_WINDOW не ставится. batch_key — письма, которые можно
+-- объединить: пачка писем одному получателю с одним ключом уходит одним письмом-сводкой, summary —
+-- строка письма в сводке.
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS dedup_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS batch_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS notifications_dedup_idx ON notifications (recipient, dedup_key, created_at) WHERE dedup_key <> '';
+CREATE INDEX IF NOT EXISTS notifications_batch_idx ON notifications (recipient, batch_key) WHERE status = 'pending' AND batch_key <> '';
@@ -0,0 +1,13 @@
+ALTER TABLE candidates DROP COLUMN IF EXISTS salary_currency;
+
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS salary NUMERIC(10,2) NOT NULL DEFAULT 0;
+UPDATE job_openings SET salary = GREATEST(salary_min, salary_max);
+DROP INDEX IF EXISTS job_openings_salary_range_idx;
+ALTER TABLE job_openings DROP CONSTRAINT IF EXISTS job_openings_salary_range_check;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS salary_currency;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS salary_max;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS salary_min;
+ALTER TABLE job_openings ALTER COLUMN salary DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS job_openings_salary_idx ON job_openings (salary);
+
+DROP TABLE IF EXISTS currency_rates;
@@ -0,0 +1,22 @@
+-- Вилка зарплаты вместо одной суммы и валюта у вакансий и ожиданий кандидатов. Нижняя или верхняя
+-- граница может быть 0 — «от …» или «до …», но хотя бы одна задана. Курс в currency_rates — стоимость
+-- единицы валюты в базовой валюте (у базовой курс 1); валюта без курса в вакансии и у кандидата не
+-- указывается, поэтому суммы всегда можно сравнить.
+CREATE TABLE IF NOT EXISTS currency_rates (
+    currency TEXT PRIMARY KEY CHECK (currency ~ '^[A-Z]{3}$'),
+    rate NUMERIC(18,6) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO currency_rates (currency, rate) VALUES ('RUB', 1) ON CONFLICT (currency) DO NOTHING;
+
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS salary_min NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS salary_max NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE job_openings ADD COLUMN IF NOT EXISTS salary_currency TEXT NOT NULL DEFAULT 'RUB' REFERENCES currency_rates (currency);
+UPDATE job_openings SET salary_min = salary, salary_max = salary;
+DROP INDEX IF EXISTS job_openings_salary_idx;
+ALTER TABLE job_openings DROP COLUMN IF EXISTS salary;
+ALTER TABLE job_openings ADD CONSTRAINT job_openings_salary_range_check
+    CHECK (salary_min >= 0 AND salary_max >= 0 AND (salary_min > 0 OR salary_max > 0) AND (salary_max = 0 OR salary_min <= salary_max));
+CREATE INDEX IF NOT EXISTS job_openings_salary_range_idx ON job_openings (salary_min, salary_max);
+
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS salary_currency TEXT NOT NULL DEFAULT 'RUB' REFERENCES currency_rates (currency);
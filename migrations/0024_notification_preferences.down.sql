@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS users_email_lower_idx;
+DROP TABLE IF EXISTS notification_policies;
+DROP TABLE IF EXISTS notification_quiet_hours;
+DROP TABLE IF EXISTS notification_preferences;
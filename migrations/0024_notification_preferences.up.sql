@@ -0,0 +1,30 @@
+-- Настройки уведомлений пользователя. notification_preferences — явно заданные пользователем
+-- вид события × канал (вкл/выкл); чего здесь нет, берётся из умолчаний роли. notification_quiet_hours —
+-- тихие часы: минуты от полуночи в часовом поясе пользователя ('' — пояс сервера), окно может
+-- переходить через полночь. notification_policies — виды, которые администратор сделал обязательными:
+-- их нельзя отключить, и тихие часы их не задерживают.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    channel TEXT NOT NULL DEFAULT 'email',
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, kind, channel)
+);
+
+CREATE TABLE IF NOT EXISTS notification_quiet_hours (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    start_minute SMALLINT NOT NULL CHECK (start_minute BETWEEN 0 AND 1439),
+    end_minute SMALLINT NOT NULL CHECK (end_minute BETWEEN 0 AND 1439),
+    time_zone TEXT NOT NULL DEFAULT '',
+    CHECK (start_minute <> end_minute)
+);
+
+CREATE TABLE IF NOT EXISTS notification_policies (
+    kind TEXT PRIMARY KEY,
+    mandatory BOOLEAN NOT NULL DEFAULT false,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email)) WHERE email <> '';
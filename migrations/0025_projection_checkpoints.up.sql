@@ -0,0 +1,14 @@
+-- Контрольные точки перестройки проекций из журнала изменений (audit_log). position — id последней
+-- обработанной записи журнала, target — до какой записи идёт текущий проход; после сбоя проход
+-- продолжается с position. lease_until не даёт двум процессам перестраивать одну проекцию
+-- одновременно; упавший процесс аренду не снимает, и она истекает сама.
+CREATE TABLE IF NOT EXISTS projection_checkpoints (
+    projection TEXT PRIMARY KEY,
+    position BIGINT NOT NULL DEFAULT 0,
+    target BIGINT NOT NULL DEFAULT 0,
+    events BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    lease_until TIMESTAMPTZ
+);
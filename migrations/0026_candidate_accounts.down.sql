@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS candidates_user_idx;
+ALTER TABLE candidates DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,4 @@
+-- Учётная запись кандидата: пользователь с ролью candidate связывается со своей анкетой.
+-- У анкеты не больше одной учётной записи и наоборот; при удалении пользователя анкета остаётся.
+ALTER TABLE candidates ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS candidates_user_idx ON candidates (user_id) WHERE user_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Рекрутер и администратор компании привязаны к компании и меняют только её вакансии.
+-- При удалении компании учётные записи её сотрудников остаются, но теряют привязку.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_company_id_fkey;
+ALTER TABLE users ADD CONSTRAINT users_company_id_fkey FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS users_company_idx ON users (company_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS applications_versions ON applications;
+DROP FUNCTION IF EXISTS application_versions_track();
+DROP TABLE IF EXISTS application_versions;
@@ -0,0 +1,57 @@
+-- История строк откликов: каждая версия действовала с valid_from до valid_to (NULL — текущая). Версии
+-- пишет триггер при любом изменении отклика, поэтому история не зависит от того, какой код меняет строку.
+-- Версии удалённых откликов остаются до очистки по сроку хранения.
+CREATE TABLE IF NOT EXISTS application_versions (
+    id BIGSERIAL PRIMARY KEY,
+    application_id INTEGER NOT NULL,
+    candidate_id INTEGER NOT NULL,
+    job_opening_id INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS application_versions_application_idx ON application_versions (application_id, valid_from);
+CREATE INDEX IF NOT EXISTS application_versions_period_idx ON application_versions (valid_from, valid_to);
+CREATE INDEX IF NOT EXISTS application_versions_closed_idx ON application_versions (valid_to) WHERE valid_to IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS application_versions_current_idx ON application_versions (application_id) WHERE valid_to IS NULL;
+
+-- Прошлое уже существующих откликов восстанавливается по истории статусов.
+INSERT INTO application_versions (application_id, candidate_id, job_opening_id, status, source, created_at, updated_at, valid_from, valid_to)
+SELECT a.id, a.candidate_id, a.job_opening_id, h.to_status, a.source, a.created_at, h.changed_at, h.changed_at,
+    LEAD(h.changed_at) OVER (PARTITION BY h.application_id ORDER BY h.changed_at, h.id)
+FROM application_status_history h
+JOIN applications a ON a.id = h.application_id;
+
+-- Отклики без истории и отклики, история которых начинается не с создания.
+INSERT INTO application_versions (application_id, candidate_id, job_opening_id, status, source, created_at, updated_at, valid_from, valid_to)
+SELECT a.id, a.candidate_id, a.job_opening_id, COALESCE(f.from_status, a.status), a.source, a.created_at, a.created_at, a.created_at, f.changed_at
+FROM applications a
+LEFT JOIN LATERAL (
+    SELECT from_status, changed_at FROM application_status_history
+    WHERE application_id = a.id ORDER BY changed_at, id LIMIT 1
+) f ON true
+WHERE f.changed_at IS NULL OR f.from_status <> '';
+
+CREATE OR REPLACE FUNCTION application_versions_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW IS NOT DISTINCT FROM OLD THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE application_versions SET valid_to = now() WHERE application_id = OLD.id AND valid_to IS NULL;
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    INSERT INTO application_versions (application_id, candidate_id, job_opening_id, status, source, created_at, updated_at, valid_from)
+    VALUES (NEW.id, NEW.candidate_id, NEW.job_opening_id, NEW.status, NEW.source, NEW.created_at, NEW.updated_at, now());
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS applications_versions ON applications;
+CREATE TRIGGER applications_versions AFTER INSERT OR UPDATE OR DELETE ON applications
+    FOR EACH ROW EXECUTE FUNCTION application_versions_track();
@@ -0,0 +1,2 @@
+-- Отдельная задача очистки истории откликов больше не существует, возвращать нечего.
+SELECT 1;
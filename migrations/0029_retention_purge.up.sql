@@ -0,0 +1,2 @@
+-- Очистку старой истории откликов теперь выполняет общая задача retention.purge.
+DELETE FROM jobs WHERE kind = 'applications.history_cleanup' AND status IN ('pending', 'running');
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS applications_legal_hold ON applications;
+DROP TRIGGER IF EXISTS candidates_legal_hold ON candidates;
+DROP FUNCTION IF EXISTS legal_hold_guard();
+DROP VIEW IF EXISTS legal_hold_records;
+DROP TABLE IF EXISTS legal_holds;
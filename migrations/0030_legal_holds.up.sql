@@ -0,0 +1,51 @@
+-- Юридические удержания: пока удержание не снято, кандидат или отклик не удаляются ни вручную, ни очисткой
+-- по сроку хранения. Удержание ставится либо на кандидата (вместе со всеми его откликами), либо на один отклик.
+CREATE TABLE IF NOT EXISTS legal_holds (
+    id SERIAL PRIMARY KEY,
+    candidate_id INTEGER REFERENCES candidates(id) ON DELETE CASCADE,
+    application_id INTEGER REFERENCES applications(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason <> ''),
+    placed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    placed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    released_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    released_at TIMESTAMPTZ,
+    release_reason TEXT NOT NULL DEFAULT '',
+    CHECK ((candidate_id IS NULL) <> (application_id IS NULL))
+);
+CREATE INDEX IF NOT EXISTS legal_holds_candidate_idx ON legal_holds (candidate_id) WHERE released_at IS NULL;
+CREATE INDEX IF NOT EXISTS legal_holds_application_idx ON legal_holds (application_id) WHERE released_at IS NULL;
+
+-- Записи под действующими удержаниями. Строка с пустым application_id — удержание на самого кандидата;
+-- отклик под удержанием удерживает и своего кандидата, иначе отклик ушёл бы при его удалении.
+CREATE OR REPLACE VIEW legal_hold_records AS
+SELECT h.id AS hold_id, h.candidate_id, NULL::INTEGER AS application_id
+FROM legal_holds h WHERE h.released_at IS NULL AND h.candidate_id IS NOT NULL
+UNION ALL
+SELECT h.id, a.candidate_id, a.id
+FROM legal_holds h JOIN applications a ON a.candidate_id = h.candidate_id WHERE h.released_at IS NULL
+UNION ALL
+SELECT h.id, a.candidate_id, a.id
+FROM legal_holds h JOIN applications a ON a.id = h.application_id WHERE h.released_at IS NULL;
+
+-- Запрет удаления в самой базе, чтобы удержание действовало и при удалении вакансии или компании
+-- вместе с откликами.
+CREATE OR REPLACE FUNCTION legal_hold_guard() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'candidates' AND EXISTS (SELECT 1 FROM legal_hold_records WHERE candidate_id = OLD.id) THEN
+        RAISE EXCEPTION 'кандидат % под юридическим удержанием, удаление запрещено', OLD.id
+            USING ERRCODE = 'integrity_constraint_violation';
+    END IF;
+    IF TG_TABLE_NAME = 'applications' AND EXISTS (SELECT 1 FROM legal_hold_records WHERE application_id = OLD.id) THEN
+        RAISE EXCEPTION 'отклик % под юридическим удержанием, удаление запрещено', OLD.id
+            USING ERRCODE = 'integrity_constraint_violation';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS candidates_legal_hold ON candidates;
+CREATE TRIGGER candidates_legal_hold BEFORE DELETE ON candidates
+    FOR EACH ROW EXECUTE FUNCTION legal_hold_guard();
+DROP TRIGGER IF EXISTS applications_legal_hold ON applications;
+CREATE TRIGGER applications_legal_hold BEFORE DELETE ON applications
+    FOR EACH ROW EXECUTE FUNCTION legal_hold_guard();
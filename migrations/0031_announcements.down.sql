@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS announcement_acks;
+DROP TABLE IF EXISTS announcements;
@@ -0,0 +1,22 @@
+-- Объявления администраторов (технические работы, изменения правил). Пустой roles — для всех ролей,
+-- company_id — только для сотрудников этой компании. Объявление показывается, пока пользователь его
+-- не отметит прочитанным, не истечёт expires_at или его не снимут.
+CREATE TABLE IF NOT EXISTS announcements (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL CHECK (title <> ''),
+    body TEXT NOT NULL DEFAULT '',
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    company_id INTEGER REFERENCES companies(id) ON DELETE CASCADE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    withdrawn_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS announcement_acks (
+    announcement_id INTEGER NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    acknowledged_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (announcement_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS announcement_acks_user_idx ON announcement_acks (user_id);
@@ -0,0 +1,2 @@
+-- Удалённые письма и погашенные коды не восстанавливаются.
+SELECT 1;
@@ -0,0 +1,4 @@
+-- Письма о сбросе пароля раньше ставились в очередь вместе с самим кодом. Такие письма удаляются, а
+-- выданные тогда коды гасятся: их текст мог остаться в резервных копиях notifications.
+DELETE FROM notifications WHERE kind = 'password_reset';
+UPDATE password_reset_tokens SET used_at = now() WHERE used_at IS NULL;
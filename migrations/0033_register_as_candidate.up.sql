@@ -0,0 +1,3 @@
+-- Самостоятельно зарегистрированные пользователи получают роль candidate, а не viewer: у viewer есть
+-- доступ к кандидатам и откликам.
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'candidate';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS candidates_email_idx;
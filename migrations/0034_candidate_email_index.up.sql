@@ -0,0 +1,2 @@
+-- Публичная форма отклика ищет кандидата по email без учёта регистра.
+CREATE INDEX IF NOT EXISTS candidates_email_idx ON candidates (lower(email));
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+const ndjsonSchemaVersion = 1
+
+const ndjsonBatchSize = 500
+
+// Формат выгрузки не зависит от внутренних структур: ссылки между сущностями
+// передаются по естественным ключам (компания — по названию), а не по ID.
+type ndjsonRecord struct {
+	Type    string          `json:"type"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type ndjsonCompany struct {
+	Name      string     `json:"name"`
+	Status    string     `json:"status,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ndjsonUser struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	Role         string `json:"role"`
+	Company      string `json:"company,omitempty"`
+	MustChange   bool   `json:"must_change_password,omitempty"`
+}
+
+type ndjsonCandidate struct {
+	FullName       string   `json:"full_name"`
+	Age            int      `json:"age"`
+	Email          string   `json:"email"`
+	Phone          string   `json:"phone"`
+	Experience     string   `json:"experience"`
+	Skills         []string `json:"skills"`
+	ExpectedSalary float64  `json:"expected_salary,omitempty"`
+	SalaryCurrency string   `json:"salary_currency,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	Languages      []string `json:"languages,omitempty"`
+}
+
+type ndjsonJobOpening struct {
+	Company        string  `json:"company"`
+	Title          string  `json:"title"`
+	Experience     string  `json:"experience"`
+	SalaryMin      float64 `json:"salary_min"`
+	SalaryMax      float64 `json:"salary_max"`
+	SalaryCurrency string  `json:"salary_currency,omitempty"`
+	// Одна сумма из выгрузок, сделанных до появления вилок; при импорте становится обеими границами.
+	Salary         float64  `json:"salary,omitempty"`
+	RequiredSkills []string `json:"required_skills"`
+	Status         string   `json:"status,omitempty"`
+	Location       string   `json:"location,omitempty"`
+	Languages      []string `json:"languages,omitempty"`
+}
+
+type ndjsonImportStats struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors"`
+}
+
+func writeNDJSONRecord(w *bufio.Writer, recordType string, data interface{}) error {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи: %w", err)
+	}
+	line, err := json.Marshal(ndjsonRecord{Type: recordType, Version: ndjsonSchemaVersion, Data: dataJSON})
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации записи: %w", err)
+	}
+	w.Write(line)
+	return w.WriteByte('\n')
+}
+
+// Строки пишутся по мере чтения из базы, вся выгрузка в памяти не держится.
+// Порядок важен для импорта: компании идут раньше вакансий.
+func exportNDJSON(db *sql.DB, path string) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	count := 0
+	exports := []struct {
+		query string
+		write func(rows *sql.Rows) error
+	}{
+		{"SELECT name, status, expires_at FROM companies ORDER BY id", func(rows *sql.Rows) error {
+			var c ndjsonCompany
+			var expiresAt sql.NullTime
+			if err := rows.Scan(&c.Name, &c.Status, &expiresAt); err != nil {
+				return err
+			}
+			if expiresAt.Valid {
+				c.ExpiresAt = &expiresAt.Time
+			}
+			return writeNDJSONRecord(w, "company", c)
+		}},
+		{`SELECT u.username, u.password_hash, u.role, COALESCE(c.name, ''), u.must_change_password
+			FROM users u LEFT JOIN companies c ON c.id = u.company_id ORDER BY u.id`, func(rows *sql.Rows) error {
+			var u ndjsonUser
+			if err := rows.Scan(&u.Username, &u.PasswordHash, &u.Role, &u.Company, &u.MustChange); err != nil {
+				return err
+			}
+			return writeNDJSONRecord(w, "user", u)
+		}},
+		{`SELECT full_name, age, email, phone, experience, skills, expected_salary, salary_currency, location, languages
+          FROM candidates ORDER BY id`, func(rows *sql.Rows) error {
+			var c ndjsonCandidate
+			var experience sql.NullString
+			var skillsJSON, languagesJSON []byte
+			if err := rows.Scan(&c.FullName, &c.Age, &c.Email, &c.Phone, &experience, &skillsJSON, &c.ExpectedSalary, &c.SalaryCurrency, &c.Location,
+				&languagesJSON); err != nil {
+				return err
+			}
+			c.Experience = experience.String
+			json.Unmarshal(skillsJSON, &c.Skills)
+			json.Unmarshal(languagesJSON, &c.Languages)
+			return writeNDJSONRecord(w, "candidate", c)
+		}},
+		{`SELECT c.name, j.title, j.experience, j.salary_min, j.salary_max, j.salary_currency, j.required_skills, j.status, j.location, j.languages
+          FROM job_openings j JOIN companies c ON c.id = j.company_id ORDER BY j.id`, func(rows *sql.Rows) error {
+			var j ndjsonJobOpening
+			var experience sql.NullString
+			var skillsJSON, languagesJSON []byte
+			if err := rows.Scan(&j.Company, &j.Title, &experience, &j.SalaryMin, &j.SalaryMax, &j.SalaryCurrency, &skillsJSON, &j.Status, &j.Location,
+				&languagesJSON); err != nil {
+				return err
+			}
+			j.Experience = experience.String
+			json.Unmarshal(skillsJSON, &j.RequiredSkills)
+			json.Unmarshal(languagesJSON, &j.Languages)
+			return writeNDJSONRecord(w, "job_opening", j)
+		}},
+	}
+
+	for _, e := range exports {
+		rows, err := db.Query(e.query)
+		if err != nil {
+			return count, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		for rows.Next() {
+			if err := e.write(rows); err != nil {
+				rows.Close()
+				return count, fmt.Errorf("ошибка выгрузки строки: %w", err)
+			}
+			count++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return count, fmt.Errorf("ошибка чтения строк: %w", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return count, fmt.Errorf("ошибка записи файла: %w", err)
+	}
+	return count, nil
+}
+
+func readCheckpoint(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения контрольной точки: %w", err)
+	}
+	line, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("повреждённая контрольная точка %s: %w", path, err)
+	}
+	return line, nil
+}
+
+func importNDJSONRecord(tx *sql.Tx, record ndjsonRecord) error {
+	if record.Version != ndjsonSchemaVersion {
+		return fmt.Errorf("неподдерживаемая версия схемы %d", record.Version)
+	}
+
+	switch record.Type {
+	case "company":
+		var c ndjsonCompany
+		if err := json.Unmarshal(record.Data, &c); err != nil {
+			return fmt.Errorf("неверные данные компании: %w", err)
+		}
+		name, err := sanitizeText("название компании", c.Name, maxNameLength)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return errors.New("имя компании не может быть пустым")
+		}
+		switch c.Status {
+		case "":
+			c.Status = storage.CompanyActive
+		case storage.CompanyTrial, storage.CompanyActive, storage.CompanySuspended, storage.CompanyExpired:
+		default:
+			return validationErrorf("неизвестный статус компании %q", c.Status)
+		}
+		_, err = tx.Exec("INSERT INTO companies (name, status, expires_at) VALUES ($1, $2, $3) ON CONFLICT (name) DO NOTHING",
+			name, c.Status, c.ExpiresAt)
+		return err
+	case "user":
+		var u ndjsonUser
+		if err := json.Unmarshal(record.Data, &u); err != nil {
+			return fmt.Errorf("неверные данные пользователя: %w", err)
+		}
+		if u.Username == "" || u.PasswordHash == "" {
+			return errors.New("у пользователя должны быть имя и хеш пароля")
+		}
+		if u.Role == "" || u.Role == "user" {
+			u.Role = storage.RoleViewer
+		}
+		if err := validateRole(u.Role); err != nil {
+			return err
+		}
+		var companyID sql.NullInt64
+		if u.Company != "" {
+			companyName := normalizeText(u.Company, false)
+			err := tx.QueryRow("SELECT id FROM companies WHERE name = $1", companyName).Scan(&companyID)
+			if err == sql.ErrNoRows {
+				return notFoundError(fmt.Sprintf("компания «%s» не найдена", companyName))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`INSERT INTO users (username, password_hash, role, company_id, must_change_password)
+			VALUES ($1, $2, $3, $4, $5) ON CONFLICT (username) DO NOTHING`,
+			u.Username, u.PasswordHash, u.Role, companyID, u.MustChange)
+		return err
+	case "candidate":
+		var c ndjsonCandidate
+		if err := json.Unmarshal(record.Data, &c); err != nil {
+			return fmt.Errorf("неверные данные кандидата: %w", err)
+		}
+		candidate := storage.Candidate{FullName: c.FullName, Age: c.Age, Email: c.Email, Phone: c.Phone, Experience: c.Experience, Skills: c.Skills,
+			ExpectedSalary: c.ExpectedSalary, SalaryCurrency: c.SalaryCurrency, Location: c.Location, Languages: c.Languages}
+		if err := sanitizeCandidate(&candidate); err != nil {
+			return err
+		}
+		var err error
+		if candidate.SalaryCurrency, err = normalizeCurrency(candidate.SalaryCurrency); err != nil {
+			return err
+		}
+		if candidate.SalaryCurrency == "" {
+			candidate.SalaryCurrency = salaryCurrency()
+		}
+		if candidate.FullName == "" || candidate.Age <= 0 {
+			return errors.New("не все обязательные поля заполнены для кандидата")
+		}
+		if err := validateEmailSyntax(candidate.Email); err != nil {
+			return err
+		}
+		skillsJSON, _ := json.Marshal(candidate.Skills)
+		languagesJSON, _ := json.Marshal(candidate.Languages)
+		_, err = tx.Exec(`INSERT INTO candidates (full_name, age, email, phone, experience, skills, expected_salary, salary_currency, location,
+				languages)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			candidate.FullName, candidate.Age, candidate.Email, candidate.Phone, candidate.Experience, skillsJSON, candidate.ExpectedSalary,
+			candidate.SalaryCurrency, candidate.Location, languagesJSON)
+		return err
+	case "job_opening":
+		var j ndjsonJobOpening
+		if err := json.Unmarshal(record.Data, &j); err != nil {
+			return fmt.Errorf("неверные данные вакансии: %w", err)
+		}
+		if j.SalaryMin == 0 && j.SalaryMax == 0 {
+			j.SalaryMin, j.SalaryMax = j.Salary, j.Salary
+		}
+		jobOpening := storage.JobOpening{Title: j.Title, Experience: j.Experience, SalaryMin: j.SalaryMin, SalaryMax: j.SalaryMax,
+			SalaryCurrency: j.SalaryCurrency, RequiredSkills: j.RequiredSkills, Status: j.Status, Location: j.Location, Languages: j.Languages}
+		if err := sanitizeJobOpening(&jobOpening); err != nil {
+			return err
+		}
+		if jobOpening.Status == "" {
+			jobOpening.Status = storage.JobOpen
+		}
+		if err := validateJobStatus(jobOpening.Status); err != nil {
+			return err
+		}
+		companyName := normalizeText(j.Company, false)
+		err := tx.QueryRow("SELECT id FROM companies WHERE name = $1", companyName).Scan(&jobOpening.CompanyID)
+		if err == sql.ErrNoRows {
+			return notFoundError(fmt.Sprintf("компания «%s» не найдена", companyName))
+		}
+		if err != nil {
+			return err
+		}
+		if jobOpening.Title == "" {
+			return errors.New("не все обязательные поля заполнены для вакансии")
+		}
+		if err := validateSalaryRange(jobOpening.SalaryMin, jobOpening.SalaryMax); err != nil {
+			return err
+		}
+		if jobOpening.SalaryCurrency, err = normalizeCurrency(jobOpening.SalaryCurrency); err != nil {
+			return err
+		}
+		if jobOpening.SalaryCurrency == "" {
+			jobOpening.SalaryCurrency = salaryCurrency()
+		}
+		skillsJSON, _ := json.Marshal(jobOpening.RequiredSkills)
+		languagesJSON, _ := json.Marshal(jobOpening.Languages)
+		_, err = tx.Exec(`INSERT INTO job_openings (company_id, title, experience, salary_min, salary_max, salary_currency, required_skills, status,
+				location, languages)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			jobOpening.CompanyID, jobOpening.Title, jobOpening.Experience, jobOpening.SalaryMin, jobOpening.SalaryMax, jobOpening.SalaryCurrency,
+			skillsJSON, jobOpening.Status, jobOpening.Location, languagesJSON)
+		return err
+	}
+	return fmt.Errorf("неизвестный тип записи %q", record.Type)
+}
+
+// Импорт идёт пачками по ndjsonBatchSize строк, каждая пачка — отдельная транзакция.
+// После фиксации пачки номер строки пишется в <файл>.checkpoint, поэтому прерванный
+// импорт продолжается с последней зафиксированной пачки без дублей.
+func importNDJSON(db *sql.DB, path string, restart bool) (ndjsonImportStats, error) {
+	var stats ndjsonImportStats
+	checkpointPath := path + ".checkpoint"
+
+	startLine := 0
+	if !restart {
+		var err error
+		if startLine, err = readCheckpoint(checkpointPath); err != nil {
+			return stats, err
+		}
+		if startLine > 0 {
+			fmt.Printf("Продолжение импорта со строки %d.\n", startLine+1)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return stats, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+
+	lineNumber := 0
+	var tx *sql.Tx
+	commit := func() error {
+		if tx == nil {
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+		}
+		tx = nil
+		if err := os.WriteFile(checkpointPath, []byte(strconv.Itoa(lineNumber)), 0644); err != nil {
+			return fmt.Errorf("ошибка записи контрольной точки: %w", err)
+		}
+		return nil
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for scanner.Scan() {
+		lineNumber++
+		if lineNumber <= startLine {
+			continue
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if tx == nil {
+			if tx, err = db.Begin(); err != nil {
+				return stats, fmt.Errorf("ошибка начала транзакции: %w", err)
+			}
+		}
+
+		var record ndjsonRecord
+		err := json.Unmarshal([]byte(line), &record)
+		if err == nil {
+			if _, err = tx.Exec("SAVEPOINT ndjson_record"); err != nil {
+				return stats, fmt.Errorf("ошибка транзакции: %w", err)
+			}
+			err = importNDJSONRecord(tx, record)
+			if err != nil {
+				if _, rbErr := tx.Exec("ROLLBACK TO SAVEPOINT ndjson_record"); rbErr != nil {
+					return stats, fmt.Errorf("ошибка транзакции: %w", rbErr)
+				}
+			}
+		}
+		if err != nil {
+			stats.Skipped++
+			message, _ := describeError(err)
+			stats.Errors = append(stats.Errors, fmt.Sprintf("строка %d: %s", lineNumber, message))
+		} else {
+			stats.Imported++
+		}
+
+		if lineNumber%ndjsonBatchSize == 0 {
+			if err := commit(); err != nil {
+				return stats, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, fmt.Errorf("ошибка чтения файла: %w", err)
+	}
+	if err := commit(); err != nil {
+		return stats, err
+	}
+
+	os.Remove(checkpointPath)
+	return stats, nil
+}
+
+func printNDJSONImportStats(w io.Writer, stats ndjsonImportStats) {
+	fmt.Fprintf(w, "Импортировано записей: %d, пропущено: %d\n", stats.Imported, stats.Skipped)
+	for _, e := range stats.Errors {
+		fmt.Fprintln(w, " ", e)
+	}
+}
+
+func runNDJSONExportCommand(db *sql.DB, args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Использование: ndjson-export <файл>")
+		return exitUsage
+	}
+	count, err := exportNDJSON(db, args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	return printDone(fmt.Sprintf("Выгружено записей: %d", count), map[string]int{"exported": count})
+}
+
+func runNDJSONImportCommand(db *sql.DB, args []string) int {
+	flags := flag.NewFlagSet("ndjson-import", flag.ContinueOnError)
+	restart := flags.Bool("restart", false, "игнорировать контрольную точку и начать сначала")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Println("Использование: ndjson-import [-restart] <файл>")
+		return exitUsage
+	}
+	stats, err := importNDJSON(db, flags.Arg(0), *restart)
+	printView(sharedView{data: stats, text: func(w io.Writer) { printNDJSONImportStats(w, stats) }})
+	if err != nil {
+		return reportError(err)
+	}
+	return exitOK
+}
+
+func ndjsonMenu(db *sql.DB) {
+	fmt.Println("1. Выгрузить все данные в NDJSON")
+	fmt.Println("2. Загрузить данные из NDJSON")
+	choice, err := getIntInput("Введите номер действия: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+
+	switch choice {
+	case 1:
+		path := getInput("Введите путь к файлу: ")
+		count, err := exportNDJSON(db, path)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Выгружено записей: %d\n", count)
+		}
+	case 2:
+		path := getInput("Введите путь к файлу: ")
+		stats, err := importNDJSON(db, path, false)
+		printNDJSONImportStats(os.Stdout, stats)
+		handleError(err)
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
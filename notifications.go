@@ -0,0 +1,804 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+// Письма о событиях: новая подходящая вакансия для кандидата, новый подходящий кандидат для рекрутера вакансии,
+// смена статуса отклика, приглашение на собеседование, код сброса пароля.
+// Письма сначала попадают в таблицу notifications и отправляются задачей notifications.send,
+// поэтому сбой SMTP не теряет их, а только откладывает.
+//
+// Одно событие не сообщается получателю дважды за NOTIFY_DEDUP_WINDOW (24h). Письма о подборе не уходят
+// сразу: первое ждёт NOTIFY_BATCH_WINDOW (10m), следующие присоединяются к нему, и вся пачка уходит
+// одним письмом-сводкой — импорт 500 подходящих кандидатов даёт рекрутеру одно письмо, а не 500.
+//
+// Настройки (см. config.go): SMTP_HOST, SMTP_PORT (587), SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM;
+// NOTIFY_INTERVAL (1m) — как часто отправлять очередь, NOTIFY_MAX_ATTEMPTS (5) — сколько попыток до failed,
+// NOTIFY_TEMPLATES_DIR — каталог с шаблонами <вид>.subject.tmpl и <вид>.body.tmpl вместо встроенных.
+// Без SMTP_HOST уведомления выключены и в очередь не ставятся.
+//
+// Какие письма получает пользователь и когда, он настраивает сам (см. notifyprefs.go).
+
+const (
+	notifyNewMatch           = "new_match"
+	notifyNewCandidate       = "new_candidate"
+	notifyApplicationStatus  = "application_status"
+	notifyInterviewScheduled = "interview_scheduled"
+	notifyPasswordReset      = "password_reset"
+)
+
+const (
+	notificationPending = "pending"
+	notificationSent    = "sent"
+	notificationFailed  = "failed"
+	// Получатель отключил такие уведомления, пока письмо ждало отправки.
+	notificationSkipped = "skipped"
+)
+
+const notificationsJob = "notifications.send"
+
+type NotificationData struct {
+	CandidateName string
+	CandidateURL  string
+	JobTitle      string
+	JobURL        string
+	CompanyName   string
+	Status        string
+	// Получатель-пользователь: рекрутер вакансии или тот, кто сбрасывает пароль.
+	Username string
+	// Код сброса пароля; такие письма не ставятся в очередь (см. passwordreset.go).
+	Token    string
+	ValidFor string
+}
+
+type notificationTemplate struct {
+	Subject string
+	Body    string
+	// Строка письма в сводке; у писем без сводки не задаётся.
+	Summary string
+	// Сводка из нескольких писем; данные — notificationDigest.
+	DigestSubject string
+	DigestBody    string
+}
+
+type notificationDigest struct {
+	Count int
+	Items []string
+}
+
+var notificationTemplates = map[string]notificationTemplate{
+	notifyNewMatch: {
+		Subject: "Новая подходящая вакансия: {{.JobTitle}}",
+		Body: `Здравствуйте, {{.CandidateName}}!
+
+Вакансия «{{.JobTitle}}»{{if .CompanyName}} компании {{.CompanyName}}{{end}} хорошо подходит под ваш профиль.
+Если она вам интересна, откликнитесь или свяжитесь с рекрутером.
+
+Вакансия: {{.JobURL}}
+`,
+		Summary:       "«{{.JobTitle}}»{{if .CompanyName}}, {{.CompanyName}}{{end}}: {{.JobURL}}",
+		DigestSubject: "Новые подходящие вакансии: {{.Count}}",
+		DigestBody: `Здравствуйте!
+
+Под ваш профиль подошли новые вакансии ({{.Count}}):
+
+{{range .Items}}— {{.}}
+{{end}}
+Если какая-то из них вам интересна, откликнитесь или свяжитесь с рекрутером.
+`,
+	},
+	notifyNewCandidate: {
+		Subject: "Новый подходящий кандидат на вакансию {{.JobTitle}}",
+		Body: `Здравствуйте, {{.Username}}!
+
+Кандидат {{.CandidateName}} хорошо подходит под вакансию «{{.JobTitle}}».
+
+Кандидат: {{.CandidateURL}}
+Вакансия: {{.JobURL}}
+`,
+		Summary:       "{{.CandidateName}} — «{{.JobTitle}}»: {{.CandidateURL}}",
+		DigestSubject: "Новые подходящие кандидаты: {{.Count}}",
+		DigestBody: `Здравствуйте!
+
+На ваши вакансии нашлись новые подходящие кандидаты ({{.Count}}):
+
+{{range .Items}}— {{.}}
+{{end}}`,
+	},
+	notifyApplicationStatus: {
+		Subject: "Статус отклика на вакансию {{.JobTitle}}: {{.Status}}",
+		Body: `Здравствуйте, {{.CandidateName}}!
+
+Статус вашего отклика на вакансию «{{.JobTitle}}»{{if .CompanyName}} компании {{.CompanyName}}{{end}} изменился: {{.Status}}.
+
+Вакансия: {{.JobURL}}
+`,
+	},
+	notifyInterviewScheduled: {
+		Subject: "Приглашение на собеседование: {{.JobTitle}}",
+		Body: `Здравствуйте, {{.CandidateName}}!
+
+Вас приглашают на собеседование по вакансии «{{.JobTitle}}»{{if .CompanyName}} компании {{.CompanyName}}{{end}}.
+Рекрутер свяжется с вами, чтобы согласовать время.
+
+Вакансия: {{.JobURL}}
+`,
+	},
+	notifyPasswordReset: {
+		Subject: "Сброс пароля",
+		Body: `Здравствуйте, {{.Username}}!
+
+Код для сброса пароля: {{.Token}}
+Код действует {{.ValidFor}}. Если вы не запрашивали сброс, просто проигнорируйте это письмо.
+`,
+	},
+}
+
+type smtpConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func loadSMTPConfig() smtpConfig {
+	cfg := smtpConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.From == "" {
+		cfg.From = cfg.Username
+	}
+	return cfg
+}
+
+func (c smtpConfig) enabled() bool {
+	return c.Host != ""
+}
+
+func notifyInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("NOTIFY_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// Сколько письмо о подборе ждёт, собирая пачку; 0 — не ждать, объединяются только письма, ждущие отправки одновременно.
+func notifyBatchWindow() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("NOTIFY_BATCH_WINDOW")); err == nil && d >= 0 {
+		return d
+	}
+	return 10 * time.Minute
+}
+
+func notifyDedupWindow() time.Duration {
+	return tokenTTL("NOTIFY_DEDUP_WINDOW", 24*time.Hour)
+}
+
+func notifyMaxAttempts() int {
+	if n, err := strconv.Atoi(os.Getenv("NOTIFY_MAX_ATTEMPTS")); err == nil && n > 0 {
+		return n
+	}
+	return 5
+}
+
+// Шаблон из NOTIFY_TEMPLATES_DIR, если файл есть, иначе встроенный.
+func loadNotificationTemplate(kind string) (notificationTemplate, error) {
+	tmpl, ok := notificationTemplates[kind]
+	if !ok {
+		return tmpl, fmt.Errorf("неизвестный вид уведомления %q", kind)
+	}
+	dir := os.Getenv("NOTIFY_TEMPLATES_DIR")
+	if dir == "" {
+		return tmpl, nil
+	}
+	files := map[string]*string{"subject": &tmpl.Subject, "body": &tmpl.Body, "summary": &tmpl.Summary,
+		"digest.subject": &tmpl.DigestSubject, "digest.body": &tmpl.DigestBody}
+	for name, target := range files {
+		data, err := os.ReadFile(filepath.Join(dir, kind+"."+name+".tmpl"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return tmpl, fmt.Errorf("ошибка чтения шаблона уведомления: %w", err)
+		}
+		*target = string(data)
+	}
+	return tmpl, nil
+}
+
+func renderTemplate(name, text string, data interface{}) (string, error) {
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("ошибка в шаблоне уведомления %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("ошибка в шаблоне уведомления %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Тема — одна строка: перевод строки в ней позволил бы дописать заголовки письма.
+func subjectLine(subject string) string {
+	return strings.Join(strings.Fields(subject), " ")
+}
+
+// Тема, текст и строка для сводки.
+func renderNotification(kind string, data NotificationData) (string, string, string, error) {
+	tmpl, err := loadNotificationTemplate(kind)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject, err := renderTemplate(kind+".subject", tmpl.Subject, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	body, err := renderTemplate(kind+".body", tmpl.Body, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	summary, err := renderTemplate(kind+".summary", tmpl.Summary, data)
+	if err != nil {
+		return "", "", "", err
+	}
+	return subjectLine(subject), body, strings.TrimSpace(summary), nil
+}
+
+func renderDigest(kind string, items []string) (string, string, error) {
+	tmpl, err := loadNotificationTemplate(kind)
+	if err != nil {
+		return "", "", err
+	}
+	data := notificationDigest{Count: len(items), Items: items}
+	subject, err := renderTemplate(kind+".digest.subject", tmpl.DigestSubject, data)
+	if err != nil {
+		return "", "", err
+	}
+	body, err := renderTemplate(kind+".digest.body", tmpl.DigestBody, data)
+	if err != nil {
+		return "", "", err
+	}
+	return subjectLine(subject), body, nil
+}
+
+// Ставит письмо в очередь; без адреса получателя или без настроенного SMTP ничего не делает.
+// dedupKey — событие, о котором письмо («match:5:17»): если о нём уже писали получателю за NOTIFY_DEDUP_WINDOW,
+// письмо не ставится; пустой ключ — без проверки. Письмо вида со сводкой присоединяется к ждущей пачке
+// того же вида для того же получателя или открывает новую на NOTIFY_BATCH_WINDOW. Письмо вида, отключённого
+// получателем, не ставится, а в его тихие часы — ставится на их конец.
+func queueNotification(q storage.DBTX, kind, recipient, dedupKey string, data NotificationData) error {
+	if data.Token != "" {
+		return errors.New("письмо с кодом сброса нельзя ставить в очередь: код попал бы в базу")
+	}
+	if recipient == "" || !loadSMTPConfig().enabled() {
+		return nil
+	}
+	policy, err := newNotificationPolicy(q)
+	if err != nil {
+		return err
+	}
+	allowed, notBefore, err := policy.decide(kind, channelEmail, recipient, time.Now())
+	if err != nil || !allowed {
+		return err
+	}
+	if dedupKey != "" {
+		var sent bool
+		err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM notifications WHERE recipient = $1 AND dedup_key = $2 AND created_at > $3)",
+			recipient, dedupKey, time.Now().Add(-notifyDedupWindow())).Scan(&sent)
+		if err != nil {
+			return fmt.Errorf("ошибка проверки повторного уведомления: %w", err)
+		}
+		if sent {
+			return nil
+		}
+	}
+	subject, body, summary, err := renderNotification(kind, data)
+	if err != nil {
+		return err
+	}
+	batchKey, sendAt := "", time.Now()
+	if notificationTemplates[kind].DigestBody != "" {
+		batchKey, sendAt = kind, sendAt.Add(notifyBatchWindow())
+	}
+	if notBefore.After(sendAt) {
+		sendAt = notBefore
+	}
+	_, err = q.Exec(`INSERT INTO notifications (kind, recipient, subject, body, summary, dedup_key, batch_key, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE((
+			SELECT MIN(next_attempt_at) FROM notifications
+			WHERE recipient = $2 AND batch_key = $7 AND $7 <> '' AND status = 'pending' AND attempts = 0), $8))`,
+		kind, recipient, subject, body, summary, dedupKey, batchKey, sendAt)
+	if err != nil {
+		return fmt.Errorf("ошибка постановки уведомления в очередь: %w", err)
+	}
+	return nil
+}
+
+func buildEmail(from, to, subject, body string) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+func sendEmail(cfg smtpConfig, to, subject, body string) error {
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return smtp.SendMail(cfg.Host+":"+cfg.Port, auth, cfg.From, []string{to}, buildEmail(cfg.From, to, subject, body))
+}
+
+// Пауза перед следующей попыткой удваивается с каждой неудачей, но не превышает шести часов.
+func notificationBackoff(attempts int) time.Duration {
+	delay := time.Minute << uint(attempts-1)
+	if attempts > 10 || delay > 6*time.Hour {
+		return 6 * time.Hour
+	}
+	return delay
+}
+
+type NotificationDelivery struct {
+	Sent    int `json:"sent"`
+	Retried int `json:"retried"`
+	Failed  int `json:"failed"`
+	// Не отправлено: получатель отключил такие уведомления или у него тихие часы.
+	Skipped  int `json:"skipped"`
+	Deferred int `json:"deferred"`
+	// Сколько уведомлений ушло в составе писем-сводок.
+	Merged int `json:"merged"`
+}
+
+type outgoingNotification struct {
+	id, attempts                                      int
+	kind, recipient, subject, body, summary, batchKey string
+}
+
+func scanOutgoingNotifications(rows *sql.Rows) ([]outgoingNotification, error) {
+	defer rows.Close()
+	var due []outgoingNotification
+	for rows.Next() {
+		var n outgoingNotification
+		if err := rows.Scan(&n.id, &n.kind, &n.recipient, &n.subject, &n.body, &n.summary, &n.batchKey, &n.attempts); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		due = append(due, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return due, nil
+}
+
+const outgoingNotificationColumns = "id, kind, recipient, subject, body, summary, batch_key, attempts"
+
+// Отправляет письма, срок которых подошёл; письма одной пачки уходят одним письмом-сводкой.
+// Строки блокируются с SKIP LOCKED, поэтому несколько обработчиков задач не отправят одно письмо дважды.
+func deliverDueNotifications(db *sql.DB, limit int) (NotificationDelivery, error) {
+	var result NotificationDelivery
+	cfg := loadSMTPConfig()
+	if !cfg.enabled() {
+		return result, validationErrorf("SMTP не настроен: задайте SMTP_HOST")
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT `+outgoingNotificationColumns+` FROM notifications
+		WHERE status = $1 AND next_attempt_at <= now()
+		ORDER BY next_attempt_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`, notificationPending, limit)
+	if err != nil {
+		return result, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	due, err := scanOutgoingNotifications(rows)
+	if err != nil {
+		return result, err
+	}
+
+	// Письма группируются по получателю и пачке; письма пачки, не вошедшие в limit, добираются отдельно,
+	// чтобы пачка не разошлась на несколько сводок.
+	var messages [][]outgoingNotification
+	batches := map[[2]string]int{}
+	var ids []int
+	for _, n := range due {
+		ids = append(ids, n.id)
+		if n.batchKey == "" {
+			messages = append(messages, []outgoingNotification{n})
+			continue
+		}
+		key := [2]string{n.recipient, n.batchKey}
+		if i, ok := batches[key]; ok {
+			messages[i] = append(messages[i], n)
+			continue
+		}
+		batches[key] = len(messages)
+		messages = append(messages, []outgoingNotification{n})
+	}
+	for key, i := range batches {
+		rows, err := tx.Query(`
+			SELECT `+outgoingNotificationColumns+` FROM notifications
+			WHERE status = $1 AND next_attempt_at <= now() AND recipient = $2 AND batch_key = $3 AND NOT (id = ANY($4))
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED`, notificationPending, key[0], key[1], pq.Array(ids))
+		if err != nil {
+			return result, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		rest, err := scanOutgoingNotifications(rows)
+		if err != nil {
+			return result, err
+		}
+		messages[i] = append(messages[i], rest...)
+	}
+
+	policy, err := newNotificationPolicy(tx)
+	if err != nil {
+		return result, err
+	}
+	maxAttempts := notifyMaxAttempts()
+	for _, parts := range messages {
+		first := parts[0]
+		subject, body := first.subject, first.body
+		partIDs := make([]int, len(parts))
+		summaries := make([]string, len(parts))
+		attempts := 0
+		for i, n := range parts {
+			partIDs[i], summaries[i] = n.id, n.summary
+			if n.attempts > attempts {
+				attempts = n.attempts
+			}
+		}
+		// Настройки могли измениться, пока письмо ждало; отложенное до конца тихих часов попыткой не считается.
+		allowed, notBefore, err := policy.decide(first.kind, channelEmail, first.recipient, time.Now())
+		if err != nil {
+			return result, err
+		}
+		switch {
+		case !allowed:
+			_, err = tx.Exec("UPDATE notifications SET status = $1 WHERE id = ANY($2)", notificationSkipped, pq.Array(partIDs))
+			result.Skipped++
+		case notBefore.After(time.Now()):
+			_, err = tx.Exec("UPDATE notifications SET next_attempt_at = $1 WHERE id = ANY($2)", notBefore, pq.Array(partIDs))
+			result.Deferred++
+		}
+		if err != nil {
+			return result, fmt.Errorf("ошибка обновления уведомления: %w", err)
+		}
+		if !allowed || notBefore.After(time.Now()) {
+			continue
+		}
+		attempts++
+		if len(parts) > 1 {
+			if subject, body, err = renderDigest(first.kind, summaries); err != nil {
+				return result, err
+			}
+		}
+		sendErr := sendEmail(cfg, first.recipient, subject, body)
+		switch {
+		case sendErr == nil:
+			_, err = tx.Exec("UPDATE notifications SET status = $1, attempts = $2, last_error = '', sent_at = now() WHERE id = ANY($3)",
+				notificationSent, attempts, pq.Array(partIDs))
+			result.Sent++
+			if len(parts) > 1 {
+				result.Merged += len(parts)
+			}
+		case attempts >= maxAttempts:
+			_, err = tx.Exec("UPDATE notifications SET status = $1, attempts = $2, last_error = $3 WHERE id = ANY($4)",
+				notificationFailed, attempts, sendErr.Error(), pq.Array(partIDs))
+			result.Failed++
+		default:
+			_, err = tx.Exec("UPDATE notifications SET attempts = $1, last_error = $2, next_attempt_at = $3 WHERE id = ANY($4)",
+				attempts, sendErr.Error(), time.Now().Add(notificationBackoff(attempts)), pq.Array(partIDs))
+			result.Retried++
+		}
+		if err != nil {
+			return result, fmt.Errorf("ошибка обновления уведомления: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return result, nil
+}
+
+// Письма кандидатам о вакансиях, в выдачу которых они впервые попали. За раз обрабатывается
+// не больше limit пар, остальные дождутся следующего запуска.
+func queueNewMatchNotifications(db *sql.DB, limit int) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT m.job_opening_id, m.candidate_id, c.full_name, c.email, j.title, COALESCE(co.name, ''),
+			COALESCE(u.email, ''), COALESCE(u.username, '')
+		FROM match_results m
+		JOIN candidates c ON c.id = m.candidate_id
+		JOIN job_openings j ON j.id = m.job_opening_id
+		LEFT JOIN companies co ON co.id = j.company_id
+		LEFT JOIN users u ON u.id = j.recruiter_id
+		WHERE m.notified_at IS NULL AND j.status = $1
+		ORDER BY m.first_matched_at
+		LIMIT $2
+		FOR UPDATE OF m SKIP LOCKED`, storage.JobOpen, limit)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	type pair struct {
+		jobID, candidateID    int
+		email, recruiterEmail string
+		data                  NotificationData
+	}
+	var pairs []pair
+	for rows.Next() {
+		var p pair
+		if err := rows.Scan(&p.jobID, &p.candidateID, &p.data.CandidateName, &p.email, &p.data.JobTitle, &p.data.CompanyName,
+			&p.recruiterEmail, &p.data.Username); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		p.data.JobURL = entityURL(entityJobOpening, p.jobID)
+		p.data.CandidateURL = entityURL(entityCandidate, p.candidateID)
+		pairs = append(pairs, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	queued := 0
+	for _, p := range pairs {
+		dedupKey := fmt.Sprintf("match:%d:%d", p.jobID, p.candidateID)
+		for kind, recipient := range map[string]string{notifyNewMatch: p.email, notifyNewCandidate: p.recruiterEmail} {
+			if recipient == "" {
+				continue
+			}
+			if err := queueNotification(tx, kind, recipient, dedupKey, p.data); err != nil {
+				return 0, err
+			}
+			queued++
+		}
+		_, err := tx.Exec("UPDATE match_results SET notified_at = now() WHERE job_opening_id = $1 AND candidate_id = $2", p.jobID, p.candidateID)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка обновления результатов подбора: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return queued, nil
+}
+
+func handleNotificationsJob(db *sql.DB, payload json.RawMessage) error {
+	if _, err := queueNewMatchNotifications(db, 500); err != nil {
+		return err
+	}
+	if _, err := deliverDueNotifications(db, 100); err != nil {
+		return err
+	}
+	_, err := enqueueJobAt(db, notificationsJob, struct{}{}, time.Now().Add(notifyInterval()))
+	return err
+}
+
+// Задача ставится, только если SMTP настроен.
+func scheduleNotifications(db *sql.DB) error {
+	if !loadSMTPConfig().enabled() {
+		return nil
+	}
+	scheduled, err := jobScheduled(db, notificationsJob)
+	if err != nil || scheduled {
+		return err
+	}
+	_, err = enqueueJob(db, notificationsJob, struct{}{})
+	return err
+}
+
+// Письмо кандидату о смене статуса отклика; переход на собеседование — отдельное приглашение.
+func notifyApplicationStatusChanged(app *App, event Event) error {
+	application, err := app.Applications.GetByID(event.ID)
+	if err != nil {
+		return err
+	}
+	kind := notifyApplicationStatus
+	switch application.Status {
+	case storage.ApplicationNew:
+		return nil
+	case storage.ApplicationInterview:
+		kind = notifyInterviewScheduled
+	}
+	candidate, err := app.Candidates.GetByID(application.CandidateID)
+	if err != nil {
+		return err
+	}
+	job, err := app.JobOpenings.GetByID(application.JobOpeningID)
+	if err != nil {
+		return err
+	}
+	data := NotificationData{CandidateName: candidate.FullName, JobTitle: job.Title, JobURL: entityURL(entityJobOpening, job.ID),
+		Status: applicationStatusTitles[application.Status]}
+	if company, err := app.Companies.GetByID(job.CompanyID); err == nil {
+		data.CompanyName = company.Name
+	}
+	return queueNotification(app.DB, kind, candidate.Email, fmt.Sprintf("application:%d:%s", application.ID, application.Status), data)
+}
+
+func init() {
+	registerJobHandler(notificationsJob, handleNotificationsJob)
+	subscribe(eventApplicationStatusChanged, notifyApplicationStatusChanged)
+}
+
+type Notification struct {
+	ID        int        `json:"id"`
+	Kind      string     `json:"kind"`
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+func listNotifications(db *sql.DB, status string, limit int) ([]Notification, error) {
+	rows, err := db.Query(`
+		SELECT id, kind, recipient, subject, status, attempts, last_error, created_at, sent_at
+		FROM notifications
+		WHERE $1 = '' OR status = $1
+		ORDER BY id DESC
+		LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var notifications []Notification
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Recipient, &n.Subject, &n.Status, &n.Attempts, &n.LastError, &n.CreatedAt, &n.SentAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return notifications, nil
+}
+
+// Возвращает письмо, исчерпавшее попытки, в очередь с обнулённым счётчиком.
+func retryNotification(db *sql.DB, id int) error {
+	result, err := db.Exec(`UPDATE notifications SET status = $1, attempts = 0, next_attempt_at = now()
+		WHERE id = $2 AND status = $3`, notificationPending, id, notificationFailed)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления уведомления: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return notFoundError("неотправленное уведомление не найдено")
+	}
+	return nil
+}
+
+// notifications [list] [-status pending|sent|failed|skipped] [-limit N] | retry <ID> | send | test <email>;
+// настройки получателей — prefs, set, quiet и mandatory (см. runNotificationPrefsCommand).
+func runNotificationsCommand(app *App, args []string) int {
+	usage := "Использование: notifications [list] [-status pending|sent|failed|skipped] [-limit N] | notifications retry <ID> | notifications send | notifications test <email>\n" +
+		"       notifications prefs|set|quiet|mandatory ... — настройки уведомлений пользователей"
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "prefs", "set", "quiet", "mandatory":
+		return runNotificationPrefsCommand(app, sub, args)
+	case "list":
+		flags := flag.NewFlagSet("notifications list", flag.ContinueOnError)
+		status := flags.String("status", "", "только письма с этим статусом")
+		limit := flags.Int("limit", 50, "сколько последних писем показать")
+		if err := flags.Parse(args); err != nil {
+			return exitUsage
+		}
+		notifications, err := listNotifications(app.DB, *status, *limit)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: notifications, text: func(w io.Writer) {
+			if len(notifications) == 0 {
+				fmt.Fprintln(w, "Уведомлений нет.")
+			}
+			for _, n := range notifications {
+				fmt.Fprintf(w, "%-6d %-8s %-20s %-30s попыток %d  %s\n", n.ID, n.Status, n.Kind, n.Recipient, n.Attempts, n.Subject)
+				if n.LastError != "" {
+					fmt.Fprintf(w, "       ошибка: %s\n", n.LastError)
+				}
+			}
+		}})
+	case "retry":
+		if len(args) != 1 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return reportError(err)
+		}
+		if err := retryNotification(app.DB, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Уведомление снова в очереди.", map[string]int{"id": id})
+	case "send":
+		queued, err := queueNewMatchNotifications(app.DB, 500)
+		if err != nil {
+			return reportError(err)
+		}
+		result, err := deliverDueNotifications(app.DB, 100)
+		if err != nil {
+			return reportError(err)
+		}
+		data := struct {
+			Queued int `json:"queued"`
+			NotificationDelivery
+		}{queued, result}
+		return printView(sharedView{data: data, text: func(w io.Writer) {
+			fmt.Fprintf(w, "Новых писем о подборе: %d. Отправлено: %d (в сводках уведомлений: %d), отложено: %d, не доставлено: %d.\n",
+				queued, result.Sent, result.Merged, result.Retried, result.Failed)
+			if result.Skipped+result.Deferred > 0 {
+				fmt.Fprintf(w, "По настройкам получателей: отменено %d, ждут конца тихих часов %d.\n", result.Skipped, result.Deferred)
+			}
+		}})
+	case "test":
+		if len(args) != 1 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		cfg := loadSMTPConfig()
+		if !cfg.enabled() {
+			return reportError(validationErrorf("SMTP не настроен: задайте SMTP_HOST"))
+		}
+		if err := validateEmailSyntax(args[0]); err != nil {
+			return reportError(err)
+		}
+		if err := sendEmail(cfg, args[0], "Проверка настроек почты", "Если вы читаете это письмо, отправка уведомлений настроена.\n"); err != nil {
+			return reportError(err)
+		}
+		return printDone("Письмо отправлено.", map[string]string{"recipient": args[0]})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Настройки уведомлений пользователя: какие виды событий по каким каналам ему присылать и тихие часы,
+// в которые письма придерживаются до их конца. Что пользователь не задал сам, берётся из умолчаний его
+// роли: рекрутеру — новые кандидаты, кандидату — вакансии и его отклики. Письма о безопасности (сброс
+// пароля) и виды, которые администратор объявил обязательными, приходят всегда и без задержки.
+// Настройки проверяются дважды: при постановке письма в очередь и перед отправкой, поэтому отключение
+// действует и на письма, уже ждущие в очереди. Адрес, за которым нет учётной записи (кандидат из базы),
+// получает все письма, как и раньше. Канал пока один — email.
+
+const channelEmail = "email"
+
+var notificationChannels = []string{channelEmail}
+
+var notificationKinds = []string{notifyNewMatch, notifyNewCandidate, notifyApplicationStatus, notifyInterviewScheduled, notifyPasswordReset}
+
+var notificationKindTitles = map[string]string{
+	notifyNewMatch:           "новая подходящая вакансия",
+	notifyNewCandidate:       "новый подходящий кандидат",
+	notifyApplicationStatus:  "смена статуса отклика",
+	notifyInterviewScheduled: "приглашение на собеседование",
+	notifyPasswordReset:      "сброс пароля",
+}
+
+// Письма о безопасности обязательны всегда, что бы ни было в notification_policies.
+var securityNotifications = map[string]bool{notifyPasswordReset: true}
+
+// Виды, включённые для роли по умолчанию; остальные выключены, пока пользователь не включит их сам.
+var roleNotificationDefaults = map[string][]string{
+	storage.RoleAdmin:        {notifyNewCandidate},
+	storage.RoleCompanyAdmin: {notifyNewCandidate},
+	storage.RoleRecruiter:    {notifyNewCandidate},
+	storage.RoleCandidate:    {notifyNewMatch, notifyApplicationStatus, notifyInterviewScheduled},
+}
+
+// Откуда взято значение настройки.
+const (
+	preferenceUser      = "user"
+	preferenceRole      = "role"
+	preferenceMandatory = "mandatory"
+)
+
+type NotificationPreference struct {
+	Kind    string `json:"kind"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// Время — ЧЧ:ММ в часовом поясе TimeZone; пустой пояс — пояс сервера.
+type QuietHours struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+type NotificationSettings struct {
+	UserID      int                      `json:"user_id"`
+	Username    string                   `json:"username"`
+	Role        string                   `json:"role"`
+	Preferences []NotificationPreference `json:"preferences"`
+	QuietHours  *QuietHours              `json:"quiet_hours,omitempty"`
+}
+
+// Тихие часы в минутах от полуночи; окно с start > end переходит через полночь.
+type quietWindow struct {
+	start, end int
+	loc        *time.Location
+}
+
+func (w quietWindow) contains(minute int) bool {
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
+
+// Если t попадает в тихие часы, возвращает их конец.
+func (w quietWindow) until(t time.Time) (time.Time, bool) {
+	local := t.In(w.loc)
+	if !w.contains(local.Hour()*60 + local.Minute()) {
+		return t, false
+	}
+	end := time.Date(local.Year(), local.Month(), local.Day(), w.end/60, w.end%60, 0, 0, w.loc)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, true
+}
+
+func formatMinute(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+func parseMinute(text string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, validationErrorf("«%s» — не время, ожидается ЧЧ:ММ", strings.TrimSpace(text))
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// «22:00-08:00» → начало и конец тихих часов.
+func parseQuietHours(spec string) (int, int, error) {
+	from, to, ok := strings.Cut(strings.NewReplacer("–", "-", "—", "-").Replace(spec), "-")
+	if !ok {
+		return 0, 0, validationErrorf("тихие часы задаются как ЧЧ:ММ-ЧЧ:ММ, например 22:00-08:00")
+	}
+	start, err := parseMinute(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseMinute(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, validationErrorf("начало и конец тихих часов совпадают")
+	}
+	return start, end, nil
+}
+
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, validationErrorf("неизвестный часовой пояс %q, ожидается, например, Europe/Moscow", name)
+	}
+	return loc, nil
+}
+
+// Настройки одного пользователя в том виде, в каком их применяет очередь писем.
+type recipientPolicy struct {
+	userID int
+	role   string
+	prefs  map[[2]string]bool
+	quiet  *quietWindow
+}
+
+func (p *recipientPolicy) enabled(kind, channel string) (bool, string) {
+	if on, ok := p.prefs[[2]string{kind, channel}]; ok {
+		return on, preferenceUser
+	}
+	return containsString(roleNotificationDefaults[p.role], kind), preferenceRole
+}
+
+func loadRecipientPolicy(q storage.DBTX, userID int, role string) (*recipientPolicy, error) {
+	p := &recipientPolicy{userID: userID, role: role, prefs: map[[2]string]bool{}}
+	rows, err := q.Query("SELECT kind, channel, enabled FROM notification_preferences WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения настроек уведомлений: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, channel string
+		var on bool
+		if err := rows.Scan(&kind, &channel, &on); err != nil {
+			return nil, fmt.Errorf("ошибка чтения настроек уведомлений: %w", err)
+		}
+		p.prefs[[2]string{kind, channel}] = on
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения настроек уведомлений: %w", err)
+	}
+
+	var w quietWindow
+	var zone string
+	err = q.QueryRow("SELECT start_minute, end_minute, time_zone FROM notification_quiet_hours WHERE user_id = $1", userID).
+		Scan(&w.start, &w.end, &zone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения тихих часов: %w", err)
+	}
+	// Пояс проверяется при сохранении; если он пропал из базы часовых поясов, считаем по поясу сервера.
+	if w.loc, err = loadTimeZone(zone); err != nil {
+		w.loc = time.Local
+	}
+	p.quiet = &w
+	return p, nil
+}
+
+func mandatoryNotificationKinds(q storage.DBTX) (map[string]bool, error) {
+	mandatory := map[string]bool{}
+	for kind := range securityNotifications {
+		mandatory[kind] = true
+	}
+	rows, err := q.Query("SELECT kind FROM notification_policies WHERE mandatory")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения обязательных уведомлений: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind string
+		if err := rows.Scan(&kind); err != nil {
+			return nil, fmt.Errorf("ошибка чтения обязательных уведомлений: %w", err)
+		}
+		mandatory[kind] = true
+	}
+	return mandatory, rows.Err()
+}
+
+// Решения для очереди писем; настройки получателей читаются один раз на получателя.
+type notificationPolicy struct {
+	q          storage.DBTX
+	mandatory  map[string]bool
+	recipients map[string]*recipientPolicy
+}
+
+func newNotificationPolicy(q storage.DBTX) (*notificationPolicy, error) {
+	mandatory, err := mandatoryNotificationKinds(q)
+	if err != nil {
+		return nil, err
+	}
+	return &notificationPolicy{q: q, mandatory: mandatory, recipients: map[string]*recipientPolicy{}}, nil
+}
+
+// Пользователь с этим адресом; nil — адрес не принадлежит ни одной учётной записи.
+func (np *notificationPolicy) recipient(address string) (*recipientPolicy, error) {
+	if p, ok := np.recipients[address]; ok {
+		return p, nil
+	}
+	var userID int
+	var role string
+	err := np.q.QueryRow("SELECT id, role FROM users WHERE lower(email) = lower($1) AND email <> '' ORDER BY id LIMIT 1", address).
+		Scan(&userID, &role)
+	var p *recipientPolicy
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("ошибка поиска получателя уведомления: %w", err)
+	default:
+		if p, err = loadRecipientPolicy(np.q, userID, role); err != nil {
+			return nil, err
+		}
+	}
+	np.recipients[address] = p
+	return p, nil
+}
+
+// Можно ли отправить письмо вида kind по каналу channel на адрес и с какого времени: в тихие часы
+// получателя отправка откладывается до их конца.
+func (np *notificationPolicy) decide(kind, channel, address string, at time.Time) (bool, time.Time, error) {
+	if np.mandatory[kind] {
+		return true, at, nil
+	}
+	p, err := np.recipient(address)
+	if err != nil || p == nil {
+		return true, at, err
+	}
+	if on, _ := p.enabled(kind, channel); !on {
+		return false, at, nil
+	}
+	if p.quiet != nil {
+		if end, quiet := p.quiet.until(at); quiet {
+			return true, end, nil
+		}
+	}
+	return true, at, nil
+}
+
+// Пользователь, чьи настройки показываются или меняются: без имени — текущий. Чужие настройки
+// меняет только тот, кто управляет пользователями; консольные команды без сессии — для любого.
+func notificationSettingsUser(app *App, username string) (storage.User, error) {
+	actingID := actingUserID()
+	var user storage.User
+	var err error
+	if username == "" {
+		if actingID == 0 {
+			return user, validationErrorf("укажите пользователя")
+		}
+		user, err = app.Users.GetByID(actingID)
+	} else {
+		user, err = app.Users.GetByUsername(normalizeText(username, false))
+	}
+	if errors.Is(err, storage.ErrNotFound) {
+		return user, notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return user, err
+	}
+	if actingID != 0 && actingID != user.ID {
+		acting, err := app.Users.GetByID(actingID)
+		if err != nil {
+			return user, err
+		}
+		if !roleAllows(acting.Role, permUsersManage) {
+			return user, permissionError("чужие настройки уведомлений может менять только администратор пользователей")
+		}
+	}
+	return user, nil
+}
+
+func notificationSettings(app *App, user storage.User) (NotificationSettings, error) {
+	settings := NotificationSettings{UserID: user.ID, Username: user.Username, Role: user.Role}
+	mandatory, err := mandatoryNotificationKinds(app.DB)
+	if err != nil {
+		return settings, err
+	}
+	p, err := loadRecipientPolicy(app.DB, user.ID, user.Role)
+	if err != nil {
+		return settings, err
+	}
+	for _, kind := range notificationKinds {
+		for _, channel := range notificationChannels {
+			pref := NotificationPreference{Kind: kind, Channel: channel, Enabled: true, Source: preferenceMandatory}
+			if !mandatory[kind] {
+				pref.Enabled, pref.Source = p.enabled(kind, channel)
+			}
+			settings.Preferences = append(settings.Preferences, pref)
+		}
+	}
+	if p.quiet != nil {
+		settings.QuietHours = &QuietHours{From: formatMinute(p.quiet.start), To: formatMinute(p.quiet.end)}
+		if p.quiet.loc != time.Local {
+			settings.QuietHours.TimeZone = p.quiet.loc.String()
+		}
+	}
+	return settings, nil
+}
+
+func validateNotificationKind(kind string) error {
+	if !containsString(notificationKinds, kind) {
+		return validationErrorf("неизвестный вид уведомления %q, допустимые: %s", kind, strings.Join(notificationKinds, ", "))
+	}
+	return nil
+}
+
+// enabled == nil возвращает настройке умолчание роли.
+func setNotificationPreference(app *App, user storage.User, kind, channel string, enabled *bool) error {
+	if err := validateNotificationKind(kind); err != nil {
+		return err
+	}
+	if channel == "" {
+		channel = channelEmail
+	}
+	if !containsString(notificationChannels, channel) {
+		return validationErrorf("неизвестный канал %q, допустимые: %s", channel, strings.Join(notificationChannels, ", "))
+	}
+	var err error
+	if enabled == nil {
+		_, err = app.DB.Exec("DELETE FROM notification_preferences WHERE user_id = $1 AND kind = $2 AND channel = $3", user.ID, kind, channel)
+	} else {
+		if !*enabled {
+			mandatory, err := mandatoryNotificationKinds(app.DB)
+			if err != nil {
+				return err
+			}
+			if mandatory[kind] {
+				return validationErrorf("уведомления «%s» обязательны, отключить их нельзя", notificationKindTitles[kind])
+			}
+		}
+		_, err = app.DB.Exec(`INSERT INTO notification_preferences (user_id, kind, channel, enabled) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, kind, channel) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = now()`,
+			user.ID, kind, channel, *enabled)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения настроек уведомлений: %w", err)
+	}
+	value := "default"
+	if enabled != nil {
+		value = strconv.FormatBool(*enabled)
+	}
+	audit("notification.preference", "target_user_id", user.ID, "kind", kind, "channel", channel, "enabled", value)
+	return nil
+}
+
+// Пустой spec снимает тихие часы.
+func setQuietHours(app *App, user storage.User, spec, zone string) error {
+	if spec == "" {
+		if _, err := app.DB.Exec("DELETE FROM notification_quiet_hours WHERE user_id = $1", user.ID); err != nil {
+			return fmt.Errorf("ошибка сохранения тихих часов: %w", err)
+		}
+		audit("notification.quiet_hours", "target_user_id", user.ID, "quiet_hours", "off")
+		return nil
+	}
+	start, end, err := parseQuietHours(spec)
+	if err != nil {
+		return err
+	}
+	if _, err := loadTimeZone(zone); err != nil {
+		return err
+	}
+	_, err = app.DB.Exec(`INSERT INTO notification_quiet_hours (user_id, start_minute, end_minute, time_zone) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET start_minute = EXCLUDED.start_minute, end_minute = EXCLUDED.end_minute, time_zone = EXCLUDED.time_zone`,
+		user.ID, start, end, zone)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения тихих часов: %w", err)
+	}
+	audit("notification.quiet_hours", "target_user_id", user.ID, "quiet_hours", formatMinute(start)+"-"+formatMinute(end), "time_zone", zone)
+	return nil
+}
+
+// Обязательный вид приходит всем, кто с ним связан, независимо от их настроек и тихих часов.
+func setMandatoryNotification(app *App, kind string, mandatory bool) error {
+	if err := validateNotificationKind(kind); err != nil {
+		return err
+	}
+	if securityNotifications[kind] && !mandatory {
+		return validationErrorf("письма о безопасности («%s») обязательны всегда", notificationKindTitles[kind])
+	}
+	_, err := app.DB.Exec(`INSERT INTO notification_policies (kind, mandatory, updated_by) VALUES ($1, $2, $3)
+		ON CONFLICT (kind) DO UPDATE SET mandatory = EXCLUDED.mandatory, updated_by = EXCLUDED.updated_by, updated_at = now()`,
+		kind, mandatory, nullUserID(actingUserID()))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения обязательных уведомлений: %w", err)
+	}
+	audit("notification.mandatory", "kind", kind, "mandatory", mandatory)
+	return nil
+}
+
+// «on», «off» или «default».
+func parsePreferenceValue(value string) (*bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "вкл":
+		on := true
+		return &on, nil
+	case "off", "выкл":
+		off := false
+		return &off, nil
+	case "default":
+		return nil, nil
+	}
+	return nil, validationErrorf("ожидается on, off или default, получено %q", value)
+}
+
+var preferenceSourceTitles = map[string]string{
+	preferenceUser:      "задано",
+	preferenceRole:      "по умолчанию для роли",
+	preferenceMandatory: "обязательное",
+}
+
+func printNotificationSettings(w io.Writer, s NotificationSettings) {
+	fmt.Fprintf(w, "Уведомления пользователя %s (роль %s):\n", s.Username, s.Role)
+	for _, p := range s.Preferences {
+		state := "выкл"
+		if p.Enabled {
+			state = "вкл"
+		}
+		fmt.Fprintf(w, "  %-20s %-6s %-5s %s — %s\n", p.Kind, p.Channel, state, notificationKindTitles[p.Kind], preferenceSourceTitles[p.Source])
+	}
+	if s.QuietHours == nil {
+		fmt.Fprintln(w, "Тихие часы не заданы.")
+		return
+	}
+	zone := s.QuietHours.TimeZone
+	if zone == "" {
+		zone = "пояс сервера"
+	}
+	fmt.Fprintf(w, "Тихие часы: %s-%s (%s)\n", s.QuietHours.From, s.QuietHours.To, zone)
+}
+
+// Настройки текущего пользователя: «вид on|off|default» меняет вид, «тихо ЧЧ:ММ-ЧЧ:ММ [пояс]» или «тихо off» — тихие часы.
+func notificationSettingsMenu(app *App) {
+	user, err := notificationSettingsUser(app, "")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	settings, err := notificationSettings(app, user)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	printNotificationSettings(os.Stdout, settings)
+	words := strings.Fields(getInput("«вид on|off|default» — изменить, «тихо 22:00-08:00 [пояс]» или «тихо off» — тихие часы, Enter — назад: "))
+	switch {
+	case len(words) == 0:
+		return
+	case words[0] == "тихо" && len(words) >= 2:
+		spec, zone := words[1], ""
+		if spec == "off" {
+			spec = ""
+		}
+		if len(words) > 2 {
+			zone = words[2]
+		}
+		err = setQuietHours(app, user, spec, zone)
+	case len(words) == 2:
+		var enabled *bool
+		if enabled, err = parsePreferenceValue(words[1]); err == nil {
+			err = setNotificationPreference(app, user, words[0], channelEmail, enabled)
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+		return
+	}
+	handleError(err)
+	if err == nil {
+		fmt.Println("Настройки сохранены.")
+	}
+}
+
+// notifications prefs <пользователь> [-json] | set <пользователь> <вид> on|off|default [-channel email] |
+// quiet <пользователь> <ЧЧ:ММ-ЧЧ:ММ>|off [-tz пояс] | mandatory <вид> on|off
+func runNotificationPrefsCommand(app *App, sub string, args []string) int {
+	usage := "Использование: notifications prefs <пользователь> [-json] | notifications set <пользователь> <вид> on|off|default [-channel email] | " +
+		"notifications quiet <пользователь> <ЧЧ:ММ-ЧЧ:ММ>|off [-tz пояс] | notifications mandatory <вид> on|off"
+	flags := flag.NewFlagSet("notifications "+sub, flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "вывести настройки в JSON")
+	channel := flags.String("channel", channelEmail, "канал уведомлений")
+	zone := flags.String("tz", "", "часовой пояс тихих часов, например Europe/Moscow")
+	positional := map[string]int{"prefs": 1, "set": 3, "quiet": 2, "mandatory": 2}[sub]
+	if len(args) < positional {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	if err := flags.Parse(args[positional:]); err != nil || flags.NArg() > 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	if sub == "mandatory" {
+		if args[1] != "on" && args[1] != "off" {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		if err := setMandatoryNotification(app, args[0], args[1] == "on"); err != nil {
+			return reportError(err)
+		}
+		return printDone("Настройка сохранена.", map[string]interface{}{"kind": args[0], "mandatory": args[1] == "on"})
+	}
+	user, err := notificationSettingsUser(app, args[0])
+	if err != nil {
+		return reportError(err)
+	}
+	switch sub {
+	case "prefs":
+		settings, err := notificationSettings(app, user)
+		if err != nil {
+			return reportError(err)
+		}
+		useJSON(*asJSON)
+		return printView(sharedView{
+			data: settings,
+			text: func(w io.Writer) { printNotificationSettings(w, settings) },
+			csv:  func(w io.Writer) error { return writeDataCSV(w, settings.Preferences) },
+		})
+	case "set":
+		enabled, err := parsePreferenceValue(args[2])
+		if err != nil {
+			return reportError(err)
+		}
+		err = setNotificationPreference(app, user, args[1], *channel, enabled)
+		if err != nil {
+			return reportError(err)
+		}
+	case "quiet":
+		spec := args[1]
+		if spec == "off" {
+			spec = ""
+		}
+		if err := setQuietHours(app, user, spec, *zone); err != nil {
+			return reportError(err)
+		}
+	}
+	return printDone("Настройки сохранены.", map[string]string{"username": user.Username})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"your_project_name/storage"
+)
+
+const demoCandidates = 50
+
+// База считается новой, пока в ней нет ни одного пользователя.
+func isFreshDatabase(db *sql.DB) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM users)").Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки пользователей: %w", err)
+	}
+	return !exists, nil
+}
+
+func confirm(prompt string) bool {
+	answer := strings.ToLower(getInput(prompt + " (y/n): "))
+	return answer == "y" || answer == "yes" || answer == "д" || answer == "да"
+}
+
+func runOnboarding(app *App) {
+	fmt.Println("\nБаза данных пуста: таблицы созданы, запускается первоначальная настройка.")
+	fmt.Println("Оставьте имя пустым, чтобы пропустить настройку; она предложится при следующем запуске.")
+
+	fmt.Println("\nШаг 1. Администратор")
+	for {
+		username := getInput("Имя администратора: ")
+		if username == "" {
+			fmt.Println("Настройка пропущена.")
+			return
+		}
+		password := getInput("Пароль: ")
+		_, err := createUser(app.Users, storage.User{Username: username, Role: storage.RoleAdmin}, password)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Администратор создан.")
+			break
+		}
+	}
+
+	fmt.Println("\nШаг 2. Первая компания")
+	for {
+		name := getInput("Название компании (Enter — пропустить): ")
+		if name == "" {
+			break
+		}
+		id, err := addCompany(app, name)
+		handleError(err)
+		if err == nil {
+			fmt.Printf("Компания добавлена, ID: %d\n", id)
+			break
+		}
+	}
+
+	fmt.Println("\nШаг 3. Демонстрационные данные")
+	if confirm(fmt.Sprintf("Добавить %d кандидатов и %d вакансий для знакомства с программой?", demoCandidates, demoCandidates/10)) {
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		err := generateLoadData(app.DB, rnd, demoCandidates, demoCandidates/10)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Демонстрационные данные добавлены.")
+		}
+	}
+
+	fmt.Println("\nШаг 4. Проверка дополнительных настроек")
+	checkIntegrations()
+	fmt.Println("\nНастройка завершена.")
+}
+
+// Проверяет необязательные настройки из .env и сообщает, что работает, а что нет.
+func checkIntegrations() {
+	region := defaultPhoneRegion()
+	if phonenumbers.GetCountryCodeForRegion(region) == 0 {
+		fmt.Printf("[!] PHONE_DEFAULT_REGION=%s — неизвестный регион, номера без кода страны не будут приниматься\n", region)
+	} else {
+		fmt.Printf("[ок] регион телефонов по умолчанию: %s\n", region)
+	}
+
+	if mxCheckEnabled() {
+		warning, err := lookupMailDomain("gmail.com")
+		switch {
+		case warning != "":
+			fmt.Println("[!] проверка почтовых доменов включена, но", warning)
+		case err != nil:
+			fmt.Println("[!] проверка почтовых доменов включена, но DNS вернул ошибку:", err)
+		default:
+			fmt.Println("[ок] проверка почтовых доменов (EMAIL_MX_CHECK) работает")
+		}
+	} else {
+		fmt.Println("[-] проверка почтовых доменов выключена (EMAIL_MX_CHECK)")
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	if _, err := exec.LookPath(editor); err != nil {
+		fmt.Printf("[!] редактор %s не найден — задайте EDITOR, чтобы редактировать длинные тексты через !edit\n", editor)
+	} else {
+		fmt.Printf("[ок] редактор для длинных текстов: %s\n", editor)
+	}
+
+	if os.Getenv("JWT_SECRET") == "" {
+		fmt.Println("[!] JWT_SECRET не задан — ключ подписи создаётся при каждом запуске, и сессии не переживут перезапуск")
+	} else {
+		fmt.Println("[ок] ключ подписи сессий (JWT_SECRET)")
+	}
+
+	if value := os.Getenv("JOB_WORKERS"); value != "" {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			fmt.Printf("[!] JOB_WORKERS=%s — ожидается неотрицательное число, будет использовано 2\n", value)
+		}
+	}
+}
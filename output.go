@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"your_project_name/storage"
+)
+
+// Карточки и отчёты можно сохранить в файл (-out; .json — данные в JSON, .csv — таблица для отчётов,
+// иначе текст как на экране) или скопировать в буфер обмена (-copy), чтобы вставить в чат или письмо. Всё, что уходит
+// за пределы терминала, проходит маскирование персональных данных: email и телефон кандидата
+// сокращаются так, чтобы их можно было узнать, но нельзя было использовать.
+
+type shareOptions struct {
+	Out  string
+	Copy bool
+}
+
+func (o *shareOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.Out, "out", "", "сохранить в файл: .json — данные в JSON, .csv — таблица (для отчётов), иначе текст")
+	flags.BoolVar(&o.Copy, "copy", false, "скопировать текст в буфер обмена")
+}
+
+// То, что уходит в файл или буфер обмена; персональные данные в нём уже замаскированы.
+type sharedView struct {
+	data interface{}
+	text func(w io.Writer)
+	// nil — в CSV не выгружается.
+	csv func(w io.Writer) error
+}
+
+func (o shareOptions) share(v sharedView) error {
+	if o.Out != "" {
+		if err := writeShareFile(o.Out, v); err != nil {
+			return err
+		}
+		printNotice("Сохранено в", o.Out)
+	}
+	if o.Copy {
+		if err := copyToClipboard(renderText(v.text)); err != nil {
+			return err
+		}
+		printNotice("Скопировано в буфер обмена.")
+	}
+	return nil
+}
+
+func writeShareFile(path string, v sharedView) error {
+	var content bytes.Buffer
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err := json.MarshalIndent(v.data, "", "  ")
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации: %w", err)
+		}
+		content.Write(data)
+		content.WriteByte('\n')
+	case ".csv":
+		if v.csv == nil {
+			return validationErrorf("эти данные нельзя сохранить в CSV, используйте .json или .txt")
+		}
+		if err := v.csv(&content); err != nil {
+			return err
+		}
+	default:
+		v.text(&content)
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("ошибка записи файла: %w", err)
+	}
+	return nil
+}
+
+func renderText(text func(w io.Writer)) string {
+	var buf bytes.Buffer
+	text(&buf)
+	return buf.String()
+}
+
+// Программы буфера обмена в порядке предпочтения; берётся первая установленная.
+func clipboardCommands() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	}
+	commands := [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}, {"clip.exe"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		commands = append([][]string{{"wl-copy"}}, commands...)
+	}
+	return commands
+}
+
+func copyToClipboard(text string) error {
+	for _, command := range clipboardCommands() {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, command[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("ошибка копирования в буфер обмена (%s): %w", command[0], err)
+		}
+		return nil
+	}
+	return errors.New("буфер обмена недоступен: не найдена ни одна из программ pbcopy, wl-copy, xclip, xsel, clip")
+}
+
+// Карточка записи: на экран — полностью, в файл и буфер обмена — с замаскированными персональными данными.
+type entityCard struct {
+	data   interface{}
+	print  func(w io.Writer)
+	shared sharedView
+}
+
+func loadEntityCard(app *App, kind string, id int) (entityCard, error) {
+	switch kind {
+	case entityCandidate:
+		candidate, err := getCandidateByID(app, id)
+		if err != nil {
+			return entityCard{}, err
+		}
+		masked := maskCandidate(candidate)
+		return entityCard{
+			data:   candidate,
+			print:  func(w io.Writer) { writeCandidate(w, candidate) },
+			shared: sharedView{data: masked, text: func(w io.Writer) { writeCandidate(w, masked) }},
+		}, nil
+	case entityJobOpening:
+		jobOpening, err := getJobOpeningByID(app, id)
+		if err != nil {
+			return entityCard{}, err
+		}
+		text := func(w io.Writer) { writeJobOpening(w, jobOpening) }
+		return entityCard{data: jobOpening, print: text, shared: sharedView{data: jobOpening, text: text}}, nil
+	case entityCompany:
+		details, err := companyDetails(app, id)
+		if err != nil {
+			return entityCard{}, err
+		}
+		text := func(w io.Writer) { writeCompanyDetails(w, details) }
+		return entityCard{data: details, print: text, shared: sharedView{data: details, text: text}}, nil
+	}
+	return entityCard{}, validationErrorf("неизвестный вид записи %q, допустимые: candidate, job, company", kind)
+}
+
+// После карточки или отчёта в меню: c — скопировать, путь — сохранить в файл, Enter — продолжить.
+func shareMenu(v sharedView) {
+	input := getInput("c — скопировать в буфер обмена, путь к файлу — сохранить, Enter — продолжить: ")
+	var o shareOptions
+	switch {
+	case input == "":
+		return
+	case strings.EqualFold(input, "c"):
+		o.Copy = true
+	default:
+		o.Out = input
+	}
+	handleError(o.share(v))
+}
+
+// ivan.petrov@example.com → i***@example.com
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return maskString(email)
+	}
+	first := []rune(local)[0]
+	return string(first) + "***@" + domain
+}
+
+// +7 900 123-45-67 → +* *** ***-**-67: остаются только две последние цифры и разметка номера.
+func maskPhone(phone string) string {
+	keep := 2
+	runes := []rune(phone)
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+func maskString(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "***"
+}
+
+func maskCandidate(c storage.Candidate) storage.Candidate {
+	c.Email = maskEmail(c.Email)
+	c.Phone = maskPhone(c.Phone)
+	return c
+}
+
+// show <candidate|job|company> <ID или название> [-out файл] [-copy]
+func runShowCommand(app *App, args []string) int {
+	usage := "Использование: show <candidate|job|company> <ID или название> [-out файл] [-copy]"
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	if _, ok := entityTitles[args[0]]; !ok {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	var o shareOptions
+	o.register(flags)
+	if err := flags.Parse(args[2:]); err != nil {
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, args[0], args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	return showEntityCard(app, args[0], id, o)
+}
+
+func showEntityCard(app *App, kind string, id int, o shareOptions) int {
+	card, err := loadEntityCard(app, kind, id)
+	if err != nil {
+		return reportError(err)
+	}
+	if code := printView(sharedView{data: card.data, text: card.print}); code != exitOK {
+		return code
+	}
+	if err := o.share(card.shared); err != nil {
+		return reportError(err)
+	}
+	return exitOK
+}
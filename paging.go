@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+const maxListPerPage = 100
+
+// Страница списка или поиска: страницы нумеруются с 1 (0 — первая), PerPage 0 — без ограничения.
+// Sort — поле сортировки, с «-» впереди — по убыванию, например "-salary".
+type ListQuery struct {
+	Page    int
+	PerPage int
+	Sort    string
+}
+
+func sortFieldNames(sorts map[string]string) string {
+	names := make([]string, 0, len(sorts))
+	for name := range sorts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (q ListQuery) options(sorts map[string]string) (storage.ListOptions, error) {
+	page := q.Page
+	if page == 0 {
+		page = 1
+	}
+	if page < 1 {
+		return storage.ListOptions{}, validationErrorf("номер страницы должен быть не меньше 1")
+	}
+	if q.PerPage < 0 || q.PerPage > maxListPerPage {
+		return storage.ListOptions{}, validationErrorf("на странице может быть от 1 до %d записей", maxListPerPage)
+	}
+	field := strings.TrimPrefix(strings.TrimSpace(q.Sort), "-")
+	if _, ok := sorts[field]; field != "" && !ok {
+		return storage.ListOptions{}, validationErrorf("неизвестное поле сортировки %q, допустимые: %s", field, sortFieldNames(sorts))
+	}
+	return storage.ListOptions{
+		Limit:  q.PerPage,
+		Offset: (page - 1) * q.PerPage,
+		Sort:   field,
+		Desc:   strings.HasPrefix(strings.TrimSpace(q.Sort), "-"),
+	}, nil
+}
+
+func sortPrompt(sorts map[string]string) string {
+	return getInput(fmt.Sprintf("Сортировка (%s; «-» впереди — по убыванию, Enter — по ID): ", sortFieldNames(sorts)))
+}
+
+// Листает список по страницам: Enter — следующая, номер — переход, q — выход.
+// fetch возвращает строки страницы и общее число записей; для поиска, где оно неизвестно, — -1,
+// и тогда листание заканчивается на неполной странице.
+func browsePages(perPage int, empty string, fetch func(page int) ([]string, int, error)) {
+	page := 1
+	for {
+		lines, total, err := fetch(page)
+		handleError(err)
+		if err != nil {
+			return
+		}
+		if len(lines) == 0 && page == 1 {
+			fmt.Println(empty)
+			return
+		}
+		pages := (total + perPage - 1) / perPage
+		if total < 0 {
+			fmt.Printf("Страница %d\n", page)
+		} else {
+			fmt.Printf("Страница %d из %d (всего записей: %d)\n", page, pages, total)
+		}
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+		last := total >= 0 && page >= pages || total < 0 && len(lines) < perPage
+		input := getInput("Enter — следующая страница, номер — перейти, q — выход: ")
+		switch {
+		case input == "q":
+			return
+		case input == "":
+			if last {
+				return
+			}
+			page++
+		default:
+			n, err := strconv.Atoi(input)
+			if err != nil || n < 1 || total >= 0 && n > pages {
+				fmt.Println("Нет такой страницы.")
+				continue
+			}
+			page = n
+		}
+	}
+}
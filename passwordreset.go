@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Сброс пароля одноразовым кодом. Сам код в базу не попадает нигде: в password_reset_tokens хранится
+// только его SHA-256, а письмо с кодом отправляется сразу, минуя очередь notifications, поэтому ни таблицы,
+// ни их резервные копии не дают действующих кодов. Новый запрос гасит прежние коды пользователя, успешный
+// сброс — все коды и все refresh-токены. Срок жизни кода — PASSWORD_RESET_TTL (1h).
+// Код уходит письмом на адрес пользователя (нужен SMTP_HOST); без почты его выдаёт администратор
+// командой user reset-password. Письмо, которое не удалось отправить, не повторяется — нужно запросить
+// новый код.
+
+func passwordResetTTL() time.Duration {
+	return tokenTTL("PASSWORD_RESET_TTL", time.Hour)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func setUserEmail(app *App, username, email string) error {
+	email = strings.TrimSpace(email)
+	if err := validateEmailSyntax(email); err != nil {
+		return err
+	}
+	user, err := app.Users.GetByUsername(normalizeText(username, false))
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+	if err := app.Users.SetEmail(user.ID, email); err != nil {
+		return err
+	}
+	audit("user.email", "target_user_id", user.ID, "username", user.Username)
+	return nil
+}
+
+// Выдаёт новый код сброса и отправляет его письмом. Код возвращается, чтобы его мог передать
+// администратор; пользователю, запросившему сброс сам, его показывать нельзя.
+func requestPasswordReset(app *App, username string) (string, error) {
+	user, err := app.Users.GetByUsername(normalizeText(username, false))
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("ошибка генерации кода: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+	ttl := passwordResetTTL()
+
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		if _, err := tx.Exec("UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL", user.ID); err != nil {
+			return fmt.Errorf("ошибка отзыва прежних кодов: %w", err)
+		}
+		_, err := tx.Exec("INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+			user.ID, hashToken(token), time.Now().Add(ttl))
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения кода: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	emailed := false
+	if cfg := loadSMTPConfig(); cfg.enabled() && user.Email != "" {
+		data := NotificationData{Username: user.Username, Token: token, ValidFor: ttl.String()}
+		if err := sendPasswordResetEmail(cfg, user.Email, data); err != nil {
+			opLogger().Warn("ошибка отправки кода сброса", "target_user_id", user.ID, "error", err)
+		} else {
+			emailed = true
+		}
+	}
+	audit("user.password_reset.requested", "target_user_id", user.ID, "username", user.Username, "emailed", emailed)
+	return token, nil
+}
+
+// Письмо с кодом собирается в памяти и сразу уходит на SMTP; в notifications его нет.
+func sendPasswordResetEmail(cfg smtpConfig, to string, data NotificationData) error {
+	subject, body, _, err := renderNotification(notifyPasswordReset, data)
+	if err != nil {
+		return err
+	}
+	return sendEmail(cfg, to, subject, body)
+}
+
+// Проверка кода, смена пароля и отзыв кодов и сессий — одна транзакция: код нельзя использовать дважды,
+// даже если два запроса пришли одновременно.
+func resetPassword(app *App, token, password string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return validationErrorf("код сброса не может быть пустым")
+	}
+	if password == "" {
+		return validationErrorf("пароль не может быть пустым")
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+
+	var userID int
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		err := tx.QueryRow(`SELECT user_id FROM password_reset_tokens
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now() FOR UPDATE`, hashToken(token)).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return permissionError("код сброса неверный, уже использован или истёк")
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка проверки кода: %w", err)
+		}
+		if err := storage.NewPostgres(tx).Users.SetPassword(userID, hashedPassword, false); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE password_reset_tokens SET used_at = now() WHERE user_id = $1 AND used_at IS NULL", userID); err != nil {
+			return fmt.Errorf("ошибка отзыва кодов: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL", userID); err != nil {
+			return fmt.Errorf("ошибка отзыва токенов: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	audit("user.password_reset", "target_user_id", userID)
+	return nil
+}
+
+// Ответ на запрос кода не зависит от того, существует ли пользователь, и сам код не показывается:
+// иначе через меню можно было бы перебирать логины или сбросить чужой пароль.
+func passwordResetMenu(app *App) {
+	fmt.Println("1. Получить код сброса на почту")
+	fmt.Println("2. Ввести код и новый пароль")
+	choice, err := getIntInput("Введите номер действия: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	switch choice {
+	case 1:
+		username := getInput("Имя пользователя: ")
+		if _, err := requestPasswordReset(app, username); err != nil && classifyError(err) != kindNotFound {
+			handleError(err)
+			return
+		}
+		fmt.Println("Если у пользователя указан адрес почты, код отправлен на него. Иначе обратитесь к администратору.")
+	case 2:
+		token := getInput("Код сброса: ")
+		password := getInput("Новый пароль: ")
+		err := resetPassword(app, token, password)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Пароль изменён. Войдите с новым паролем.")
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+func defaultPhoneRegion() string {
+	region := strings.ToUpper(strings.TrimSpace(os.Getenv("PHONE_DEFAULT_REGION")))
+	if region == "" {
+		return "RU"
+	}
+	return region
+}
+
+// Номер без кода страны разбирается по региону из PHONE_DEFAULT_REGION (по умолчанию RU)
+// и хранится в формате E.164, чтобы по нему можно было отправлять SMS и искать дубликаты.
+func normalizePhone(input string) (string, error) {
+	input = normalizeText(input, false)
+	if input == "" {
+		return "", nil
+	}
+	number, err := phonenumbers.Parse(input, defaultPhoneRegion())
+	if err != nil || !phonenumbers.IsValidNumber(number) {
+		return "", validationErrorf("неверный номер телефона")
+	}
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Условия поиска по воронке откликов в том виде, в каком их задаёт пользователь. Область поиска —
+// одна вакансия (JobOpeningID) или все вакансии компании (CompanyID); остальные поля сужают выборку.
+type PipelineQuery struct {
+	JobOpeningID int      `json:"job_opening_id"`
+	CompanyID    int      `json:"company_id"`
+	Candidate    string   `json:"candidate"`
+	Skill        string   `json:"skill"`
+	Statuses     []string `json:"statuses"`
+	Recruiter    string   `json:"recruiter"`
+	MinDays      int      `json:"min_days"`
+	MaxDays      int      `json:"max_days"`
+	SLA          string   `json:"sla"`
+	// Воронка на прошедший момент, см. parseAsOf; пусто — нынешняя.
+	AsOf string `json:"as_of"`
+}
+
+func (p PipelineQuery) filter(app *App) (storage.ApplicationFilter, error) {
+	filter := storage.ApplicationFilter{JobOpeningID: p.JobOpeningID, CompanyID: p.CompanyID, SLALimit: applicationSLA()}
+	switch {
+	case p.JobOpeningID != 0:
+		if _, err := getJobOpeningByID(app, p.JobOpeningID); err != nil {
+			return filter, err
+		}
+	case p.CompanyID != 0:
+		if _, err := getCompany(app, p.CompanyID); err != nil {
+			return filter, err
+		}
+	default:
+		return filter, validationErrorf("укажите вакансию или компанию, по откликам которой искать")
+	}
+
+	var err error
+	if filter.AsOf, err = parseAsOf(p.AsOf); err != nil {
+		return filter, err
+	}
+	if filter.Candidate, err = sanitizeText("кандидат", p.Candidate, maxNameLength); err != nil {
+		return filter, err
+	}
+	if filter.Skill, err = sanitizeText("навык", p.Skill, maxNameLength); err != nil {
+		return filter, err
+	}
+	for _, status := range p.Statuses {
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		if err := validateApplicationStatus(status); err != nil {
+			return filter, err
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if username := normalizeText(p.Recruiter, false); username != "" {
+		user, err := app.Users.GetByUsername(username)
+		if errors.Is(err, storage.ErrNotFound) {
+			return filter, notFoundError("рекрутер не найден")
+		}
+		if err != nil {
+			return filter, err
+		}
+		filter.RecruiterID = user.ID
+	}
+	if p.MinDays < 0 || p.MaxDays < 0 || (p.MaxDays > 0 && p.MaxDays <= p.MinDays) {
+		return filter, validationErrorf("дни на этапе: «от» и «до» не могут быть отрицательными, «до» должно быть больше «от»")
+	}
+	filter.InStageMin = time.Duration(p.MinDays) * 24 * time.Hour
+	filter.InStageMax = time.Duration(p.MaxDays) * 24 * time.Hour
+	switch p.SLA {
+	case "", storage.SLABreached, storage.SLAWithin:
+		filter.SLA = p.SLA
+	default:
+		return filter, validationErrorf("неизвестный фильтр SLA %q, допустимые: %s, %s", p.SLA, storage.SLABreached, storage.SLAWithin)
+	}
+	return filter, nil
+}
+
+func searchPipeline(app *App, p PipelineQuery, q ListQuery) ([]storage.PipelineEntry, error) {
+	filter, err := p.filter(app)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := q.options(storage.ApplicationSorts)
+	if err != nil {
+		return nil, err
+	}
+	return app.Applications.Search(filter, opts)
+}
+
+// Число откликов на каждом этапе; с AsOf — на тот момент.
+func pipelineStages(app *App, p PipelineQuery) (map[string]int, error) {
+	filter, err := p.filter(app)
+	if err != nil {
+		return nil, err
+	}
+	return app.Applications.CountByStatus(filter)
+}
+
+// Момент, от которого отсчитываются дни на этапе и SLA в строках воронки.
+func pipelineMoment(p PipelineQuery) time.Time {
+	if at, err := parseAsOf(p.AsOf); err == nil && at != nil {
+		return *at
+	}
+	return time.Now()
+}
+
+// Открытый отклик, который к моменту at стоит на этапе дольше applicationSLA.
+func slaBreached(a storage.Application, at time.Time) bool {
+	return a.Status != storage.ApplicationHired && a.Status != storage.ApplicationRejected && at.Sub(a.UpdatedAt) > applicationSLA()
+}
+
+func writePipelineStages(w io.Writer, counts map[string]int) {
+	total := 0
+	for _, status := range storage.ApplicationStatuses {
+		fmt.Fprintf(w, "%-14s %6d\n", applicationStatusTitles[status], counts[status])
+		total += counts[status]
+	}
+	fmt.Fprintf(w, "%-14s %6d\n", "всего", total)
+}
+
+func writePipelineStagesCSV(w io.Writer, counts map[string]int) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"status", "count"})
+	for _, status := range storage.ApplicationStatuses {
+		writer.Write([]string{status, strconv.Itoa(counts[status])})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func pipelineLine(e storage.PipelineEntry, at time.Time) string {
+	mark := ""
+	if slaBreached(e.Application, at) {
+		mark = "  ! SLA"
+	}
+	recruiter := e.Recruiter
+	if recruiter == "" {
+		recruiter = "—"
+	}
+	return fmt.Sprintf("%-6d %-28s %-24s %-14s %5d дн.  %-16s%s", e.ID, e.CandidateName, e.JobTitle,
+		applicationStatusTitles[e.Status], int(at.Sub(e.UpdatedAt).Hours()/24), recruiter, mark)
+}
+
+func pipelineSearchMenu(app *App) {
+	var p PipelineQuery
+	var err error
+	p.JobOpeningID, err = getEntityInput(app, entityJobOpening, "ID или название вакансии (0 — все вакансии компании): ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if p.JobOpeningID == 0 {
+		p.CompanyID, err = getEntityInput(app, entityCompany, "ID или название компании: ")
+		handleError(err)
+		if err != nil {
+			return
+		}
+	}
+	p.Candidate = getInput("Часть ФИО кандидата (Enter — любой): ")
+	p.Skill = getInput("Навык кандидата (Enter — любой): ")
+	p.Statuses = splitList(getInput(fmt.Sprintf("Этапы через запятую (%s; Enter — все): ", strings.Join(storage.ApplicationStatuses, "/"))), ",")
+	p.Recruiter = getInput("Рекрутер (Enter — любой): ")
+	if text := getInput("На этапе не меньше дней (Enter — не важно): "); text != "" {
+		p.MinDays, err = strconv.Atoi(text)
+		if err != nil {
+			handleError(fmt.Errorf("неверный ввод целого числа: %w", err))
+			return
+		}
+	}
+	p.SLA = getInput("SLA (breached — просрочен, ok — в срок, Enter — не важно): ")
+	p.AsOf = getInput("На дату (ГГГГ-ММ-ДД, Enter — сейчас): ")
+	at := pipelineMoment(p)
+	q := ListQuery{PerPage: 20, Sort: sortPrompt(storage.ApplicationSorts)}
+	browsePages(q.PerPage, "Откликов не найдено.", func(page int) ([]string, int, error) {
+		q.Page = page
+		entries, err := searchPipeline(app, p, q)
+		lines := make([]string, len(entries))
+		for i, e := range entries {
+			lines[i] = pipelineLine(e, at)
+		}
+		return lines, -1, err
+	})
+}
+
+// pipeline -job N | -company N [-candidate текст] [-skill навык] [-status этап,…] [-recruiter имя]
+// [-min-days N] [-max-days N] [-sla breached|ok] [-as-of дата] [-summary] [-page N] [-per-page N] [-sort поле]
+func runPipelineCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("pipeline", flag.ContinueOnError)
+	var p PipelineQuery
+	var statuses string
+	jobOpening := entityFlagVar(flags, "job", entityJobOpening, "ID или название вакансии")
+	company := entityFlagVar(flags, "company", entityCompany, "ID или название компании: поиск по всем её вакансиям")
+	flags.StringVar(&p.Candidate, "candidate", "", "часть ФИО кандидата")
+	flags.StringVar(&p.Skill, "skill", "", "навык кандидата")
+	flags.StringVar(&statuses, "status", "", "этапы через запятую")
+	flags.StringVar(&p.Recruiter, "recruiter", "", "имя пользователя рекрутера вакансии")
+	flags.IntVar(&p.MinDays, "min-days", 0, "на этапе не меньше N дней")
+	flags.IntVar(&p.MaxDays, "max-days", 0, "на этапе меньше N дней")
+	flags.StringVar(&p.SLA, "sla", "", "breached — SLA нарушен, ok — в срок")
+	flags.StringVar(&p.AsOf, "as-of", "", "воронка на конец дня ГГГГ-ММ-ДД или на момент RFC 3339")
+	summary := flags.Bool("summary", false, "вывести число откликов на каждом этапе вместо списка")
+	q := ListQuery{}
+	flags.IntVar(&q.Page, "page", 1, "номер страницы")
+	flags.IntVar(&q.PerPage, "per-page", 50, "откликов на странице")
+	flags.StringVar(&q.Sort, "sort", "", "поле сортировки: "+sortFieldNames(storage.ApplicationSorts)+"; «-» впереди — по убыванию")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	p.Statuses = splitList(statuses, ",")
+	var err error
+	if p.JobOpeningID, err = jobOpening.resolve(app.DB); err != nil {
+		return reportError(err)
+	}
+	if p.CompanyID, err = company.resolve(app.DB); err != nil {
+		return reportError(err)
+	}
+	if *summary {
+		counts, err := pipelineStages(app, p)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{
+			data: counts,
+			text: func(w io.Writer) { writePipelineStages(w, counts) },
+			csv:  func(w io.Writer) error { return writePipelineStagesCSV(w, counts) },
+		})
+	}
+	entries, err := searchPipeline(app, p, q)
+	if err != nil {
+		return reportError(err)
+	}
+	at := pipelineMoment(p)
+	return printView(sharedView{data: entries, text: func(w io.Writer) {
+		if len(entries) == 0 {
+			fmt.Fprintln(w, "Откликов не найдено.")
+		}
+		for _, e := range entries {
+			fmt.Fprintln(w, pipelineLine(e, at))
+		}
+	}})
+}
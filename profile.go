@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var profiling bool
+
+func profileOperation(cpuPath, heapPath string, fn func()) error {
+	if profiling {
+		return errors.New("профилирование уже запущено")
+	}
+	cpuFile, err := os.Create(cpuPath)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла CPU-профиля: %w", err)
+	}
+	defer cpuFile.Close()
+
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		return fmt.Errorf("ошибка запуска CPU-профилирования: %w", err)
+	}
+	profiling = true
+	fn()
+	pprof.StopCPUProfile()
+	profiling = false
+
+	heapFile, err := os.Create(heapPath)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла heap-профиля: %w", err)
+	}
+	defer heapFile.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(heapFile); err != nil {
+		return fmt.Errorf("ошибка записи heap-профиля: %w", err)
+	}
+	return nil
+}
+
+func profileMenu(app *App) {
+	choice, err := getIntInput("Введите номер действия для профилирования: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if choice == 0 {
+		fmt.Println("Выход нельзя профилировать.")
+		return
+	}
+	cpuPath := getInput("Файл CPU-профиля (Enter — cpu.pprof): ")
+	if cpuPath == "" {
+		cpuPath = "cpu.pprof"
+	}
+	heapPath := getInput("Файл heap-профиля (Enter — heap.pprof): ")
+	if heapPath == "" {
+		heapPath = "heap.pprof"
+	}
+
+	err = profileOperation(cpuPath, heapPath, func() { runMenuAction(app, choice) })
+	handleError(err)
+	if err == nil {
+		fmt.Printf("Профили сохранены: %s, %s (go tool pprof <файл>)\n", cpuPath, heapPath)
+	}
+}
+
+// Обёртка для неинтерактивных команд: profile [-cpu файл] [-heap файл] <команда> [аргументы].
+func runProfileCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
+	cpuPath := flags.String("cpu", "cpu.pprof", "файл CPU-профиля")
+	heapPath := flags.String("heap", "heap.pprof", "файл heap-профиля")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() == 0 {
+		fmt.Println("Укажите команду для профилирования, например: profile bench")
+		return exitUsage
+	}
+
+	code := 0
+	err := profileOperation(*cpuPath, *heapPath, func() { code = runCommand(app, flags.Arg(0), flags.Args()[1:]) })
+	if err != nil {
+		return reportError(err)
+	}
+	printNotice(fmt.Sprintf("Профили сохранены: %s, %s", *cpuPath, *heapPath))
+	return code
+}
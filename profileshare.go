@@ -0,0 +1,450 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"your_project_name/storage"
+)
+
+// Ссылки на профиль кандидата для нанимающего менеджера без учётной записи: <APP_BASE_URL>/p/<токен>.
+// По ссылке видны только выбранные при создании поля. Создавать ссылки, смотреть их список и отзывать
+// их может пользователь, которому можно изменять кандидатов, и только для кандидатов своей компании
+// (authorizeCandidate). Ссылка действует PROFILE_SHARE_TTL (7 дней) или срок, заданный
+// при создании, но не дольше 90 дней; её можно отозвать раньше. В базе хранится только SHA-256 токена,
+// каждый просмотр записывается в profile_share_views.
+
+const maxProfileShareTTL = 90 * 24 * time.Hour
+
+func profileShareTTL() time.Duration {
+	return tokenTTL("PROFILE_SHARE_TTL", 7*24*time.Hour)
+}
+
+// Поля профиля, которые можно открыть по ссылке, в порядке показа.
+var profileShareFields = []string{"full_name", "age", "experience", "skills", "expected_salary", "location", "languages",
+	"available_from", "email", "phone"}
+
+var defaultProfileShareFields = []string{"full_name", "experience", "skills", "location", "languages", "available_from"}
+
+type ProfileShare struct {
+	ID           int        `json:"id"`
+	CandidateID  int        `json:"candidate_id"`
+	Fields       []string   `json:"fields"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	Views        int        `json:"views"`
+	LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
+}
+
+func (s ProfileShare) active() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// Только что созданная ссылка; токен больше нигде не показывается.
+type ProfileShareLink struct {
+	ProfileShare
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// Профиль, каким его видят по ссылке: невыбранные поля пусты.
+type PublicProfile struct {
+	FullName       string     `json:"full_name,omitempty"`
+	Age            int        `json:"age,omitempty"`
+	Experience     string     `json:"experience,omitempty"`
+	Skills         []string   `json:"skills,omitempty"`
+	ExpectedSalary float64    `json:"expected_salary,omitempty"`
+	SalaryCurrency string     `json:"salary_currency,omitempty"`
+	Location       string     `json:"location,omitempty"`
+	Languages      []string   `json:"languages,omitempty"`
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	Email          string     `json:"email,omitempty"`
+	Phone          string     `json:"phone,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+func profileShareURL(token string) string {
+	return fmt.Sprintf("%s/p/%s", appBaseURL(), token)
+}
+
+// Токен из ссылки вида .../p/<токен> или сам токен.
+func profileShareToken(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.LastIndex(text, "/p/"); i >= 0 {
+		text = text[i+len("/p/"):]
+	}
+	return strings.Trim(text, "/")
+}
+
+// Проверяет выбранные поля; пустой список — поля по умолчанию. Порядок — как в profileShareFields.
+func normalizeProfileShareFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return defaultProfileShareFields, nil
+	}
+	chosen := map[string]bool{}
+	for _, f := range fields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if !containsString(profileShareFields, f) {
+			return nil, validationErrorf("поле %q нельзя открыть по ссылке, допустимые: %s", f, strings.Join(profileShareFields, ", "))
+		}
+		chosen[f] = true
+	}
+	var result []string
+	for _, f := range profileShareFields {
+		if chosen[f] {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// ttl 0 — срок по умолчанию.
+func createProfileShare(app *App, candidateID int, fields []string, ttl time.Duration) (ProfileShareLink, error) {
+	var link ProfileShareLink
+	fields, err := normalizeProfileShareFields(fields)
+	if err != nil {
+		return link, err
+	}
+	if ttl == 0 {
+		ttl = profileShareTTL()
+	}
+	if ttl < time.Hour || ttl > maxProfileShareTTL {
+		return link, validationErrorf("срок действия ссылки должен быть от часа до %d дней", int(maxProfileShareTTL.Hours()/24))
+	}
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return link, err
+	}
+	if err := authorizeCandidate(app, candidateID); err != nil {
+		return link, err
+	}
+	userID := actingUserID()
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return link, fmt.Errorf("ошибка генерации ссылки: %w", err)
+	}
+	link.Token = hex.EncodeToString(raw)
+	link.URL = profileShareURL(link.Token)
+	link.CandidateID, link.Fields = candidateID, fields
+	link.ExpiresAt = time.Now().Add(ttl)
+	err = app.DB.QueryRow(`INSERT INTO profile_shares (candidate_id, token_hash, fields, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		candidateID, hashToken(link.Token), pq.Array(fields), nullUserID(userID), link.ExpiresAt).Scan(&link.ID, &link.CreatedAt)
+	if err != nil {
+		return link, fmt.Errorf("ошибка сохранения ссылки: %w", err)
+	}
+	audit("candidate.profile_share.created", "candidate_id", candidateID, "share_id", link.ID, "fields", strings.Join(fields, ","),
+		"expires_at", link.ExpiresAt)
+	return link, nil
+}
+
+func listProfileShares(app *App, candidateID int) ([]ProfileShare, error) {
+	if err := authorizeCandidate(app, candidateID); err != nil {
+		return nil, err
+	}
+	rows, err := app.DB.Query(`
+		SELECT s.id, s.candidate_id, s.fields, COALESCE(u.username, ''), s.created_at, s.expires_at, s.revoked_at,
+			COUNT(v.id), MAX(v.viewed_at)
+		FROM profile_shares s
+		LEFT JOIN users u ON u.id = s.created_by
+		LEFT JOIN profile_share_views v ON v.share_id = s.id
+		WHERE s.candidate_id = $1
+		GROUP BY s.id, u.username
+		ORDER BY s.created_at DESC`, candidateID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var shares []ProfileShare
+	for rows.Next() {
+		var s ProfileShare
+		var revokedAt, lastViewedAt sql.NullTime
+		if err := rows.Scan(&s.ID, &s.CandidateID, pq.Array(&s.Fields), &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt, &revokedAt,
+			&s.Views, &lastViewedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if revokedAt.Valid {
+			s.RevokedAt = &revokedAt.Time
+		}
+		if lastViewedAt.Valid {
+			s.LastViewedAt = &lastViewedAt.Time
+		}
+		shares = append(shares, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return shares, nil
+}
+
+// Отозвать ссылку может её автор или администратор, и только если ему можно изменять кандидатов
+// и кандидат относится к его компании (см. authorizeCandidate).
+func revokeProfileShare(app *App, id int) error {
+	var candidateID int
+	var createdBy sql.NullInt64
+	err := app.DB.QueryRow("SELECT candidate_id, created_by FROM profile_shares WHERE id = $1 AND revoked_at IS NULL", id).
+		Scan(&candidateID, &createdBy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundError("действующая ссылка с таким ID не найдена")
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	if userID := actingUserID(); userID != 0 {
+		if err := authorizeUser(app, &TokenClaims{Subject: strconv.Itoa(userID)}, permCandidatesWrite); err != nil {
+			return err
+		}
+		user, err := app.Users.GetByID(userID)
+		if err != nil {
+			return err
+		}
+		if user.Role != storage.RoleAdmin && createdBy.Int64 != int64(userID) {
+			return permissionError("отозвать ссылку может только её автор или администратор")
+		}
+		if err := authorizeCandidate(app, candidateID); err != nil {
+			return err
+		}
+	}
+	result, err := app.DB.Exec("UPDATE profile_shares SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва ссылки: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return notFoundError("действующая ссылка с таким ID не найдена")
+	}
+	audit("candidate.profile_share.revoked", "share_id", id)
+	return nil
+}
+
+// Профиль по ссылке; via — откуда открыли (rpc, cli), пишется в журнал просмотров.
+// Неизвестная, отозванная и истёкшая ссылки не различаются, чтобы по ответу нельзя было подбирать токены.
+func viewSharedProfile(app *App, token, via string) (PublicProfile, error) {
+	var profile PublicProfile
+	token = profileShareToken(token)
+	if token == "" {
+		return profile, validationErrorf("укажите ссылку на профиль")
+	}
+	var shareID, candidateID int
+	var fields []string
+	err := app.DB.QueryRow(`SELECT id, candidate_id, fields, expires_at FROM profile_shares
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > now()`, hashToken(token)).
+		Scan(&shareID, &candidateID, pq.Array(&fields), &profile.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return profile, permissionError("ссылка недействительна: её отозвали или срок её действия истёк")
+	}
+	if err != nil {
+		return profile, fmt.Errorf("ошибка проверки ссылки: %w", err)
+	}
+	candidate, err := app.Candidates.GetByID(candidateID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return profile, permissionError("ссылка недействительна: её отозвали или срок её действия истёк")
+	}
+	if err != nil {
+		return profile, err
+	}
+	if _, err := app.DB.Exec("INSERT INTO profile_share_views (share_id, via) VALUES ($1, $2)", shareID, via); err != nil {
+		return profile, fmt.Errorf("ошибка записи просмотра: %w", err)
+	}
+	audit("candidate.profile_share.viewed", "share_id", shareID, "candidate_id", candidateID, "via", via)
+
+	for _, f := range fields {
+		switch f {
+		case "full_name":
+			profile.FullName = candidate.FullName
+		case "age":
+			profile.Age = candidate.Age
+		case "experience":
+			profile.Experience = candidate.Experience
+		case "skills":
+			profile.Skills = candidate.Skills
+		case "expected_salary":
+			profile.ExpectedSalary, profile.SalaryCurrency = candidate.ExpectedSalary, candidate.SalaryCurrency
+		case "location":
+			profile.Location = candidate.Location
+		case "languages":
+			profile.Languages = candidate.Languages
+		case "available_from":
+			profile.AvailableFrom = candidate.AvailableFrom
+		case "email":
+			profile.Email = candidate.Email
+		case "phone":
+			profile.Phone = candidate.Phone
+		}
+	}
+	return profile, nil
+}
+
+func writePublicProfile(w io.Writer, p PublicProfile) {
+	lines := [][2]string{
+		{"ФИО", p.FullName},
+		{"Опыт", p.Experience},
+		{"Навыки", strings.Join(p.Skills, ", ")},
+		{"Город", p.Location},
+		{"Языки", strings.Join(p.Languages, ", ")},
+		{"Email", p.Email},
+		{"Телефон", p.Phone},
+	}
+	if p.Age > 0 {
+		lines = append(lines, [2]string{"Возраст", strconv.Itoa(p.Age)})
+	}
+	if p.ExpectedSalary > 0 {
+		lines = append(lines, [2]string{"Ожидаемая зарплата", formatAmount(p.ExpectedSalary) + " " + p.SalaryCurrency})
+	}
+	if p.AvailableFrom != nil {
+		lines = append(lines, [2]string{"Может выйти с", p.AvailableFrom.Format("2006-01-02")})
+	}
+	for _, line := range lines {
+		if line[1] != "" {
+			fmt.Fprintf(w, "%s: %s\n", line[0], line[1])
+		}
+	}
+	fmt.Fprintf(w, "Ссылка действует до %s\n", p.ExpiresAt.Local().Format("2006-01-02 15:04"))
+}
+
+func profileShareLine(s ProfileShare) string {
+	state := "действует до " + s.ExpiresAt.Local().Format("2006-01-02 15:04")
+	switch {
+	case s.RevokedAt != nil:
+		state = "отозвана " + s.RevokedAt.Local().Format("2006-01-02 15:04")
+	case !s.active():
+		state = "истекла " + s.ExpiresAt.Local().Format("2006-01-02 15:04")
+	}
+	viewed := "не открывали"
+	if s.LastViewedAt != nil {
+		viewed = fmt.Sprintf("просмотров: %d, последний %s", s.Views, s.LastViewedAt.Local().Format("2006-01-02 15:04"))
+	}
+	createdBy := s.CreatedBy
+	if createdBy == "" {
+		createdBy = "—"
+	}
+	return fmt.Sprintf("#%d %s; поля: %s; создал %s; %s", s.ID, state, strings.Join(s.Fields, ", "), createdBy, viewed)
+}
+
+func profileShareMenu(app *App) {
+	candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	shares, err := listProfileShares(app, candidateID)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	for _, s := range shares {
+		fmt.Println(profileShareLine(s))
+	}
+	input := getInput("n — новая ссылка, «r номер» — отозвать, Enter — назад: ")
+	switch text, revoke := strings.CutPrefix(input, "r"); {
+	case input == "":
+		return
+	case revoke:
+		id, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			fmt.Println("Укажите номер ссылки, например: r 12")
+			return
+		}
+		err = revokeProfileShare(app, id)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Ссылка отозвана.")
+		}
+		return
+	case input != "n":
+		fmt.Println("Неверный выбор действия.")
+		return
+	}
+
+	fmt.Printf("Поля: %s\n", strings.Join(profileShareFields, ", "))
+	fields := splitList(getInput(fmt.Sprintf("Какие поля открыть, через запятую (Enter — %s): ", strings.Join(defaultProfileShareFields, ", "))), ",")
+	days, err := getIntInput(fmt.Sprintf("Сколько дней действует ссылка (0 — %s): ", profileShareTTL()))
+	handleError(err)
+	if err != nil {
+		return
+	}
+	link, err := createProfileShare(app, candidateID, fields, time.Duration(days)*24*time.Hour)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Ссылка действует до %s:\n%s\n", link.ExpiresAt.Local().Format("2006-01-02 15:04"), link.URL)
+	if confirm("Скопировать ссылку в буфер обмена?") {
+		handleError(copyToClipboard(link.URL))
+	}
+}
+
+// profile-share create <кандидат> [-fields поля] [-ttl 72h] | profile-share list <кандидат> |
+// profile-share revoke <ID ссылки> | profile-share view <ссылка или токен>
+func runProfileShareCommand(app *App, args []string) int {
+	usage := "Использование: profile-share create <ID или ФИО кандидата> [-fields поля через запятую] [-ttl 72h] | " +
+		"profile-share list <ID или ФИО кандидата> | profile-share revoke <ID ссылки> | profile-share view <ссылка или токен>"
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("profile-share create", flag.ContinueOnError)
+		fields := flags.String("fields", "", "поля профиля: "+strings.Join(profileShareFields, ", ")+"; по умолчанию "+
+			strings.Join(defaultProfileShareFields, ", "))
+		ttl := flags.Duration("ttl", 0, "срок действия ссылки (по умолчанию PROFILE_SHARE_TTL)")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		id, err := resolveEntityID(app.DB, entityCandidate, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		link, err := createProfileShare(app, id, splitList(*fields, ","), *ttl)
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(link.URL, link)
+	case "list":
+		id, err := resolveEntityID(app.DB, entityCandidate, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		shares, err := listProfileShares(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: shares, text: func(w io.Writer) {
+			if len(shares) == 0 {
+				fmt.Fprintln(w, "Ссылок на профиль нет.")
+			}
+			for _, s := range shares {
+				fmt.Fprintln(w, profileShareLine(s))
+			}
+		}})
+	case "revoke":
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(validationErrorf("ID ссылки должен быть числом"))
+		}
+		if err := revokeProfileShare(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Ссылка отозвана.", map[string]int{"id": id})
+	case "view":
+		profile, err := viewSharedProfile(app, args[1], "cli")
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: profile, text: func(w io.Writer) { writePublicProfile(w, profile) }})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
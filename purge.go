@@ -0,0 +1,334 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Сроки хранения данных. У каждого класса данных свой срок в днях (RETENTION_*_DAYS, 0 — хранить без
+// ограничения); раз в сутки фоновая задача удаляет записи старше срока пачками по PURGE_BATCH, записывая
+// ход в журнал. Перед удалением можно посмотреть, сколько записей каждого класса уже вышло за срок
+// (purge report). Кандидаты удаляются так же, как из меню — вместе с файлом резюме, но фоновая очистка
+// записывается в журнал изменений без пользователя (newJobApp). Записи под юридическим
+// удержанием (legalhold.go) и их история в очистку не попадают.
+
+const (
+	retentionPurgeJob   = "retention.purge"
+	retentionPurgeOnce  = "retention.purge_once"
+	defaultPurgeBatch   = 500
+	purgeRescheduleTime = 24 * time.Hour
+)
+
+type retentionClass struct {
+	Name        string
+	Title       string
+	Env         string
+	DefaultDays int
+	Table       string
+	// Условие на записи таблицы, вышедшие за срок; $1 — граница срока.
+	Expired string
+	// nil — строки удаляются из Table по id.
+	remove func(app *App, ids []int64) error
+}
+
+var retentionClasses = []retentionClass{
+	{
+		Name: "rejected_candidates", Title: "Отклонённые кандидаты", Env: "RETENTION_REJECTED_CANDIDATES_DAYS", DefaultDays: 730,
+		Table: "candidates",
+		// Все отклики кандидата отклонены, и ни анкета, ни отклики не менялись с границы срока. Анкеты,
+		// связанные с учётной записью, ведёт сам кандидат — они не удаляются.
+		Expired: `user_id IS NULL AND updated_at < $1
+      AND EXISTS (SELECT 1 FROM applications a WHERE a.candidate_id = candidates.id)
+      AND NOT EXISTS (SELECT 1 FROM applications a WHERE a.candidate_id = candidates.id
+                      AND (a.status <> 'rejected' OR a.updated_at >= $1))
+      AND NOT EXISTS (SELECT 1 FROM legal_hold_records r WHERE r.candidate_id = candidates.id)`,
+		remove: func(app *App, ids []int64) error {
+			for _, id := range ids {
+				if err := deleteCandidate(app, int(id)); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name: "audit_log", Title: "Журнал изменений", Env: "RETENTION_AUDIT_LOG_DAYS", DefaultDays: 1825,
+		Table: "audit_log",
+		Expired: `changed_at < $1 AND NOT EXISTS (SELECT 1 FROM legal_hold_records r
+                      WHERE (audit_log.entity = 'candidates' AND r.candidate_id = audit_log.entity_id)
+                         OR (audit_log.entity = 'applications' AND r.application_id = audit_log.entity_id))`,
+	},
+	{
+		Name: "notifications", Title: "Уведомления", Env: "RETENTION_NOTIFICATIONS_DAYS", DefaultDays: 90,
+		// Ожидающие отправки не трогаем, как бы давно они ни были созданы.
+		Table: "notifications", Expired: "status <> 'pending' AND created_at < $1",
+	},
+	{
+		Name: "application_history", Title: "История откликов", Env: "APPLICATION_HISTORY_DAYS", DefaultDays: defaultApplicationHistoryDays,
+		// Текущие версии не удаляются.
+		Table: "application_versions",
+		Expired: `valid_to < $1 AND NOT EXISTS (SELECT 1 FROM legal_hold_records r
+                      WHERE r.candidate_id = application_versions.candidate_id
+                        AND (r.application_id IS NULL OR r.application_id = application_versions.application_id))`,
+	},
+}
+
+func lookupRetentionClass(name string) (retentionClass, error) {
+	names := make([]string, len(retentionClasses))
+	for i, c := range retentionClasses {
+		if c.Name == name {
+			return c, nil
+		}
+		names[i] = c.Name
+	}
+	return retentionClass{}, validationErrorf("неизвестный класс данных %q, допустимые: %s", name, strings.Join(names, ", "))
+}
+
+// 0 — класс хранится без ограничения.
+func (c retentionClass) days() int {
+	days, err := strconv.Atoi(os.Getenv(c.Env))
+	if err != nil || days < 0 {
+		days = c.DefaultDays
+	}
+	return days
+}
+
+func purgeBatchSize() int {
+	batch, err := strconv.Atoi(os.Getenv("PURGE_BATCH"))
+	if err != nil || batch < 1 {
+		batch = defaultPurgeBatch
+	}
+	return batch
+}
+
+type PurgeReportRow struct {
+	Class  string     `json:"class"`
+	Title  string     `json:"title"`
+	Days   int        `json:"days"`
+	Cutoff *time.Time `json:"cutoff,omitempty"`
+	// Сколько записей будет удалено при следующей очистке.
+	Expired int `json:"expired"`
+}
+
+func purgeReport(app *App) ([]PurgeReportRow, error) {
+	now := time.Now()
+	rows := make([]PurgeReportRow, 0, len(retentionClasses))
+	for _, c := range retentionClasses {
+		row := PurgeReportRow{Class: c.Name, Title: c.Title, Days: c.days()}
+		if row.Days > 0 {
+			cutoff := now.AddDate(0, 0, -row.Days)
+			row.Cutoff = &cutoff
+			err := app.DB.QueryRow("SELECT count(*) FROM "+c.Table+" WHERE "+c.Expired, cutoff).Scan(&row.Expired)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка подсчёта записей «%s»: %w", c.Title, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Удаляет записи класса старше срока пачками по batch; граница считается один раз, поэтому проход
+// конечен, даже если данные продолжают стареть. Возвращает число удалённых записей.
+func purgeRetentionClass(app *App, c retentionClass, batch int) (int, error) {
+	days := c.days()
+	if days == 0 {
+		return 0, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+	var expired int
+	if err := app.DB.QueryRow("SELECT count(*) FROM "+c.Table+" WHERE "+c.Expired, cutoff).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("ошибка подсчёта записей «%s»: %w", c.Title, err)
+	}
+	logger := app.logger().With("action", "retention.purge", "class", c.Name)
+	total := 0
+	for total < expired {
+		ids, err := expiredIDs(app.DB, c, cutoff, batch)
+		if err != nil {
+			return total, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		if err := c.delete(app, ids); err != nil {
+			return total, err
+		}
+		total += len(ids)
+		logger.Info("удалена пачка записей по сроку хранения", "deleted", total, "expired", expired)
+	}
+	if total > 0 {
+		app.audit("retention.purge", "class", c.Name, "count", total, "cutoff", cutoff.Format(time.RFC3339))
+	}
+	return total, nil
+}
+
+func (c retentionClass) delete(app *App, ids []int64) error {
+	if c.remove != nil {
+		return c.remove(app, ids)
+	}
+	if _, err := app.DB.Exec("DELETE FROM "+c.Table+" WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return fmt.Errorf("ошибка удаления записей «%s»: %w", c.Title, err)
+	}
+	return nil
+}
+
+func expiredIDs(db *sql.DB, c retentionClass, cutoff time.Time, limit int) ([]int64, error) {
+	rows, err := db.Query("SELECT id FROM "+c.Table+" WHERE "+c.Expired+" ORDER BY id LIMIT $2", cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выборки записей «%s»: %w", c.Title, err)
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка чтения записей «%s»: %w", c.Title, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Пустое имя — все классы. Возвращает число удалённых записей по классам.
+func purgeExpired(app *App, class string, batch int) (map[string]int, error) {
+	classes := retentionClasses
+	if class != "" {
+		c, err := lookupRetentionClass(class)
+		if err != nil {
+			return nil, err
+		}
+		classes = []retentionClass{c}
+	}
+	deleted := map[string]int{}
+	for _, c := range classes {
+		n, err := purgeRetentionClass(app, c, batch)
+		deleted[c.Name] = n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+type retentionPurgePayload struct {
+	Class string `json:"class,omitempty"`
+}
+
+func handleRetentionPurgeOnceJob(db *sql.DB, payload json.RawMessage) error {
+	var p retentionPurgePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("ошибка разбора задачи: %w", err)
+	}
+	_, err := purgeExpired(newJobApp(db), p.Class, purgeBatchSize())
+	return err
+}
+
+// Ежесуточная очистка ставит себя на следующие сутки; разовая (purge run -background) — нет, поэтому
+// у них разные типы задач и одна не мешает планировать другую.
+func handleRetentionPurgeJob(db *sql.DB, payload json.RawMessage) error {
+	if _, err := purgeExpired(newJobApp(db), "", purgeBatchSize()); err != nil {
+		return err
+	}
+	_, err := enqueueJobAt(db, retentionPurgeJob, struct{}{}, time.Now().Add(purgeRescheduleTime))
+	return err
+}
+
+func init() {
+	registerJobHandler(retentionPurgeJob, handleRetentionPurgeJob)
+	registerJobHandler(retentionPurgeOnce, handleRetentionPurgeOnceJob)
+}
+
+func scheduleRetentionPurge(db *sql.DB) error {
+	scheduled, err := jobScheduled(db, retentionPurgeJob)
+	if err != nil || scheduled {
+		return err
+	}
+	_, err = enqueueJob(db, retentionPurgeJob, struct{}{})
+	return err
+}
+
+func scheduleRetentionPurgeOnce(app *App, class string) (int, error) {
+	if class != "" {
+		if _, err := lookupRetentionClass(class); err != nil {
+			return 0, err
+		}
+	}
+	return enqueueJob(app.DB, retentionPurgeOnce, retentionPurgePayload{Class: class})
+}
+
+func printPurgeReport(w io.Writer, rows []PurgeReportRow) {
+	for _, r := range rows {
+		if r.Days == 0 {
+			fmt.Fprintf(w, "%-24s хранится без ограничения\n", r.Title)
+			continue
+		}
+		fmt.Fprintf(w, "%-24s срок %d дн., старше %s: %d\n", r.Title, r.Days, r.Cutoff.Format("2006-01-02"), r.Expired)
+	}
+}
+
+// purge report [-json] | purge run [-class имя] [-batch N] [-background]
+func runPurgeCommand(app *App, args []string) int {
+	usage := "Использование: purge report [-json] | purge run [-class класс] [-batch N] [-background]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "report":
+		flags := flag.NewFlagSet("purge report", flag.ContinueOnError)
+		asJSON := flags.Bool("json", false, "вывести отчёт в JSON")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		useJSON(*asJSON)
+		rows, err := purgeReport(app)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: rows, text: func(w io.Writer) { printPurgeReport(w, rows) }})
+	case "run":
+		flags := flag.NewFlagSet("purge run", flag.ContinueOnError)
+		class := flags.String("class", "", "класс данных; по умолчанию все")
+		batch := flags.Int("batch", purgeBatchSize(), "сколько записей удалять за раз")
+		background := flags.Bool("background", false, "поставить очистку в очередь задач")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if *batch < 1 {
+			return reportError(validationErrorf("размер пачки должен быть положительным"))
+		}
+		if *background {
+			id, err := scheduleRetentionPurgeOnce(app, *class)
+			if err != nil {
+				return reportError(err)
+			}
+			return printDone(fmt.Sprintf("Очистка поставлена в очередь, задача %d.", id), map[string]int{"job_id": id})
+		}
+		// Удалённое до ошибки тоже показывается.
+		deleted, err := purgeExpired(app, *class, *batch)
+		if deleted != nil {
+			printView(sharedView{data: deleted, text: func(w io.Writer) {
+				for _, c := range retentionClasses {
+					if n, ok := deleted[c.Name]; ok {
+						fmt.Fprintf(w, "%-24s удалено: %d\n", c.Title, n)
+					}
+				}
+			}})
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type CompanyUsage struct {
+	CompanyID      int
+	CompanyName    string
+	JobOpenings    int
+	MaxJobOpenings sql.NullInt64
+}
+
+func setJobOpeningQuota(db *sql.DB, companyID int, limit sql.NullInt64) error {
+	if limit.Valid && limit.Int64 < 0 {
+		return validationErrorf("квота не может быть отрицательной")
+	}
+	_, err := db.Exec(`INSERT INTO company_quotas (company_id, max_job_openings) VALUES ($1, $2)
+		ON CONFLICT (company_id) DO UPDATE SET max_job_openings = EXCLUDED.max_job_openings`, companyID, limit)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения квоты: %w", err)
+	}
+	return nil
+}
+
+func getCompanyUsage(db *sql.DB) ([]CompanyUsage, error) {
+	rows, err := db.Query(`
+		SELECT c.id, c.name, COUNT(j.id) FILTER (WHERE j.status <> 'closed'), q.max_job_openings
+		FROM companies c
+		LEFT JOIN job_openings j ON j.company_id = c.id
+		LEFT JOIN company_quotas q ON q.company_id = c.id
+		GROUP BY c.id, c.name, q.max_job_openings
+		ORDER BY c.id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []CompanyUsage
+	for rows.Next() {
+		var u CompanyUsage
+		if err := rows.Scan(&u.CompanyID, &u.CompanyName, &u.JobOpenings, &u.MaxJobOpenings); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return usage, nil
+}
+
+// nil — квоты нет.
+func companyQuotaValue(limit sql.NullInt64) *int64 {
+	if !limit.Valid {
+		return nil
+	}
+	return &limit.Int64
+}
+
+func companyUsageRows(usage []CompanyUsage) interface{} {
+	type row struct {
+		CompanyID      int    `json:"company_id"`
+		CompanyName    string `json:"company"`
+		JobOpenings    int    `json:"job_openings"`
+		MaxJobOpenings *int64 `json:"max_job_openings"`
+	}
+	rows := make([]row, len(usage))
+	for i, u := range usage {
+		rows[i] = row{CompanyID: u.CompanyID, CompanyName: u.CompanyName, JobOpenings: u.JobOpenings, MaxJobOpenings: companyQuotaValue(u.MaxJobOpenings)}
+	}
+	return rows
+}
+
+func printCompanyUsage(w io.Writer, usage []CompanyUsage) {
+	fmt.Fprintf(w, "%-6s %-30s %s\n", "ID", "Компания", "Вакансии")
+	for _, u := range usage {
+		limit := "без ограничений"
+		if u.MaxJobOpenings.Valid {
+			limit = fmt.Sprintf("из %d", u.MaxJobOpenings.Int64)
+			if int64(u.JobOpenings) >= u.MaxJobOpenings.Int64 {
+				limit += " (квота исчерпана)"
+			}
+		}
+		fmt.Fprintf(w, "%-6d %-30s %d %s\n", u.CompanyID, u.CompanyName, u.JobOpenings, limit)
+	}
+}
+
+// quota report | quota set <ID компании> <лимит вакансий | none>
+func runQuotaCommand(db *sql.DB, args []string) int {
+	usage := "Использование: quota report | quota set <ID компании> <лимит вакансий | none>"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+
+	switch {
+	case args[0] == "report" && len(args) == 1:
+		report, err := getCompanyUsage(db)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: companyUsageRows(report), text: func(w io.Writer) { printCompanyUsage(w, report) }})
+	case args[0] == "set" && len(args) == 3:
+		companyID, err := resolveEntityID(db, entityCompany, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		var limit sql.NullInt64
+		if args[2] != "none" {
+			if limit.Int64, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+				return reportError(err)
+			}
+			limit.Valid = true
+		}
+		if err := setJobOpeningQuota(db, companyID, limit); err != nil {
+			return reportError(err)
+		}
+		return printDone("Квота сохранена.", map[string]interface{}{"company_id": companyID, "max_job_openings": companyQuotaValue(limit)})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
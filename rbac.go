@@ -0,0 +1,422 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+type permission string
+
+const (
+	permCompaniesManage   permission = "companies.manage"
+	permCandidatesRead    permission = "candidates.read"
+	permCandidatesWrite   permission = "candidates.write"
+	permJobsRead          permission = "jobs.read"
+	permJobsWrite         permission = "jobs.write"
+	permApplicationsRead  permission = "applications.read"
+	permApplicationsWrite permission = "applications.write"
+	permReports           permission = "reports.view"
+	// Отчёт по всем рекрутерам; без этого права рекрутер видит только себя.
+	permRecruiterReports permission = "reports.recruiters"
+	permUsersManage      permission = "users.manage"
+	// Настройка весов подбора кандидатов компании.
+	permMatchRules permission = "matching.rules"
+	// Служебные операции: очередь задач, анонимизация, нагрузочные данные, профилирование, миграция.
+	permSystem permission = "system"
+	// Просмотр журнала изменений данных; нет ни у одной роли, кроме admin.
+	permAuditLog permission = "audit.view"
+	// Свои настройки: какие уведомления получать и тихие часы, прочитанные объявления. Есть у всех ролей.
+	permOwnSettings permission = "settings.own"
+	// Своя анкета кандидата: просмотр и изменение анкеты, связанной с учётной записью.
+	permOwnProfile permission = "profile.own"
+	// Юридические удержания; как и журнал изменений, только у admin.
+	permLegalHold permission = "legal.hold"
+	// Публикация и снятие объявлений для пользователей; только у admin.
+	permAnnouncements permission = "announcements.manage"
+)
+
+var recruiterPermissions = []permission{
+	permCandidatesRead, permCandidatesWrite, permJobsRead, permJobsWrite,
+	permApplicationsRead, permApplicationsWrite, permReports, permOwnSettings,
+}
+
+// У admin есть все права, поэтому в таблице его нет.
+var rolePermissions = map[string][]permission{
+	storage.RoleCompanyAdmin: append([]permission{permRecruiterReports, permMatchRules}, recruiterPermissions...),
+	storage.RoleRecruiter:    recruiterPermissions,
+	storage.RoleCandidate:    {permJobsRead, permOwnSettings, permOwnProfile},
+	storage.RoleViewer:       {permCandidatesRead, permJobsRead, permApplicationsRead, permOwnSettings},
+}
+
+var roleNames = []string{storage.RoleAdmin, storage.RoleCompanyAdmin, storage.RoleRecruiter, storage.RoleCandidate, storage.RoleViewer}
+
+func validateRole(role string) error {
+	for _, r := range roleNames {
+		if r == role {
+			return nil
+		}
+	}
+	return validationErrorf("неизвестная роль %q, допустимые: %s", role, strings.Join(roleNames, ", "))
+}
+
+func roleAllows(role string, perm permission) bool {
+	if role == storage.RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Роль читается из базы, а не из токена, чтобы её изменение действовало сразу, а не после обновления токена.
+func authorizeUser(app *App, claims *TokenClaims, perm permission) error {
+	if claims == nil {
+		return permissionError("требуется авторизация")
+	}
+	user, err := app.Users.GetByID(claims.UserID())
+	if errors.Is(err, storage.ErrNotFound) {
+		return permissionError("пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+	if !roleAllows(user.Role, perm) {
+		return permissionError(fmt.Sprintf("недостаточно прав: роль %s не может выполнить это действие", user.Role))
+	}
+	return nil
+}
+
+// Проверка для консольного меню по текущей сессии.
+func authorize(app *App, perm permission) error {
+	claims, err := activeSession(app)
+	if err != nil {
+		return err
+	}
+	return authorizeUser(app, claims, perm)
+}
+
+// Вакансии компании меняют только её сотрудники: у рекрутера и администратора компании должна быть
+// указана компания (user company), и она должна совпадать с компанией вакансии. Ограничения нет у admin
+// и у консольных команд без сессии — их запускает тот, у кого есть доступ к базе.
+func authorizeCompany(app *App, companyID int) error {
+	scope, err := companyScope(app)
+	if err != nil {
+		return err
+	}
+	if scope != 0 && scope != companyID {
+		return permissionError("вакансии другой компании изменять нельзя")
+	}
+	return nil
+}
+
+// Компания, которой ограничена работа пользователя, по тем же правилам; 0 — без ограничения.
+func companyScope(app *App) (int, error) {
+	userID := actingUserID()
+	if userID == 0 {
+		return 0, nil
+	}
+	user, err := app.Users.GetByID(userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return 0, permissionError("пользователь не найден")
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case user.Role == storage.RoleAdmin:
+		return 0, nil
+	case user.CompanyID == 0:
+		return 0, permissionError("учётная запись не привязана к компании, обратитесь к администратору")
+	}
+	return user.CompanyID, nil
+}
+
+// Кандидат своей компании: хотя бы один его отклик — на вакансию компании пользователя. У кандидата
+// без откликов компании нет, с ним может работать любой, у кого есть права на кандидатов.
+func authorizeCandidate(app *App, candidateID int) error {
+	if actingUserID() == 0 {
+		return nil
+	}
+	rows, err := app.DB.Query(`SELECT DISTINCT j.company_id FROM applications a
+		JOIN job_openings j ON j.id = a.job_opening_id WHERE a.candidate_id = $1`, candidateID)
+	if err != nil {
+		return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	var companies []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		companies = append(companies, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	for _, companyID := range companies {
+		if err = authorizeCompany(app, companyID); err == nil {
+			return nil
+		}
+	}
+	if err != nil && classifyError(err) == kindPermission {
+		return permissionError("кандидат откликался только на вакансии других компаний")
+	}
+	return err
+}
+
+func authorizeJobOpening(app *App, id int) error {
+	jobOpening, err := getJobOpeningByID(app, id)
+	if err != nil {
+		return err
+	}
+	return authorizeCompany(app, jobOpening.CompanyID)
+}
+
+// Последнего администратора понизить нельзя, иначе назначать роли станет некому. Администраторы
+// блокируются на время проверки: иначе два администратора, одновременно понижающие друг друга,
+// оба увидели бы второго и остались бы без администраторов.
+func setUserRole(app *App, username, role string) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	var user storage.User
+	err := storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		if _, err := tx.Exec("SELECT id FROM users WHERE role = $1 FOR UPDATE", storage.RoleAdmin); err != nil {
+			return fmt.Errorf("ошибка блокировки администраторов: %w", err)
+		}
+		users := storage.NewPostgres(tx).Users
+		var err error
+		user, err = users.GetByUsername(normalizeText(username, false))
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFoundError("пользователь не найден")
+		}
+		if err != nil {
+			return err
+		}
+		if user.Role == storage.RoleAdmin && role != storage.RoleAdmin {
+			admins, err := users.CountByRole(storage.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return validationErrorf("нельзя снять роль с последнего администратора")
+			}
+		}
+		return users.SetRole(user.ID, role)
+	})
+	if err != nil {
+		return err
+	}
+	audit("user.role", "target_user_id", user.ID, "username", user.Username, "from", user.Role, "to", role)
+	return nil
+}
+
+// Пункты меню и нужные для них права; пункты без записи доступны всем.
+var menuPermissions = map[int]permission{
+	3:  permCompaniesManage,
+	4:  permCandidatesWrite,
+	5:  permJobsWrite,
+	6:  permCandidatesRead,
+	7:  permJobsRead,
+	8:  permJobsRead,
+	9:  permCandidatesWrite,
+	10: permJobsWrite,
+	11: permSystem,
+	12: permSystem,
+	13: permSystem,
+	14: permSystem,
+	15: permCandidatesWrite,
+	16: permJobsWrite,
+	17: permSystem,
+	20: permCandidatesRead,
+	21: permUsersManage,
+	23: permCandidatesRead,
+	24: permCandidatesRead,
+	25: permCandidatesWrite,
+	26: permJobsWrite,
+	27: permJobsWrite,
+	28: permJobsRead,
+	29: permCompaniesManage,
+	30: permCompaniesManage,
+	31: permCandidatesWrite,
+	32: permCandidatesRead,
+	33: permJobsRead,
+	34: permCandidatesRead,
+	35: permCandidatesRead,
+	// Права на сами записи проверяются при открытии, по их виду.
+	37: permJobsRead,
+	38: permJobsRead,
+	39: permAuditLog,
+	// Права на продолжение черновика проверяются по его форме.
+	40: permJobsRead,
+	41: permCandidatesWrite,
+	// Загрузка, замена и удаление файла дополнительно требуют права на изменение кандидатов.
+	42: permCandidatesRead,
+	43: permCandidatesWrite,
+	44: permApplicationsWrite,
+	45: permOwnSettings,
+	// Лента строится по анкете, связанной с учётной записью.
+	46: permJobsRead,
+	47: permOwnProfile,
+	48: permLegalHold,
+	// Публиковать и снимать объявления из этого пункта может только тот, у кого есть permAnnouncements.
+	49: permOwnSettings,
+}
+
+// Права для методов JSON-RPC; методы без записи (регистрация, вход, обновление токена) доступны без токена.
+var rpcPermissions = map[string]permission{
+	"company.add":                  permCompaniesManage,
+	"company.get":                  permJobsRead,
+	"company.list":                 permJobsRead,
+	"company.update":               permCompaniesManage,
+	"company.delete":               permCompaniesManage,
+	"candidate.add":                permCandidatesWrite,
+	"candidate.get":                permCandidatesRead,
+	"candidate.update":             permCandidatesWrite,
+	"candidate.searchBySkill":      permCandidatesRead,
+	"candidate.query":              permCandidatesRead,
+	"savedSearch.list":             permCandidatesRead,
+	"savedSearch.save":             permCandidatesRead,
+	"savedSearch.delete":           permCandidatesRead,
+	"candidate.similar":            permCandidatesRead,
+	"candidate.list":               permCandidatesRead,
+	"candidate.delete":             permCandidatesWrite,
+	"jobOpening.add":               permJobsWrite,
+	"jobOpening.get":               permJobsRead,
+	"jobOpening.update":            permJobsWrite,
+	"jobOpening.searchBySkill":     permJobsRead,
+	"jobOpening.similar":           permJobsRead,
+	"jobOpening.list":              permJobsRead,
+	"jobOpening.setStatus":         permJobsWrite,
+	"jobOpening.delete":            permJobsWrite,
+	"jobOpening.match":             permCandidatesRead,
+	"jobOpening.forecast":          permReports,
+	"matchRules.get":               permJobsRead,
+	"matchRules.set":               permMatchRules,
+	"matchRules.reset":             permMatchRules,
+	"matchRules.preview":           permMatchRules,
+	"matchRules.history":           permMatchRules,
+	"matches.new":                  permCandidatesRead,
+	"matches.get":                  permCandidatesRead,
+	"application.create":           permApplicationsWrite,
+	"application.setStatus":        permApplicationsWrite,
+	"application.listByCandidate":  permApplicationsRead,
+	"application.listByJobOpening": permApplicationsRead,
+	"application.history":          permApplicationsRead,
+	"application.search":           permApplicationsRead,
+	"application.stages":           permApplicationsRead,
+	"usage.monthly":                permReports,
+	"user.setRole":                 permUsersManage,
+	"user.setEmail":                permUsersManage,
+	"user.linkCandidate":           permUsersManage,
+	"user.setCompany":              permUsersManage,
+	"me.profile":                   permOwnProfile,
+	"me.createProfile":             permOwnProfile,
+	"me.updateProfile":             permOwnProfile,
+	"feed.vacancies":               permJobsRead,
+	"audit.list":                   permAuditLog,
+	"profileShare.create":          permCandidatesWrite,
+	"profileShare.list":            permCandidatesWrite,
+	"profileShare.revoke":          permCandidatesWrite,
+	"applyForm.publish":            permJobsWrite,
+	"applyForm.revoke":             permJobsWrite,
+	"candidate.parseResume":        permCandidatesWrite,
+	"applyReview.list":             permApplicationsWrite,
+	"applyReview.approve":          permApplicationsWrite,
+	"applyReview.reject":           permApplicationsWrite,
+	"currency.list":                permJobsRead,
+	"currency.set":                 permSystem,
+	"currency.delete":              permSystem,
+	"notifyPrefs.get":              permOwnSettings,
+	"notifyPrefs.set":              permOwnSettings,
+	"notifyPrefs.quietHours":       permOwnSettings,
+	"notifyPrefs.mandatory":        permSystem,
+	"projection.list":              permSystem,
+	"projection.replay":            permSystem,
+	"purge.report":                 permSystem,
+	"purge.run":                    permSystem,
+	"legalHold.place":              permLegalHold,
+	"legalHold.release":            permLegalHold,
+	"legalHold.list":               permLegalHold,
+	"announcement.publish":         permAnnouncements,
+	"announcement.withdraw":        permAnnouncements,
+	"announcement.list":            permAnnouncements,
+	"announcement.pending":         permOwnSettings,
+	"announcement.acknowledge":     permOwnSettings,
+}
+
+func roleMenu(app *App) {
+	username := getInput("Имя пользователя: ")
+	role := strings.TrimSpace(getInput(fmt.Sprintf("Новая роль (%s): ", strings.Join(roleNames, "/"))))
+	err := setUserRole(app, username, role)
+	handleError(err)
+	if err == nil {
+		fmt.Println("Роль изменена.")
+	}
+}
+
+// user role <имя> <роль> | user email <имя> <адрес> | user reset-password <имя> | user candidate <имя> <анкета>|-.
+// Команды запускаются с доступом к базе, поэтому сессия для них не требуется.
+func runUserCommand(app *App, args []string) int {
+	switch {
+	case len(args) == 3 && args[0] == "role":
+		if err := setUserRole(app, args[1], args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Роль изменена.", map[string]string{"username": args[1], "role": args[2]})
+	case len(args) == 3 && args[0] == "email":
+		if err := setUserEmail(app, args[1], args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Адрес изменён.", map[string]string{"username": args[1], "email": args[2]})
+	case len(args) == 2 && args[0] == "reset-password":
+		// Код печатается администратору, чтобы передать его пользователю без почты.
+		token, err := requestPasswordReset(app, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone(fmt.Sprintf("Код сброса: %s (действует %s)", token, passwordResetTTL()),
+			map[string]interface{}{"username": args[1], "token": token, "ttl": passwordResetTTL().String()})
+	case len(args) == 3 && args[0] == "candidate":
+		candidateID := 0
+		if args[2] != "-" {
+			id, err := resolveEntityID(app.DB, entityCandidate, args[2])
+			if err != nil {
+				return reportError(err)
+			}
+			candidateID = id
+		}
+		if err := linkCandidateAccount(app, args[1], candidateID); err != nil {
+			return reportError(err)
+		}
+		return printDone("Связь с анкетой сохранена.", map[string]interface{}{"username": args[1], "candidate_id": candidateID})
+	case len(args) == 3 && args[0] == "company":
+		companyID := 0
+		if args[2] != "-" {
+			id, err := resolveEntityID(app.DB, entityCompany, args[2])
+			if err != nil {
+				return reportError(err)
+			}
+			companyID = id
+		}
+		if err := setUserCompany(app, args[1], companyID); err != nil {
+			return reportError(err)
+		}
+		return printDone("Компания пользователя сохранена.", map[string]interface{}{"username": args[1], "company_id": companyID})
+	default:
+		fmt.Println("Использование: user role <имя пользователя> <" + strings.Join(roleNames, "|") + ">")
+		fmt.Println("               user email <имя пользователя> <адрес>")
+		fmt.Println("               user reset-password <имя пользователя>")
+		fmt.Println("               user candidate <имя пользователя> <ID, ФИО или email кандидата | - чтобы снять связь>")
+		fmt.Println("               user company <имя пользователя> <ID или название компании | - чтобы снять привязку>")
+		return exitUsage
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+
+	"your_project_name/storage"
+)
+
+func TestRoleAllows(t *testing.T) {
+	tests := []struct {
+		role string
+		perm permission
+		want bool
+	}{
+		{storage.RoleAdmin, permSystem, true},
+		{storage.RoleAdmin, permAuditLog, true},
+		{storage.RoleCompanyAdmin, permMatchRules, true},
+		{storage.RoleCompanyAdmin, permRecruiterReports, true},
+		{storage.RoleCompanyAdmin, permCandidatesWrite, true},
+		{storage.RoleCompanyAdmin, permUsersManage, false},
+		{storage.RoleRecruiter, permCandidatesWrite, true},
+		{storage.RoleRecruiter, permApplicationsWrite, true},
+		{storage.RoleRecruiter, permRecruiterReports, false},
+		{storage.RoleRecruiter, permLegalHold, false},
+		{storage.RoleViewer, permCandidatesRead, true},
+		{storage.RoleViewer, permCandidatesWrite, false},
+		{storage.RoleViewer, permApplicationsWrite, false},
+		// Роль самостоятельной регистрации не видит чужих кандидатов и откликов.
+		{storage.RoleCandidate, permJobsRead, true},
+		{storage.RoleCandidate, permOwnProfile, true},
+		{storage.RoleCandidate, permCandidatesRead, false},
+		{storage.RoleCandidate, permApplicationsRead, false},
+		{"", permJobsRead, false},
+		{"superuser", permJobsRead, false},
+	}
+	for _, tt := range tests {
+		if got := roleAllows(tt.role, tt.perm); got != tt.want {
+			t.Errorf("roleAllows(%q, %q) = %v, ожидалось %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	for _, role := range roleNames {
+		if err := validateRole(role); err != nil {
+			t.Errorf("validateRole(%q): %v", role, err)
+		}
+	}
+	for _, role := range []string{"", "Admin", "root", "viewer "} {
+		if err := validateRole(role); classifyError(err) != kindValidation {
+			t.Errorf("validateRole(%q) = %v, ожидалась ошибка ввода", role, err)
+		}
+	}
+}
+
+// Пользователи для проверок прав без базы: остальные методы репозитория не вызываются.
+type fakeUsers struct {
+	storage.UserRepository
+	users map[int]storage.User
+}
+
+func (f fakeUsers) GetByID(id int) (storage.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return storage.User{}, storage.ErrNotFound
+	}
+	return user, nil
+}
+
+// Вид ошибки; пустой — ошибки нет.
+func errorKindOf(err error) errorKind {
+	if err == nil {
+		return ""
+	}
+	return classifyError(err)
+}
+
+func TestAuthorizeCompany(t *testing.T) {
+	app := &App{Repositories: storage.Repositories{Users: fakeUsers{users: map[int]storage.User{
+		1: {ID: 1, Role: storage.RoleAdmin},
+		2: {ID: 2, Role: storage.RoleRecruiter, CompanyID: 10},
+		3: {ID: 3, Role: storage.RoleCompanyAdmin, CompanyID: 20},
+		4: {ID: 4, Role: storage.RoleRecruiter},
+	}}}}
+	tests := []struct {
+		name      string
+		userID    int
+		companyID int
+		want      errorKind
+	}{
+		{"консольная команда без пользователя", 0, 10, ""},
+		{"admin — любая компания", 1, 10, ""},
+		{"рекрутер — своя компания", 2, 10, ""},
+		{"рекрутер — чужая компания", 2, 20, kindPermission},
+		{"администратор компании — своя", 3, 20, ""},
+		{"администратор компании — чужая", 3, 10, kindPermission},
+		{"без компании", 4, 10, kindPermission},
+		{"удалённый пользователь", 99, 10, kindPermission},
+	}
+	saved := currentOperation
+	defer func() { currentOperation = saved }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currentOperation.UserID = tt.userID
+			if got := errorKindOf(authorizeCompany(app, tt.companyID)); got != tt.want {
+				t.Errorf("authorizeCompany(%d) от пользователя %d: %v, ожидалось %v", tt.companyID, tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	app := &App{Repositories: storage.Repositories{Users: fakeUsers{users: map[int]storage.User{
+		2: {ID: 2, Role: storage.RoleRecruiter},
+		5: {ID: 5, Role: storage.RoleCandidate},
+	}}}}
+	tests := []struct {
+		name   string
+		claims *TokenClaims
+		perm   permission
+		want   errorKind
+	}{
+		{"без токена", nil, permJobsRead, kindPermission},
+		{"право есть", &TokenClaims{Subject: "2"}, permCandidatesWrite, ""},
+		{"права нет", &TokenClaims{Subject: "5"}, permCandidatesRead, kindPermission},
+		{"пользователя нет", &TokenClaims{Subject: "99"}, permJobsRead, kindPermission},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorKindOf(authorizeUser(app, tt.claims, tt.perm)); got != tt.want {
+				t.Errorf("authorizeUser(%q): %v, ожидалось %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Недавно открытые записи и быстрый переход по части названия: «open cand iva» открывает Иванова,
+// не заставляя искать и вводить его ID. Открытия записываются для авторизованного пользователя меню;
+// на каждого хранятся последние recentViewsLimit записей.
+
+const (
+	entityCandidate  = "candidate"
+	entityJobOpening = "job"
+	entityCompany    = "company"
+)
+
+const recentViewsLimit = 20
+
+var entityTitles = map[string]string{
+	entityCandidate:  "кандидат",
+	entityJobOpening: "вакансия",
+	entityCompany:    "компания",
+}
+
+// Слова, по которым быстрый переход узнаёт вид записи; достаточно начала слова.
+var entityAliases = map[string][]string{
+	entityCandidate:  {"candidate", "кандидат"},
+	entityJobOpening: {"job", "vacancy", "вакансия"},
+	entityCompany:    {"company", "компания"},
+}
+
+// Порядок видов в результатах быстрого перехода.
+var entityKinds = []string{entityCandidate, entityJobOpening, entityCompany}
+
+// Таблица и колонка с названием для каждого вида записей.
+var entityNameColumns = map[string][2]string{
+	entityCandidate:  {"candidates", "full_name"},
+	entityJobOpening: {"job_openings", "title"},
+	entityCompany:    {"companies", "name"},
+}
+
+type EntityRef struct {
+	Kind string `json:"kind"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r EntityRef) String() string {
+	return fmt.Sprintf("%s #%d %s", entityTitles[r.Kind], r.ID, r.Name)
+}
+
+type RecentView struct {
+	EntityRef
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+// Вид записи по слову запроса: «cand», «вак», «comp»; пустая строка — слово не похоже ни на один вид.
+func entityKindByAlias(word string) string {
+	word = strings.ToLower(word)
+	if len([]rune(word)) < 3 {
+		return ""
+	}
+	for _, kind := range entityKinds {
+		for _, alias := range entityAliases[kind] {
+			if strings.HasPrefix(alias, word) {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// Латиница → кириллица, чтобы «iva» находило «Иванов» без переключения раскладки.
+// Многобуквенные сочетания проверяются раньше однобуквенных.
+var translitPairs = []string{
+	"shch", "щ", "zh", "ж", "kh", "х", "ts", "ц", "ch", "ч", "sh", "ш", "yu", "ю", "ya", "я", "yo", "ё",
+	"a", "а", "b", "б", "v", "в", "g", "г", "d", "д", "e", "е", "z", "з", "i", "и", "y", "ы", "j", "й",
+	"k", "к", "l", "л", "m", "м", "n", "н", "o", "о", "p", "п", "r", "р", "s", "с", "t", "т", "u", "у",
+	"f", "ф", "h", "х", "c", "ц", "w", "в",
+}
+
+var translitReplacer = strings.NewReplacer(translitPairs...)
+
+func translitToCyrillic(text string) string {
+	return translitReplacer.Replace(strings.ToLower(text))
+}
+
+// Записи вида kind, в названии которых есть text (как есть или в транслитерации);
+// сначала те, что пользователь открывал недавно.
+func findEntitiesByName(db *sql.DB, kind, text string, userID, limit int) ([]EntityRef, error) {
+	table := entityNameColumns[kind]
+	rows, err := db.Query(fmt.Sprintf(`SELECT t.id, t.%[2]s FROM %[1]s t
+		LEFT JOIN recent_views v ON v.user_id = $3 AND v.entity = $4 AND v.entity_id = t.id
+		WHERE t.%[2]s ILIKE $1 OR t.%[2]s ILIKE $2
+		ORDER BY v.viewed_at DESC NULLS LAST, t.%[2]s, t.id LIMIT $5`, table[0], table[1]),
+		"%"+storage.EscapeLike(text)+"%", "%"+storage.EscapeLike(translitToCyrillic(text))+"%", userID, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var refs []EntityRef
+	for rows.Next() {
+		ref := EntityRef{Kind: kind}
+		if err := rows.Scan(&ref.ID, &ref.Name); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return refs, nil
+}
+
+// Разбирает запрос быстрого перехода: необязательное «open»/«открыть», необязательный вид записи
+// и часть названия. Без вида ищет среди всех видов.
+func quickOpenSearch(app *App, query string, userID, limit int) ([]EntityRef, error) {
+	words := strings.Fields(query)
+	if len(words) > 0 {
+		switch strings.ToLower(words[0]) {
+		case "open", "открыть":
+			words = words[1:]
+		}
+	}
+	var kinds []string
+	if len(words) > 1 {
+		if kind := entityKindByAlias(words[0]); kind != "" {
+			if err := authorize(app, entityPermission(kind)); err != nil {
+				return nil, err
+			}
+			kinds, words = []string{kind}, words[1:]
+		}
+	}
+	if kinds == nil {
+		// Без явного вида ищем только среди записей, которые пользователю можно смотреть.
+		for _, kind := range entityKinds {
+			if authorize(app, entityPermission(kind)) == nil {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	text := strings.Join(words, " ")
+	if text == "" {
+		return nil, validationErrorf("укажите часть названия, например: open cand иван")
+	}
+	var refs []EntityRef
+	for _, kind := range kinds {
+		found, err := findEntitiesByName(app.DB, kind, text, userID, limit)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, found...)
+	}
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+	return refs, nil
+}
+
+// Запоминает открытие записи; старые записи сверх recentViewsLimit удаляются.
+func recordView(db *sql.DB, userID int, kind string, id int) error {
+	return storage.WithTx(db, func(tx storage.DBTX) error {
+		_, err := tx.Exec(`INSERT INTO recent_views (user_id, entity, entity_id) VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, entity, entity_id) DO UPDATE SET viewed_at = now()`, userID, kind, id)
+		if err != nil {
+			return fmt.Errorf("ошибка записи недавних: %w", err)
+		}
+		_, err = tx.Exec(`DELETE FROM recent_views WHERE user_id = $1 AND (entity, entity_id) NOT IN (
+			SELECT entity, entity_id FROM recent_views WHERE user_id = $1 ORDER BY viewed_at DESC LIMIT $2)`, userID, recentViewsLimit)
+		if err != nil {
+			return fmt.Errorf("ошибка записи недавних: %w", err)
+		}
+		return nil
+	})
+}
+
+// Недавно открытые записи пользователя, от последних к ранним. Удалённые записи пропускаются.
+func recentViews(db *sql.DB, userID int) ([]RecentView, error) {
+	rows, err := db.Query(`SELECT v.entity, v.entity_id, COALESCE(c.full_name, j.title, co.name), v.viewed_at
+		FROM recent_views v
+		LEFT JOIN candidates c ON v.entity = $2 AND c.id = v.entity_id
+		LEFT JOIN job_openings j ON v.entity = $3 AND j.id = v.entity_id
+		LEFT JOIN companies co ON v.entity = $4 AND co.id = v.entity_id
+		WHERE v.user_id = $1 AND COALESCE(c.id, j.id, co.id) IS NOT NULL
+		ORDER BY v.viewed_at DESC`, userID, entityCandidate, entityJobOpening, entityCompany)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var views []RecentView
+	for rows.Next() {
+		var v RecentView
+		if err := rows.Scan(&v.Kind, &v.ID, &v.Name, &v.ViewedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		views = append(views, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return views, nil
+}
+
+func entityPermission(kind string) permission {
+	if kind == entityCandidate {
+		return permCandidatesRead
+	}
+	return permJobsRead
+}
+
+// Показывает запись, запоминает её в недавних и предлагает скопировать или сохранить. Права проверяются
+// по виду записи, потому что быстрый переход и список недавних открывают записи любого вида.
+func openEntity(app *App, kind string, id int) error {
+	if err := authorize(app, entityPermission(kind)); err != nil {
+		return err
+	}
+	card, err := loadEntityCard(app, kind, id)
+	if err != nil {
+		return err
+	}
+	card.print(os.Stdout)
+	noteView(app, kind, id)
+	shareMenu(card.shared)
+	return nil
+}
+
+// Запоминает открытие записи текущим пользователем меню. Недавние — вспомогательные:
+// если их не удалось записать, запись всё равно показывается.
+func noteView(app *App, kind string, id int) {
+	userID := sessionUserID()
+	if userID == 0 {
+		return
+	}
+	if err := recordView(app.DB, userID, kind, id); err != nil {
+		opLogger().Warn("ошибка записи недавних", "error", err)
+	}
+}
+
+// Печатает пронумерованный список и открывает выбранную запись; Enter — ничего не открывать.
+func pickAndOpen(app *App, refs []EntityRef) {
+	for i, ref := range refs {
+		fmt.Printf("%d. %s\n", i+1, ref)
+	}
+	input := getInput("Номер записи (Enter — назад): ")
+	if input == "" {
+		return
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(refs) {
+		fmt.Println("Нет такой записи.")
+		return
+	}
+	handleError(openEntity(app, refs[n-1].Kind, refs[n-1].ID))
+}
+
+func recentViewsMenu(app *App) {
+	views, err := recentViews(app.DB, sessionUserID())
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if len(views) == 0 {
+		fmt.Println("Вы ещё ничего не открывали.")
+		return
+	}
+	refs := make([]EntityRef, len(views))
+	for i, v := range views {
+		refs[i] = v.EntityRef
+	}
+	pickAndOpen(app, refs)
+}
+
+// Единственное совпадение открывается сразу, несколько — предлагаются на выбор.
+func quickOpenMenu(app *App) {
+	query := getInput("Что открыть (например: cand иван, вак разработчик, comp ромашка или ссылка на запись): ")
+	if link, ok := linkInQuery(query); ok {
+		kind, id, err := parseEntityURL(link)
+		if err == nil {
+			err = openEntity(app, kind, id)
+		}
+		handleError(err)
+		return
+	}
+	refs, err := quickOpenSearch(app, query, sessionUserID(), 10)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	switch len(refs) {
+	case 0:
+		fmt.Println("Ничего не найдено.")
+	case 1:
+		handleError(openEntity(app, refs[0].Kind, refs[0].ID))
+	default:
+		pickAndOpen(app, refs)
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"your_project_name/storage"
+)
+
+type RecruiterStats struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	// Текущая нагрузка.
+	Vacancies        int     `json:"vacancies"`
+	OpenApplications int     `json:"open_applications"`
+	Load             float64 `json:"load"`
+	StaleNow         int     `json:"stale_now"`
+	// Движение за период.
+	Moves       int `json:"moves"`
+	Interviews  int `json:"interviews"`
+	Hires       int `json:"hires"`
+	SLABreaches int `json:"sla_breaches"`
+}
+
+// Срок, дольше которого отклик не должен стоять на одном этапе.
+func applicationSLA() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("APPLICATION_SLA_DAYS"))
+	if err != nil || days <= 0 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Сколько открытых откликов рекрутер ведёт без перегрузки; от этого считается загрузка.
+func recruiterCapacity() int {
+	capacity, err := strconv.Atoi(os.Getenv("RECRUITER_CAPACITY"))
+	if err != nil || capacity <= 0 {
+		capacity = 40
+	}
+	return capacity
+}
+
+func assignRecruiter(app *App, jobOpeningID int, username string) error {
+	jobOpening, err := getJobOpeningByID(app, jobOpeningID)
+	if err != nil {
+		return err
+	}
+	if err := authorizeCompany(app, jobOpening.CompanyID); err != nil {
+		return err
+	}
+	user, err := app.Users.GetByUsername(normalizeText(username, false))
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("пользователь не найден")
+	}
+	if err != nil {
+		return err
+	}
+	if !roleAllows(user.Role, permJobsWrite) {
+		return validationErrorf("пользователь %s с ролью %s не может вести вакансии", user.Username, user.Role)
+	}
+	if user.CompanyID != 0 && user.CompanyID != jobOpening.CompanyID {
+		return validationErrorf("пользователь %s работает в другой компании", user.Username)
+	}
+	if _, err := app.DB.Exec("UPDATE job_openings SET recruiter_id = $1 WHERE id = $2", user.ID, jobOpeningID); err != nil {
+		return fmt.Errorf("ошибка назначения рекрутера: %w", err)
+	}
+	return nil
+}
+
+// Нарушение SLA — переход, которого отклик ждал дольше applicationSLA, и открытый отклик,
+// который уже сейчас стоит дольше этого срока. onlyUserID ограничивает отчёт одним рекрутером (0 — все).
+func recruiterReport(app *App, from, to time.Time, onlyUserID int) ([]RecruiterStats, error) {
+	if !from.Before(to) {
+		return nil, validationErrorf("начало периода должно быть раньше конца")
+	}
+	sla := fmt.Sprintf("%d hours", int(applicationSLA().Hours()))
+	rows, err := app.reader().Query(`
+    SELECT u.id, u.username,
+        (SELECT COUNT(*) FROM job_openings j WHERE j.recruiter_id = u.id),
+        (SELECT COUNT(*) FROM applications a JOIN job_openings j ON j.id = a.job_opening_id
+         WHERE j.recruiter_id = u.id AND a.status NOT IN ('hired', 'rejected')),
+        (SELECT COUNT(*) FROM applications a JOIN job_openings j ON j.id = a.job_opening_id
+         WHERE j.recruiter_id = u.id AND a.status NOT IN ('hired', 'rejected')
+           AND a.updated_at < now() - $1::interval)
+    FROM users u
+    WHERE (u.role IN ('recruiter', 'company_admin') OR EXISTS (SELECT 1 FROM job_openings j WHERE j.recruiter_id = u.id))
+      AND ($2 = 0 OR u.id = $2)
+    ORDER BY u.username`, sla, onlyUserID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	capacity := recruiterCapacity()
+	var report []RecruiterStats
+	index := map[int]int{}
+	for rows.Next() {
+		var s RecruiterStats
+		if err := rows.Scan(&s.UserID, &s.Username, &s.Vacancies, &s.OpenApplications, &s.StaleNow); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		s.Load = float64(s.OpenApplications) / float64(capacity)
+		index[s.UserID] = len(report)
+		report = append(report, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+
+	movement, err := app.reader().Query(`
+    WITH moves AS (
+        SELECT j.recruiter_id, h.from_status, h.to_status, h.changed_at,
+            h.changed_at - LAG(h.changed_at) OVER (PARTITION BY h.application_id ORDER BY h.changed_at, h.id) AS waited
+        FROM application_status_history h
+        JOIN applications a ON a.id = h.application_id
+        JOIN job_openings j ON j.id = a.job_opening_id
+        WHERE j.recruiter_id IS NOT NULL
+    )
+    SELECT recruiter_id,
+        COUNT(*) FILTER (WHERE from_status <> ''),
+        COUNT(*) FILTER (WHERE to_status = 'interview'),
+        COUNT(*) FILTER (WHERE to_status = 'hired'),
+        COUNT(*) FILTER (WHERE waited > $3::interval)
+    FROM moves
+    WHERE changed_at >= $1 AND changed_at < $2
+    GROUP BY recruiter_id`, from, to, sla)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer movement.Close()
+	for movement.Next() {
+		var userID, moves, interviews, hires, breaches int
+		if err := movement.Scan(&userID, &moves, &interviews, &hires, &breaches); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		i, ok := index[userID]
+		if !ok {
+			continue
+		}
+		report[i].Moves, report[i].Interviews, report[i].Hires = moves, interviews, hires
+		report[i].SLABreaches = breaches
+	}
+	if err := movement.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	for i := range report {
+		report[i].SLABreaches += report[i].StaleNow
+	}
+	return report, nil
+}
+
+// Полный отчёт видят администраторы; рекрутер видит только свою строку, остальные — ничего.
+func recruiterReportFor(app *App, claims *TokenClaims, from, to time.Time) ([]RecruiterStats, error) {
+	if err := authorizeUser(app, claims, permRecruiterReports); err == nil {
+		return recruiterReport(app, from, to, 0)
+	}
+	if err := authorizeUser(app, claims, permJobsWrite); err != nil {
+		return nil, err
+	}
+	return recruiterReport(app, from, to, claims.UserID())
+}
+
+func writeRecruiterCSV(w io.Writer, report []RecruiterStats, from, to time.Time) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"period_from", "period_to", "user_id", "username", "vacancies", "open_applications", "load",
+		"moves", "interviews", "hires", "sla_breaches"})
+	for _, s := range report {
+		writer.Write([]string{from.Format("2006-01-02"), to.Format("2006-01-02"), strconv.Itoa(s.UserID), s.Username,
+			strconv.Itoa(s.Vacancies), strconv.Itoa(s.OpenApplications), strconv.FormatFloat(s.Load, 'f', 2, 64),
+			strconv.Itoa(s.Moves), strconv.Itoa(s.Interviews), strconv.Itoa(s.Hires), strconv.Itoa(s.SLABreaches)})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("ошибка записи CSV: %w", err)
+	}
+	return nil
+}
+
+func writeRecruiterReport(w io.Writer, report []RecruiterStats) {
+	if len(report) == 0 {
+		fmt.Fprintln(w, "Рекрутеров нет.")
+		return
+	}
+	fmt.Fprintf(w, "%-20s %9s %8s %9s %9s %9s %6s %6s\n", "Рекрутер", "Вакансии", "Открыто", "Загрузка", "Переходы", "Интервью", "Наймы", "SLA")
+	for _, s := range report {
+		fmt.Fprintf(w, "%-20s %9d %8d %8.0f%% %9d %9d %6d %6d\n",
+			s.Username, s.Vacancies, s.OpenApplications, s.Load*100, s.Moves, s.Interviews, s.Hires, s.SLABreaches)
+	}
+}
+
+func parseReportPeriod(fromText, toText string) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toText != "" {
+		t, err := time.ParseInLocation("2006-01-02", toText, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, validationErrorf("дата %q должна быть в формате ГГГГ-ММ-ДД", toText)
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -30)
+	if fromText != "" {
+		t, err := time.ParseInLocation("2006-01-02", fromText, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, validationErrorf("дата %q должна быть в формате ГГГГ-ММ-ДД", fromText)
+		}
+		from = t
+	}
+	return from, to, nil
+}
+
+func recruiterReportMenu(app *App) {
+	claims, err := activeSession(app)
+	if err == nil && claims == nil {
+		err = permissionError("требуется авторизация")
+	}
+	handleError(err)
+	if err != nil {
+		return
+	}
+	from, to, err := parseReportPeriod(getInput("Начало периода (ГГГГ-ММ-ДД, Enter — 30 дней назад): "), getInput("Конец периода (ГГГГ-ММ-ДД, Enter — сегодня): "))
+	handleError(err)
+	if err != nil {
+		return
+	}
+	var report []RecruiterStats
+	err = withReportSnapshot(app, reportSnapshotEnabled(), func(app *App) (err error) {
+		report, err = recruiterReportFor(app, claims, from, to)
+		return err
+	})
+	handleError(err)
+	if err != nil {
+		return
+	}
+	view := recruiterReportView(report, from, to)
+	view.text(os.Stdout)
+	shareMenu(view)
+}
+
+func recruiterReportView(report []RecruiterStats, from, to time.Time) sharedView {
+	return sharedView{
+		data: report,
+		text: func(w io.Writer) { writeRecruiterReport(w, report) },
+		csv:  func(w io.Writer) error { return writeRecruiterCSV(w, report, from, to) },
+	}
+}
+
+// recruiter assign <ID вакансии> <имя> | recruiter report [-from ГГГГ-ММ-ДД] [-to ГГГГ-ММ-ДД] [-snapshot] [-out файл] [-copy]
+func runRecruiterCommand(app *App, args []string) int {
+	usage := "Использование: recruiter assign <ID вакансии> <имя пользователя> | recruiter report [-from ГГГГ-ММ-ДД] [-to ГГГГ-ММ-ДД] [-snapshot] [-out файл] [-copy]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch {
+	case args[0] == "assign" && len(args) == 3:
+		id, err := resolveEntityID(app.DB, entityJobOpening, args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		if err := assignRecruiter(app, id, args[2]); err != nil {
+			return reportError(err)
+		}
+		return printDone("Рекрутер назначен.", map[string]interface{}{"job_opening_id": id, "recruiter": args[2]})
+	case args[0] == "report":
+		flags := flag.NewFlagSet("recruiter report", flag.ContinueOnError)
+		fromText := flags.String("from", "", "начало периода (по умолчанию 30 дней до конца)")
+		toText := flags.String("to", "", "конец периода включительно (по умолчанию сегодня)")
+		snapshot := flags.Bool("snapshot", reportSnapshotEnabled(), "строить отчёт по снимку базы на один момент")
+		var o shareOptions
+		o.register(flags)
+		flags.StringVar(&o.Out, "o", "", "то же, что -out (оставлено для совместимости)")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		from, to, err := parseReportPeriod(*fromText, *toText)
+		if err != nil {
+			return reportError(err)
+		}
+		var report []RecruiterStats
+		err = withReportSnapshot(app, *snapshot, func(app *App) (err error) {
+			report, err = recruiterReport(app, from, to, 0)
+			return err
+		})
+		if err != nil {
+			return reportError(err)
+		}
+		view := recruiterReportView(report, from, to)
+		if o.Out == "" {
+			if code := printView(view); code != exitOK {
+				return code
+			}
+		}
+		if err := o.share(view); err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Перестройка проекций — таблиц, которые подписчики событий выводят из основных данных (выдача
+// подбора в match_results), — повторным проигрыванием событий. Отдельной таблицы событий нет:
+// журнал изменений audit_log пишется в той же транзакции, что и само изменение, поэтому события
+// восстанавливаются из него (см. auditEvent). Проход идёт пачками, после каждой пачки позиция
+// записывается в projection_checkpoints, и прерванный проход продолжается с неё. Поэтому события
+// последней пачки после сбоя приходят повторно, а обработчики проекций обязаны быть идемпотентными:
+// они пересчитывают проекцию по текущему состоянию записи, а не применяют изменение. По той же
+// причине одно событие о записи в пачке обрабатывается один раз.
+//
+// В проекции участвуют только зарегистрированные здесь обработчики: обычные подписчики (письма
+// о смене статуса отклика) при проигрывании не вызываются, иначе кандидаты получили бы письма заново.
+
+const projectionReplayJob = "projections.replay"
+
+// Сколько аренда проекции действует без продления; продлевается после каждой пачки.
+const projectionLease = 15 * time.Minute
+
+type Projection struct {
+	Name        string
+	Description string
+	Kinds       []string
+	// Очищает проекцию перед перестройкой с нуля; nil — перестройка идёт поверх прежних данных.
+	Reset func(app *App) error
+	// Должен быть идемпотентным: одно событие может прийти несколько раз.
+	Handle EventHandler
+}
+
+var projections = map[string]Projection{}
+
+func registerProjection(p Projection) {
+	projections[p.Name] = p
+}
+
+type ProjectionStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ID последней обработанной записи журнала и конец текущего прохода.
+	Position int64 `json:"position"`
+	Target   int64 `json:"target"`
+	// Сколько событий обработано в текущем проходе.
+	Events     int64      `json:"events"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Записей журнала после контрольной точки.
+	Lag     int64 `json:"lag"`
+	Running bool  `json:"running"`
+}
+
+// Событие, которое означает запись журнала; false — запись ни одному событию не соответствует.
+// Удаление кандидата или вакансии тоже считается сохранением: обработчики убирают удалённое из проекций.
+func auditEvent(entity, action string, entityID int, statusChanged bool) (Event, bool) {
+	switch {
+	case entity == "candidates":
+		return Event{Kind: eventCandidateSaved, ID: entityID}, true
+	case entity == "job_openings":
+		return Event{Kind: eventJobOpeningSaved, ID: entityID}, true
+	case entity == "applications" && action == storage.AuditUpdate && statusChanged:
+		return Event{Kind: eventApplicationStatusChanged, ID: entityID}, true
+	}
+	return Event{}, false
+}
+
+func lookupProjection(name string) (Projection, error) {
+	p, ok := projections[name]
+	if !ok {
+		return p, notFoundError(fmt.Sprintf("проекция %q не найдена", name))
+	}
+	return p, nil
+}
+
+func auditLogEnd(db *sql.DB) (int64, error) {
+	var end int64
+	if err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM audit_log").Scan(&end); err != nil {
+		return 0, fmt.Errorf("ошибка чтения журнала изменений: %w", err)
+	}
+	return end, nil
+}
+
+func projectionStatuses(db *sql.DB) ([]ProjectionStatus, error) {
+	end, err := auditLogEnd(db)
+	if err != nil {
+		return nil, err
+	}
+	var statuses []ProjectionStatus
+	for _, p := range projections {
+		s := ProjectionStatus{Name: p.Name, Description: p.Description}
+		var leaseUntil sql.NullTime
+		err := db.QueryRow(`SELECT position, target, events, started_at, updated_at, finished_at, lease_until
+			FROM projection_checkpoints WHERE projection = $1`, p.Name).
+			Scan(&s.Position, &s.Target, &s.Events, &s.StartedAt, &s.UpdatedAt, &s.FinishedAt, &leaseUntil)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("ошибка чтения контрольных точек: %w", err)
+		}
+		s.Lag = end - s.Position
+		s.Running = leaseUntil.Valid && leaseUntil.Time.After(time.Now())
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Name < statuses[k].Name })
+	return statuses, nil
+}
+
+// Берёт проекцию в работу и возвращает контрольную точку; ошибка — проекцию уже перестраивает другой процесс.
+func claimProjection(db *sql.DB, name string) (position, target int64, finished bool, err error) {
+	if _, err = db.Exec("INSERT INTO projection_checkpoints (projection) VALUES ($1) ON CONFLICT DO NOTHING", name); err != nil {
+		return 0, 0, false, fmt.Errorf("ошибка записи контрольной точки: %w", err)
+	}
+	var finishedAt sql.NullTime
+	err = db.QueryRow(`UPDATE projection_checkpoints SET lease_until = now() + $2::interval
+		WHERE projection = $1 AND (lease_until IS NULL OR lease_until < now())
+		RETURNING position, target, finished_at`, name, fmt.Sprintf("%d seconds", int(projectionLease.Seconds()))).
+		Scan(&position, &target, &finishedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, 0, false, validationErrorf("проекцию %s уже перестраивает другой процесс", name)
+	}
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("ошибка записи контрольной точки: %w", err)
+	}
+	return position, target, finishedAt.Valid || target == 0, nil
+}
+
+type replayEntry struct {
+	id    int64
+	event Event
+}
+
+func loadReplayBatch(db *sql.DB, after, upTo int64, limit int) ([]replayEntry, int64, error) {
+	rows, err := db.Query(`SELECT id, entity, entity_id, action, COALESCE(new_values ? 'status', false)
+		FROM audit_log WHERE id > $1 AND id <= $2 ORDER BY id LIMIT $3`, after, upTo, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения журнала изменений: %w", err)
+	}
+	defer rows.Close()
+	var entries []replayEntry
+	last := after
+	for rows.Next() {
+		var entity, action string
+		var entityID int
+		var statusChanged bool
+		if err := rows.Scan(&last, &entity, &entityID, &action, &statusChanged); err != nil {
+			return nil, 0, fmt.Errorf("ошибка чтения журнала изменений: %w", err)
+		}
+		if event, ok := auditEvent(entity, action, entityID, statusChanged); ok {
+			entries = append(entries, replayEntry{id: last, event: event})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("ошибка чтения журнала изменений: %w", err)
+	}
+	return entries, last, nil
+}
+
+func releaseProjection(app *App, name string) {
+	if _, err := app.DB.Exec("UPDATE projection_checkpoints SET lease_until = NULL WHERE projection = $1", name); err != nil {
+		app.logger().Warn("аренда проекции не снята", "projection", name, "error", err)
+	}
+}
+
+// Очищает проекцию и переводит контрольную точку в начало журнала: следующий проход перестроит её с нуля.
+// Сброс отделён от прохода, чтобы повтор упавшей задачи продолжал перестройку, а не начинал её заново.
+func resetProjection(app *App, name string) error {
+	p, err := lookupProjection(name)
+	if err != nil {
+		return err
+	}
+	if _, _, _, err := claimProjection(app.DB, name); err != nil {
+		return err
+	}
+	defer releaseProjection(app, name)
+	if p.Reset != nil {
+		if err := p.Reset(app); err != nil {
+			return err
+		}
+	}
+	target, err := auditLogEnd(app.DB)
+	if err != nil {
+		return err
+	}
+	_, err = app.DB.Exec(`UPDATE projection_checkpoints SET position = 0, target = $2, events = 0, started_at = now(),
+		updated_at = now(), finished_at = NULL WHERE projection = $1`, name, target)
+	if err != nil {
+		return fmt.Errorf("ошибка записи контрольной точки: %w", err)
+	}
+	app.audit("projection.reset", "projection", name)
+	return nil
+}
+
+// Проигрывает проекции события журнала с контрольной точки до его текущего конца: прерванный проход
+// продолжается, законченный — начинается новый с прежней позиции. progress вызывается после каждой пачки.
+func replayProjection(app *App, name string, batch int, progress func(ProjectionStatus)) (ProjectionStatus, error) {
+	status := ProjectionStatus{Name: name}
+	p, err := lookupProjection(name)
+	if err != nil {
+		return status, err
+	}
+	status.Description = p.Description
+	if batch <= 0 {
+		batch = 500
+	}
+	position, _, finished, err := claimProjection(app.DB, name)
+	if err != nil {
+		return status, err
+	}
+	defer releaseProjection(app, name)
+
+	target, err := auditLogEnd(app.DB)
+	if err != nil {
+		return status, err
+	}
+	switch {
+	case finished:
+		_, err = app.DB.Exec(`UPDATE projection_checkpoints SET target = $2, events = 0, started_at = now(),
+			updated_at = now(), finished_at = NULL WHERE projection = $1`, name, target)
+	default:
+		_, err = app.DB.Exec("UPDATE projection_checkpoints SET target = $2, updated_at = now() WHERE projection = $1", name, target)
+	}
+	if err != nil {
+		return status, fmt.Errorf("ошибка записи контрольной точки: %w", err)
+	}
+	app.audit("projection.replay", "projection", name, "from", position, "to", target)
+
+	for position < target {
+		entries, last, err := loadReplayBatch(app.DB, position, target, batch)
+		if err != nil {
+			return status, err
+		}
+		if last == position {
+			break
+		}
+		handled := map[Event]bool{}
+		for _, e := range entries {
+			if handled[e.event] || !containsString(p.Kinds, e.event.Kind) {
+				continue
+			}
+			handled[e.event] = true
+			if err := p.Handle(app, e.event); err != nil {
+				return status, fmt.Errorf("проекция %s, запись журнала %d (%s %d): %w", name, e.id, e.event.Kind, e.event.ID, err)
+			}
+		}
+		position = last
+		_, err = app.DB.Exec(`UPDATE projection_checkpoints SET position = $2, events = events + $3, updated_at = now(),
+			lease_until = now() + $4::interval WHERE projection = $1`,
+			name, position, len(handled), fmt.Sprintf("%d seconds", int(projectionLease.Seconds())))
+		if err != nil {
+			return status, fmt.Errorf("ошибка записи контрольной точки: %w", err)
+		}
+		if progress != nil {
+			progress(ProjectionStatus{Name: name, Description: p.Description, Position: position, Target: target, Lag: target - position, Running: true})
+		}
+	}
+	if _, err := app.DB.Exec("UPDATE projection_checkpoints SET position = $2, finished_at = now(), updated_at = now() WHERE projection = $1",
+		name, target); err != nil {
+		return status, fmt.Errorf("ошибка записи контрольной точки: %w", err)
+	}
+	statuses, err := projectionStatuses(app.DB)
+	if err != nil {
+		return status, err
+	}
+	for _, s := range statuses {
+		if s.Name == name {
+			status = s
+		}
+	}
+	return status, nil
+}
+
+type projectionReplayPayload struct {
+	Projection string `json:"projection"`
+}
+
+func handleProjectionReplayJob(db *sql.DB, payload json.RawMessage) error {
+	var p projectionReplayPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("неверные параметры задачи: %w", err)
+	}
+	_, err := replayProjection(newJobApp(db), p.Projection, 0, nil)
+	return err
+}
+
+// Ставит проход в очередь задач; перестройка с нуля сначала сбрасывает проекцию здесь же.
+func scheduleProjectionReplay(app *App, name string, rebuild bool) (int, error) {
+	if _, err := lookupProjection(name); err != nil {
+		return 0, err
+	}
+	if rebuild {
+		if err := resetProjection(app, name); err != nil {
+			return 0, err
+		}
+	}
+	return enqueueJob(app.DB, projectionReplayJob, projectionReplayPayload{Projection: name})
+}
+
+func init() {
+	registerJobHandler(projectionReplayJob, handleProjectionReplayJob)
+}
+
+func printProjectionStatus(w io.Writer, s ProjectionStatus) {
+	state := "не перестраивалась"
+	switch {
+	case s.Running:
+		state = fmt.Sprintf("идёт проход: %d из %d", s.Position, s.Target)
+	case s.FinishedAt != nil:
+		state = "готова " + s.FinishedAt.Format("2006-01-02 15:04")
+	case s.StartedAt != nil:
+		state = fmt.Sprintf("проход прерван на записи %d из %d", s.Position, s.Target)
+	}
+	fmt.Fprintf(w, "%-12s %s; событий в проходе: %d, отставание: %d — %s\n", s.Name, state, s.Events, s.Lag, s.Description)
+}
+
+// projections [list] [-json] | projections run <проекция> [-batch N] [-background] | projections rebuild <проекция> [-batch N] [-background]
+func runProjectionsCommand(app *App, args []string) int {
+	usage := "Использование: projections [list] [-json] | projections run|rebuild <проекция> [-batch N] [-background]"
+	sub := "list"
+	if len(args) > 0 && args[0] != "-json" {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "list":
+		statuses, err := projectionStatuses(app.DB)
+		if err != nil {
+			return reportError(err)
+		}
+		useJSON(len(args) == 1 && args[0] == "-json")
+		return printView(sharedView{data: statuses, text: func(w io.Writer) {
+			for _, s := range statuses {
+				printProjectionStatus(w, s)
+			}
+		}})
+	case "run", "rebuild":
+		if len(args) < 1 {
+			fmt.Println(usage)
+			return exitUsage
+		}
+		flags := flag.NewFlagSet("projections "+sub, flag.ContinueOnError)
+		batch := flags.Int("batch", 500, "сколько записей журнала обрабатывать между контрольными точками")
+		background := flags.Bool("background", false, "поставить проход в очередь задач")
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		if *background {
+			id, err := scheduleProjectionReplay(app, args[0], sub == "rebuild")
+			if err != nil {
+				return reportError(err)
+			}
+			return printDone(fmt.Sprintf("Проход поставлен в очередь, задача %d.", id), map[string]int{"job_id": id})
+		}
+		if sub == "rebuild" {
+			if err := resetProjection(app, args[0]); err != nil {
+				return reportError(err)
+			}
+		}
+		status, err := replayProjection(app, args[0], *batch, func(s ProjectionStatus) {
+			printNotice(fmt.Sprintf("Обработано записей журнала: %d из %d", s.Position, s.Target))
+		})
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: status, text: func(w io.Writer) { printProjectionStatus(w, status) }})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	"your_project_name/storage"
+)
+
+// Отчёт из нескольких запросов читает таблицы в разные моменты: пока строится отчёт по рекрутерам,
+// отклик успевает сменить статус и попасть в одну колонку, но не в другую. В режиме снимка все запросы
+// отчёта идут в одной транзакции REPEATABLE READ только для чтения — PostgreSQL показывает им базу
+// на момент первого запроса, и now() в них тоже одно. Режим включают настройка REPORT_SNAPSHOT и флаг
+// -snapshot у команд отчётов. Запросы внутри снимка выполняются по очереди на одном соединении, поэтому
+// отчёт не должен начинать запрос, не дочитав предыдущий.
+
+func reportSnapshotEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("REPORT_SNAPSHOT"))
+	return enabled
+}
+
+// Выполняет fn с App, запросы которого идут в транзакцию снимка; без snapshot — с исходным App.
+func withReportSnapshot(app *App, snapshot bool, fn func(app *App) error) error {
+	if !snapshot || app.snapshot != nil {
+		return fn(app)
+	}
+	tx, err := app.DB.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции отчёта: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(&App{DB: app.DB, Repositories: storage.NewPostgres(tx), snapshot: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции отчёта: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Вместо числового ID команды и меню принимают название: ФИО или email кандидата, название вакансии
+// или компании. Сначала ищется точное совпадение, затем — вхождение части названия. Если подходит
+// несколько записей, в терминале предлагается выбрать, а в скриптах это ошибка со списком вариантов.
+// Глобальный флаг -id отключает поиск: принимаются только числовые ID.
+
+var exactIDs bool
+
+const maxResolveChoices = 10
+
+// Записи с точно таким названием, без учёта регистра; кандидата можно указать и по email.
+func findEntitiesExact(db *sql.DB, kind, text string) ([]EntityRef, error) {
+	table := entityNameColumns[kind]
+	condition := fmt.Sprintf("lower(%s) = lower($1)", table[1])
+	if kind == entityCandidate {
+		condition += " OR lower(email) = lower($1)"
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT id, %s FROM %s WHERE %s ORDER BY id LIMIT $2", table[1], table[0], condition),
+		text, maxResolveChoices)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var refs []EntityRef
+	for rows.Next() {
+		ref := EntityRef{Kind: kind}
+		if err := rows.Scan(&ref.ID, &ref.Name); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return refs, nil
+}
+
+// Выбор из нескольких записей возможен, только если ввод идёт с терминала.
+func stdinInteractive() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ID записи вида kind по числу или названию. Число всегда считается ID, даже если такой записи нет:
+// существование проверяет вызывающий код, как и раньше.
+func resolveEntityID(db *sql.DB, kind, text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if id, err := strconv.Atoi(text); err == nil {
+		return id, nil
+	}
+	if exactIDs {
+		return 0, validationErrorf("ожидается числовой ID (%s), получено «%s»", entityTitles[kind], text)
+	}
+	if text == "" {
+		return 0, validationErrorf("укажите ID или название (%s)", entityTitles[kind])
+	}
+	refs, err := findEntitiesExact(db, kind, text)
+	if err == nil && len(refs) == 0 {
+		refs, err = findEntitiesByName(db, kind, text, sessionUserID(), maxResolveChoices)
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case len(refs) == 0:
+		return 0, notFoundError(fmt.Sprintf("не найдено: %s «%s»", entityTitles[kind], text))
+	case len(refs) == 1:
+		return refs[0].ID, nil
+	case stdinInteractive():
+		return chooseEntity(refs, text)
+	}
+	choices := make([]string, len(refs))
+	for i, ref := range refs {
+		choices[i] = fmt.Sprintf("%d (%s)", ref.ID, ref.Name)
+	}
+	return 0, validationErrorf("«%s» подходит под несколько записей (%s): %s; укажите ID", text, entityTitles[kind], strings.Join(choices, ", "))
+}
+
+func chooseEntity(refs []EntityRef, text string) (int, error) {
+	fmt.Printf("Под «%s» подходит несколько записей:\n", text)
+	for i, ref := range refs {
+		fmt.Printf("%d. %s\n", i+1, ref)
+	}
+	n, err := getIntInput("Номер нужной записи: ")
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 || n > len(refs) {
+		return 0, validationErrorf("нет записи с номером %d", n)
+	}
+	return refs[n-1].ID, nil
+}
+
+// Запрос ID в меню: можно ввести число или название.
+func getEntityInput(app *App, kind, prompt string) (int, error) {
+	return resolveEntityID(app.DB, kind, getInput(prompt))
+}
+
+// Флаг команды, принимающий ID или название; resolve вызывается после разбора флагов.
+type entityFlag struct {
+	kind string
+	text string
+}
+
+func (f *entityFlag) String() string        { return f.text }
+func (f *entityFlag) Set(text string) error { f.text = text; return nil }
+
+// 0 — флаг не задан.
+func (f *entityFlag) resolve(db *sql.DB) (int, error) {
+	if f.text == "" {
+		return 0, nil
+	}
+	return resolveEntityID(db, f.kind, f.text)
+}
+
+func entityFlagVar(flags *flag.FlagSet, name, kind, usage string) *entityFlag {
+	f := &entityFlag{kind: kind}
+	flags.Var(f, name, usage)
+	return f
+}
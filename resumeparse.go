@@ -0,0 +1,282 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Разбор текстового резюме в черновик кандидата: ФИО, email, телефон, стаж и навыки из словаря.
+// Разбор эвристический, поэтому черновик не сохраняется сам: в меню он открывается в обычной форме
+// добавления кандидата, где рекрутер проверяет поля, дописывает недостающие (возраст в резюме обычно
+// не пишут) и только потом сохраняет. Словарь навыков — встроенный список и навыки, уже записанные
+// у кандидатов и в вакансиях, в их написании из базы.
+
+var builtinSkills = []string{
+	"Go", "Python", "Java", "Kotlin", "Scala", "C", "C++", "C#", ".NET", "JavaScript", "TypeScript", "PHP", "Ruby",
+	"Rust", "Swift", "Objective-C", "Dart", "Flutter", "SQL", "PostgreSQL", "MySQL", "Oracle", "MongoDB", "Redis",
+	"ClickHouse", "Elasticsearch", "Kafka", "RabbitMQ", "Docker", "Kubernetes", "Terraform", "Ansible", "Linux",
+	"Git", "CI/CD", "AWS", "GCP", "Azure", "React", "Vue", "Angular", "Node.js", "Django", "Flask", "FastAPI",
+	"Spring", "gRPC", "REST", "GraphQL", "HTML", "CSS", "Figma", "Photoshop", "1С", "Excel", "Power BI", "Tableau",
+	"Jira", "Confluence", "Agile", "Scrum", "Kanban", "Machine Learning", "Pandas", "NumPy", "PyTorch", "TensorFlow",
+	"Selenium", "QA", "Android", "iOS", "Nginx", "Prometheus", "Grafana",
+}
+
+var (
+	resumeEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	resumePhonePattern = regexp.MustCompile(`\+?\d[\d\s()\-]{8,}\d`)
+	// Периоды работы: «2018 — 2021», «2021 – н.в.», «2019-present».
+	resumePeriodPattern = regexp.MustCompile(`(?i)\b((?:19|20)\d{2})\s*[-–—]\s*((?:19|20)\d{2}|н\.\s?в\.?|настоящее время|по настоящее время|сейчас|present|now)`)
+	resumeNamePrefix    = regexp.MustCompile(`(?i)^(фио|имя|name)\s*:\s*`)
+)
+
+type ResumeDraft struct {
+	FullName string   `json:"full_name,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Phone    string   `json:"phone,omitempty"`
+	Years    float64  `json:"experience_years,omitempty"`
+	Skills   []string `json:"skills,omitempty"`
+}
+
+// Значения для формы добавления кандидата; ненайденные поля не заполняются.
+func (d ResumeDraft) formValues() map[string]string {
+	values := map[string]string{
+		"full_name": d.FullName,
+		"email":     d.Email,
+		"phone":     d.Phone,
+		"skills":    strings.Join(d.Skills, ", "),
+	}
+	if d.Years > 0 {
+		values["experience"] = fmt.Sprintf("%g лет", d.Years)
+	}
+	return values
+}
+
+func parseResumeText(text string, dictionary []string) ResumeDraft {
+	var draft ResumeDraft
+	draft.FullName = resumeFullName(text)
+	draft.Email = resumeEmailPattern.FindString(text)
+	for _, candidate := range resumePhonePattern.FindAllString(text, -1) {
+		if phone, err := normalizePhone(candidate); err == nil && phone != "" {
+			draft.Phone = phone
+			break
+		}
+	}
+	draft.Years = resumeExperienceYears(text, time.Now().Year())
+	draft.Skills = resumeSkills(text, dictionary)
+	return draft
+}
+
+// ФИО — первая строка в начале резюме из двух-четырёх слов с заглавной буквы без цифр и знаков.
+func resumeFullName(text string) string {
+	lines := strings.Split(text, "\n")
+	checked := 0
+	for _, line := range lines {
+		line = resumeNamePrefix.ReplaceAllString(strings.TrimSpace(line), "")
+		if line == "" {
+			continue
+		}
+		if checked++; checked > 5 {
+			break
+		}
+		words := strings.Fields(line)
+		if len(words) < 2 || len(words) > 4 {
+			continue
+		}
+		name := true
+		for _, word := range words {
+			runes := []rune(word)
+			if !unicode.IsUpper(runes[0]) {
+				name = false
+				break
+			}
+			for _, r := range runes {
+				if !unicode.IsLetter(r) && r != '-' && r != '.' {
+					name = false
+					break
+				}
+			}
+		}
+		if name {
+			return strings.Join(words, " ")
+		}
+	}
+	return ""
+}
+
+// Стаж: явное «опыт 5 лет» важнее периодов работы; без него складываются периоды «2018 — 2021».
+func resumeExperienceYears(text string, currentYear int) float64 {
+	var years float64
+	for _, m := range experienceYearsPattern.FindAllStringSubmatch(text, -1) {
+		if n, err := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64); err == nil && n < 60 && n > years {
+			years = n
+		}
+	}
+	if years > 0 {
+		return years
+	}
+	total := 0
+	for _, m := range resumePeriodPattern.FindAllStringSubmatch(text, -1) {
+		from, _ := strconv.Atoi(m[1])
+		to, err := strconv.Atoi(m[2])
+		if err != nil {
+			to = currentYear
+		}
+		if to >= from && to <= currentYear {
+			total += to - from
+		}
+	}
+	return float64(total)
+}
+
+// Навыки словаря, упомянутые в тексте отдельным словом: «Go» не находится в «Google», «C» — в «CSS».
+func resumeSkills(text string, dictionary []string) []string {
+	lower := strings.ToLower(text)
+	found := map[string]bool{}
+	var skills []string
+	for _, skill := range dictionary {
+		key := skillKey(skill)
+		if key == "" || found[key] {
+			continue
+		}
+		if containsWord(lower, key) {
+			found[key] = true
+			skills = append(skills, strings.TrimSpace(skill))
+		}
+	}
+	sort.Slice(skills, func(i, k int) bool { return skillKey(skills[i]) < skillKey(skills[k]) })
+	return skills
+}
+
+func containsWord(text, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		if !wordRuneBefore(text, i) && !wordRuneAt(text, end) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#'
+}
+
+func wordRuneBefore(text string, i int) bool {
+	if i == 0 {
+		return false
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return isWordRune(r)
+}
+
+func wordRuneAt(text string, i int) bool {
+	if i == len(text) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return isWordRune(r)
+}
+
+// Словарь навыков: навыки из базы в их написании, затем встроенные, которых в базе нет.
+func skillDictionary(db *sql.DB) ([]string, error) {
+	sets, err := loadSkillSets(db)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var dictionary []string
+	for _, set := range append(sets, builtinSkills) {
+		for _, skill := range set {
+			if key := skillKey(skill); key != "" && !seen[key] {
+				seen[key] = true
+				dictionary = append(dictionary, strings.TrimSpace(skill))
+			}
+		}
+	}
+	return dictionary, nil
+}
+
+func parseResume(app *App, text string) (ResumeDraft, error) {
+	if strings.TrimSpace(text) == "" {
+		return ResumeDraft{}, validationErrorf("текст резюме пуст")
+	}
+	dictionary, err := skillDictionary(app.DB)
+	if err != nil {
+		return ResumeDraft{}, err
+	}
+	return parseResumeText(text, dictionary), nil
+}
+
+func printResumeDraft(w io.Writer, d ResumeDraft) {
+	lines := [][2]string{
+		{"ФИО", d.FullName},
+		{"Email", d.Email},
+		{"Телефон", d.Phone},
+		{"Навыки", strings.Join(d.Skills, ", ")},
+	}
+	if d.Years > 0 {
+		lines = append(lines, [2]string{"Стаж", fmt.Sprintf("%g лет", d.Years)})
+	}
+	for _, line := range lines {
+		value := line[1]
+		if value == "" {
+			value = "не найдено"
+		}
+		fmt.Fprintf(w, "%s: %s\n", line[0], value)
+	}
+}
+
+// Резюме вставляется текстом или берётся из файла (@путь); найденное открывается в форме добавления кандидата.
+func addCandidateFromResumeMenu(app *App) {
+	text, err := getLongTextInput("Вставьте текст резюме: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	draft, err := parseResume(app, text)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	fmt.Println("Найдено в резюме:")
+	printResumeDraft(os.Stdout, draft)
+	addCandidateMenu(app, newDraftSaver(app, entityCandidate, nil), draft.formValues())
+}
+
+// parse-resume <файл> [-json]: только разбор, без сохранения кандидата.
+func runParseResumeCommand(app *App, args []string) int {
+	flags := flag.NewFlagSet("parse-resume", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "вывести черновик в JSON")
+	if len(args) < 1 {
+		fmt.Println("Использование: parse-resume <файл с текстом резюме> [-json]")
+		return exitUsage
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	text, err := os.ReadFile(args[0])
+	if err != nil {
+		return reportError(fmt.Errorf("ошибка чтения файла: %w", err))
+	}
+	draft, err := parseResume(app, string(text))
+	if err != nil {
+		return reportError(err)
+	}
+	useJSON(*asJSON)
+	return printView(sharedView{data: draft, text: func(w io.Writer) { printResumeDraft(w, draft) }})
+}
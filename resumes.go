@@ -0,0 +1,563 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Файлы резюме (PDF и DOCX). Описание файла — в таблице resume_files, сам файл — в хранилище:
+// RESUME_STORAGE=disk (по умолчанию) кладёт файлы в каталог RESUME_DIR (resumes), s3 — в бакет
+// S3-совместимого хранилища (S3_ENDPOINT, S3_BUCKET, S3_REGION, S3_ACCESS_KEY, S3_SECRET_KEY).
+// Каждая версия файла получает свой ключ, поэтому замена не портит текущий файл, если загрузка не удалась;
+// прежний файл удаляется после сохранения описания нового.
+
+const maxResumeSize = 10 << 20
+
+var resumeContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+type ResumeFile struct {
+	ID          int       `json:"id"`
+	CandidateID int       `json:"candidate_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"`
+	StorageKey  string    `json:"-"`
+	UploadedBy  string    `json:"uploaded_by,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+type resumeStore interface {
+	Put(key, contentType string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+func openResumeStore() (resumeStore, error) {
+	switch kind := os.Getenv("RESUME_STORAGE"); kind {
+	case "", "disk":
+		dir := os.Getenv("RESUME_DIR")
+		if dir == "" {
+			dir = "resumes"
+		}
+		return diskResumeStore{dir: dir}, nil
+	case "s3":
+		s := s3ResumeStore{
+			endpoint:  strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+			bucket:    os.Getenv("S3_BUCKET"),
+			region:    os.Getenv("S3_REGION"),
+			accessKey: os.Getenv("S3_ACCESS_KEY"),
+			secretKey: os.Getenv("S3_SECRET_KEY"),
+		}
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		if s.endpoint == "" || s.bucket == "" || s.accessKey == "" || s.secretKey == "" {
+			return nil, validationErrorf("для RESUME_STORAGE=s3 задайте S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY и S3_SECRET_KEY")
+		}
+		return s, nil
+	default:
+		return nil, validationErrorf("RESUME_STORAGE: допустимые значения disk и s3, получено %q", kind)
+	}
+}
+
+type diskResumeStore struct{ dir string }
+
+func (s diskResumeStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// Файл пишется во временный и переименовывается, чтобы недописанный файл не оказался под ключом.
+func (s diskResumeStore) Put(key, contentType string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("ошибка создания каталога резюме: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("ошибка записи файла резюме: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("ошибка записи файла резюме: %w", err)
+	}
+	return nil
+}
+
+func (s diskResumeStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, notFoundError("файл резюме отсутствует в хранилище")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла резюме: %w", err)
+	}
+	return data, nil
+}
+
+func (s diskResumeStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("ошибка удаления файла резюме: %w", err)
+	}
+	return nil
+}
+
+// S3-совместимое хранилище (AWS S3, MinIO и т. п.): адресация по пути, запросы подписываются AWS Signature V4.
+type s3ResumeStore struct {
+	endpoint, bucket, region, accessKey, secretKey string
+}
+
+var s3Client = &http.Client{Timeout: time.Minute}
+
+func (s s3ResumeStore) do(method, key, contentType string, body []byte) ([]byte, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key))
+	if err != nil {
+		return nil, fmt.Errorf("S3_ENDPOINT: неверный адрес: %w", err)
+	}
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к хранилищу резюме: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, body, time.Now().UTC())
+	resp, err := s3Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к хранилищу резюме: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResumeSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа хранилища резюме: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound && method == http.MethodGet:
+		return nil, notFoundError("файл резюме отсутствует в хранилище")
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("хранилище резюме ответило %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+func (s s3ResumeStore) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (s s3ResumeStore) Put(key, contentType string, data []byte) error {
+	_, err := s.do(http.MethodPut, key, contentType, data)
+	return err
+}
+
+func (s s3ResumeStore) Get(key string) ([]byte, error) {
+	return s.do(http.MethodGet, key, "", nil)
+}
+
+func (s s3ResumeStore) Delete(key string) error {
+	_, err := s.do(http.MethodDelete, key, "", nil)
+	return err
+}
+
+// Проверяет, что файл действительно PDF или DOCX, а не только так назван; возвращает тип содержимого.
+func detectResumeType(fileName string, data []byte) (string, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	contentType, ok := resumeContentTypes[ext]
+	if !ok {
+		return "", validationErrorf("резюме принимается в PDF или DOCX, получен файл %q", fileName)
+	}
+	if len(data) == 0 {
+		return "", validationErrorf("файл резюме пуст")
+	}
+	if len(data) > maxResumeSize {
+		return "", validationErrorf("файл резюме больше %d МБ", maxResumeSize>>20)
+	}
+	magic := "%PDF-"
+	if ext == ".docx" {
+		magic = "PK\x03\x04"
+	}
+	if !bytes.HasPrefix(data, []byte(magic)) {
+		return "", validationErrorf("содержимое файла %q не похоже на %s", fileName, strings.ToUpper(ext[1:]))
+	}
+	return contentType, nil
+}
+
+func getResumeFile(q storage.DBTX, candidateID int) (ResumeFile, error) {
+	var r ResumeFile
+	err := q.QueryRow(`
+		SELECT r.id, r.candidate_id, r.file_name, r.content_type, r.size_bytes, r.sha256, r.storage_key,
+			COALESCE(u.username, ''), r.uploaded_at
+		FROM resume_files r LEFT JOIN users u ON u.id = r.uploaded_by
+		WHERE r.candidate_id = $1`, candidateID).
+		Scan(&r.ID, &r.CandidateID, &r.FileName, &r.ContentType, &r.Size, &r.SHA256, &r.StorageKey, &r.UploadedBy, &r.UploadedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, notFoundError("у кандидата нет резюме")
+	}
+	if err != nil {
+		return r, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return r, nil
+}
+
+// Резюме — часть анкеты кандидата: доступно тем же, кому доступен кандидат (как и ссылки на анкету),
+// а загрузить или удалить его можно только с правом на изменение кандидатов.
+func authorizeResume(app *App, candidateID int, write bool) error {
+	if userID := actingUserID(); write && userID != 0 {
+		if err := authorizeUser(app, &TokenClaims{Subject: strconv.Itoa(userID)}, permCandidatesWrite); err != nil {
+			return err
+		}
+	}
+	return authorizeCandidate(app, candidateID)
+}
+
+// Сохраняет резюме кандидата; replace = false не даёт случайно заменить уже загруженное.
+func uploadResume(app *App, candidateID int, fileName string, data []byte, replace bool) (ResumeFile, error) {
+	if err := authorizeResume(app, candidateID, true); err != nil {
+		return ResumeFile{}, err
+	}
+	return storeResume(app, candidateID, fileName, data, replace)
+}
+
+// Без проверки прав: для формы отклика, которая сама решает, от чьего имени сохраняется резюме.
+func storeResume(app *App, candidateID int, fileName string, data []byte, replace bool) (ResumeFile, error) {
+	var r ResumeFile
+	fileName = filepath.Base(strings.TrimSpace(fileName))
+	fileName, err := sanitizeText("имя файла", fileName, maxNameLength)
+	if err != nil {
+		return r, err
+	}
+	contentType, err := detectResumeType(fileName, data)
+	if err != nil {
+		return r, err
+	}
+	if _, err := getCandidateByID(app, candidateID); err != nil {
+		return r, err
+	}
+	store, err := openResumeStore()
+	if err != nil {
+		return r, err
+	}
+
+	previous, err := getResumeFile(app.DB, candidateID)
+	switch {
+	case err == nil && !replace:
+		return r, validationErrorf("у кандидата уже есть резюме %q; чтобы заменить его, используйте замену", previous.FileName)
+	case err != nil && classifyError(err) != kindNotFound:
+		return r, err
+	}
+
+	sum := sha256Hex(data)
+	key := fmt.Sprintf("candidates/%d/%s-%d%s", candidateID, sum[:16], time.Now().UnixNano(), strings.ToLower(filepath.Ext(fileName)))
+	if err := store.Put(key, contentType, data); err != nil {
+		return r, err
+	}
+	err = app.DB.QueryRow(`
+		INSERT INTO resume_files (candidate_id, file_name, content_type, size_bytes, sha256, storage_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (candidate_id) DO UPDATE SET file_name = EXCLUDED.file_name, content_type = EXCLUDED.content_type,
+			size_bytes = EXCLUDED.size_bytes, sha256 = EXCLUDED.sha256, storage_key = EXCLUDED.storage_key,
+			uploaded_by = EXCLUDED.uploaded_by, uploaded_at = now()
+		RETURNING id, uploaded_at`,
+		candidateID, fileName, contentType, len(data), sum, key, nullUserID(actingUserID())).Scan(&r.ID, &r.UploadedAt)
+	if err != nil {
+		if err := store.Delete(key); err != nil {
+			opLogger().Warn("ошибка удаления несохранённого файла резюме", "key", key, "error", err)
+		}
+		return r, fmt.Errorf("ошибка сохранения резюме: %w", err)
+	}
+	if previous.StorageKey != "" {
+		removeResumeObject(opLogger(), store, previous.StorageKey)
+	}
+	audit("candidate.resume.uploaded", "candidate_id", candidateID, "file_name", fileName, "size", len(data), "replaced", previous.ID != 0)
+	r.CandidateID, r.FileName, r.ContentType, r.Size, r.SHA256, r.StorageKey = candidateID, fileName, contentType, int64(len(data)), sum, key
+	return r, nil
+}
+
+// Файл в хранилище вспомогательный: если удалить не удалось, остаётся лишний файл, но не битая запись.
+func removeResumeObject(logger *slog.Logger, store resumeStore, key string) {
+	if err := store.Delete(key); err != nil {
+		logger.Warn("ошибка удаления файла резюме", "key", key, "error", err)
+	}
+}
+
+func downloadResume(app *App, candidateID int) (ResumeFile, []byte, error) {
+	if err := authorizeResume(app, candidateID, false); err != nil {
+		return ResumeFile{}, nil, err
+	}
+	r, err := getResumeFile(app.DB, candidateID)
+	if err != nil {
+		return r, nil, err
+	}
+	store, err := openResumeStore()
+	if err != nil {
+		return r, nil, err
+	}
+	data, err := store.Get(r.StorageKey)
+	if err != nil {
+		return r, nil, err
+	}
+	if sha256Hex(data) != r.SHA256 {
+		return r, nil, fmt.Errorf("файл резюме в хранилище повреждён: контрольная сумма не совпадает")
+	}
+	return r, data, nil
+}
+
+func deleteResume(app *App, candidateID int) error {
+	if err := authorizeResume(app, candidateID, true); err != nil {
+		return err
+	}
+	r, err := getResumeFile(app.DB, candidateID)
+	if err != nil {
+		return err
+	}
+	store, err := openResumeStore()
+	if err != nil {
+		return err
+	}
+	if _, err := app.DB.Exec("DELETE FROM resume_files WHERE id = $1", r.ID); err != nil {
+		return fmt.Errorf("ошибка удаления резюме: %w", err)
+	}
+	removeResumeObject(opLogger(), store, r.StorageKey)
+	audit("candidate.resume.deleted", "candidate_id", candidateID, "file_name", r.FileName)
+	return nil
+}
+
+// Ключ файла резюме, пока кандидат ещё не удалён: строку resume_files удалит каскад, а файл — вызывающий.
+func resumeKeyBeforeDelete(q storage.DBTX, candidateID int) string {
+	r, err := getResumeFile(q, candidateID)
+	if err != nil {
+		return ""
+	}
+	return r.StorageKey
+}
+
+func removeCandidateResume(logger *slog.Logger, key string) {
+	if key == "" {
+		return
+	}
+	store, err := openResumeStore()
+	if err != nil {
+		logger.Warn("ошибка удаления файла резюме", "key", key, "error", err)
+		return
+	}
+	removeResumeObject(logger, store, key)
+}
+
+// Сохраняет файл, не перезаписывая существующий.
+func saveResumeTo(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return validationErrorf("файл %s уже существует", path)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка записи файла: %w", err)
+	}
+	return file.Close()
+}
+
+func resumeLine(r ResumeFile) string {
+	uploadedBy := r.UploadedBy
+	if uploadedBy == "" {
+		uploadedBy = "—"
+	}
+	return fmt.Sprintf("%s, %.1f КБ, загружено %s (%s)", r.FileName, float64(r.Size)/1024,
+		r.UploadedAt.Local().Format("2006-01-02 15:04"), uploadedBy)
+}
+
+func resumeMenu(app *App) {
+	candidateID, err := getEntityInput(app, entityCandidate, "Введите ID, ФИО или email кандидата: ")
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if err := authorizeResume(app, candidateID, false); err != nil {
+		handleError(err)
+		return
+	}
+	current, err := getResumeFile(app.DB, candidateID)
+	hasResume := err == nil
+	if err != nil && classifyError(err) != kindNotFound {
+		handleError(err)
+		return
+	}
+	if hasResume {
+		fmt.Println("Резюме:", resumeLine(current))
+		fmt.Println("1. Скачать")
+		fmt.Println("2. Заменить")
+		fmt.Println("3. Удалить")
+	} else {
+		fmt.Println("У кандидата нет резюме.")
+		fmt.Println("2. Загрузить")
+	}
+	choice, err := getIntInput("Введите номер действия (0 — назад): ")
+	handleError(err)
+	if err != nil || choice == 0 {
+		return
+	}
+	switch {
+	case choice == 1 && hasResume:
+		path := getInput(fmt.Sprintf("Куда сохранить (Enter — %s): ", current.FileName))
+		if path == "" {
+			path = current.FileName
+		}
+		_, data, err := downloadResume(app, candidateID)
+		if err == nil {
+			err = saveResumeTo(path, data)
+		}
+		handleError(err)
+		if err == nil {
+			fmt.Println("Резюме сохранено в", path)
+		}
+	case choice == 2:
+		if err := authorize(app, permCandidatesWrite); err != nil {
+			handleError(err)
+			return
+		}
+		path := getInput("Путь к файлу PDF или DOCX: ")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			handleError(fmt.Errorf("ошибка чтения файла: %w", err))
+			return
+		}
+		_, err = uploadResume(app, candidateID, path, data, hasResume)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Резюме сохранено.")
+		}
+	case choice == 3 && hasResume:
+		if err := authorize(app, permCandidatesWrite); err != nil {
+			handleError(err)
+			return
+		}
+		if !confirm(fmt.Sprintf("Удалить резюме %s?", current.FileName)) {
+			fmt.Println("Удаление отменено.")
+			return
+		}
+		err := deleteResume(app, candidateID)
+		handleError(err)
+		if err == nil {
+			fmt.Println("Резюме удалено.")
+		}
+	default:
+		fmt.Println("Неверный выбор действия.")
+	}
+}
+
+// resume upload|replace <кандидат> <файл> | resume download <кандидат> [-o файл] | resume info <кандидат> | resume delete <кандидат>
+func runResumeCommand(app *App, args []string) int {
+	usage := "Использование: resume upload <кандидат> <файл> | resume replace <кандидат> <файл> | " +
+		"resume download <кандидат> [-o файл] | resume info <кандидат> | resume delete <кандидат>"
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	id, err := resolveEntityID(app.DB, entityCandidate, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	switch {
+	case (args[0] == "upload" || args[0] == "replace") && len(args) == 3:
+		data, err := os.ReadFile(args[2])
+		if err != nil {
+			return reportError(fmt.Errorf("ошибка чтения файла: %w", err))
+		}
+		r, err := uploadResume(app, id, args[2], data, args[0] == "replace")
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Резюме сохранено: "+r.FileName, r)
+	case args[0] == "download":
+		flags := flag.NewFlagSet("resume download", flag.ContinueOnError)
+		output := flags.String("o", "", "куда сохранить (по умолчанию исходное имя файла в текущем каталоге)")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		r, data, err := downloadResume(app, id)
+		if err != nil {
+			return reportError(err)
+		}
+		path := *output
+		if path == "" {
+			path = r.FileName
+		}
+		if err := saveResumeTo(path, data); err != nil {
+			return reportError(err)
+		}
+		return printDone("Резюме сохранено в "+path, map[string]interface{}{"candidate_id": id, "file": path})
+	case args[0] == "info" && len(args) == 2:
+		if err := authorizeResume(app, id, false); err != nil {
+			return reportError(err)
+		}
+		r, err := getResumeFile(app.DB, id)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: r, text: func(w io.Writer) { fmt.Fprintln(w, resumeLine(r)) }})
+	case args[0] == "delete" && len(args) == 2:
+		if err := deleteResume(app, id); err != nil {
+			return reportError(err)
+		}
+		return printDone("Резюме удалено.", map[string]int{"candidate_id": id})
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"your_project_name/storage"
+)
+
+var retentionHorizons = []int{3, 6, 12}
+
+type hireRecord struct {
+	ApplicationID int
+	CandidateID   int
+	JobOpeningID  int
+	Source        string
+	Company       string
+	HiredAt       time.Time
+	ConfirmedAt   *time.Time
+	LeftAt        *time.Time
+}
+
+// Удержание на горизонте: Retained из Eligible; Eligible — наймы, для которых исход уже известен.
+type RetentionCell struct {
+	Retained int `json:"retained"`
+	Eligible int `json:"eligible"`
+}
+
+type RetentionRow struct {
+	Group  string                `json:"group"`
+	Cohort string                `json:"cohort"`
+	Hires  int                   `json:"hires"`
+	Cells  map[int]RetentionCell `json:"retention"`
+}
+
+func parseRetentionDate(text string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", text, time.Local)
+	if err != nil {
+		return time.Time{}, validationErrorf("дата %q должна быть в формате ГГГГ-ММ-ДД", text)
+	}
+	return t, nil
+}
+
+func requireHiredApplication(app *App, applicationID int) error {
+	application, err := getApplication(app, applicationID)
+	if err != nil {
+		return err
+	}
+	if application.Status != storage.ApplicationHired {
+		return validationErrorf("отклик %d не в статусе «принят»", applicationID)
+	}
+	return nil
+}
+
+// Подтверждение «всё ещё работает» на дату; более раннее подтверждение не затирает позднее.
+func confirmEmployed(app *App, applicationID int, on time.Time) error {
+	if err := requireHiredApplication(app, applicationID); err != nil {
+		return err
+	}
+	_, err := app.DB.Exec(`INSERT INTO hire_outcomes (application_id, confirmed_at) VALUES ($1, $2)
+		ON CONFLICT (application_id) DO UPDATE SET confirmed_at = GREATEST(hire_outcomes.confirmed_at, EXCLUDED.confirmed_at), updated_at = now()`,
+		applicationID, on)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения статуса сотрудника: %w", err)
+	}
+	return nil
+}
+
+func recordLeft(app *App, applicationID int, on time.Time) error {
+	if err := requireHiredApplication(app, applicationID); err != nil {
+		return err
+	}
+	_, err := app.DB.Exec(`INSERT INTO hire_outcomes (application_id, left_at) VALUES ($1, $2)
+		ON CONFLICT (application_id) DO UPDATE SET left_at = EXCLUDED.left_at, updated_at = now()`, applicationID, on)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения статуса сотрудника: %w", err)
+	}
+	return nil
+}
+
+func loadHires(app *App) ([]hireRecord, error) {
+	rows, err := app.DB.Query(`
+    SELECT a.id, a.candidate_id, a.job_opening_id, a.source, COALESCE(c.name, ''),
+        (SELECT MIN(h.changed_at) FROM application_status_history h WHERE h.application_id = a.id AND h.to_status = 'hired'),
+        o.confirmed_at, o.left_at
+    FROM applications a
+    JOIN job_openings j ON j.id = a.job_opening_id
+    LEFT JOIN companies c ON c.id = j.company_id
+    LEFT JOIN hire_outcomes o ON o.application_id = a.id
+    WHERE a.status = 'hired'`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var hires []hireRecord
+	for rows.Next() {
+		var h hireRecord
+		var hiredAt, confirmedAt, leftAt sql.NullTime
+		if err := rows.Scan(&h.ApplicationID, &h.CandidateID, &h.JobOpeningID, &h.Source, &h.Company, &hiredAt, &confirmedAt, &leftAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if !hiredAt.Valid {
+			continue
+		}
+		h.HiredAt = hiredAt.Time
+		if confirmedAt.Valid {
+			h.ConfirmedAt = &confirmedAt.Time
+		}
+		if leftAt.Valid {
+			h.LeftAt = &leftAt.Time
+		}
+		hires = append(hires, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return hires, nil
+}
+
+// Ушёл до горизонта — не удержан; ушёл позже или подтверждён на дату горизонта — удержан;
+// иначе исход неизвестен, и найм в знаменатель не попадает.
+func retainedAt(h hireRecord, months int) (retained, known bool) {
+	horizon := h.HiredAt.AddDate(0, months, 0)
+	if h.LeftAt != nil {
+		if h.LeftAt.Before(horizon) {
+			return false, true
+		}
+		return true, true
+	}
+	if h.ConfirmedAt != nil && !h.ConfirmedAt.Before(horizon) {
+		return true, true
+	}
+	return false, false
+}
+
+// Оценка совпадения по текущим данным кандидата и вакансии, разбитая на диапазоны.
+func matchBands(app *App, hires []hireRecord) (map[int]string, error) {
+	candidates, err := app.Candidates.List()
+	if err != nil {
+		return nil, err
+	}
+	jobOpenings, err := app.JobOpenings.List()
+	if err != nil {
+		return nil, err
+	}
+	candidateByID := map[int]int{}
+	for i, c := range candidates {
+		candidateByID[c.ID] = i
+	}
+	jobByID := map[int]int{}
+	for i, j := range jobOpenings {
+		jobByID[j.ID] = i
+	}
+
+	bands := map[int]string{}
+	for _, h := range hires {
+		ci, okC := candidateByID[h.CandidateID]
+		ji, okJ := jobByID[h.JobOpeningID]
+		if !okC || !okJ {
+			continue
+		}
+		score := matchCandidate(jobOpenings[ji], candidates[ci], defaultMatchWeights).Score
+		switch {
+		case score >= 0.75:
+			bands[h.ApplicationID] = "совпадение ≥75%"
+		case score >= 0.5:
+			bands[h.ApplicationID] = "совпадение 50–75%"
+		default:
+			bands[h.ApplicationID] = "совпадение <50%"
+		}
+	}
+	return bands, nil
+}
+
+// by: source, company или match — группировка строк; когорта — месяц найма.
+func retentionReport(app *App, by string) ([]RetentionRow, error) {
+	if by != "source" && by != "company" && by != "match" {
+		return nil, validationErrorf("группировка должна быть source, company или match")
+	}
+	hires, err := loadHires(app)
+	if err != nil {
+		return nil, err
+	}
+	var bands map[int]string
+	if by == "match" {
+		if bands, err = matchBands(app, hires); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := map[[2]string]*RetentionRow{}
+	for _, h := range hires {
+		group := h.Source
+		switch by {
+		case "company":
+			group = h.Company
+		case "match":
+			group = bands[h.ApplicationID]
+		}
+		if group == "" {
+			group = "не указано"
+		}
+		key := [2]string{group, h.HiredAt.Format("2006-01")}
+		row, ok := rows[key]
+		if !ok {
+			row = &RetentionRow{Group: key[0], Cohort: key[1], Cells: map[int]RetentionCell{}}
+			rows[key] = row
+		}
+		row.Hires++
+		for _, months := range retentionHorizons {
+			retained, known := retainedAt(h, months)
+			if !known {
+				continue
+			}
+			cell := row.Cells[months]
+			cell.Eligible++
+			if retained {
+				cell.Retained++
+			}
+			row.Cells[months] = cell
+		}
+	}
+
+	var report []RetentionRow
+	for _, row := range rows {
+		report = append(report, *row)
+	}
+	sort.Slice(report, func(i, k int) bool {
+		if report[i].Group != report[k].Group {
+			return report[i].Group < report[k].Group
+		}
+		return report[i].Cohort < report[k].Cohort
+	})
+	return report, nil
+}
+
+func writeRetentionReport(w io.Writer, report []RetentionRow) {
+	if len(report) == 0 {
+		fmt.Fprintln(w, "Наймов пока нет.")
+		return
+	}
+	fmt.Fprintf(w, "%-24s %-8s %6s %14s %14s %14s\n", "Группа", "Когорта", "Наймы", "3 мес.", "6 мес.", "12 мес.")
+	for _, row := range report {
+		cells := make([]string, len(retentionHorizons))
+		for i, months := range retentionHorizons {
+			cell := row.Cells[months]
+			cells[i] = "—"
+			if cell.Eligible > 0 {
+				cells[i] = fmt.Sprintf("%d/%d (%.0f%%)", cell.Retained, cell.Eligible, float64(cell.Retained)/float64(cell.Eligible)*100)
+			}
+		}
+		fmt.Fprintf(w, "%-24s %-8s %6d %14s %14s %14s\n", row.Group, row.Cohort, row.Hires, cells[0], cells[1], cells[2])
+	}
+	fmt.Fprintln(w, "\n«—» — для когорты ещё нет подтверждённых исходов на этом горизонте.")
+}
+
+// retention employed <ID отклика> [-date ГГГГ-ММ-ДД] | retention left <ID отклика> <ГГГГ-ММ-ДД> |
+// retention report [-by source|company|match] [-out файл] [-copy]
+func runRetentionCommand(app *App, args []string) int {
+	usage := "Использование: retention employed <ID отклика> [-date ГГГГ-ММ-ДД] | retention left <ID отклика> <ГГГГ-ММ-ДД> | " +
+		"retention report [-by source|company|match] [-out файл] [-copy]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	switch {
+	case args[0] == "employed" && len(args) >= 2:
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		flags := flag.NewFlagSet("retention employed", flag.ContinueOnError)
+		dateText := flags.String("date", time.Now().Format("2006-01-02"), "дата, на которую сотрудник работает")
+		if err := flags.Parse(args[2:]); err != nil {
+			return exitUsage
+		}
+		on, err := parseRetentionDate(*dateText)
+		if err == nil {
+			err = confirmEmployed(app, id, on)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Статус сотрудника сохранён.", map[string]interface{}{"application_id": id, "date": on.Format("2006-01-02")})
+	case args[0] == "left" && len(args) == 3:
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return reportError(err)
+		}
+		on, err := parseRetentionDate(args[2])
+		if err == nil {
+			err = recordLeft(app, id, on)
+		}
+		if err != nil {
+			return reportError(err)
+		}
+		return printDone("Дата ухода сохранена.", map[string]interface{}{"application_id": id, "left_on": on.Format("2006-01-02")})
+	case args[0] == "report":
+		flags := flag.NewFlagSet("retention report", flag.ContinueOnError)
+		by := flags.String("by", "source", "группировка: source, company или match")
+		var o shareOptions
+		o.register(flags)
+		if err := flags.Parse(args[1:]); err != nil {
+			return exitUsage
+		}
+		report, err := retentionReport(app, *by)
+		if err != nil {
+			return reportError(err)
+		}
+		view := sharedView{data: report, text: func(w io.Writer) { writeRetentionReport(w, report) }}
+		if code := printView(view); code != exitOK {
+			return code
+		}
+		if err := o.share(view); err != nil {
+			return reportError(err)
+		}
+		return exitOK
+	}
+	fmt.Println(usage)
+	return exitUsage
+}
@@ -0,0 +1,1270 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"your_project_name/storage"
+)
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcAppError       = -32000
+)
+
+// Auth — расширение протокола: access-токен из user.login, нужен методам из rpcPermissions.
+// ClientIP — тоже расширение: адрес, с которого пришёл запрос; его ставит веб-сервер, передающий запросы.
+type rpcRequest struct {
+	JSONRPC  string          `json:"jsonrpc"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params"`
+	ID       json.RawMessage `json:"id"`
+	Auth     string          `json:"auth,omitempty"`
+	ClientIP string          `json:"client_ip,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcMethod func(app *App, params json.RawMessage) (interface{}, error)
+
+type rpcParamsError struct{ err error }
+
+func (e rpcParamsError) Error() string { return e.err.Error() }
+
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return rpcParamsError{errors.New("параметры не переданы")}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return rpcParamsError{err}
+	}
+	return nil
+}
+
+type rpcIDParams struct {
+	ID int `json:"id"`
+}
+
+type rpcSimilarParams struct {
+	ID    int `json:"id"`
+	Limit int `json:"limit"`
+}
+
+// Страница и сортировка для списков и поиска; per_page 0 в поиске — все результаты.
+type rpcListParams struct {
+	Page    int    `json:"page"`
+	PerPage int    `json:"per_page"`
+	Sort    string `json:"sort"`
+}
+
+func (p rpcListParams) query() ListQuery {
+	return ListQuery{Page: p.Page, PerPage: p.PerPage, Sort: p.Sort}
+}
+
+type rpcSkillParams struct {
+	Skill string `json:"skill"`
+	rpcListParams
+}
+
+// Поиск кандидатов: либо один skill, либо список skills с режимом all/any и порогом min_match.
+// С expand навыки дополняются родственными, а ответ — список {candidate, score, related}.
+type rpcCandidateSearchParams struct {
+	Skill    string   `json:"skill"`
+	Skills   []string `json:"skills"`
+	Mode     string   `json:"mode"`
+	MinMatch int      `json:"min_match"`
+	Expand   bool     `json:"expand"`
+	rpcListParams
+}
+
+type rpcCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type rpcRefreshParams struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type rpcUpdateParams struct {
+	ID     int                        `json:"id"`
+	Fields map[string]json.RawMessage `json:"fields"`
+}
+
+// Значения полей приходят как JSON; тип определяется описанием поля, а не тем, что прислал клиент.
+func decodeFieldValues(fields []EditableField, raw map[string]json.RawMessage) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for name, data := range raw {
+		field, ok := findEditableField(fields, name)
+		if !ok {
+			return nil, rpcParamsError{fmt.Errorf("поле %q нельзя изменять", name)}
+		}
+		var err error
+		switch field.Kind {
+		case "int":
+			var v int
+			err = json.Unmarshal(data, &v)
+			values[name] = v
+		case "float":
+			var v float64
+			err = json.Unmarshal(data, &v)
+			values[name] = v
+		case "skills":
+			var v []string
+			err = json.Unmarshal(data, &v)
+			if v == nil {
+				v = []string{}
+			}
+			values[name] = v
+		default:
+			var v string
+			err = json.Unmarshal(data, &v)
+			if err == nil && field.Kind == "phone" {
+				values[name], err = normalizePhone(v)
+			} else {
+				values[name] = v
+			}
+		}
+		if err != nil {
+			return nil, rpcParamsError{fmt.Errorf("поле %q: %w", name, err)}
+		}
+	}
+	return values, nil
+}
+
+var rpcMethods = map[string]rpcMethod{
+	"user.register": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcCredentials
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, registerUser(app, p.Username, p.Password)
+	},
+	"user.login": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcCredentials
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		pair, err := loginWithTokens(app, p.Username, p.Password)
+		if err != nil {
+			return nil, err
+		}
+		claims, err := parseToken(pair.AccessToken, tokenAccess)
+		if err != nil {
+			return nil, err
+		}
+		required, err := passwordChangeRequired(app, claims.UserID())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"user_id": claims.UserID(), "role": claims.Role, "must_change_password": required,
+			"access_token": pair.AccessToken, "refresh_token": pair.RefreshToken,
+			"access_expires_at": pair.AccessExpiresAt, "refresh_expires_at": pair.RefreshExpiresAt,
+		}, nil
+	},
+	"auth.refresh": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcRefreshParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return refreshTokenPair(app, p.RefreshToken)
+	},
+	"auth.logout": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcRefreshParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, revokeRefreshToken(app.DB, p.RefreshToken)
+	},
+	// Ответ не зависит от существования пользователя, а код уходит только письмом.
+	"auth.requestPasswordReset": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if _, err := requestPasswordReset(app, p.Username); err != nil && classifyError(err) != kindNotFound {
+			return nil, err
+		}
+		return true, nil
+	},
+	"auth.resetPassword": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, resetPassword(app, p.Token, p.Password)
+	},
+	"company.add": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Name string `json:"name"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := addCompany(app, p.Name)
+		return map[string]int{"id": id}, err
+	},
+	"company.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return companyDetails(app, p.ID)
+	},
+	"company.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Search string `json:"search"`
+			rpcListParams
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		companies, err := companyDirectory(app, p.Search, p.query())
+		if companies == nil {
+			companies = []storage.CompanySummary{}
+		}
+		return companies, err
+	},
+	"company.update": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID       int     `json:"id"`
+			Name     string  `json:"name"`
+			Industry *string `json:"industry"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Name != "" || p.Industry == nil {
+			if err := renameCompany(app, p.ID, p.Name); err != nil {
+				return nil, err
+			}
+		}
+		if p.Industry != nil {
+			if err := setCompanyIndustry(app, p.ID, *p.Industry); err != nil {
+				return nil, err
+			}
+		}
+		return getCompany(app, p.ID)
+	},
+	"company.delete": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID    int  `json:"id"`
+			Force bool `json:"force"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, deleteCompany(app, p.ID, p.Force)
+	},
+	"candidate.add": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p storage.Candidate
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := addCandidate(app, p)
+		return map[string]int{"id": id}, err
+	},
+	"candidate.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return getCandidateByID(app, p.ID)
+	},
+	"candidate.update": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcUpdateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		values, err := decodeFieldValues(candidateEditableFields, p.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := updateCandidateFields(app, p.ID, values); err != nil {
+			return nil, err
+		}
+		return getCandidateByID(app, p.ID)
+	},
+	"candidate.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			CandidateListFilter
+			rpcListParams
+		}
+		p.Page, p.PerPage = 1, 20
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		candidates, total, err := listCandidates(app, p.CandidateListFilter, p.query())
+		if candidates == nil {
+			candidates = []storage.Candidate{}
+		}
+		return map[string]interface{}{"candidates": candidates, "total": total, "page": p.Page, "per_page": p.PerPage}, err
+	},
+	"candidate.delete": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, deleteCandidate(app, p.ID)
+	},
+	"candidate.searchBySkill": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcCandidateSearchParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Expand {
+			if len(p.Skills) == 0 {
+				p.Skills = []string{p.Skill}
+			}
+			hits, err := findCandidatesBySkillsExpanded(app, p.Skills, p.Mode, p.MinMatch, p.query())
+			if hits == nil {
+				hits = []SkillSearchHit{}
+			}
+			return hits, err
+		}
+		var candidates []storage.Candidate
+		var err error
+		if len(p.Skills) > 0 {
+			candidates, err = findCandidatesBySkills(app, p.Skills, p.Mode, p.MinMatch, p.query())
+		} else {
+			candidates, err = findCandidatesBySkill(app, p.Skill, p.query())
+		}
+		if candidates == nil {
+			candidates = []storage.Candidate{}
+		}
+		return candidates, err
+	},
+	"candidate.query": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Query string `json:"query"`
+			rpcListParams
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		candidates, err := searchCandidates(app, p.Query, p.query(), 0)
+		if candidates == nil {
+			candidates = []storage.Candidate{}
+		}
+		return candidates, err
+	},
+	// Поиски вызывающего и общие; общие (created_by NULL) создаются только консольной командой.
+	"savedSearch.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		searches, err := listSavedSearches(app, actingUserID())
+		if searches == nil {
+			searches = []SavedSearch{}
+		}
+		return searches, err
+	},
+	"savedSearch.save": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Name  string `json:"name"`
+			Query string `json:"query"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, saveSearch(app, p.Name, p.Query, actingUserID())
+	},
+	// Удалить можно только свой поиск, поэтому, как и сохранение, требует лишь права на чтение кандидатов.
+	"savedSearch.delete": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Name string `json:"name"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, deleteSavedSearch(app, p.Name, actingUserID())
+	},
+	"jobOpening.add": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p storage.JobOpening
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := addJobOpening(app, p)
+		return map[string]int{"id": id}, err
+	},
+	"jobOpening.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return getJobOpeningByID(app, p.ID)
+	},
+	"jobOpening.update": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcUpdateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		values, err := decodeFieldValues(jobOpeningEditableFields, p.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := updateJobOpeningFields(app, p.ID, values); err != nil {
+			return nil, err
+		}
+		return getJobOpeningByID(app, p.ID)
+	},
+	"jobOpening.searchBySkill": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcSkillParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		jobOpenings, err := findJobOpeningsBySkill(app, p.Skill, p.query())
+		if jobOpenings == nil {
+			jobOpenings = []storage.JobOpening{}
+		}
+		return jobOpenings, err
+	},
+	"usage.monthly": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Month string `json:"month"`
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		records, err := getMonthlyUsage(app.DB, p.Month)
+		if records == nil {
+			records = []UsageRecord{}
+		}
+		return records, err
+	},
+	"application.create": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			CandidateID  int    `json:"candidate_id"`
+			JobOpeningID int    `json:"job_opening_id"`
+			Source       string `json:"source"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := createApplication(app, p.CandidateID, p.JobOpeningID, p.Source, 0)
+		if err != nil {
+			return nil, err
+		}
+		return getApplication(app, id)
+	},
+	"application.setStatus": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID      int    `json:"id"`
+			Status  string `json:"status"`
+			Comment string `json:"comment"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := changeApplicationStatus(app, p.ID, p.Status, p.Comment, 0); err != nil {
+			return nil, err
+		}
+		return getApplication(app, p.ID)
+	},
+	"application.listByCandidate": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		applications, err := applicationsByCandidate(app, p.ID)
+		if applications == nil {
+			applications = []storage.Application{}
+		}
+		return applications, err
+	},
+	"application.listByJobOpening": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		applications, err := applicationsByJobOpening(app, p.ID)
+		if applications == nil {
+			applications = []storage.Application{}
+		}
+		return applications, err
+	},
+	"application.history": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		history, err := applicationHistory(app, p.ID)
+		if history == nil {
+			history = []storage.ApplicationStatusChange{}
+		}
+		return history, err
+	},
+	"application.search": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			PipelineQuery
+			rpcListParams
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		entries, err := searchPipeline(app, p.PipelineQuery, p.query())
+		if entries == nil {
+			entries = []storage.PipelineEntry{}
+		}
+		return entries, err
+	},
+	// Число откликов на этапах; as_of — на прошедший момент.
+	"application.stages": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p PipelineQuery
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return pipelineStages(app, p)
+	},
+	"profileShare.create": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			CandidateID int      `json:"candidate_id"`
+			Fields      []string `json:"fields"`
+			// Срок действия в часах; 0 — PROFILE_SHARE_TTL.
+			TTLHours int `json:"ttl_hours"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return createProfileShare(app, p.CandidateID, p.Fields, time.Duration(p.TTLHours)*time.Hour)
+	},
+	"profileShare.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		shares, err := listProfileShares(app, p.ID)
+		if shares == nil {
+			shares = []ProfileShare{}
+		}
+		return shares, err
+	},
+	"profileShare.revoke": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, revokeProfileShare(app, p.ID)
+	},
+	// Без токена: по ссылке профиль смотрит человек без учётной записи.
+	"profile.view": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Token string `json:"token"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return viewSharedProfile(app, p.Token, "rpc")
+	},
+	// Только черновик: клиент показывает его пользователю и сохраняет через candidate.add.
+	"candidate.parseResume": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Text string `json:"text"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return parseResume(app, p.Text)
+	},
+	"applyForm.publish": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return publishApplyForm(app, p.ID)
+	},
+	"applyForm.revoke": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, revokeApplyForm(app, p.ID)
+	},
+	"applyReview.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		items, err := listApplyReviewQueue(app)
+		if items == nil {
+			items = []ApplyReviewItem{}
+		}
+		return items, err
+	},
+	"applyReview.approve": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return approveApplyReview(app, p.ID)
+	},
+	"applyReview.reject": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, rejectApplyReview(app, p.ID)
+	},
+	"currency.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		rates, err := listCurrencyRates(app.DB)
+		if rates == nil {
+			rates = []CurrencyRate{}
+		}
+		return map[string]interface{}{"default": salaryCurrency(), "rates": rates}, err
+	},
+	"currency.set": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Currency string  `json:"currency"`
+			Rate     float64 `json:"rate"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setCurrencyRate(app, p.Currency, p.Rate)
+	},
+	"currency.delete": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Currency string `json:"currency"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, deleteCurrencyRate(app, p.Currency)
+	},
+	"projection.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		return projectionStatuses(app.DB)
+	},
+	// Проход идёт в очереди задач; rebuild сначала сбрасывает проекцию.
+	"projection.replay": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Projection string `json:"projection"`
+			Rebuild    bool   `json:"rebuild"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := scheduleProjectionReplay(app, p.Projection, p.Rebuild)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"job_id": id}, nil
+	},
+	"purge.report": func(app *App, params json.RawMessage) (interface{}, error) {
+		return purgeReport(app)
+	},
+	// Очистка идёт в очереди задач; без class — все классы данных.
+	"purge.run": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p retentionPurgePayload
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := scheduleRetentionPurgeOnce(app, p.Class)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"job_id": id}, nil
+	},
+	// Удержание ставится на кандидата (candidate_id) или на отклик (application_id).
+	"legalHold.place": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			CandidateID   int    `json:"candidate_id"`
+			ApplicationID int    `json:"application_id"`
+			Reason        string `json:"reason"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := placeLegalHold(app, p.CandidateID, p.ApplicationID, p.Reason)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"id": id}, nil
+	},
+	"legalHold.release": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID     int    `json:"id"`
+			Reason string `json:"reason"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, releaseLegalHold(app, p.ID, p.Reason)
+	},
+	// Без all — только действующие удержания.
+	"legalHold.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			All bool `json:"all"`
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return listLegalHolds(app, p.All)
+	},
+	// until — последний день показа, ГГГГ-ММ-ДД; пустой roles — для всех ролей.
+	"announcement.publish": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Title     string   `json:"title"`
+			Body      string   `json:"body"`
+			Roles     []string `json:"roles"`
+			CompanyID int      `json:"company_id"`
+			Until     string   `json:"until"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := publishAnnouncement(app, Announcement{Title: p.Title, Body: p.Body, Roles: p.Roles, CompanyID: p.CompanyID}, p.Until)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"id": id}, nil
+	},
+	"announcement.withdraw": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID int `json:"id"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, withdrawAnnouncement(app, p.ID)
+	},
+	"announcement.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			All bool `json:"all"`
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return listAnnouncements(app, p.All)
+	},
+	// Баннер для веб-интерфейса: объявления вызывающего, которые он ещё не отметил прочитанными.
+	"announcement.pending": func(app *App, params json.RawMessage) (interface{}, error) {
+		announcements, err := pendingAnnouncements(app, actingUserID())
+		if announcements == nil {
+			announcements = []Announcement{}
+		}
+		return announcements, err
+	},
+	// Без ids — все показанные объявления.
+	"announcement.acknowledge": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			IDs []int `json:"ids"`
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return true, acknowledgeAnnouncements(app, actingUserID(), p.IDs...)
+	},
+	// Без username — настройки вызывающего; чужие доступны тому, кто управляет пользователями.
+	"notifyPrefs.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		user, err := notificationSettingsUser(app, p.Username)
+		if err != nil {
+			return nil, err
+		}
+		return notificationSettings(app, user)
+	},
+	// enabled: null возвращает умолчание роли.
+	"notifyPrefs.set": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+			Kind     string `json:"kind"`
+			Channel  string `json:"channel"`
+			Enabled  *bool  `json:"enabled"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		user, err := notificationSettingsUser(app, p.Username)
+		if err != nil {
+			return nil, err
+		}
+		if err := setNotificationPreference(app, user, p.Kind, p.Channel, p.Enabled); err != nil {
+			return nil, err
+		}
+		return notificationSettings(app, user)
+	},
+	// Пустые from и to снимают тихие часы.
+	"notifyPrefs.quietHours": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+			QuietHours
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		user, err := notificationSettingsUser(app, p.Username)
+		if err != nil {
+			return nil, err
+		}
+		spec := ""
+		if p.From != "" || p.To != "" {
+			spec = p.From + "-" + p.To
+		}
+		if err := setQuietHours(app, user, spec, p.TimeZone); err != nil {
+			return nil, err
+		}
+		return notificationSettings(app, user)
+	},
+	"notifyPrefs.mandatory": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Kind      string `json:"kind"`
+			Mandatory bool   `json:"mandatory"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setMandatoryNotification(app, p.Kind, p.Mandatory)
+	},
+	// Без токена: форму отклика заполняет внешний кандидат.
+	"apply.form": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Token string `json:"token"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return publicVacancy(app, p.Token)
+	},
+	"apply.submit": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p ApplyRequest
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		p.ClientIP = currentOperation.ClientIP
+		if _, err := submitPublicApplication(app, p); err != nil {
+			return nil, err
+		}
+		return true, nil
+	},
+	"audit.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			AuditLogQuery
+			rpcListParams
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		entries, err := listAuditLog(app, p.AuditLogQuery, p.query())
+		if entries == nil {
+			entries = []storage.AuditEntry{}
+		}
+		return entries, err
+	},
+	"jobOpening.similar": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcSimilarParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		similar, err := similarJobOpenings(app, p.ID, p.Limit)
+		if similar == nil {
+			similar = []SimilarJobOpening{}
+		}
+		return similar, err
+	},
+	"candidate.similar": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcSimilarParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		similar, err := similarCandidates(app, p.ID, p.Limit)
+		if similar == nil {
+			similar = []SimilarCandidate{}
+		}
+		return similar, err
+	},
+	"jobOpening.match": func(app *App, params json.RawMessage) (interface{}, error) {
+		p := struct {
+			ID      int           `json:"id"`
+			Limit   int           `json:"limit"`
+			Weights *MatchWeights `json:"weights"`
+		}{}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		weights, err := jobMatchWeights(app, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Weights != nil {
+			weights = *p.Weights
+		}
+		return rankCandidates(app, p.ID, weights, p.Limit)
+	},
+	"matches.new": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Since     time.Time `json:"since"`
+			CompanyID int       `json:"company_id"`
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		if p.Since.IsZero() {
+			p.Since = time.Now().Add(-24 * time.Hour)
+		}
+		counts, err := newMatchCounts(app.DB, p.Since, p.CompanyID)
+		if counts == nil {
+			counts = []NewMatchCount{}
+		}
+		return counts, err
+	},
+	"matches.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if _, err := getJobOpeningByID(app, p.ID); err != nil {
+			return nil, err
+		}
+		matches, err := storedMatches(app.DB, p.ID)
+		if matches == nil {
+			matches = []StoredMatch{}
+		}
+		return matches, err
+	},
+	"matchRules.get": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if _, err := getCompany(app, p.ID); err != nil {
+			return nil, err
+		}
+		return companyMatchWeights(app.DB, p.ID)
+	},
+	"matchRules.set": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID      int          `json:"id"`
+			Weights MatchWeights `json:"weights"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setMatchWeights(app, p.ID, p.Weights, 0)
+	},
+	"matchRules.reset": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, resetMatchWeights(app, p.ID, 0)
+	},
+	"matchRules.preview": func(app *App, params json.RawMessage) (interface{}, error) {
+		p := struct {
+			ID      int          `json:"id"`
+			Limit   int          `json:"limit"`
+			Weights MatchWeights `json:"weights"`
+		}{Limit: 10}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := validateMatchWeights(p.Weights); err != nil {
+			return nil, err
+		}
+		rows, err := previewMatchWeights(app, p.ID, p.Weights, p.Limit)
+		if rows == nil {
+			rows = []MatchPreviewRow{}
+		}
+		return rows, err
+	},
+	"matchRules.history": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		changes, err := matchRuleHistory(app.DB, p.ID)
+		if changes == nil {
+			changes = []MatchRuleChange{}
+		}
+		return changes, err
+	},
+	"jobOpening.forecast": func(app *App, params json.RawMessage) (interface{}, error) {
+		p := struct {
+			ID       int `json:"id"`
+			Openings int `json:"openings"`
+		}{Openings: 1}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		var forecast HiringForecast
+		err := withReportSnapshot(app, reportSnapshotEnabled(), func(app *App) (err error) {
+			forecast, err = forecastHiring(app, p.ID, p.Openings)
+			return err
+		})
+		return forecast, err
+	},
+	"user.setRole": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+			Role     string `json:"role"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setUserRole(app, p.Username, p.Role)
+	},
+	"user.setEmail": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setUserEmail(app, p.Username, p.Email)
+	},
+	// candidate_id = 0 снимает связь.
+	"user.linkCandidate": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username    string `json:"username"`
+			CandidateID int    `json:"candidate_id"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, linkCandidateAccount(app, p.Username, p.CandidateID)
+	},
+	// company_id = 0 снимает привязку.
+	"user.setCompany": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Username  string `json:"username"`
+			CompanyID int    `json:"company_id"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, setUserCompany(app, p.Username, p.CompanyID)
+	},
+	// Анкета вызывающего пользователя.
+	"me.profile": func(app *App, params json.RawMessage) (interface{}, error) {
+		return userCandidate(app, actingUserID())
+	},
+	"me.createProfile": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p storage.Candidate
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		id, err := createOwnCandidateProfile(app, p)
+		return map[string]int{"id": id}, err
+	},
+	"me.updateProfile": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Fields map[string]json.RawMessage `json:"fields"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		values, err := decodeFieldValues(ownCandidateFields, p.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := updateOwnCandidateProfile(app, values); err != nil {
+			return nil, err
+		}
+		return userCandidate(app, actingUserID())
+	},
+	// Лента вызывающего пользователя; like — «ещё похожие» на эту вакансию.
+	"feed.vacancies": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			Like  int `json:"like"`
+			Limit int `json:"limit"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		feed, err := vacancyFeed(app, actingUserID(), p.Like, p.Limit)
+		if feed == nil {
+			feed = []VacancyRecommendation{}
+		}
+		return feed, err
+	},
+	"jobOpening.list": func(app *App, params json.RawMessage) (interface{}, error) {
+		// Ответ остаётся массивом, как до появления страниц; без per_page возвращаются все вакансии.
+		var p struct {
+			JobOpeningListFilter
+			rpcListParams
+		}
+		if len(params) > 0 {
+			if err := decodeParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		jobOpenings, _, err := listJobOpenings(app, p.JobOpeningListFilter, p.query())
+		if jobOpenings == nil {
+			jobOpenings = []storage.JobOpening{}
+		}
+		return jobOpenings, err
+	},
+	"jobOpening.setStatus": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p struct {
+			ID     int    `json:"id"`
+			Status string `json:"status"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := setJobOpeningStatus(app, p.ID, p.Status); err != nil {
+			return nil, err
+		}
+		return getJobOpeningByID(app, p.ID)
+	},
+	"jobOpening.delete": func(app *App, params json.RawMessage) (interface{}, error) {
+		var p rpcIDParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return true, deleteJobOpening(app, p.ID)
+	},
+}
+
+func authorizeRPC(app *App, req rpcRequest) error {
+	perm, ok := rpcPermissions[req.Method]
+	if !ok {
+		return nil
+	}
+	if req.Auth == "" {
+		return permissionError("требуется авторизация: передайте access-токен в поле auth")
+	}
+	claims, err := parseToken(req.Auth, tokenAccess)
+	if err != nil {
+		return err
+	}
+	return authorizeUser(app, claims, perm)
+}
+
+func handleRPCRequest(app *App, raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return &rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &rpcError{Code: rpcInvalidRequest, Message: "неверный запрос JSON-RPC 2.0"}}
+	}
+	isNotification := len(req.ID) == 0
+
+	// Пользователь для журнала; сам токен проверяет authorizeRPC.
+	userID := 0
+	if claims, err := parseToken(req.Auth, tokenAccess); err == nil {
+		userID = claims.UserID()
+	}
+	beginOperation("rpc."+req.Method, userID)
+	currentOperation.ClientIP = req.ClientIP
+
+	resp := &rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		resp.Error = &rpcError{Code: rpcMethodNotFound, Message: "метод не найден: " + req.Method}
+	} else {
+		var result interface{}
+		err := authorizeRPC(app, req)
+		if err == nil {
+			result, err = method(app, req.Params)
+		}
+		if err != nil {
+			logError(err)
+		}
+		var paramsErr rpcParamsError
+		switch {
+		case errors.As(err, &paramsErr):
+			resp.Error = &rpcError{Code: rpcInvalidParams, Message: "неверные параметры: " + paramsErr.Error()}
+		case err != nil:
+			message, hint := describeError(err)
+			data := map[string]string{"kind": string(classifyError(err))}
+			if hint != "" {
+				data["hint"] = hint
+			}
+			resp.Error = &rpcError{Code: rpcAppError, Message: message, Data: data}
+		default:
+			resp.Result = result
+		}
+	}
+
+	if isNotification {
+		return nil
+	}
+	return resp
+}
+
+// Запросы читаются потоком JSON-значений из stdin (обычно по одному на строку),
+// ответы пишутся в stdout по одному на строку. Поддерживаются пакетные запросы и уведомления.
+func serveRPC(app *App, in io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(bufio.NewReader(in))
+	encoder := json.NewEncoder(out)
+
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &rpcError{Code: rpcParseError, Message: "ошибка разбора JSON"}})
+			return fmt.Errorf("ошибка разбора входного потока: %w", err)
+		}
+
+		trimmed := bytes.TrimSpace(raw)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(trimmed, &batch); err != nil || len(batch) == 0 {
+				encoder.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+					Error: &rpcError{Code: rpcInvalidRequest, Message: "пустой или неверный пакет запросов"}})
+				continue
+			}
+			var responses []*rpcResponse
+			for _, item := range batch {
+				if resp := handleRPCRequest(app, item); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+			if len(responses) > 0 {
+				if err := encoder.Encode(responses); err != nil {
+					return fmt.Errorf("ошибка записи ответа: %w", err)
+				}
+			}
+			continue
+		}
+
+		if resp := handleRPCRequest(app, trimmed); resp != nil {
+			if err := encoder.Encode(resp); err != nil {
+				return fmt.Errorf("ошибка записи ответа: %w", err)
+			}
+		}
+	}
+}
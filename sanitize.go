@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"your_project_name/storage"
+)
+
+const (
+	maxNameLength     = 200
+	maxEmailLength    = 254
+	maxUsernameLength = 100
+	maxSkillLength    = 100
+	maxLongTextLength = 10000
+)
+
+// Приводит строку к NFC, выбрасывает управляющие и невидимые символы и схлопывает пробелы.
+// Переводы строк сохраняются только при keepNewlines.
+func normalizeText(s string, keepNewlines bool) string {
+	s = norm.NFC.String(s)
+
+	var b strings.Builder
+	pendingSpace := false
+	for _, r := range s {
+		switch {
+		case r == '\n' && keepNewlines:
+			b.WriteRune('\n')
+			pendingSpace = false
+			continue
+		case unicode.IsSpace(r):
+			pendingSpace = true
+			continue
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r), r == utf8.RuneError:
+			continue
+		}
+		if pendingSpace && b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteRune(' ')
+		}
+		pendingSpace = false
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func sanitizeText(field, s string, maxLength int) (string, error) {
+	s = normalizeText(s, false)
+	if utf8.RuneCountInString(s) > maxLength {
+		return "", validationErrorf("поле «%s» длиннее %d символов", field, maxLength)
+	}
+	return s, nil
+}
+
+func sanitizeLongText(field, s string) (string, error) {
+	s = normalizeText(strings.ReplaceAll(s, "\r\n", "\n"), true)
+	if utf8.RuneCountInString(s) > maxLongTextLength {
+		return "", validationErrorf("поле «%s» длиннее %d символов", field, maxLongTextLength)
+	}
+	return s, nil
+}
+
+func sanitizeSkills(skills []string) ([]string, error) {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, skill := range skills {
+		clean, err := sanitizeText("навык", skill, maxSkillLength)
+		if err != nil {
+			return nil, err
+		}
+		if clean == "" || seen[clean] {
+			continue
+		}
+		seen[clean] = true
+		result = append(result, clean)
+	}
+	return result, nil
+}
+
+func sanitizeCandidate(c *storage.Candidate) error {
+	var err error
+	if c.FullName, err = sanitizeText("ФИО", c.FullName, maxNameLength); err != nil {
+		return err
+	}
+	if c.Email, err = sanitizeText("email", c.Email, maxEmailLength); err != nil {
+		return err
+	}
+	if c.Phone, err = normalizePhone(c.Phone); err != nil {
+		return err
+	}
+	if c.Experience, err = sanitizeLongText("опыт работы", c.Experience); err != nil {
+		return err
+	}
+	if c.Location, err = sanitizeText("город", c.Location, maxNameLength); err != nil {
+		return err
+	}
+	if c.Skills, err = sanitizeSkills(c.Skills); err != nil {
+		return err
+	}
+	if c.Languages, err = sanitizeSkills(c.Languages); err != nil {
+		return err
+	}
+	if c.Tags, err = sanitizeSkills(c.Tags); err != nil {
+		return err
+	}
+	if c.Status == "" {
+		c.Status = storage.CandidateActive
+	}
+	return validateCandidateStatus(c.Status)
+}
+
+func sanitizeJobOpening(j *storage.JobOpening) error {
+	var err error
+	if j.Title, err = sanitizeText("название вакансии", j.Title, maxNameLength); err != nil {
+		return err
+	}
+	if j.Experience, err = sanitizeLongText("требуемый опыт", j.Experience); err != nil {
+		return err
+	}
+	if j.Location, err = sanitizeText("город", j.Location, maxNameLength); err != nil {
+		return err
+	}
+	if j.RequiredSkills, err = sanitizeSkills(j.RequiredSkills); err != nil {
+		return err
+	}
+	j.Languages, err = sanitizeSkills(j.Languages)
+	return err
+}
+
+func sanitizeFieldValue(field EditableField, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if field.Kind == "phone" {
+			return normalizePhone(v)
+		}
+		if field.Kind == "currency" {
+			return normalizeCurrency(v)
+		}
+		if field.Kind == "text" {
+			return sanitizeLongText(field.Label, v)
+		}
+		if field.Kind == "date" {
+			date, err := parseOptionalDate(v)
+			if err != nil || date == nil {
+				return nil, err
+			}
+			return *date, nil
+		}
+		maxLength := maxNameLength
+		if field.Kind == "email" {
+			maxLength = maxEmailLength
+		}
+		return sanitizeText(field.Label, v, maxLength)
+	case []string:
+		return sanitizeSkills(v)
+	}
+	return value, nil
+}
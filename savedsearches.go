@@ -0,0 +1,420 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Сохранённый поиск принадлежит пользователю, который его сохранил; поиски, сохранённые без
+// авторизации (userID 0 — команды и JSON-RPC), общие и видны всем. Имя уникально в пределах владельца,
+// и при совпадении имён «@имя» находит сначала свой поиск.
+type SavedSearch struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Query     string    `json:"query"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Shared    bool      `json:"shared"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Сохраняет запрос под именем; свой запрос с тем же именем заменяется. Сохраняются только разбираемые запросы.
+func saveSearch(app *App, name, query string, userID int) error {
+	name, err := sanitizeText("название поиска", name, maxNameLength)
+	if err != nil {
+		return err
+	}
+	if name == "" || strings.HasPrefix(name, "@") {
+		return validationErrorf("название поиска не может быть пустым или начинаться с @")
+	}
+	query = normalizeText(query, false)
+	if _, _, err := compileCandidateQuery(query); err != nil {
+		return err
+	}
+	_, err = app.DB.Exec(`INSERT INTO saved_searches (name, query, created_by) VALUES ($1, $2, $3)
+		ON CONFLICT ((COALESCE(created_by, 0)), name) DO UPDATE SET query = EXCLUDED.query, created_at = now()`,
+		name, query, nullUserID(userID))
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения поиска: %w", err)
+	}
+	return nil
+}
+
+// Свои поиски пользователя, затем общие.
+func listSavedSearches(app *App, userID int) ([]SavedSearch, error) {
+	rows, err := app.DB.Query(`
+		SELECT s.id, s.name, s.query, COALESCE(u.username, ''), s.created_by IS NULL, s.created_at
+		FROM saved_searches s LEFT JOIN users u ON u.id = s.created_by
+		WHERE s.created_by = $1 OR s.created_by IS NULL
+		ORDER BY s.created_by IS NULL, s.name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var searches []SavedSearch
+	for rows.Next() {
+		var s SavedSearch
+		if err := rows.Scan(&s.ID, &s.Name, &s.Query, &s.CreatedBy, &s.Shared, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		searches = append(searches, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return searches, nil
+}
+
+// Удалить можно только свой поиск; общие удаляются без авторизации (userID 0).
+func deleteSavedSearch(app *App, name string, userID int) error {
+	result, err := app.DB.Exec("DELETE FROM saved_searches WHERE name = $1 AND COALESCE(created_by, 0) = $2",
+		strings.TrimPrefix(strings.TrimSpace(name), "@"), userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления поиска: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return notFoundError("сохранённый поиск не найден")
+	}
+	return nil
+}
+
+// «@имя» — сохранённый запрос, иначе текст и есть запрос.
+func resolveSearchQuery(app *App, text string, userID int) (string, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "@") {
+		return text, nil
+	}
+	var query string
+	err := app.DB.QueryRow(`SELECT query FROM saved_searches
+		WHERE name = $1 AND (created_by = $2 OR created_by IS NULL)
+		ORDER BY created_by IS NULL
+		LIMIT 1`, strings.TrimPrefix(text, "@"), userID).Scan(&query)
+	if err == sql.ErrNoRows {
+		return "", notFoundError("сохранённый поиск не найден")
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return query, nil
+}
+
+// Расширенный поиск кандидатов по запросу или сохранённому поиску «@имя». Поиск авторизованного
+// пользователя попадает в его историю; листание следующих страниц историю не пополняет.
+func searchCandidates(app *App, text string, q ListQuery, userID int) ([]storage.Candidate, error) {
+	query, err := resolveSearchQuery(app, text, userID)
+	if err != nil {
+		return nil, err
+	}
+	condition, args, err := compileCandidateQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := q.options(storage.CandidateSorts)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := app.Candidates.FindWhere(condition, args, opts)
+	if err == nil && userID > 0 && q.Page <= 1 {
+		// История вспомогательная: если её не удалось записать, результат поиска всё равно возвращается.
+		if err := recordSearch(app.DB, userID, strings.TrimSpace(text), len(candidates)); err != nil {
+			opLogger().Warn("ошибка записи истории поиска", "error", err)
+		}
+	}
+	return candidates, err
+}
+
+// Сколько последних поисков хранится для каждого пользователя.
+const searchHistoryLimit = 50
+
+type SearchHistoryEntry struct {
+	ID         int       `json:"id"`
+	Query      string    `json:"query"`
+	Results    int       `json:"results"`
+	SearchedAt time.Time `json:"searched_at"`
+}
+
+// Повтор последнего запроса не добавляет новую запись, а только обновляет её время и число результатов.
+func recordSearch(db *sql.DB, userID int, query string, results int) error {
+	return storage.WithTx(db, func(tx storage.DBTX) error {
+		var lastID int
+		var lastQuery string
+		err := tx.QueryRow("SELECT id, query FROM search_history WHERE user_id = $1 ORDER BY id DESC LIMIT 1", userID).Scan(&lastID, &lastQuery)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("ошибка записи истории поиска: %w", err)
+		}
+		if err == nil && lastQuery == query {
+			_, err = tx.Exec("UPDATE search_history SET results = $1, searched_at = now() WHERE id = $2", results, lastID)
+		} else {
+			_, err = tx.Exec("INSERT INTO search_history (user_id, query, results) VALUES ($1, $2, $3)", userID, query, results)
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка записи истории поиска: %w", err)
+		}
+		_, err = tx.Exec(`DELETE FROM search_history WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM search_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2)`, userID, searchHistoryLimit)
+		if err != nil {
+			return fmt.Errorf("ошибка записи истории поиска: %w", err)
+		}
+		return nil
+	})
+}
+
+// История поиска пользователя, от последних запросов к ранним.
+func searchHistory(db *sql.DB, userID int) ([]SearchHistoryEntry, error) {
+	rows, err := db.Query("SELECT id, query, results, searched_at FROM search_history WHERE user_id = $1 ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var history []SearchHistoryEntry
+	for rows.Next() {
+		var e SearchHistoryEntry
+		if err := rows.Scan(&e.ID, &e.Query, &e.Results, &e.SearchedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		history = append(history, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return history, nil
+}
+
+func clearSearchHistory(db *sql.DB, userID int) error {
+	if _, err := db.Exec("DELETE FROM search_history WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("ошибка очистки истории поиска: %w", err)
+	}
+	return nil
+}
+
+// Сохранённые поиски и история есть только у авторизованного пользователя.
+func requireSessionUser() (int, error) {
+	userID := sessionUserID()
+	if userID == 0 {
+		return 0, permissionError("войдите в систему, чтобы пользоваться сохранёнными поисками и историей")
+	}
+	return userID, nil
+}
+
+func printSavedSearches(w io.Writer, searches []SavedSearch) {
+	if len(searches) == 0 {
+		fmt.Fprintln(w, "Сохранённых поисков нет.")
+		return
+	}
+	for i, s := range searches {
+		shared := ""
+		if s.Shared {
+			shared = " (общий)"
+		}
+		fmt.Fprintf(w, "%d. @%s%s: %s\n", i+1, s.Name, shared, s.Query)
+	}
+}
+
+func searchCandidatesMenu(app *App) {
+	text := getInput("Запрос (например: (go OR golang) AND postgres AND experience>=3 AND salary<=200000) или @имя сохранённого: ")
+	if !strings.HasPrefix(strings.TrimSpace(text), "@") {
+		if name := getInput("Сохранить запрос под именем (Enter — не сохранять): "); name != "" {
+			err := saveSearch(app, name, text, sessionUserID())
+			handleError(err)
+			if err != nil {
+				return
+			}
+		}
+	}
+	browseSearchResults(app, text)
+}
+
+func browseSearchResults(app *App, text string) {
+	userID := sessionUserID()
+	q := ListQuery{PerPage: 20, Sort: sortPrompt(storage.CandidateSorts)}
+	browsePages(q.PerPage, "Кандидаты не найдены.", func(page int) ([]string, int, error) {
+		q.Page = page
+		candidates, err := searchCandidates(app, text, q, userID)
+		lines := make([]string, len(candidates))
+		for i, c := range candidates {
+			lines[i] = fmt.Sprintf("ID: %d, ФИО: %s, Опыт: %s, Навыки: %s", c.ID, c.FullName, c.Experience, strings.Join(c.Skills, ", "))
+		}
+		return lines, -1, err
+	})
+}
+
+// Свои и общие сохранённые поиски: номер — запустить, «-номер» — удалить свой.
+func savedSearchesMenu(app *App) {
+	userID, err := requireSessionUser()
+	handleError(err)
+	if err != nil {
+		return
+	}
+	searches, err := listSavedSearches(app, userID)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	printSavedSearches(os.Stdout, searches)
+	if len(searches) == 0 {
+		return
+	}
+	choice := getInput("Номер поиска для запуска, «-номер» — удалить, Enter — назад: ")
+	if choice == "" {
+		return
+	}
+	num, err := strconv.Atoi(strings.TrimPrefix(choice, "-"))
+	if err != nil || num < 1 || num > len(searches) {
+		fmt.Println("Неверный номер поиска.")
+		return
+	}
+	s := searches[num-1]
+	if !strings.HasPrefix(choice, "-") {
+		browseSearchResults(app, s.Query)
+		return
+	}
+	if s.Shared {
+		fmt.Println("Общий поиск можно удалить только командой saved-searches delete.")
+		return
+	}
+	err = deleteSavedSearch(app, s.Name, userID)
+	handleError(err)
+	if err == nil {
+		fmt.Println("Поиск удалён.")
+	}
+}
+
+// История поиска текущего пользователя: номер — повторить поиск, «c» — очистить историю.
+func searchHistoryMenu(app *App) {
+	userID, err := requireSessionUser()
+	handleError(err)
+	if err != nil {
+		return
+	}
+	history, err := searchHistory(app.DB, userID)
+	handleError(err)
+	if err != nil {
+		return
+	}
+	if len(history) == 0 {
+		fmt.Println("История поиска пуста.")
+		return
+	}
+	printSearchHistory(os.Stdout, history)
+	choice := getInput("Номер запроса, чтобы повторить его, «c» — очистить историю, Enter — назад: ")
+	switch {
+	case choice == "":
+		return
+	case strings.EqualFold(choice, "c"):
+		err := clearSearchHistory(app.DB, userID)
+		handleError(err)
+		if err == nil {
+			fmt.Println("История поиска очищена.")
+		}
+		return
+	}
+	num, err := strconv.Atoi(choice)
+	if err != nil || num < 1 || num > len(history) {
+		fmt.Println("Неверный номер запроса.")
+		return
+	}
+	query := history[num-1].Query
+	if name := getInput("Сохранить запрос под именем (Enter — не сохранять): "); name != "" {
+		err := saveSearch(app, name, query, userID)
+		handleError(err)
+		if err != nil {
+			return
+		}
+	}
+	browseSearchResults(app, query)
+}
+
+func printSearchHistory(w io.Writer, history []SearchHistoryEntry) {
+	for i, e := range history {
+		fmt.Fprintf(w, "%d. %s  %s  (найдено: %d)\n", i+1, e.SearchedAt.Format("2006-01-02 15:04"), e.Query, e.Results)
+	}
+}
+
+// search <запрос|@имя> [-page N] [-per-page N] [-sort поле] [-save имя]
+func runSearchCommand(app *App, args []string) int {
+	if len(args) == 0 {
+		fmt.Println(`Использование: search "<запрос>"|@имя [-page N] [-per-page N] [-sort поле] [-save имя]`)
+		return exitUsage
+	}
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	q := ListQuery{}
+	flags.IntVar(&q.Page, "page", 1, "номер страницы")
+	flags.IntVar(&q.PerPage, "per-page", 50, "кандидатов на странице")
+	flags.StringVar(&q.Sort, "sort", "", "поле сортировки, «-» впереди — по убыванию")
+	save := flags.String("save", "", "сохранить запрос под этим именем")
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if *save != "" {
+		if err := saveSearch(app, *save, args[0], sessionUserID()); err != nil {
+			return reportError(err)
+		}
+		printNotice(fmt.Sprintf("Поиск сохранён как @%s.", strings.TrimSpace(*save)))
+	}
+	candidates, err := searchCandidates(app, args[0], q, sessionUserID())
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: candidates, text: func(w io.Writer) {
+		if len(candidates) == 0 {
+			fmt.Fprintln(w, "Кандидаты не найдены.")
+		}
+		for _, c := range candidates {
+			fmt.Fprintf(w, "ID: %d, ФИО: %s, Опыт: %s, Навыки: %s\n", c.ID, c.FullName, c.Experience, strings.Join(c.Skills, ", "))
+		}
+	}})
+}
+
+// saved-searches [list] | saved-searches delete <имя>; без входа в систему — только общие поиски.
+func runSavedSearchesCommand(app *App, args []string) int {
+	if len(args) == 0 || args[0] == "list" {
+		searches, err := listSavedSearches(app, sessionUserID())
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: searches, text: func(w io.Writer) { printSavedSearches(w, searches) }})
+	}
+	if args[0] == "delete" && len(args) == 2 {
+		if err := deleteSavedSearch(app, args[1], sessionUserID()); err != nil {
+			return reportError(err)
+		}
+		return printDone("Поиск удалён.", map[string]string{"name": args[1]})
+	}
+	fmt.Println("Использование: saved-searches [list] | saved-searches delete <имя>")
+	return exitUsage
+}
+
+// search-history [list] | search-history clear
+func runSearchHistoryCommand(app *App, args []string) int {
+	userID, err := requireSessionUser()
+	if err != nil {
+		return reportError(err)
+	}
+	if len(args) == 0 || args[0] == "list" {
+		history, err := searchHistory(app.DB, userID)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: history, text: func(w io.Writer) {
+			if len(history) == 0 {
+				fmt.Fprintln(w, "История поиска пуста.")
+			}
+			printSearchHistory(w, history)
+		}})
+	}
+	if args[0] == "clear" && len(args) == 1 {
+		if err := clearSearchHistory(app.DB, userID); err != nil {
+			return reportError(err)
+		}
+		return printDone("История поиска очищена.", nil)
+	}
+	fmt.Println("Использование: search-history [list] | search-history clear")
+	return exitUsage
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"your_project_name/storage"
+)
+
+const (
+	tokenAccess  = "access"
+	tokenRefresh = "refresh"
+)
+
+var errTokenExpired = permissionError("срок действия токена истёк")
+
+type TokenClaims struct {
+	Subject   string `json:"sub"`
+	Username  string `json:"name"`
+	Role      string `json:"role"`
+	CompanyID int    `json:"company_id,omitempty"`
+	Type      string `json:"typ"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func (c *TokenClaims) UserID() int {
+	id, _ := strconv.Atoi(c.Subject)
+	return id
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+var (
+	jwtSecretOnce sync.Once
+	jwtSecretKey  []byte
+	jwtSecretErr  error
+)
+
+// Без JWT_SECRET ключ генерируется при запуске, и токены перестают действовать после перезапуска.
+// Если случайный ключ получить не удалось, токены не выдаются и не принимаются: нулевой ключ подобрал бы кто угодно.
+func jwtSecret() ([]byte, error) {
+	jwtSecretOnce.Do(func() {
+		if secret := os.Getenv("JWT_SECRET"); secret != "" {
+			jwtSecretKey = []byte(secret)
+			return
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			jwtSecretErr = fmt.Errorf("не удалось сгенерировать ключ подписи токенов, задайте JWT_SECRET: %w", err)
+			return
+		}
+		jwtSecretKey = key
+	})
+	return jwtSecretKey, jwtSecretErr
+}
+
+func tokenTTL(env string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(env)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func signToken(claims TokenClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сериализации токена: %w", err)
+	}
+	secret, err := jwtSecret()
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Принимается только HS256; заголовок с другим алгоритмом (в том числе "none") отклоняется.
+func parseToken(token, tokenType string) (*TokenClaims, error) {
+	invalid := permissionError("недействительный токен")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if json.Unmarshal(headerJSON, &header) != nil || header.Alg != "HS256" {
+		return nil, invalid
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid
+	}
+	secret, err := jwtSecret()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, invalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, invalid
+	}
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Type != tokenType {
+		return nil, invalid
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return &claims, errTokenExpired
+	}
+	return &claims, nil
+}
+
+// Refresh-токен записывается в refresh_tokens, чтобы его можно было отозвать и использовать только один раз.
+func issueTokenPair(q storage.DBTX, user storage.User) (TokenPair, error) {
+	now := time.Now()
+	pair := TokenPair{
+		AccessExpiresAt:  now.Add(tokenTTL("JWT_ACCESS_TTL", 15*time.Minute)),
+		RefreshExpiresAt: now.Add(tokenTTL("JWT_REFRESH_TTL", 30*24*time.Hour)),
+	}
+	claims := TokenClaims{
+		Subject:   strconv.Itoa(user.ID),
+		Username:  user.Username,
+		Role:      user.Role,
+		CompanyID: user.CompanyID,
+		Type:      tokenAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: pair.AccessExpiresAt.Unix(),
+	}
+	var err error
+	if pair.AccessToken, err = signToken(claims); err != nil {
+		return TokenPair{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return TokenPair{}, fmt.Errorf("ошибка генерации токена: %w", err)
+	}
+	claims.Type = tokenRefresh
+	claims.ID = hex.EncodeToString(id)
+	claims.ExpiresAt = pair.RefreshExpiresAt.Unix()
+	_, err = q.Exec("INSERT INTO refresh_tokens (id, user_id, expires_at) VALUES ($1, $2, $3)", claims.ID, user.ID, pair.RefreshExpiresAt)
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("ошибка сохранения токена: %w", err)
+	}
+	if pair.RefreshToken, err = signToken(claims); err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+func loginWithTokens(app *App, username, password string) (TokenPair, error) {
+	userID, _, err := loginUser(app, username, password)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	user, err := app.Users.GetByID(userID)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return issueTokenPair(app.DB, user)
+}
+
+// Старый refresh-токен отзывается в той же транзакции, в которой выдаётся новая пара.
+// Роль и компания берутся из базы заново, а не из старого токена.
+func refreshTokenPair(app *App, refreshToken string) (TokenPair, error) {
+	claims, err := parseToken(refreshToken, tokenRefresh)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	var pair TokenPair
+	err = storage.WithTx(app.DB, func(tx storage.DBTX) error {
+		result, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL AND expires_at > now()", claims.ID)
+		if err != nil {
+			return fmt.Errorf("ошибка отзыва токена: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return permissionError("токен обновления отозван или истёк, авторизуйтесь заново")
+		}
+
+		user, err := storage.NewPostgres(tx).Users.GetByID(claims.UserID())
+		if errors.Is(err, storage.ErrNotFound) {
+			return permissionError("пользователь не найден")
+		}
+		if err != nil {
+			return err
+		}
+		pair, err = issueTokenPair(tx, user)
+		return err
+	})
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// Истёкший refresh-токен тоже можно отозвать: выход из сессии не должен завершаться ошибкой.
+func revokeRefreshToken(db *sql.DB, refreshToken string) error {
+	claims, err := parseToken(refreshToken, tokenRefresh)
+	if err != nil && !errors.Is(err, errTokenExpired) {
+		return err
+	}
+	_, err = db.Exec("UPDATE refresh_tokens SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", claims.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва токена: %w", err)
+	}
+	return nil
+}
+
+// Сессия консольного интерфейса; nil — пользователь не авторизован.
+var currentSession *TokenPair
+
+// Проверяет access-токен текущей сессии и при истечении обновляет пару по refresh-токену.
+func activeSession(app *App) (*TokenClaims, error) {
+	if currentSession == nil {
+		return nil, nil
+	}
+	claims, err := parseToken(currentSession.AccessToken, tokenAccess)
+	if errors.Is(err, errTokenExpired) {
+		var pair TokenPair
+		pair, err = refreshTokenPair(app, currentSession.RefreshToken)
+		if err == nil {
+			currentSession = &pair
+			claims, err = parseToken(pair.AccessToken, tokenAccess)
+		}
+	}
+	if err != nil {
+		currentSession = nil
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ID пользователя текущей сессии или 0, если никто не авторизован.
+func sessionUserID() int {
+	if currentSession == nil {
+		return 0
+	}
+	claims, err := parseToken(currentSession.AccessToken, tokenAccess)
+	if err != nil {
+		return 0
+	}
+	return claims.UserID()
+}
+
+func logout(app *App) error {
+	if currentSession == nil {
+		return nil
+	}
+	audit("user.logout")
+	err := revokeRefreshToken(app.DB, currentSession.RefreshToken)
+	currentSession = nil
+	return err
+}
+
+type claimsContextKey struct{}
+
+// Для HTTP-режима: пропускает запрос только с действующим access-токеном в заголовке
+// Authorization: Bearer и кладёт данные токена в контекст запроса.
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			http.Error(w, "требуется авторизация", http.StatusUnauthorized)
+			return
+		}
+		claims, err := parseToken(token, tokenAccess)
+		if err != nil {
+			message, _ := describeError(err)
+			http.Error(w, message, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims)))
+	})
+}
+
+func claimsFromContext(ctx context.Context) (*TokenClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*TokenClaims)
+	return claims, ok
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signTestToken(t *testing.T, claims TokenClaims) string {
+	t.Helper()
+	token, err := signToken(claims)
+	if err != nil {
+		t.Fatalf("signToken: %v", err)
+	}
+	return token
+}
+
+func TestParseToken(t *testing.T) {
+	now := time.Now()
+	valid := TokenClaims{Subject: "7", Username: "anna", Role: "recruiter", Type: tokenAccess,
+		IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+	token := signTestToken(t, valid)
+	parts := strings.Split(token, ".")
+
+	expired := valid
+	expired.ExpiresAt = now.Add(-time.Minute).Unix()
+	admin := valid
+	admin.Role = "admin"
+	forgedPayload := strings.Split(signTestToken(t, admin), ".")[1]
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	hs512Header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS512","typ":"JWT"}`))
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	signature[0] ^= 1
+	badSignature := base64.RawURLEncoding.EncodeToString(signature)
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantErr   error
+	}{
+		{"действующий токен", token, tokenAccess, nil},
+		{"refresh вместо access", token, tokenRefresh, errInvalidToken},
+		{"истёкший", signTestToken(t, expired), tokenAccess, errTokenExpired},
+		{"подменённые данные", parts[0] + "." + forgedPayload + "." + parts[2], tokenAccess, errInvalidToken},
+		{"испорченная подпись", parts[0] + "." + parts[1] + "." + badSignature, tokenAccess, errInvalidToken},
+		{"без подписи", parts[0] + "." + parts[1] + ".", tokenAccess, errInvalidToken},
+		{"alg none", noneHeader + "." + parts[1] + ".", tokenAccess, errInvalidToken},
+		{"другой алгоритм", hs512Header + "." + parts[1] + "." + parts[2], tokenAccess, errInvalidToken},
+		{"две части", parts[0] + "." + parts[1], tokenAccess, errInvalidToken},
+		{"не base64", "%%%." + parts[1] + "." + parts[2], tokenAccess, errInvalidToken},
+		{"пустая строка", "", tokenAccess, errInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := parseToken(tt.token, tt.tokenType)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("parseToken: %v", err)
+			case tt.wantErr == errTokenExpired && !errors.Is(err, errTokenExpired):
+				t.Fatalf("parseToken = %v, ожидался истёкший срок", err)
+			case tt.wantErr == errInvalidToken && (err == nil || errors.Is(err, errTokenExpired) || classifyError(err) != kindPermission):
+				t.Fatalf("parseToken = %v, ожидался отказ в доступе", err)
+			}
+			if tt.wantErr == errInvalidToken && claims != nil {
+				t.Errorf("для недействительного токена вернулись данные %+v", claims)
+			}
+			if tt.wantErr == nil && (claims.UserID() != 7 || claims.Role != "recruiter") {
+				t.Errorf("разобрано %+v, ожидалось %+v", claims, valid)
+			}
+		})
+	}
+}
+
+// Отметка для таблицы: ожидается «недействительный токен», а не истёкший срок.
+var errInvalidToken = errors.New("недействительный токен")
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"your_project_name/storage"
+)
+
+const (
+	defaultSimilarLimit = 10
+	// Вакансии считаются одного уровня, если меньшая зарплата не ниже этой доли от большей.
+	similarSalaryBand = 0.75
+)
+
+type SimilarJobOpening struct {
+	storage.JobOpening
+	Score        float64  `json:"score"`
+	SharedSkills []string `json:"shared_skills"`
+}
+
+type SimilarCandidate struct {
+	storage.Candidate
+	Score        float64  `json:"score"`
+	SharedSkills []string `json:"shared_skills"`
+}
+
+func skillSet(skills []string) map[string]bool {
+	set := make(map[string]bool, len(skills))
+	for _, s := range skills {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// Косинусная мера между бинарными векторами навыков: |A∩B| / √(|A|·|B|).
+func skillSimilarity(a, b map[string]bool) (float64, []string) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, nil
+	}
+	var shared []string
+	for s := range a {
+		if b[s] {
+			shared = append(shared, s)
+		}
+	}
+	sort.Strings(shared)
+	return float64(len(shared)) / math.Sqrt(float64(len(a)*len(b))), shared
+}
+
+func salaryRatio(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	return math.Min(a, b) / math.Max(a, b)
+}
+
+func normalizeSimilarLimit(limit int) int {
+	if limit <= 0 {
+		return defaultSimilarLimit
+	}
+	return limit
+}
+
+// Похожие вакансии должны пересекаться по навыкам и попадать в ту же зарплатную вилку;
+// в оценке навыки весят 0.8, близость зарплаты — 0.2. Зарплаты сравниваются серединами вилок
+// в валюте вакансии-образца; вакансии в валюте без курса не сравниваются.
+func similarJobOpenings(app *App, id, limit int) ([]SimilarJobOpening, error) {
+	target, err := getJobOpeningByID(app, id)
+	if err != nil {
+		return nil, err
+	}
+	jobOpenings, err := app.JobOpenings.ListByStatus(storage.JobOpen)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := loadCurrencyRates(app.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	targetSkills := skillSet(target.RequiredSkills)
+	var similar []SimilarJobOpening
+	for _, j := range jobOpenings {
+		if j.ID == target.ID {
+			continue
+		}
+		score, shared := skillSimilarity(targetSkills, skillSet(j.RequiredSkills))
+		salary, ok := rates.convert(jobSalary(j), j.SalaryCurrency, target.SalaryCurrency)
+		if !ok {
+			continue
+		}
+		ratio := salaryRatio(jobSalary(target), salary)
+		if score == 0 || ratio < similarSalaryBand {
+			continue
+		}
+		similar = append(similar, SimilarJobOpening{JobOpening: j, Score: 0.8*score + 0.2*ratio, SharedSkills: shared})
+	}
+	sort.SliceStable(similar, func(i, k int) bool { return similar[i].Score > similar[k].Score })
+	if limit = normalizeSimilarLimit(limit); len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+func similarCandidates(app *App, id, limit int) ([]SimilarCandidate, error) {
+	target, err := getCandidateByID(app, id)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := app.Candidates.List()
+	if err != nil {
+		return nil, err
+	}
+
+	targetSkills := skillSet(target.Skills)
+	var similar []SimilarCandidate
+	for _, c := range candidates {
+		if c.ID == target.ID {
+			continue
+		}
+		score, shared := skillSimilarity(targetSkills, skillSet(c.Skills))
+		if score == 0 {
+			continue
+		}
+		similar = append(similar, SimilarCandidate{Candidate: c, Score: score, SharedSkills: shared})
+	}
+	sort.SliceStable(similar, func(i, k int) bool { return similar[i].Score > similar[k].Score })
+	if limit = normalizeSimilarLimit(limit); len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+func printSimilarJobOpenings(w io.Writer, similar []SimilarJobOpening) {
+	if len(similar) == 0 {
+		fmt.Fprintln(w, "Похожих вакансий не найдено.")
+		return
+	}
+	for _, s := range similar {
+		fmt.Fprintf(w, "%.2f  ID: %d, %s, зарплата: %s, общие навыки: %s\n",
+			s.Score, s.ID, s.Title, jobSalaryText(s.JobOpening), strings.Join(s.SharedSkills, ", "))
+	}
+}
+
+func printSimilarCandidates(w io.Writer, similar []SimilarCandidate) {
+	if len(similar) == 0 {
+		fmt.Fprintln(w, "Похожих кандидатов не найдено.")
+		return
+	}
+	for _, s := range similar {
+		fmt.Fprintf(w, "%.2f  ID: %d, %s, общие навыки: %s\n", s.Score, s.ID, s.FullName, strings.Join(s.SharedSkills, ", "))
+	}
+}
+
+// similar job <ID> [-limit N] | similar candidate <ID> [-limit N]
+func runSimilarCommand(app *App, args []string) int {
+	usage := "Использование: similar job <ID> [-limit N] | similar candidate <ID> [-limit N]"
+	if len(args) < 2 || (args[0] != "job" && args[0] != "candidate") {
+		fmt.Println(usage)
+		return exitUsage
+	}
+	kind := entityJobOpening
+	if args[0] == "candidate" {
+		kind = entityCandidate
+	}
+	id, err := resolveEntityID(app.DB, kind, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	flags := flag.NewFlagSet("similar "+args[0], flag.ContinueOnError)
+	limit := flags.Int("limit", defaultSimilarLimit, "сколько результатов показать")
+	if err := flags.Parse(args[2:]); err != nil {
+		return exitUsage
+	}
+
+	if args[0] == "job" {
+		similar, err := similarJobOpenings(app, id, *limit)
+		if err != nil {
+			return reportError(err)
+		}
+		return printView(sharedView{data: similar, text: func(w io.Writer) { printSimilarJobOpenings(w, similar) }})
+	}
+	similar, err := similarCandidates(app, id, *limit)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: similar, text: func(w io.Writer) { printSimilarCandidates(w, similar) }})
+}
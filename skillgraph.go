@@ -0,0 +1,337 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"your_project_name/storage"
+)
+
+// Связь «у кого есть Skill, обычно есть и Related»: Confidence — доля профилей и вакансий
+// со Skill, где встречается и Related; Together — в скольких из них навыки встретились вместе.
+type SkillRelation struct {
+	Skill      string  `json:"skill"`
+	Related    string  `json:"related"`
+	Together   int     `json:"together"`
+	SkillCount int     `json:"skill_count"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Родственные навыки по ключу навыка в нижнем регистре, от самых надёжных связей к менее надёжным.
+type SkillGraph map[string][]SkillRelation
+
+// Связь учитывается, если навыки встретились вместе хотя бы minSupport раз и Confidence не ниже minConfidence.
+type skillGraphParams struct {
+	MinSupport    int
+	MinConfidence float64
+}
+
+func defaultSkillGraphParams() skillGraphParams {
+	params := skillGraphParams{MinSupport: 3, MinConfidence: 0.5}
+	if n, err := strconv.Atoi(os.Getenv("SKILL_GRAPH_MIN_SUPPORT")); err == nil && n > 0 {
+		params.MinSupport = n
+	}
+	if x, err := strconv.ParseFloat(os.Getenv("SKILL_GRAPH_MIN_CONFIDENCE"), 64); err == nil && x > 0 && x <= 1 {
+		params.MinConfidence = x
+	}
+	return params
+}
+
+// Вес родственного навыка в поиске относительно точного совпадения.
+const relatedSkillSearchWeight = 0.5
+
+func skillKey(skill string) string {
+	return strings.ToLower(strings.TrimSpace(skill))
+}
+
+// Строит граф по наборам навыков кандидатов и требованиям вакансий: каждый набор — одно «наблюдение».
+func buildSkillGraph(skillSets [][]string, params skillGraphParams) SkillGraph {
+	names := map[string]string{}
+	counts := map[string]int{}
+	together := map[[2]string]int{}
+	for _, set := range skillSets {
+		keys := make([]string, 0, len(set))
+		for key := range skillSet(set) {
+			keys = append(keys, key)
+		}
+		for _, skill := range set {
+			if key := skillKey(skill); key != "" && names[key] == "" {
+				names[key] = strings.TrimSpace(skill)
+			}
+		}
+		for _, a := range keys {
+			counts[a]++
+			for _, b := range keys {
+				if a != b {
+					together[[2]string{a, b}]++
+				}
+			}
+		}
+	}
+
+	graph := SkillGraph{}
+	for pair, n := range together {
+		confidence := float64(n) / float64(counts[pair[0]])
+		if n < params.MinSupport || confidence < params.MinConfidence {
+			continue
+		}
+		graph[pair[0]] = append(graph[pair[0]], SkillRelation{
+			Skill: names[pair[0]], Related: names[pair[1]], Together: n, SkillCount: counts[pair[0]], Confidence: confidence,
+		})
+	}
+	for _, relations := range graph {
+		sortSkillRelations(relations)
+	}
+	return graph
+}
+
+func sortSkillRelations(relations []SkillRelation) {
+	sort.Slice(relations, func(i, k int) bool {
+		if relations[i].Confidence != relations[k].Confidence {
+			return relations[i].Confidence > relations[k].Confidence
+		}
+		if relations[i].Together != relations[k].Together {
+			return relations[i].Together > relations[k].Together
+		}
+		return relations[i].Related < relations[k].Related
+	})
+}
+
+func loadSkillSets(db *sql.DB) ([][]string, error) {
+	rows, err := db.Query("SELECT skills FROM candidates UNION ALL SELECT required_skills FROM job_openings")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var sets [][]string
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		var skills []string
+		json.Unmarshal(data, &skills)
+		if len(skills) > 0 {
+			sets = append(sets, skills)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return sets, nil
+}
+
+func loadSkillGraph(db *sql.DB, params skillGraphParams) (SkillGraph, error) {
+	sets, err := loadSkillSets(db)
+	if err != nil {
+		return nil, err
+	}
+	return buildSkillGraph(sets, params), nil
+}
+
+// Граф с параметрами по умолчанию перестраивается не чаще раза в SKILL_GRAPH_TTL (по умолчанию час):
+// связи навыков меняются медленно, а подбор обращается к графу для каждой вакансии.
+var skillGraphCache struct {
+	sync.Mutex
+	graph   SkillGraph
+	builtAt time.Time
+}
+
+func skillGraphTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("SKILL_GRAPH_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+func cachedSkillGraph(db *sql.DB) (SkillGraph, error) {
+	skillGraphCache.Lock()
+	defer skillGraphCache.Unlock()
+	if skillGraphCache.graph != nil && time.Since(skillGraphCache.builtAt) < skillGraphTTL() {
+		return skillGraphCache.graph, nil
+	}
+	graph, err := loadSkillGraph(db, defaultSkillGraphParams())
+	if err != nil {
+		return nil, err
+	}
+	skillGraphCache.graph = graph
+	skillGraphCache.builtAt = time.Now()
+	return graph, nil
+}
+
+// Подключает граф к весам, если компания учитывает родственные навыки.
+func withSkillGraph(db *sql.DB, weights MatchWeights) (MatchWeights, error) {
+	if weights.RelatedSkills <= 0 || weights.related != nil {
+		return weights, nil
+	}
+	graph, err := cachedSkillGraph(db)
+	if err != nil {
+		return MatchWeights{}, err
+	}
+	weights.related = &graph
+	return weights, nil
+}
+
+// Лучшая замена недостающему навыку среди навыков кандидата.
+func (g SkillGraph) bestSubstitute(skill string, has map[string]bool) (SkillRelation, bool) {
+	for _, r := range g[skillKey(skill)] {
+		if has[skillKey(r.Related)] {
+			return r, true
+		}
+	}
+	return SkillRelation{}, false
+}
+
+type SkillSearchHit struct {
+	Candidate storage.Candidate `json:"candidate"`
+	Score     float64           `json:"score"`
+	// Искомые навыки, засчитанные по родственным: «Kubernetes ← Docker».
+	Related []string `json:"related,omitempty"`
+}
+
+// Поиск с расширением родственными навыками: искомый навык засчитывается полностью, если он есть
+// у кандидата, и с весом relatedSkillSearchWeight × Confidence, если есть родственный. Порог minMatch
+// считается по засчитанным навыкам; Score — их средний вес. Результат упорядочен по Score и
+// листается по q.Page/q.PerPage, сортировка q.Sort не применяется.
+func findCandidatesBySkillsExpanded(app *App, skills []string, mode string, minMatch int, q ListQuery) ([]SkillSearchHit, error) {
+	skills, err := sanitizeSkills(skills)
+	if err != nil {
+		return nil, err
+	}
+	if len(skills) == 0 {
+		return nil, validationErrorf("не указано ни одного навыка для поиска")
+	}
+	if minMatch == 0 {
+		minMatch = len(skills)
+		if mode == skillMatchAny {
+			minMatch = 1
+		} else if mode != skillMatchAll && mode != "" {
+			return nil, validationErrorf("неизвестный режим поиска %q, допустимые: all, any", mode)
+		}
+	}
+	if minMatch < 0 || minMatch > len(skills) {
+		return nil, validationErrorf("минимальное число совпадений должно быть от 1 до %d", len(skills))
+	}
+	opts, err := q.options(storage.CandidateSorts)
+	if err != nil {
+		return nil, err
+	}
+	graph, err := cachedSkillGraph(app.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := append([]string{}, skills...)
+	for _, skill := range skills {
+		for _, r := range graph[skillKey(skill)] {
+			expanded = append(expanded, r.Related)
+		}
+	}
+	candidates, err := app.Candidates.FindBySkills(expanded, 1, storage.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []SkillSearchHit
+	for _, c := range candidates {
+		has := skillSet(c.Skills)
+		hit := SkillSearchHit{Candidate: c}
+		covered := 0
+		for _, skill := range skills {
+			if has[skillKey(skill)] {
+				hit.Score += 1
+				covered++
+			} else if r, ok := graph.bestSubstitute(skill, has); ok {
+				hit.Score += relatedSkillSearchWeight * r.Confidence
+				hit.Related = append(hit.Related, skill+" ← "+r.Related)
+				covered++
+			}
+		}
+		if covered < minMatch {
+			continue
+		}
+		hit.Score /= float64(len(skills))
+		hits = append(hits, hit)
+	}
+	sort.SliceStable(hits, func(i, k int) bool {
+		return hits[i].Score > hits[k].Score
+	})
+	if opts.Offset >= len(hits) {
+		return nil, nil
+	}
+	hits = hits[opts.Offset:]
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+	return hits, nil
+}
+
+// Связи для просмотра: для одного навыка — все его родственные, иначе — limit самых частых пар.
+func skillRelations(graph SkillGraph, skill string, limit int) []SkillRelation {
+	if skill != "" {
+		return graph[skillKey(skill)]
+	}
+	var all []SkillRelation
+	for _, relations := range graph {
+		all = append(all, relations...)
+	}
+	sort.Slice(all, func(i, k int) bool {
+		if all[i].Together != all[k].Together {
+			return all[i].Together > all[k].Together
+		}
+		if all[i].Confidence != all[k].Confidence {
+			return all[i].Confidence > all[k].Confidence
+		}
+		return all[i].Skill+all[i].Related < all[k].Skill+all[k].Related
+	})
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all
+}
+
+func printSkillRelations(w io.Writer, relations []SkillRelation) {
+	if len(relations) == 0 {
+		fmt.Fprintln(w, "Связей не найдено.")
+		return
+	}
+	fmt.Fprintf(w, "%-20s %-20s %8s %10s\n", "Навык", "Родственный", "Вместе", "Уверенность")
+	for _, r := range relations {
+		fmt.Fprintf(w, "%-20s %-20s %4d/%-4d %9.0f%%\n", r.Skill, r.Related, r.Together, r.SkillCount, r.Confidence*100)
+	}
+}
+
+// skill-graph [навык] [-min-support N] [-min-confidence X] [-limit N]
+func runSkillGraphCommand(app *App, args []string) int {
+	skill := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		skill, args = args[0], args[1:]
+	}
+	params := defaultSkillGraphParams()
+	flags := flag.NewFlagSet("skill-graph", flag.ContinueOnError)
+	flags.IntVar(&params.MinSupport, "min-support", params.MinSupport, "сколько раз навыки должны встретиться вместе")
+	flags.Float64Var(&params.MinConfidence, "min-confidence", params.MinConfidence, "минимальная доля совместных появлений (0–1)")
+	limit := flags.Int("limit", 30, "сколько самых частых связей показать без навыка (0 — все)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if params.MinSupport < 1 || params.MinConfidence <= 0 || params.MinConfidence > 1 {
+		return reportError(validationErrorf("min-support должен быть не меньше 1, min-confidence — от 0 до 1"))
+	}
+
+	graph, err := loadSkillGraph(app.DB, params)
+	if err != nil {
+		return reportError(err)
+	}
+	relations := skillRelations(graph, skill, *limit)
+	return printView(sharedView{data: relations, text: func(w io.Writer) { printSkillRelations(w, relations) }})
+}
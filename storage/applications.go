@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PostgresApplicationRepository struct{ repo }
+
+const applicationSelect = "SELECT id, candidate_id, job_opening_id, status, source, created_at, updated_at FROM applications"
+
+func (r *PostgresApplicationRepository) Create(application Application, changedBy int) (int, error) {
+	return auditCreate(r.repo, "applications", func(tx DBTX) (int, error) {
+		if application.Status == "" {
+			application.Status = ApplicationNew
+		}
+		var id int
+		err := tx.QueryRow("INSERT INTO applications (candidate_id, job_opening_id, status, source) VALUES ($1, $2, $3, $4) RETURNING id",
+			application.CandidateID, application.JobOpeningID, application.Status, application.Source).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка добавления отклика: %w", err)
+		}
+		return id, recordStatusChange(tx, id, "", application.Status, "", changedBy)
+	})
+}
+
+func (r *PostgresApplicationRepository) GetByID(id int) (Application, error) {
+	applications, err := r.query(applicationSelect+" WHERE id = $1", id)
+	if err != nil {
+		return Application{}, err
+	}
+	if len(applications) == 0 {
+		return Application{}, ErrNotFound
+	}
+	return applications[0], nil
+}
+
+// Строка отклика блокируется, чтобы в историю не попали два перехода из одного и того же статуса.
+// Повторная установка текущего статуса ничего не меняет и в историю не пишется.
+func (r *PostgresApplicationRepository) SetStatus(id int, status, comment string, changedBy int) error {
+	return auditChange(r.repo, "applications", id, func(tx DBTX) error {
+		var current string
+		err := tx.QueryRow("SELECT status FROM applications WHERE id = $1 FOR UPDATE", id).Scan(&current)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		if current == status {
+			return nil
+		}
+		if _, err := tx.Exec("UPDATE applications SET status = $1, updated_at = now() WHERE id = $2", status, id); err != nil {
+			return fmt.Errorf("ошибка изменения статуса отклика: %w", err)
+		}
+		return recordStatusChange(tx, id, current, status, comment, changedBy)
+	})
+}
+
+func (r *PostgresApplicationRepository) ListByCandidate(candidateID int) ([]Application, error) {
+	return r.query(applicationSelect+" WHERE candidate_id = $1 ORDER BY created_at DESC, id DESC", candidateID)
+}
+
+func (r *PostgresApplicationRepository) ListByJobOpening(jobOpeningID int) ([]Application, error) {
+	return r.query(applicationSelect+" WHERE job_opening_id = $1 ORDER BY created_at DESC, id DESC", jobOpeningID)
+}
+
+func (r *PostgresApplicationRepository) List() ([]Application, error) {
+	return r.query(applicationSelect + " ORDER BY id")
+}
+
+func (r *PostgresApplicationRepository) History(id int) ([]ApplicationStatusChange, error) {
+	rows, err := r.q.Query(`SELECT from_status, to_status, comment, changed_by, changed_at
+    FROM application_status_history WHERE application_id = $1 ORDER BY changed_at, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var history []ApplicationStatusChange
+	for rows.Next() {
+		var change ApplicationStatusChange
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&change.FromStatus, &change.ToStatus, &change.Comment, &changedBy, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		change.ChangedBy = int(changedBy.Int64)
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return history, nil
+}
+
+func (r *PostgresApplicationRepository) query(query string, args ...interface{}) ([]Application, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var applications []Application
+	for rows.Next() {
+		var application Application
+		err := rows.Scan(&application.ID, &application.CandidateID, &application.JobOpeningID, &application.Status,
+			&application.Source, &application.CreatedAt, &application.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		applications = append(applications, application)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return applications, nil
+}
+
+// Соединение с кандидатом, вакансией и рекрутером оформлено подзапросом, чтобы фильтры и сортировка
+// обращались к колонкам без префиксов таблиц.
+const pipelineColumns = `SELECT id, candidate_id, job_opening_id, status, source, created_at, updated_at,
+    candidate_name, job_title, company_id, recruiter`
+
+const pipelineSelect = pipelineColumns + `
+FROM (
+    SELECT a.id, a.candidate_id, a.job_opening_id, a.status, a.source, a.created_at, a.updated_at,
+        c.full_name AS candidate_name, COALESCE(c.skills, '[]') AS skills, j.title AS job_title,
+        COALESCE(j.company_id, 0) AS company_id, j.recruiter_id, COALESCE(u.username, '') AS recruiter
+    FROM applications a
+    JOIN candidates c ON c.id = a.candidate_id
+    JOIN job_openings j ON j.id = a.job_opening_id
+    LEFT JOIN users u ON u.id = j.recruiter_id
+) p`
+
+// Воронка на момент $1: отклики берутся в версиях из application_versions, действовавших в этот момент.
+// Кандидат и вакансия — в нынешнем виде; у удалённых пустые названия.
+const pipelineAsOfSelect = pipelineColumns + `
+FROM (
+    SELECT v.application_id AS id, v.candidate_id, v.job_opening_id, v.status, v.source, v.created_at, v.updated_at,
+        COALESCE(c.full_name, '') AS candidate_name, COALESCE(c.skills, '[]') AS skills, COALESCE(j.title, '') AS job_title,
+        COALESCE(j.company_id, 0) AS company_id, j.recruiter_id, COALESCE(u.username, '') AS recruiter
+    FROM application_versions v
+    LEFT JOIN candidates c ON c.id = v.candidate_id
+    LEFT JOIN job_openings j ON j.id = v.job_opening_id
+    LEFT JOIN users u ON u.id = j.recruiter_id
+    WHERE v.valid_from <= $1 AND (v.valid_to IS NULL OR v.valid_to > $1)
+) p`
+
+// Запрос воронки по фильтру без сортировки и страниц. Время на этапе и SLA отсчитываются от AsOf, если он задан.
+func pipelineQuery(filter ApplicationFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		for _, v := range values {
+			args = append(args, v)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+	query, now := pipelineSelect, time.Now()
+	if filter.AsOf != nil {
+		query, now = pipelineAsOfSelect, *filter.AsOf
+		args = append(args, now)
+	}
+	if filter.JobOpeningID != 0 {
+		add("job_opening_id = ?", filter.JobOpeningID)
+	}
+	if filter.CompanyID != 0 {
+		add("company_id = ?", filter.CompanyID)
+	}
+	if filter.Candidate != "" {
+		add("candidate_name ILIKE ?", "%"+EscapeLike(filter.Candidate)+"%")
+	}
+	if filter.Skill != "" {
+		add("EXISTS (SELECT 1 FROM jsonb_array_elements_text(skills) AS s(v) WHERE lower(s.v) = lower(?))", filter.Skill)
+	}
+	if len(filter.Statuses) > 0 {
+		add("status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if filter.RecruiterID != 0 {
+		add("recruiter_id = ?", filter.RecruiterID)
+	}
+	if filter.InStageMin > 0 {
+		add("updated_at <= ?", now.Add(-filter.InStageMin))
+	}
+	if filter.InStageMax > 0 {
+		add("updated_at > ?", now.Add(-filter.InStageMax))
+	}
+	breached := "(status NOT IN ('hired', 'rejected') AND updated_at < ?)"
+	switch filter.SLA {
+	case SLABreached:
+		add(breached, now.Add(-filter.SLALimit))
+	case SLAWithin:
+		add("NOT "+breached, now.Add(-filter.SLALimit))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return query, args
+}
+
+func (r *PostgresApplicationRepository) Search(filter ApplicationFilter, opts ListOptions) ([]PipelineEntry, error) {
+	page, err := pageClause(ApplicationSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	query, args := pipelineQuery(filter)
+	rows, err := r.q.Query(query+page, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []PipelineEntry
+	for rows.Next() {
+		var e PipelineEntry
+		err := rows.Scan(&e.ID, &e.CandidateID, &e.JobOpeningID, &e.Status, &e.Source, &e.CreatedAt, &e.UpdatedAt,
+			&e.CandidateName, &e.JobTitle, &e.CompanyID, &e.Recruiter)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return entries, nil
+}
+
+// Число откликов на каждом этапе среди подходящих под фильтр.
+func (r *PostgresApplicationRepository) CountByStatus(filter ApplicationFilter) (map[string]int, error) {
+	query, args := pipelineQuery(filter)
+	rows, err := r.q.Query("SELECT status, COUNT(*) FROM ("+query+") s GROUP BY status", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		counts[status] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return counts, nil
+}
+
+func recordStatusChange(tx DBTX, applicationID int, from, to, comment string, changedBy int) error {
+	_, err := tx.Exec("INSERT INTO application_status_history (application_id, from_status, to_status, comment, changed_by) VALUES ($1, $2, $3, $4, $5)",
+		applicationID, from, to, comment, nullID(changedBy))
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории отклика: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Журнал изменений данных (audit_log): каждая вставка, изменение и удаление через репозитории
+// записывается в той же транзакции, что и само изменение. Для изменения хранятся только поменявшиеся
+// поля, для вставки — новая строка, для удаления — удалённая. Каскадно удалённые строки
+// (вакансии удалённой компании, отклики удалённого кандидата) отдельно не записываются.
+
+const (
+	AuditInsert = "insert"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+var AuditActions = []string{AuditInsert, AuditUpdate, AuditDelete}
+
+// Таблицы, изменения которых попадают в журнал; entity в журнале — имя таблицы.
+var AuditEntities = []string{"candidates", "job_openings", "companies", "applications", "users"}
+
+// Пользователь, от имени которого идут изменения через NewPostgres; задаёт приложение. 0 — без пользователя.
+var AuditActor = func() int { return 0 }
+
+// Значения этих колонок в журнал не пишутся, видно только, что они изменились.
+var auditMaskedColumns = map[string]bool{"password_hash": true}
+
+// Колонки, которые меняются при любом изменении строки; сами по себе записью в журнале не считаются.
+var auditIgnoredColumns = map[string]bool{"updated_at": true}
+
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	UserID    int             `json:"user_id,omitempty"`
+	Username  string          `json:"username,omitempty"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	OldValues json.RawMessage `json:"old_values,omitempty"`
+	NewValues json.RawMessage `json:"new_values,omitempty"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// Условия выборки журнала; нулевые значения не ограничивают. To не включается.
+type AuditFilter struct {
+	UserID   int
+	Entity   string
+	EntityID int
+	Action   string
+	From     *time.Time
+	To       *time.Time
+}
+
+var AuditSorts = map[string]string{"id": "id", "changed": "changed_at", "entity": "entity", "user": "username"}
+
+type PostgresAuditRepository struct{ repo }
+
+func auditSnapshot(q DBTX, table string, id int) (map[string]interface{}, error) {
+	var data []byte
+	err := q.QueryRow(fmt.Sprintf("SELECT to_jsonb(t) FROM %s t WHERE id = $1", table), id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения строки для журнала изменений: %w", err)
+	}
+	var row map[string]interface{}
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, fmt.Errorf("ошибка разбора строки для журнала изменений: %w", err)
+	}
+	return row, nil
+}
+
+func maskAuditValues(row map[string]interface{}) map[string]interface{} {
+	for column := range row {
+		if auditMaskedColumns[column] {
+			row[column] = "***"
+		}
+	}
+	return row
+}
+
+// Записывает изменение строки: при вставке before = nil, при удалении after = nil.
+// Изменение без отличий (кроме служебных колонок) не записывается.
+func recordAudit(q DBTX, actor int, table string, id int, before, after map[string]interface{}) error {
+	action := AuditUpdate
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		action = AuditInsert
+	case after == nil:
+		action = AuditDelete
+	default:
+		oldValues, newValues := map[string]interface{}{}, map[string]interface{}{}
+		for column, value := range after {
+			if !auditIgnoredColumns[column] && !reflect.DeepEqual(before[column], value) {
+				oldValues[column], newValues[column] = before[column], value
+			}
+		}
+		if len(newValues) == 0 {
+			return nil
+		}
+		before, after = oldValues, newValues
+	}
+
+	var oldJSON, newJSON []byte
+	var err error
+	if before != nil {
+		if oldJSON, err = json.Marshal(maskAuditValues(before)); err != nil {
+			return fmt.Errorf("ошибка сериализации журнала изменений: %w", err)
+		}
+	}
+	if after != nil {
+		if newJSON, err = json.Marshal(maskAuditValues(after)); err != nil {
+			return fmt.Errorf("ошибка сериализации журнала изменений: %w", err)
+		}
+	}
+	_, err = q.Exec("INSERT INTO audit_log (user_id, entity, entity_id, action, old_values, new_values) VALUES ($1, $2, $3, $4, $5, $6)",
+		nullID(actor), table, id, action, nullJSON(oldJSON), nullJSON(newJSON))
+	if err != nil {
+		return fmt.Errorf("ошибка записи журнала изменений: %w", err)
+	}
+	return nil
+}
+
+func nullJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return data
+}
+
+// Выполняет изменение строки table/id и записывает его в журнал в одной транзакции.
+func auditChange(r repo, table string, id int, change func(tx DBTX) error) error {
+	return auditChangeBy(r.q, r.actor(), table, id, change)
+}
+
+func auditChangeBy(q DBTX, actor int, table string, id int, change func(tx DBTX) error) error {
+	return WithTx(q, func(tx DBTX) error {
+		before, err := auditSnapshot(tx, table, id)
+		if err != nil {
+			return err
+		}
+		if err := change(tx); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, table, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, table, id, before, after)
+	})
+}
+
+// Вставляет строку и записывает её в журнал в одной транзакции; create возвращает ID новой строки.
+func auditCreate(r repo, table string, create func(tx DBTX) (int, error)) (int, error) {
+	var id int
+	err := WithTx(r.q, func(tx DBTX) error {
+		var err error
+		if id, err = create(tx); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, table, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, r.actor(), table, id, nil, after)
+	})
+	return id, err
+}
+
+func (r *PostgresAuditRepository) List(filter AuditFilter, opts ListOptions) ([]AuditEntry, error) {
+	page, err := pageClause(AuditSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1))
+	}
+	if filter.UserID != 0 {
+		add("user_id = ?", filter.UserID)
+	}
+	if filter.Entity != "" {
+		add("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		add("entity_id = ?", filter.EntityID)
+	}
+	if filter.Action != "" {
+		add("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		add("changed_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		add("changed_at < ?", *filter.To)
+	}
+	query := `SELECT * FROM (
+    SELECT l.id, l.user_id, COALESCE(u.username, '') AS username, l.entity, l.entity_id, l.action,
+        l.old_values, l.new_values, l.changed_at
+    FROM audit_log l LEFT JOIN users u ON u.id = l.user_id
+) l`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.q.Query(query+page, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var userID sql.NullInt64
+		var oldValues, newValues []byte
+		if err := rows.Scan(&e.ID, &userID, &e.Username, &e.Entity, &e.EntityID, &e.Action, &oldValues, &newValues, &e.ChangedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		e.UserID = int(userID.Int64)
+		e.OldValues, e.NewValues = oldValues, newValues
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return entries, nil
+}
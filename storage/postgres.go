@@ -0,0 +1,979 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const UsageVacancyPublished = "vacancy_published"
+
+// Единица работы: fn выполняется в транзакции, ошибка или паника в fn её откатывают. Внутри переданной
+// транзакции fn выполняется как есть, без вложенной транзакции, иначе открывается своя.
+func WithTx(q DBTX, fn func(DBTX) error) error {
+	db, ok := q.(*sql.DB)
+	if !ok {
+		return fn(q)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// Блокирует строку до конца транзакции q: то, что прочитано после блокировки, не изменится до записи.
+func LockRow(q DBTX, table string, id int) error {
+	if _, err := q.Exec(fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", table), id); err != nil {
+		return fmt.Errorf("ошибка блокировки записи: %w", err)
+	}
+	return nil
+}
+
+// Пишется в той же транзакции, что и само действие, чтобы откат не оставлял лишних начислений.
+func RecordUsage(q DBTX, companyID int, event string, quantity int) error {
+	_, err := q.Exec("INSERT INTO usage_events (company_id, event, quantity) VALUES ($1, $2, $3)", nullID(companyID), event, quantity)
+	if err != nil {
+		return fmt.Errorf("ошибка записи события учёта: %w", err)
+	}
+	return nil
+}
+
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id > 0}
+}
+
+// Колонки-списки объявлены NOT NULL, поэтому nil пишется как пустой массив.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Собирает UPDATE только из разрешённых колонок; навыки сериализуются в JSONB.
+func updateColumns(q DBTX, table string, allowed []string, id int, columns map[string]interface{}) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("не указано ни одной колонки для изменения")
+	}
+	var setClauses []string
+	var args []interface{}
+	for _, column := range allowed {
+		value, ok := columns[column]
+		if !ok {
+			continue
+		}
+		if skills, ok := value.([]string); ok {
+			skillsJSON, err := json.Marshal(skills)
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации навыков: %w", err)
+			}
+			value = skillsJSON
+		}
+		args = append(args, value)
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	if len(setClauses) != len(columns) {
+		return fmt.Errorf("недопустимая колонка для таблицы %s", table)
+	}
+
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", table, strings.Join(setClauses, ", "), len(args))
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления записи: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type PostgresUserRepository struct{ repo }
+
+func (r *PostgresUserRepository) Create(user User) (int, error) {
+	return auditCreate(r.repo, "users", func(tx DBTX) (int, error) {
+		var id int
+		err := tx.QueryRow("INSERT INTO users (username, password_hash, role, company_id, must_change_password, email) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+			user.Username, user.PasswordHash, user.Role, nullID(user.CompanyID), user.MustChangePassword, user.Email).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка регистрации пользователя: %w", err)
+		}
+		return id, nil
+	})
+}
+
+func (r *PostgresUserRepository) get(where string, arg interface{}) (User, error) {
+	var user User
+	var companyID sql.NullInt64
+	err := r.q.QueryRow("SELECT id, username, password_hash, role, company_id, must_change_password, email FROM users WHERE "+where, arg).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &companyID, &user.MustChangePassword, &user.Email)
+	if err == sql.ErrNoRows {
+		return User{}, ErrNotFound
+	}
+	if err != nil {
+		return User{}, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	user.CompanyID = int(companyID.Int64)
+	return user, nil
+}
+
+func (r *PostgresUserRepository) GetByID(id int) (User, error) {
+	return r.get("id = $1", id)
+}
+
+func (r *PostgresUserRepository) GetByUsername(username string) (User, error) {
+	return r.get("username = $1", username)
+}
+
+func (r *PostgresUserRepository) SetPassword(id int, passwordHash string, mustChange bool) error {
+	return auditChange(r.repo, "users", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE users SET password_hash = $1, must_change_password = $2 WHERE id = $3", passwordHash, mustChange, id)
+		if err != nil {
+			return fmt.Errorf("ошибка смены пароля: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresUserRepository) SetEmail(id int, email string) error {
+	return auditChange(r.repo, "users", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE users SET email = $1 WHERE id = $2", email, id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения email пользователя: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresUserRepository) SetRole(id int, role string) error {
+	return auditChange(r.repo, "users", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE users SET role = $1 WHERE id = $2", role, id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения роли: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresUserRepository) SetCompany(id, companyID int) error {
+	return auditChange(r.repo, "users", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE users SET company_id = $1 WHERE id = $2", nullID(companyID), id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения компании пользователя: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresUserRepository) CountByRole(role string) (int, error) {
+	var count int
+	if err := r.q.QueryRow("SELECT COUNT(*) FROM users WHERE role = $1", role).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	return count, nil
+}
+
+type PostgresCompanyRepository struct{ repo }
+
+func (r *PostgresCompanyRepository) Create(company Company) (int, error) {
+	return auditCreate(r.repo, "companies", func(tx DBTX) (int, error) {
+		if company.Status == "" {
+			company.Status = CompanyActive
+		}
+		var id int
+		err := tx.QueryRow("INSERT INTO companies (name, status, expires_at, industry) VALUES ($1, $2, $3, $4) RETURNING id",
+			company.Name, company.Status, company.ExpiresAt, company.Industry).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка добавления компании: %w", err)
+		}
+		return id, nil
+	})
+}
+
+const companySelect = "SELECT id, name, status, expires_at, industry FROM companies"
+
+func (r *PostgresCompanyRepository) GetByID(id int) (Company, error) {
+	companies, err := r.query(companySelect+" WHERE id = $1", id)
+	if err != nil {
+		return Company{}, err
+	}
+	if len(companies) == 0 {
+		return Company{}, ErrNotFound
+	}
+	return companies[0], nil
+}
+
+func (r *PostgresCompanyRepository) List() ([]Company, error) {
+	return r.query(companySelect + " ORDER BY id")
+}
+
+func (r *PostgresCompanyRepository) SetStatus(id int, status string, expiresAt *time.Time) error {
+	return auditChange(r.repo, "companies", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE companies SET status = $1, expires_at = $2 WHERE id = $3", status, expiresAt, id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения статуса компании: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresCompanyRepository) Rename(id int, name string) error {
+	return auditChange(r.repo, "companies", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE companies SET name = $1 WHERE id = $2", name, id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения компании: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresCompanyRepository) SetIndustry(id int, industry string) error {
+	return auditChange(r.repo, "companies", id, func(tx DBTX) error {
+		result, err := tx.Exec("UPDATE companies SET industry = $1 WHERE id = $2", industry, id)
+		if err != nil {
+			return fmt.Errorf("ошибка изменения компании: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresCompanyRepository) Directory(search string, opts ListOptions) ([]CompanySummary, error) {
+	page, err := pageClause(CompanySorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.q.Query(`SELECT * FROM (
+    SELECT co.id, co.name, co.status, co.expires_at, co.industry,
+        (SELECT COUNT(*) FROM job_openings j WHERE j.company_id = co.id AND j.status <> $2) AS open_job_openings,
+        (SELECT COUNT(*) FROM job_openings j WHERE j.company_id = co.id AND j.status = $2) AS closed_job_openings,
+        (SELECT COUNT(DISTINCT j.recruiter_id) FROM job_openings j WHERE j.company_id = co.id AND j.status <> $2) AS active_recruiters,
+        (SELECT EXTRACT(EPOCH FROM AVG(h.changed_at - a.created_at)) / 86400
+         FROM application_status_history h
+         JOIN applications a ON a.id = h.application_id
+         JOIN job_openings j ON j.id = a.job_opening_id
+         WHERE j.company_id = co.id AND h.to_status = $3) AS avg_days_to_hire
+    FROM companies co
+    WHERE $1 = '' OR co.name ILIKE '%' || $1 || '%' OR co.industry ILIKE '%' || $1 || '%'
+) c`+page, EscapeLike(search), JobClosed, ApplicationHired)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var companies []CompanySummary
+	for rows.Next() {
+		var c CompanySummary
+		var expiresAt sql.NullTime
+		var avgDays sql.NullFloat64
+		err := rows.Scan(&c.ID, &c.Name, &c.Status, &expiresAt, &c.Industry,
+			&c.OpenJobOpenings, &c.ClosedJobOpenings, &c.ActiveRecruiters, &avgDays)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if expiresAt.Valid {
+			c.ExpiresAt = &expiresAt.Time
+		}
+		if avgDays.Valid {
+			c.AvgDaysToHire = &avgDays.Float64
+		}
+		companies = append(companies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return companies, nil
+}
+
+// Строка компании блокируется, чтобы между проверкой и удалением не появилась новая вакансия.
+func (r *PostgresCompanyRepository) Delete(id int, force bool) error {
+	return auditChange(r.repo, "companies", id, func(tx DBTX) error {
+		var exists bool
+		err := tx.QueryRow("SELECT true FROM companies WHERE id = $1 FOR UPDATE", id).Scan(&exists)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		if !force {
+			var open int
+			err := tx.QueryRow("SELECT COUNT(*) FROM job_openings WHERE company_id = $1 AND status <> $2", id, JobClosed).Scan(&open)
+			if err != nil {
+				return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+			}
+			if open > 0 {
+				return &OpenJobOpeningsError{CompanyID: id, Open: open}
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM companies WHERE id = $1", id); err != nil {
+			return fmt.Errorf("ошибка удаления компании: %w", err)
+		}
+		return nil
+	})
+}
+
+// Истечение подписки делает фоновая задача, а не пользователь: в журнал пишется без пользователя.
+func (r *PostgresCompanyRepository) ExpireDue(now time.Time) (int64, error) {
+	var expired int64
+	err := WithTx(r.q, func(tx DBTX) error {
+		rows, err := tx.Query("SELECT id FROM companies WHERE status IN ($1, $2) AND expires_at <= $3 FOR UPDATE",
+			CompanyTrial, CompanyActive, now)
+		if err != nil {
+			return fmt.Errorf("ошибка обработки истёкших компаний: %w", err)
+		}
+		var ids []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("ошибка сканирования строки: %w", err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("ошибка чтения строк: %w", err)
+		}
+		for _, id := range ids {
+			err := auditChangeBy(tx, 0, "companies", id, func(tx DBTX) error {
+				if _, err := tx.Exec("UPDATE companies SET status = $1 WHERE id = $2", CompanyExpired, id); err != nil {
+					return fmt.Errorf("ошибка обработки истёкших компаний: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		expired = int64(len(ids))
+		return nil
+	})
+	return expired, err
+}
+
+func (r *PostgresCompanyRepository) query(query string, args ...interface{}) ([]Company, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var companies []Company
+	for rows.Next() {
+		var company Company
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&company.ID, &company.Name, &company.Status, &expiresAt, &company.Industry); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		if expiresAt.Valid {
+			company.ExpiresAt = &expiresAt.Time
+		}
+		companies = append(companies, company)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return companies, nil
+}
+
+type PostgresCandidateRepository struct{ repo }
+
+var candidateColumns = []string{"full_name", "age", "email", "phone", "experience", "skills", "expected_salary", "salary_currency", "location",
+	"languages", "status", "tags", "available_from", "updated_at"}
+
+const candidateSelect = `SELECT id, full_name, age, email, phone, experience, skills, expected_salary, salary_currency, location, languages, status, tags,
+    available_from FROM candidates`
+
+// Стаж в годах из свободного текста опыта: первое число лет («от 3 лет», «5 years»), а без него —
+// минимальный стаж по названию уровня, как parseExperienceYears в подборе. NULL — стаж не распознан.
+const ExperienceYearsSQL = `COALESCE(
+    replace(substring(experience from '(?i)(\d+(?:[.,]\d+)?)\s*(?:\+\s*)?(?:год|года|лет|г\.|years?|yrs?)'), ',', '.')::numeric,
+    CASE WHEN experience ILIKE '%lead%' THEN 8 WHEN experience ILIKE '%senior%' THEN 5
+         WHEN experience ILIKE '%middle%' THEN 2 WHEN experience ILIKE '%junior%' THEN 0 END)`
+
+const lastActivitySQL = "GREATEST(updated_at, (SELECT MAX(a.updated_at) FROM applications a WHERE a.candidate_id = candidates.id))"
+
+// Сумма из колонки строки в базовой валюте: у кандидатов и вакансий валюта в колонке salary_currency.
+func BaseSalarySQL(amount string) string {
+	return fmt.Sprintf("(%s * (SELECT rate FROM currency_rates WHERE currency = salary_currency))", amount)
+}
+
+// Курс валюты, переданной параметром param.
+func currencyRateSQL(param string) string {
+	return fmt.Sprintf("(SELECT rate FROM currency_rates WHERE currency = %s)", param)
+}
+
+func (r *PostgresCandidateRepository) Create(candidate Candidate) (int, error) {
+	return auditCreate(r.repo, "candidates", func(tx DBTX) (int, error) {
+		skillsJSON, err := json.Marshal(candidate.Skills)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка сериализации навыков: %w", err)
+		}
+		languagesJSON, err := json.Marshal(nonNilStrings(candidate.Languages))
+		if err != nil {
+			return 0, fmt.Errorf("ошибка сериализации языков: %w", err)
+		}
+		tagsJSON, err := json.Marshal(nonNilStrings(candidate.Tags))
+		if err != nil {
+			return 0, fmt.Errorf("ошибка сериализации тегов: %w", err)
+		}
+		if candidate.Status == "" {
+			candidate.Status = CandidateActive
+		}
+		if candidate.SalaryCurrency == "" {
+			candidate.SalaryCurrency = DefaultCurrency()
+		}
+
+		var id int
+		err = tx.QueryRow(`INSERT INTO candidates (full_name, age, email, phone, experience, skills, expected_salary, salary_currency, location,
+				languages, status, tags, available_from)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`,
+			candidate.FullName, candidate.Age, candidate.Email, candidate.Phone, candidate.Experience, skillsJSON, candidate.ExpectedSalary,
+			candidate.SalaryCurrency, candidate.Location, languagesJSON, candidate.Status, tagsJSON, candidate.AvailableFrom).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка добавления кандидата: %w", err)
+		}
+		return id, nil
+	})
+}
+
+func (r *PostgresCandidateRepository) GetByID(id int) (Candidate, error) {
+	candidates, err := r.query(candidateSelect+" WHERE id = $1", id)
+	if err != nil {
+		return Candidate{}, err
+	}
+	if len(candidates) == 0 {
+		return Candidate{}, ErrNotFound
+	}
+	return candidates[0], nil
+}
+
+// Вместе с изменёнными колонками обновляется updated_at — от него считается последняя активность.
+func (r *PostgresCandidateRepository) Update(id int, columns map[string]interface{}) error {
+	return auditChange(r.repo, "candidates", id, func(tx DBTX) error {
+		if len(columns) == 0 {
+			return updateColumns(tx, "candidates", candidateColumns, id, columns)
+		}
+		touched := map[string]interface{}{"updated_at": time.Now()}
+		for column, value := range columns {
+			touched[column] = value
+		}
+		return updateColumns(tx, "candidates", candidateColumns, id, touched)
+	})
+}
+
+// Экранирует %, _ и \ для подстановки строки в шаблон LIKE/ILIKE.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Хвост запроса для страницы списка; колонка сортировки берётся только из белого списка.
+func pageClause(sorts map[string]string, opts ListOptions) (string, error) {
+	column := "id"
+	if opts.Sort != "" {
+		var ok bool
+		if column, ok = sorts[opts.Sort]; !ok {
+			return "", fmt.Errorf("неизвестное поле сортировки %q", opts.Sort)
+		}
+	}
+	direction := "ASC"
+	if opts.Desc {
+		direction = "DESC"
+	}
+	clause := fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+	if column == "id" {
+		clause = " ORDER BY id " + direction
+	}
+	if opts.Limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", opts.Limit)
+	}
+	if opts.Offset > 0 {
+		clause += fmt.Sprintf(" OFFSET %d", opts.Offset)
+	}
+	return clause, nil
+}
+
+// JSON-массив навыков для проверки вхождения @>. Собирается через json.Marshal, а не склейкой строк:
+// кавычка или обратная косая черта в навыке иначе ломают JSON или добавляют в условие лишние элементы.
+// Пустой список — [], а не null.
+func skillsParam(skills ...string) ([]byte, error) {
+	if skills == nil {
+		skills = []string{}
+	}
+	data, err := json.Marshal(skills)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка сериализации навыков: %w", err)
+	}
+	return data, nil
+}
+
+func (r *PostgresCandidateRepository) FindBySkill(skill string, opts ListOptions) ([]Candidate, error) {
+	page, err := pageClause(CandidateSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	skillJSON, err := skillsParam(skill)
+	if err != nil {
+		return nil, err
+	}
+	return r.query(candidateSelect+" WHERE skills @> $1::jsonb"+page, skillJSON)
+}
+
+// Все навыки — проверка вхождения @> по GIN-индексу, хотя бы один — оператор ?|;
+// для промежуточного порога ?| отсекает кандидатов без общих навыков, а совпавшие считаются поштучно.
+func (r *PostgresCandidateRepository) FindBySkills(skills []string, minMatch int, opts ListOptions) ([]Candidate, error) {
+	if len(skills) == 0 || minMatch > len(skills) {
+		return nil, nil
+	}
+	page, err := pageClause(CandidateSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	if minMatch == len(skills) {
+		skillsJSON, err := skillsParam(skills...)
+		if err != nil {
+			return nil, err
+		}
+		return r.query(candidateSelect+" WHERE skills @> $1::jsonb"+page, skillsJSON)
+	}
+	if minMatch <= 1 {
+		return r.query(candidateSelect+" WHERE skills ?| $1::text[]"+page, pq.Array(skills))
+	}
+	return r.query(candidateSelect+` WHERE skills ?| $1::text[]
+		AND (SELECT COUNT(*) FROM jsonb_array_elements_text(skills) AS s(skill) WHERE s.skill = ANY($1::text[])) >= $2`+page,
+		pq.Array(skills), minMatch)
+}
+
+// Условие собирается вызывающим из фиксированных фрагментов SQL, пользовательский ввод — только в args.
+func (r *PostgresCandidateRepository) FindWhere(condition string, args []interface{}, opts ListOptions) ([]Candidate, error) {
+	page, err := pageClause(CandidateSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.query(candidateSelect+" WHERE "+condition+page, args...)
+}
+
+func (r *PostgresCandidateRepository) List() ([]Candidate, error) {
+	return r.query(candidateSelect + " ORDER BY id")
+}
+
+func (r *PostgresCandidateRepository) ListPage(filter CandidateFilter, opts ListOptions) ([]Candidate, int, error) {
+	page, err := pageClause(CandidateSorts, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	where, args, err := candidateFilterClause(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	if err := r.q.QueryRow("SELECT COUNT(*) FROM candidates"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	candidates, err := r.query(candidateSelect+where+page, args...)
+	return candidates, total, err
+}
+
+func candidateFilterClause(filter CandidateFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	// Параметр подставляется вместо последнего «?»: в выражении стажа «?» встречается и в регулярном выражении.
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		i := strings.LastIndex(condition, "?")
+		conditions = append(conditions, condition[:i]+fmt.Sprintf("$%d", len(args))+condition[i+1:])
+	}
+	if len(filter.Statuses) > 0 {
+		add("status = ANY(?)", pq.Array(filter.Statuses))
+	}
+	if len(filter.Tags) > 0 {
+		tagsJSON, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return "", nil, fmt.Errorf("ошибка сериализации тегов: %w", err)
+		}
+		add("tags @> ?::jsonb", tagsJSON)
+	}
+	if filter.MinExperience > 0 {
+		add(ExperienceYearsSQL+" >= ?", filter.MinExperience)
+	}
+	if filter.MaxExperience > 0 {
+		add(ExperienceYearsSQL+" <= ?", filter.MaxExperience)
+	}
+	if filter.Location != "" {
+		add("lower(location) = lower(?)", filter.Location)
+	}
+	if filter.AvailableBy != nil {
+		add("(available_from IS NULL OR available_from <= ?)", *filter.AvailableBy)
+	}
+	if filter.ActiveSince != nil {
+		add(lastActivitySQL+" >= ?", *filter.ActiveSince)
+	}
+	if filter.ActiveBefore != nil {
+		add(lastActivitySQL+" < ?", *filter.ActiveBefore)
+	}
+	if filter.MinSalary > 0 || filter.MaxSalary > 0 {
+		args = append(args, filter.SalaryCurrency)
+		rate := currencyRateSQL(fmt.Sprintf("$%d", len(args)))
+		conditions = append(conditions, "expected_salary > 0")
+		if filter.MinSalary > 0 {
+			add(BaseSalarySQL("expected_salary")+" >= ? * "+rate, filter.MinSalary)
+		}
+		if filter.MaxSalary > 0 {
+			add(BaseSalarySQL("expected_salary")+" <= ? * "+rate, filter.MaxSalary)
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+func (r *PostgresCandidateRepository) Delete(id int) error {
+	return auditChange(r.repo, "candidates", id, func(tx DBTX) error {
+		result, err := tx.Exec("DELETE FROM candidates WHERE id = $1", id)
+		if err != nil {
+			return fmt.Errorf("ошибка удаления кандидата: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresCandidateRepository) query(query string, args ...interface{}) ([]Candidate, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var candidate Candidate
+		var experience sql.NullString
+		var skillsJSON, languagesJSON, tagsJSON []byte
+		var availableFrom sql.NullTime
+		err := rows.Scan(&candidate.ID, &candidate.FullName, &candidate.Age, &candidate.Email, &candidate.Phone, &experience, &skillsJSON,
+			&candidate.ExpectedSalary, &candidate.SalaryCurrency, &candidate.Location, &languagesJSON, &candidate.Status, &tagsJSON, &availableFrom)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		candidate.Experience = experience.String
+		json.Unmarshal(skillsJSON, &candidate.Skills)
+		json.Unmarshal(languagesJSON, &candidate.Languages)
+		json.Unmarshal(tagsJSON, &candidate.Tags)
+		if availableFrom.Valid {
+			candidate.AvailableFrom = &availableFrom.Time
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return candidates, nil
+}
+
+type PostgresJobRepository struct{ repo }
+
+var jobOpeningColumns = []string{"company_id", "title", "experience", "salary_min", "salary_max", "salary_currency", "required_skills", "location",
+	"languages"}
+
+const jobOpeningSelect = `SELECT id, company_id, title, experience, salary_min, salary_max, salary_currency, required_skills, status, location,
+    languages FROM job_openings`
+
+// Вставка, проверка квоты и запись события учёта выполняются в одной транзакции.
+func (r *PostgresJobRepository) Create(jobOpening JobOpening) (int, error) {
+	return auditCreate(r.repo, "job_openings", func(tx DBTX) (int, error) {
+		requiredSkillsJSON, err := json.Marshal(jobOpening.RequiredSkills)
+		if err != nil {
+			return 0, fmt.Errorf("ошибка сериализации навыков: %w", err)
+		}
+		languagesJSON, err := json.Marshal(nonNilStrings(jobOpening.Languages))
+		if err != nil {
+			return 0, fmt.Errorf("ошибка сериализации языков: %w", err)
+		}
+		if jobOpening.Status == "" {
+			jobOpening.Status = JobOpen
+		}
+		if jobOpening.SalaryCurrency == "" {
+			jobOpening.SalaryCurrency = DefaultCurrency()
+		}
+
+		var id int
+		err = WithTx(tx, func(tx DBTX) error {
+			if err := checkCompanyWritable(tx, jobOpening.CompanyID); err != nil {
+				return err
+			}
+			if jobOpening.Status != JobClosed {
+				if err := checkJobOpeningQuota(tx, jobOpening.CompanyID); err != nil {
+					return err
+				}
+			}
+			err := tx.QueryRow(`INSERT INTO job_openings (company_id, title, experience, salary_min, salary_max, salary_currency, required_skills,
+					status, location, languages)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+				jobOpening.CompanyID, jobOpening.Title, jobOpening.Experience, jobOpening.SalaryMin, jobOpening.SalaryMax, jobOpening.SalaryCurrency,
+				requiredSkillsJSON, jobOpening.Status, jobOpening.Location, languagesJSON).Scan(&id)
+			if err != nil {
+				return fmt.Errorf("ошибка добавления вакансии: %w", err)
+			}
+			return RecordUsage(tx, jobOpening.CompanyID, UsageVacancyPublished, 1)
+		})
+		return id, err
+	})
+}
+
+func (r *PostgresJobRepository) GetByID(id int) (JobOpening, error) {
+	jobOpenings, err := r.query(jobOpeningSelect+" WHERE id = $1", id)
+	if err != nil {
+		return JobOpening{}, err
+	}
+	if len(jobOpenings) == 0 {
+		return JobOpening{}, ErrNotFound
+	}
+	return jobOpenings[0], nil
+}
+
+// Перенос вакансии в другую компанию расходует квоту компании-получателя.
+func (r *PostgresJobRepository) Update(id int, columns map[string]interface{}) error {
+	return auditChange(r.repo, "job_openings", id, func(tx DBTX) error {
+		companyID, moving := columns["company_id"].(int)
+		if !moving {
+			return updateColumns(tx, "job_openings", jobOpeningColumns, id, columns)
+		}
+		return WithTx(tx, func(tx DBTX) error {
+			var current sql.NullInt64
+			err := tx.QueryRow("SELECT company_id FROM job_openings WHERE id = $1", id).Scan(&current)
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+			}
+			if !current.Valid || current.Int64 != int64(companyID) {
+				if err := checkCompanyWritable(tx, companyID); err != nil {
+					return err
+				}
+				if err := checkJobOpeningQuota(tx, companyID); err != nil {
+					return err
+				}
+			}
+			return updateColumns(tx, "job_openings", jobOpeningColumns, id, columns)
+		})
+	})
+}
+
+func (r *PostgresJobRepository) FindBySkill(skill string, opts ListOptions) ([]JobOpening, error) {
+	page, err := pageClause(JobOpeningSorts, opts)
+	if err != nil {
+		return nil, err
+	}
+	skillJSON, err := skillsParam(skill)
+	if err != nil {
+		return nil, err
+	}
+	return r.query(jobOpeningSelect+" WHERE required_skills @> $1::jsonb"+page, skillJSON)
+}
+
+func (r *PostgresJobRepository) List() ([]JobOpening, error) {
+	return r.query(jobOpeningSelect + " ORDER BY id")
+}
+
+func (r *PostgresJobRepository) ListByStatus(status string) ([]JobOpening, error) {
+	return r.query(jobOpeningSelect+" WHERE status = $1 ORDER BY id", status)
+}
+
+func (r *PostgresJobRepository) ListPage(filter JobOpeningFilter, opts ListOptions) ([]JobOpening, int, error) {
+	page, err := pageClause(JobOpeningSorts, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	where, args := jobOpeningFilterClause(filter)
+	var total int
+	if err := r.q.QueryRow("SELECT COUNT(*) FROM job_openings"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	jobOpenings, err := r.query(jobOpeningSelect+where+page, args...)
+	return jobOpenings, total, err
+}
+
+// Вилки сравниваются в базовой валюте: нижняя граница вакансии не выше SalaryTo, а верхняя (если задана)
+// не ниже SalaryFrom.
+func jobOpeningFilterClause(filter JobOpeningFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Skill != "" {
+		args = append(args, filter.Skill)
+		conditions = append(conditions, fmt.Sprintf("required_skills @> jsonb_build_array($%d::text)", len(args)))
+	}
+	if filter.SalaryFrom > 0 || filter.SalaryTo > 0 {
+		args = append(args, filter.SalaryCurrency)
+		rate := currencyRateSQL(fmt.Sprintf("$%d", len(args)))
+		if filter.SalaryFrom > 0 {
+			args = append(args, filter.SalaryFrom)
+			conditions = append(conditions, fmt.Sprintf("(salary_max = 0 OR %s >= $%d * %s)", BaseSalarySQL("salary_max"), len(args), rate))
+		}
+		if filter.SalaryTo > 0 {
+			args = append(args, filter.SalaryTo)
+			conditions = append(conditions, fmt.Sprintf("%s <= $%d * %s", BaseSalarySQL("salary_min"), len(args), rate))
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *PostgresJobRepository) SetStatus(id int, status string) error {
+	return auditChange(r.repo, "job_openings", id, func(tx DBTX) error {
+		var current string
+		var companyID sql.NullInt64
+		err := tx.QueryRow("SELECT status, company_id FROM job_openings WHERE id = $1 FOR UPDATE", id).Scan(&current, &companyID)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка запроса к базе данных: %w", err)
+		}
+		if current == JobClosed && status != JobClosed && companyID.Valid {
+			if err := checkCompanyWritable(tx, int(companyID.Int64)); err != nil {
+				return err
+			}
+			if err := checkJobOpeningQuota(tx, int(companyID.Int64)); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec("UPDATE job_openings SET status = $1 WHERE id = $2", status, id); err != nil {
+			return fmt.Errorf("ошибка изменения статуса вакансии: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *PostgresJobRepository) Delete(id int) error {
+	return auditChange(r.repo, "job_openings", id, func(tx DBTX) error {
+		result, err := tx.Exec("DELETE FROM job_openings WHERE id = $1", id)
+		if err != nil {
+			return fmt.Errorf("ошибка удаления вакансии: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *PostgresJobRepository) query(query string, args ...interface{}) ([]JobOpening, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var jobOpenings []JobOpening
+	for rows.Next() {
+		var jobOpening JobOpening
+		var companyID sql.NullInt64
+		var experience sql.NullString
+		var requiredSkillsJSON, languagesJSON []byte
+		err := rows.Scan(&jobOpening.ID, &companyID, &jobOpening.Title, &experience, &jobOpening.SalaryMin, &jobOpening.SalaryMax, &jobOpening.SalaryCurrency,
+			&requiredSkillsJSON, &jobOpening.Status, &jobOpening.Location, &languagesJSON)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		jobOpening.CompanyID = int(companyID.Int64)
+		jobOpening.Experience = experience.String
+		json.Unmarshal(requiredSkillsJSON, &jobOpening.RequiredSkills)
+		json.Unmarshal(languagesJSON, &jobOpening.Languages)
+		jobOpenings = append(jobOpenings, jobOpening)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return jobOpenings, nil
+}
+
+// Срок проверяется и здесь, чтобы не зависеть от того, успела ли отработать задача истечения.
+// Несуществующую компанию отклонит внешний ключ при вставке.
+func checkCompanyWritable(tx DBTX, companyID int) error {
+	var status string
+	var expiresAt sql.NullTime
+	err := tx.QueryRow("SELECT status, expires_at FROM companies WHERE id = $1 FOR SHARE", companyID).Scan(&status, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка чтения статуса компании: %w", err)
+	}
+	if status != CompanySuspended && expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		status = CompanyExpired
+	}
+	if status == CompanySuspended || status == CompanyExpired {
+		return &CompanyInactiveError{CompanyID: companyID, Status: status}
+	}
+	return nil
+}
+
+// Строка квоты блокируется до конца транзакции, чтобы параллельные добавления не обошли лимит.
+// Отсутствие строки в company_quotas означает, что ограничений нет.
+func checkJobOpeningQuota(tx DBTX, companyID int) error {
+	var limit sql.NullInt64
+	err := tx.QueryRow("SELECT max_job_openings FROM company_quotas WHERE company_id = $1 FOR UPDATE", companyID).Scan(&limit)
+	if err == sql.ErrNoRows || (err == nil && !limit.Valid) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка чтения квоты: %w", err)
+	}
+
+	var count int64
+	err = tx.QueryRow("SELECT COUNT(*) FROM job_openings WHERE company_id = $1 AND status <> 'closed'", companyID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("ошибка подсчёта вакансий: %w", err)
+	}
+	if count >= limit.Int64 {
+		return &QuotaError{CompanyID: companyID, Used: count, Limit: limit.Int64}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSkillsParamRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		skills []string
+	}{
+		{"обычный навык", []string{"Go"}},
+		{"кавычка", []string{`Go"`}},
+		{"попытка добавить элемент", []string{`a", "b`}},
+		{"закрытие массива", []string{`Go"]}, {"x": "`}},
+		{"обратная косая черта", []string{`C:\path\`, `\`}},
+		{"экранированная кавычка", []string{`\"`}},
+		{"метасимволы JSON", []string{`{}[]:,`, `null`, `true`}},
+		{"управляющие символы", []string{"a\nb\tc\x00"}},
+		{"юникод", []string{"Гоу", "日本語", "emoji 🚀", "\u2028\u2029"}},
+		{"SQL", []string{"'; DROP TABLE candidates; --"}},
+		{"пустая строка", []string{""}},
+		{"несколько навыков", []string{"Go", "SQL", "Docker"}},
+		{"пустой список", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := skillsParam(tt.skills...)
+			if err != nil {
+				t.Fatalf("skillsParam: %v", err)
+			}
+			var got []string
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("результат %s — не JSON: %v", data, err)
+			}
+			if !reflect.DeepEqual(got, tt.skills) {
+				t.Errorf("после разбора %s получено %q, ожидалось %q", data, got, tt.skills)
+			}
+		})
+	}
+}
+
+func TestSkillsParamNil(t *testing.T) {
+	data, err := skillsParam()
+	if err != nil {
+		t.Fatalf("skillsParam: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("для пустого вызова получено %s, ожидалось []", data)
+	}
+	data, err = skillsParam(nil...)
+	if err != nil {
+		t.Fatalf("skillsParam: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("для nil получено %s, ожидалось []", data)
+	}
+}
@@ -0,0 +1,387 @@
+// Пакет storage описывает доступ к данным через интерфейсы репозиториев,
+// чтобы консольный интерфейс не зависел от конкретной базы данных.
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type User struct {
+	ID                 int    `db:"id" json:"id"`
+	Username           string `db:"username" json:"username"`
+	PasswordHash       string `db:"password_hash" json:"-"`
+	Role               string `db:"role" json:"role"`
+	CompanyID          int    `db:"company_id" json:"company_id,omitempty"`
+	MustChangePassword bool   `db:"must_change_password" json:"must_change_password"`
+	// Адрес для кода сброса пароля; пустая строка — не указан.
+	Email string `db:"email" json:"email,omitempty"`
+}
+
+type Candidate struct {
+	ID         int      `db:"id" json:"id"`
+	FullName   string   `db:"full_name" json:"full_name"`
+	Age        int      `db:"age" json:"age"`
+	Email      string   `db:"email" json:"email"`
+	Phone      string   `db:"phone" json:"phone"`
+	Experience string   `db:"experience" json:"experience"`
+	Skills     []string `db:"skills" json:"skills"`
+	// Ожидаемая зарплата в валюте SalaryCurrency; 0 — не указана.
+	ExpectedSalary float64  `db:"expected_salary" json:"expected_salary,omitempty"`
+	SalaryCurrency string   `db:"salary_currency" json:"salary_currency,omitempty"`
+	Location       string   `db:"location" json:"location,omitempty"`
+	Languages      []string `db:"languages" json:"languages,omitempty"`
+	Status         string   `db:"status" json:"status,omitempty"`
+	// Произвольные метки рекрутеров: «referral», «relocation» и т. п.
+	Tags []string `db:"tags" json:"tags,omitempty"`
+	// Дата, с которой кандидат может выйти на работу; nil — готов сразу.
+	AvailableFrom *time.Time `db:"available_from" json:"available_from,omitempty"`
+}
+
+// Статусы поиска работы: active — ищет, passive — рассматривает предложения,
+// placed — трудоустроен, archived — профиль больше не ведётся.
+const (
+	CandidateActive   = "active"
+	CandidatePassive  = "passive"
+	CandidatePlaced   = "placed"
+	CandidateArchived = "archived"
+)
+
+var CandidateStatuses = []string{CandidateActive, CandidatePassive, CandidatePlaced, CandidateArchived}
+
+// Фильтр списка кандидатов; пустые поля выборку не ограничивают. Tags — кандидат должен иметь все теги.
+// Стаж в годах берётся из текста опыта так же, как в подборе. Последняя активность — самое позднее из
+// изменения профиля и изменения его откликов. Ожидаемая зарплата сравнивается с границами
+// MinSalary и MaxSalary в валюте SalaryCurrency по курсам валют; кандидаты без ожиданий не подходят.
+type CandidateFilter struct {
+	Statuses       []string
+	Tags           []string
+	MinExperience  float64
+	MaxExperience  float64
+	Location       string
+	AvailableBy    *time.Time
+	ActiveSince    *time.Time
+	ActiveBefore   *time.Time
+	MinSalary      float64
+	MaxSalary      float64
+	SalaryCurrency string
+}
+
+type JobOpening struct {
+	ID         int    `db:"id" json:"id"`
+	CompanyID  int    `db:"company_id" json:"company_id"`
+	Title      string `db:"title" json:"title"`
+	Experience string `db:"experience" json:"experience"`
+	// Вилка зарплаты: 0 в одной из границ — «от …» или «до …».
+	SalaryMin      float64  `db:"salary_min" json:"salary_min"`
+	SalaryMax      float64  `db:"salary_max" json:"salary_max"`
+	SalaryCurrency string   `db:"salary_currency" json:"salary_currency"`
+	RequiredSkills []string `db:"required_skills" json:"required_skills"`
+	Status         string   `db:"status" json:"status"`
+	Location       string   `db:"location" json:"location,omitempty"`
+	Languages      []string `db:"languages" json:"languages,omitempty"`
+}
+
+// Статусы вакансий: закрытая вакансия не занимает квоту и не принимает отклики.
+const (
+	JobOpen   = "open"
+	JobOnHold = "on_hold"
+	JobClosed = "closed"
+)
+
+var JobStatuses = []string{JobOpen, JobOnHold, JobClosed}
+
+// Фильтр списка вакансий; пустые поля выборку не ограничивают. Вакансия подходит, если её вилка
+// пересекается с диапазоном SalaryFrom–SalaryTo в валюте SalaryCurrency (0 — граница не задана).
+type JobOpeningFilter struct {
+	Status string
+	// Вакансия требует этот навык.
+	Skill          string
+	SalaryFrom     float64
+	SalaryTo       float64
+	SalaryCurrency string
+}
+
+// Валюта зарплаты, если она не указана; задаёт приложение.
+var DefaultCurrency = func() string { return "RUB" }
+
+// Страница списка: Limit 0 — без ограничения; Sort — ключ из CandidateSorts, JobOpeningSorts или ApplicationSorts
+// (пустой — по ID), Desc — по убыванию. При равных значениях порядок всегда по ID, чтобы страницы не пересекались.
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Sort   string
+	Desc   bool
+}
+
+// Допустимые поля сортировки и соответствующие им колонки.
+var (
+	// «experience» и «activity» — выражения: стаж в годах из текста опыта и время последней активности.
+	// «salary» сравнивает суммы в базовой валюте, у вакансий — по верхней границе вилки.
+	CandidateSorts = map[string]string{"id": "id", "name": "full_name", "age": "age", "salary": BaseSalarySQL("expected_salary"), "created": "created_at",
+		"experience": ExperienceYearsSQL, "activity": lastActivitySQL}
+	JobOpeningSorts = map[string]string{"id": "id", "title": "title", "salary": BaseSalarySQL("GREATEST(salary_min, salary_max)"), "created": "created_at"}
+	CompanySorts    = map[string]string{"id": "id", "name": "name", "open": "open_job_openings", "time_to_hire": "avg_days_to_hire"}
+	// «stage» — по времени последней смены статуса: по возрастанию сначала дольше всех ждущие.
+	ApplicationSorts = map[string]string{"id": "id", "candidate": "candidate_name", "status": "status", "stage": "updated_at", "created": "created_at"}
+)
+
+type Company struct {
+	ID        int        `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	Status    string     `db:"status" json:"status"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	Industry  string     `db:"industry" json:"industry,omitempty"`
+}
+
+// Строка каталога компаний. Открытыми считаются и приостановленные вакансии, активные рекрутеры —
+// назначенные на них; время найма — среднее число дней от отклика до перехода в hired (nil, если наймов не было).
+type CompanySummary struct {
+	Company
+	OpenJobOpenings   int      `json:"open_job_openings"`
+	ClosedJobOpenings int      `json:"closed_job_openings"`
+	ActiveRecruiters  int      `json:"active_recruiters"`
+	AvgDaysToHire     *float64 `json:"avg_days_to_hire,omitempty"`
+}
+
+// Роли пользователей; права каждой роли описаны в консольном приложении.
+const (
+	RoleAdmin        = "admin"
+	RoleCompanyAdmin = "company_admin"
+	RoleRecruiter    = "recruiter"
+	RoleCandidate    = "candidate"
+	RoleViewer       = "viewer"
+)
+
+// Отклик кандидата на вакансию; на одну вакансию кандидат откликается один раз.
+type Application struct {
+	ID           int    `db:"id" json:"id"`
+	CandidateID  int    `db:"candidate_id" json:"candidate_id"`
+	JobOpeningID int    `db:"job_opening_id" json:"job_opening_id"`
+	Status       string `db:"status" json:"status"`
+	// Откуда пришёл кандидат (сайт, рекомендация, hh.ru и т. п.); пустая строка — не указано.
+	Source    string    `db:"source" json:"source,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Запись истории отклика; у первой записи FromStatus пустой.
+type ApplicationStatusChange struct {
+	FromStatus string    `db:"from_status" json:"from_status"`
+	ToStatus   string    `db:"to_status" json:"to_status"`
+	Comment    string    `db:"comment" json:"comment,omitempty"`
+	ChangedBy  int       `db:"changed_by" json:"changed_by,omitempty"`
+	ChangedAt  time.Time `db:"changed_at" json:"changed_at"`
+}
+
+const (
+	ApplicationNew       = "new"
+	ApplicationScreening = "screening"
+	ApplicationInterview = "interview"
+	ApplicationOffer     = "offer"
+	ApplicationRejected  = "rejected"
+	ApplicationHired     = "hired"
+)
+
+var ApplicationStatuses = []string{
+	ApplicationNew, ApplicationScreening, ApplicationInterview, ApplicationOffer, ApplicationRejected, ApplicationHired,
+}
+
+// Отклик в воронке вместе с тем, что нужно, чтобы его найти: кандидат, вакансия, рекрутер.
+type PipelineEntry struct {
+	Application
+	CandidateName string `json:"candidate_name"`
+	JobTitle      string `json:"job_title"`
+	CompanyID     int    `json:"company_id,omitempty"`
+	Recruiter     string `json:"recruiter,omitempty"`
+}
+
+// Фильтр воронки; пустые поля выборку не ограничивают. Время на этапе отсчитывается от updated_at —
+// последней смены статуса. SLA — "breached" (открытый отклик стоит на этапе дольше SLALimit)
+// или "ok" (всё остальное).
+type ApplicationFilter struct {
+	JobOpeningID int
+	CompanyID    int
+	Candidate    string
+	Skill        string
+	Statuses     []string
+	RecruiterID  int
+	InStageMin   time.Duration
+	InStageMax   time.Duration
+	SLA          string
+	SLALimit     time.Duration
+	// Воронка на этот момент по истории откликов; nil — нынешняя.
+	AsOf *time.Time
+}
+
+const (
+	SLABreached = "breached"
+	SLAWithin   = "ok"
+)
+
+// Статусы компаний: в suspended и expired данные можно читать, но не создавать.
+const (
+	CompanyTrial     = "trial"
+	CompanyActive    = "active"
+	CompanySuspended = "suspended"
+	CompanyExpired   = "expired"
+)
+
+// Репозитории не проверяют и не нормализуют данные — это делает вызывающий код.
+// Update принимает значения по именам колонок; колонки вне допустимого набора отклоняются.
+
+type UserRepository interface {
+	Create(user User) (int, error)
+	GetByID(id int) (User, error)
+	GetByUsername(username string) (User, error)
+	SetPassword(id int, passwordHash string, mustChange bool) error
+	SetEmail(id int, email string) error
+	SetRole(id int, role string) error
+	// companyID = 0 отвязывает пользователя от компании.
+	SetCompany(id, companyID int) error
+	CountByRole(role string) (int, error)
+}
+
+type CompanyRepository interface {
+	Create(company Company) (int, error)
+	GetByID(id int) (Company, error)
+	List() ([]Company, error)
+	SetStatus(id int, status string, expiresAt *time.Time) error
+	Rename(id int, name string) error
+	SetIndustry(id int, industry string) error
+	// Каталог: search ищет подстроку в названии и отрасли; пустой search — все компании.
+	Directory(search string, opts ListOptions) ([]CompanySummary, error)
+	// Без force отказывает, пока у компании есть незакрытые вакансии; удаление каскадно убирает её данные.
+	Delete(id int, force bool) error
+	// Переводит в expired все компании с истёкшим сроком и возвращает их количество.
+	ExpireDue(now time.Time) (int64, error)
+}
+
+type CandidateRepository interface {
+	Create(candidate Candidate) (int, error)
+	GetByID(id int) (Candidate, error)
+	Update(id int, columns map[string]interface{}) error
+	FindBySkill(skill string, opts ListOptions) ([]Candidate, error)
+	// Кандидаты, у которых есть хотя бы minMatch навыков из списка.
+	FindBySkills(skills []string, minMatch int, opts ListOptions) ([]Candidate, error)
+	// Кандидаты по готовому условию WHERE; значения передаются только через args.
+	FindWhere(condition string, args []interface{}, opts ListOptions) ([]Candidate, error)
+	List() ([]Candidate, error)
+	// Страница списка по фильтру и общее число подходящих кандидатов.
+	ListPage(filter CandidateFilter, opts ListOptions) ([]Candidate, int, error)
+	// Удаляет кандидата вместе с его откликами.
+	Delete(id int) error
+}
+
+type JobRepository interface {
+	Create(jobOpening JobOpening) (int, error)
+	GetByID(id int) (JobOpening, error)
+	Update(id int, columns map[string]interface{}) error
+	FindBySkill(skill string, opts ListOptions) ([]JobOpening, error)
+	List() ([]JobOpening, error)
+	ListByStatus(status string) ([]JobOpening, error)
+	// Страница вакансий по фильтру и их общее число.
+	ListPage(filter JobOpeningFilter, opts ListOptions) ([]JobOpening, int, error)
+	// Возврат закрытой вакансии в работу проверяет статус компании и квоту, как и создание.
+	SetStatus(id int, status string) error
+	Delete(id int) error
+}
+
+// Create и SetStatus пишут историю в той же транзакции; changedBy = 0 — изменение без пользователя.
+type ApplicationRepository interface {
+	Create(application Application, changedBy int) (int, error)
+	GetByID(id int) (Application, error)
+	SetStatus(id int, status, comment string, changedBy int) error
+	ListByCandidate(candidateID int) ([]Application, error)
+	ListByJobOpening(jobOpeningID int) ([]Application, error)
+	List() ([]Application, error)
+	History(id int) ([]ApplicationStatusChange, error)
+	Search(filter ApplicationFilter, opts ListOptions) ([]PipelineEntry, error)
+	CountByStatus(filter ApplicationFilter) (map[string]int, error)
+}
+
+type AuditRepository interface {
+	List(filter AuditFilter, opts ListOptions) ([]AuditEntry, error)
+}
+
+type Repositories struct {
+	Users        UserRepository
+	Companies    CompanyRepository
+	Candidates   CandidateRepository
+	JobOpenings  JobRepository
+	Applications ApplicationRepository
+	Audit        AuditRepository
+}
+
+var ErrNotFound = errors.New("запись не найдена")
+
+// Превышен лимит вакансий компании (таблица company_quotas).
+type QuotaError struct {
+	CompanyID int
+	Used      int64
+	Limit     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("превышена квота компании %d: открыто %d вакансий из %d", e.CompanyID, e.Used, e.Limit)
+}
+
+// Компания приостановлена или её срок истёк.
+type CompanyInactiveError struct {
+	CompanyID int
+	Status    string
+}
+
+func (e *CompanyInactiveError) Error() string {
+	if e.Status == CompanyExpired {
+		return fmt.Sprintf("срок действия компании %d истёк, добавление данных недоступно", e.CompanyID)
+	}
+	return fmt.Sprintf("компания %d приостановлена, добавление данных недоступно", e.CompanyID)
+}
+
+// У удаляемой компании остались незакрытые вакансии.
+type OpenJobOpeningsError struct {
+	CompanyID int
+	Open      int
+}
+
+func (e *OpenJobOpeningsError) Error() string {
+	return fmt.Sprintf("у компании %d есть незакрытые вакансии: %d", e.CompanyID, e.Open)
+}
+
+// Общее подмножество *sql.DB и *sql.Tx: репозитории работают и с подключением, и внутри чужой транзакции.
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Общее для репозиториев: где выполнять запросы и от чьего имени писать журнал изменений.
+type repo struct {
+	q     DBTX
+	actor func() int
+}
+
+// Изменения записываются в журнал от имени AuditActor.
+func NewPostgres(q DBTX) Repositories {
+	return newPostgres(repo{q, func() int { return AuditActor() }})
+}
+
+// Изменения записываются в журнал от имени actor (0 — без пользователя), а не AuditActor: для фоновых
+// задач, которым нельзя брать пользователя из состояния приложения.
+func NewPostgresBy(q DBTX, actor int) Repositories {
+	return newPostgres(repo{q, func() int { return actor }})
+}
+
+func newPostgres(r repo) Repositories {
+	return Repositories{
+		Users:        &PostgresUserRepository{r},
+		Companies:    &PostgresCompanyRepository{r},
+		Candidates:   &PostgresCandidateRepository{r},
+		JobOpenings:  &PostgresJobRepository{r},
+		Applications: &PostgresApplicationRepository{r},
+		Audit:        &PostgresAuditRepository{r},
+	}
+}
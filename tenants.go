@@ -0,0 +1,218 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"your_project_name/storage"
+)
+
+type JobTemplate struct {
+	ID             int      `json:"id"`
+	CompanyID      int      `json:"company_id"`
+	Title          string   `json:"title"`
+	Experience     string   `json:"experience"`
+	RequiredSkills []string `json:"required_skills"`
+}
+
+// Шаблоны, которые получает каждая новая компания; их можно изменить или удалить в базе.
+var defaultJobTemplates = []JobTemplate{
+	{Title: "Backend-разработчик", Experience: "от 2 лет коммерческой разработки", RequiredSkills: []string{"Go", "PostgreSQL", "Docker"}},
+	{Title: "Frontend-разработчик", Experience: "от 2 лет разработки интерфейсов", RequiredSkills: []string{"JavaScript", "TypeScript", "React"}},
+	{Title: "Тестировщик", Experience: "от 1 года ручного или автоматизированного тестирования", RequiredSkills: []string{"SQL", "Postman"}},
+	{Title: "Менеджер проектов", Experience: "от 3 лет управления командами", RequiredSkills: []string{"Agile", "Jira"}},
+}
+
+type TenantProvisioning struct {
+	CompanyID         int       `json:"company_id"`
+	AdminID           int       `json:"admin_id"`
+	AdminUsername     string    `json:"admin_username"`
+	TemporaryPassword string    `json:"temporary_password"`
+	Templates         int       `json:"templates"`
+	TrialEndsAt       time.Time `json:"trial_ends_at"`
+}
+
+const temporaryPasswordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func generateTemporaryPassword(length int) (string, error) {
+	password := make([]byte, length)
+	max := big.NewInt(int64(len(temporaryPasswordAlphabet)))
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("ошибка генерации пароля: %w", err)
+		}
+		password[i] = temporaryPasswordAlphabet[n.Int64()]
+	}
+	return string(password), nil
+}
+
+// Создаёт компанию со всем необходимым в одной транзакции: администратора компании
+// с временным паролем, шаблоны вакансий и настройки уведомлений.
+func provisionCompany(db *sql.DB, companyName, adminUsername, notifyEmail string) (TenantProvisioning, error) {
+	var result TenantProvisioning
+	companyName, err := sanitizeText("название компании", companyName, maxNameLength)
+	if err != nil {
+		return result, err
+	}
+	adminUsername, err = sanitizeText("имя пользователя", adminUsername, maxUsernameLength)
+	if err != nil {
+		return result, err
+	}
+	notifyEmail, err = sanitizeText("email", notifyEmail, maxEmailLength)
+	if err != nil {
+		return result, err
+	}
+	if companyName == "" || adminUsername == "" {
+		return result, validationErrorf("название компании и имя администратора обязательны")
+	}
+	if err := validateEmailSyntax(notifyEmail); err != nil {
+		return result, err
+	}
+
+	password, err := generateTemporaryPassword(12)
+	if err != nil {
+		return result, err
+	}
+
+	trialEnds := time.Now().Add(trialPeriod())
+	err = storage.WithTx(db, func(tx storage.DBTX) error {
+		repos := storage.NewPostgres(tx)
+		company := storage.Company{Name: companyName, Status: storage.CompanyTrial, ExpiresAt: &trialEnds}
+		var err error
+		if result.CompanyID, err = repos.Companies.Create(company); err != nil {
+			return err
+		}
+		admin := storage.User{Username: adminUsername, Role: storage.RoleCompanyAdmin, CompanyID: result.CompanyID, MustChangePassword: true}
+		if result.AdminID, err = createUser(repos.Users, admin, password); err != nil {
+			return fmt.Errorf("ошибка создания администратора компании: %w", err)
+		}
+
+		for _, template := range defaultJobTemplates {
+			skillsJSON, err := json.Marshal(template.RequiredSkills)
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации навыков: %w", err)
+			}
+			_, err = tx.Exec("INSERT INTO job_templates (company_id, title, experience, required_skills) VALUES ($1, $2, $3, $4)",
+				result.CompanyID, template.Title, template.Experience, skillsJSON)
+			if err != nil {
+				return fmt.Errorf("ошибка добавления шаблона вакансии: %w", err)
+			}
+			result.Templates++
+		}
+
+		_, err = tx.Exec("INSERT INTO notification_settings (company_id, email) VALUES ($1, $2)", result.CompanyID, notifyEmail)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения настроек уведомлений: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return TenantProvisioning{}, err
+	}
+	result.AdminUsername = adminUsername
+	result.TrialEndsAt = trialEnds
+	result.TemporaryPassword = password
+	return result, nil
+}
+
+func getJobTemplates(db *sql.DB, companyID int) ([]JobTemplate, error) {
+	rows, err := db.Query("SELECT id, company_id, title, experience, required_skills FROM job_templates WHERE company_id = $1 ORDER BY id", companyID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к базе данных: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []JobTemplate
+	for rows.Next() {
+		var template JobTemplate
+		var requiredSkillsJSON []byte
+		if err := rows.Scan(&template.ID, &template.CompanyID, &template.Title, &template.Experience, &requiredSkillsJSON); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		json.Unmarshal(requiredSkillsJSON, &template.RequiredSkills)
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения строк: %w", err)
+	}
+	return templates, nil
+}
+
+// Предлагает выбрать шаблон вакансии компании; nil — шаблон не выбран.
+func chooseJobTemplate(db *sql.DB, companyID int) (*JobTemplate, error) {
+	templates, err := getJobTemplates(db, companyID)
+	if err != nil || len(templates) == 0 {
+		return nil, err
+	}
+	fmt.Println("Шаблоны вакансий компании:")
+	for i, template := range templates {
+		fmt.Printf("%d. %s\n", i+1, template.Title)
+	}
+	input := getInput("Номер шаблона (Enter — без шаблона): ")
+	if input == "" {
+		return nil, nil
+	}
+	var n int
+	if _, err := fmt.Sscan(input, &n); err != nil || n < 1 || n > len(templates) {
+		return nil, validationErrorf("неверный номер шаблона")
+	}
+	return &templates[n-1], nil
+}
+
+func passwordChangeRequired(app *App, userID int) (bool, error) {
+	user, err := app.Users.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return user.MustChangePassword, nil
+}
+
+func changePassword(app *App, userID int, password string) error {
+	if password == "" {
+		return validationErrorf("пароль не может быть пустым")
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return fmt.Errorf("ошибка хеширования пароля: %w", err)
+	}
+	err = app.Users.SetPassword(userID, hashedPassword, false)
+	if errors.Is(err, storage.ErrNotFound) {
+		return notFoundError("пользователь не найден")
+	}
+	if err == nil {
+		audit("user.password_changed", "target_user_id", userID)
+	}
+	return err
+}
+
+func runOnboardCompanyCommand(db *sql.DB, args []string) int {
+	flags := flag.NewFlagSet("onboard-company", flag.ContinueOnError)
+	notifyEmail := flags.String("email", "", "адрес для уведомлений компании")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 2 {
+		fmt.Println("Использование: onboard-company [-email адрес] <название компании> <логин администратора>")
+		return exitUsage
+	}
+
+	result, err := provisionCompany(db, flags.Arg(0), flags.Arg(1), *notifyEmail)
+	if err != nil {
+		return reportError(err)
+	}
+	return printView(sharedView{data: result, text: func(w io.Writer) {
+		fmt.Fprintf(w, "Компания создана, ID: %d\n", result.CompanyID)
+		fmt.Fprintf(w, "Администратор компании: %s (ID %d)\n", result.AdminUsername, result.AdminID)
+		fmt.Fprintf(w, "Временный пароль: %s — его нужно сменить при первом входе\n", result.TemporaryPassword)
+		fmt.Fprintf(w, "Добавлено шаблонов вакансий: %d\n", result.Templates)
+		fmt.Fprintf(w, "Пробный период до %s\n", result.TrialEndsAt.Format("2006-01-02"))
+	}})
+}